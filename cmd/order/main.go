@@ -7,17 +7,26 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 
 	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/internal/order/idempotency"
+	"github.com/dwikikusuma/atlas/internal/order/quote"
 	"github.com/dwikikusuma/atlas/internal/order/service"
+	"github.com/dwikikusuma/atlas/internal/pricing"
+	"github.com/dwikikusuma/atlas/internal/surge"
 	"github.com/dwikikusuma/atlas/pkg/database"
 	"github.com/dwikikusuma/atlas/pkg/kafka"
+	"github.com/dwikikusuma/atlas/pkg/outbox"
+	"github.com/dwikikusuma/atlas/pkg/pb/dispatch"
 	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
 	wallet "github.com/dwikikusuma/atlas/pkg/pb/wallet"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -26,10 +35,97 @@ const (
 	kafkaBroker   = "localhost:9092"
 	dispatchTopic = "ride-dispatch"
 	dispatchGroup = "order-service-group"
+	statusTopic   = "ride-status"
+	statusGroup   = "order-status-group"
 	wallerPort    = ":50054"
+	trackerAddr   = "localhost:50051"
+	dispatchAddr  = "localhost:50053"
+	redisAddr     = "localhost:6379"
+
+	// The Wallet Service reports the outcome of every charge on these.
+	paymentSucceededTopic = "payment-succeeded"
+	paymentSucceededGroup = "order-payment-succeeded-group"
+	paymentFailedTopic    = "payment-failed"
+	paymentFailedGroup    = "order-payment-failed-group"
+
+	tariffsPath          = "config/tariffs.json"
+	tariffReloadInterval = 30 * time.Second
+
+	quoteValidity = 2 * time.Minute
+	// quoteKeyEnv names the environment variable holding the key fare quotes
+	// are signed with. Every replica must share it.
+	quoteKeyEnv = "QUOTE_SIGNING_KEY"
+
+	cancellationGracePeriod = 2 * time.Minute
+	cancellationFee         = 5000.0 // IDR
+
+	// A metered fare within 10% of the upfront fare is not worth surprising
+	// the passenger with; beyond that it is charged, but never more than 25% up.
+	fareTolerance   = 0.10
+	fareMaxIncrease = 0.25
+
+	// idempotencyTTL is how long a retry with the same idempotency key gets
+	// the original response back.
+	idempotencyTTL = 24 * time.Hour
 )
 
+var relayConfig = outbox.Config{
+	Interval:  time.Second,
+	BatchSize: 100,
+	Lease:     30 * time.Second,
+	RetryBase: time.Second,
+	RetryMax:  5 * time.Minute,
+	Retention: 7 * 24 * time.Hour,
+}
+
+var surgeConfig = surge.Config{
+	Interval:      30 * time.Second,
+	Window:        10 * time.Minute,
+	CellKm:        2,
+	MinRequests:   5,
+	Threshold:     1.2,
+	Sensitivity:   0.25,
+	MaxMultiplier: 2.5,
+	Smoothing:     0.5,
+	Step:          0.1,
+}
+
+// A fare that could not be charged is tried again after 1 minute, then 2, 4
+// and 8, before the order is PAYMENT_FAILED.
+var paymentPolicy = service.PaymentPolicy{
+	MaxAttempts: 5,
+	RetryBase:   time.Minute,
+	RetryMax:    30 * time.Minute,
+	Interval:    15 * time.Second,
+	BatchSize:   50,
+}
+
+var schedulerConfig = service.SchedulerConfig{
+	Interval:      15 * time.Second,
+	ReminderLead:  time.Hour,
+	DispatchLead:  15 * time.Minute,
+	RetryInterval: 2 * time.Minute,
+	MaxAttempts:   5,
+	BaseRadiusKm:  5,
+	RadiusStepKm:  2.5,
+	MaxRadiusKm:   15,
+	BatchSize:     50,
+}
+
+// Immediate rides get 10 minutes to find a driver. Scheduled rides count from
+// pickup, by when their dispatch attempts are long over.
+var expiryConfig = service.ExpiryConfig{
+	Timeout:   10 * time.Minute,
+	Interval:  30 * time.Second,
+	BatchSize: 50,
+}
+
 func main() {
+	quoteKey := os.Getenv(quoteKeyEnv)
+	if quoteKey == "" {
+		log.Fatalf("❌ %s is not set", quoteKeyEnv)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -39,27 +135,124 @@ func main() {
 	}
 	defer connPool.Close()
 
-	producer := kafka.NewProducer([]string{kafkaBroker})
+	producer := kafka.NewSyncProducer([]string{kafkaBroker})
+	defer producer.Close()
 	log.Println("✅ Connecting to Producer...")
 
+	redisClient, err := database.NewRedisClient(database.Config{
+		Addr: redisAddr,
+	})
+	if err != nil {
+		log.Fatalf("❌ cannot connect to Redis: %v", err)
+	}
+	defer redisClient.Close()
+
 	walletConn, err := grpc.NewClient(wallerPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		log.Fatalf("❌ cannot connect to Wallet Service: %v", err)
 	}
 	walletClient := wallet.NewWalletServiceClient(walletConn)
 
+	trackerConn, err := grpc.NewClient(trackerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("❌ cannot connect to Tracker Service: %v", err)
+	}
+	trackerClient := tracker.NewTrackerServiceClient(trackerConn)
+
+	dispatchConn, err := grpc.NewClient(dispatchAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("❌ cannot connect to Dispatch Service: %v", err)
+	}
+	dispatchClient := dispatch.NewDispatchServiceClient(dispatchConn)
+
 	var wg sync.WaitGroup
 
-	sqlcDB := db.New(connPool)
-	svc := service.NewOrderService(sqlcDB, producer, walletClient)
+	tariffs, err := pricing.LoadFile(tariffsPath)
+	if err != nil {
+		log.Fatalf("❌ cannot load tariffs: %v", err)
+	}
+	pricer := pricing.NewEngine(tariffs)
+	log.Printf("💲 Loaded tariffs version %s", tariffs.Version)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pricer.Watch(ctx, tariffsPath, tariffReloadInterval)
+	}()
+
+	surgeCalc := surge.New(surge.NewRedisStore(redisClient), trackerClient, producer, surgeConfig)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		surgeCalc.Run(ctx)
+	}()
+
+	store := db.NewStore(connPool)
+	quotes := quote.NewIssuer(quote.NewRedisStore(redisClient), []byte(quoteKey), quoteValidity)
+	svc := service.NewOrderService(store, pricer, surgeCalc, quotes, walletClient, trackerClient, service.CancellationPolicy{
+		GracePeriod: cancellationGracePeriod,
+		Fee:         cancellationFee,
+	}, service.FarePolicy{
+		Tolerance:   fareTolerance,
+		MaxIncrease: fareMaxIncrease,
+	})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		startConsumer(ctx, store, trackerClient)
+	}()
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		startConsumer(ctx, sqlcDB)
+		startStatusConsumer(ctx, store)
 	}()
 
-	grpcServer := grpc.NewServer()
+	for topic, group := range map[string]string{
+		paymentSucceededTopic: paymentSucceededGroup,
+		paymentFailedTopic:    paymentFailedGroup,
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			startPaymentConsumer(ctx, store, topic, group)
+		}()
+	}
+
+	retrier := service.NewPaymentRetrier(store, paymentPolicy)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		retrier.Run(ctx)
+	}()
+
+	scheduler := service.NewScheduler(store, dispatchClient, schedulerConfig)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scheduler.Run(ctx)
+	}()
+
+	expirer := service.NewOrderExpirer(store, expiryConfig)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		expirer.Run(ctx)
+	}()
+
+	relay := outbox.NewRelay(service.NewOutboxStore(store), producer, relayConfig)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		relay.Run(ctx)
+	}()
+
+	keeper := idempotency.New(idempotency.NewRedisStore(redisClient), idempotencyTTL)
+	keeper.Register("/order.OrderService/CreateOrder", func() proto.Message { return &order.CreateOrderResponse{} })
+	keeper.Register("/order.OrderService/UpdateOrderStatus", func() proto.Message { return &order.UpdateOrderStatusResponse{} })
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(keeper.UnaryServerInterceptor()))
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -96,15 +289,33 @@ func connectDB(ctx context.Context) (*pgxpool.Pool, error) {
 	return connPool, nil
 }
 
-func startConsumer(ctx context.Context, sqlcDB *db.Queries) {
+func startConsumer(ctx context.Context, store db.Store, trackerClient tracker.TrackerServiceClient) {
 	dispatchConsumer := kafka.NewConsumer([]string{kafkaBroker}, dispatchGroup, dispatchTopic)
-	setDriverConsumer := service.NewOrderWorker(dispatchConsumer, sqlcDB)
+	setDriverConsumer := service.NewOrderWorker(dispatchConsumer, store, trackerClient)
 	if err := setDriverConsumer.Start(ctx); err != nil {
 		log.Fatalf("❌ order worker failed: %v", err)
 	}
 	log.Println("✅ Order worker started")
 }
 
+func startStatusConsumer(ctx context.Context, store db.Store) {
+	statusConsumer := kafka.NewConsumer([]string{kafkaBroker}, statusGroup, statusTopic)
+	statusWorker := service.NewRideStatusWorker(statusConsumer, store)
+	if err := statusWorker.Start(ctx); err != nil {
+		log.Fatalf("❌ ride status worker failed: %v", err)
+	}
+	log.Println("✅ Ride status worker started")
+}
+
+func startPaymentConsumer(ctx context.Context, store db.Store, topic, group string) {
+	paymentConsumer := kafka.NewConsumer([]string{kafkaBroker}, group, topic)
+	paymentWorker := service.NewPaymentWorker(paymentConsumer, store, paymentPolicy)
+	if err := paymentWorker.Start(ctx); err != nil {
+		log.Fatalf("❌ payment worker failed: %v", err)
+	}
+	log.Println("✅ Payment worker started")
+}
+
 func startGRPCServer(grpcServer *grpc.Server, svc *service.Service) {
 
 	order.RegisterOrderServiceServer(grpcServer, svc)
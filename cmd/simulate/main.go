@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/service"
+	"github.com/dwikikusuma/atlas/internal/simulation"
+)
+
+// The simulated city and the dispatch settings mirror cmd/dispatch.
+const (
+	centerLat  = -6.2088
+	centerLong = 106.8456
+	cityRadius = 10.0
+
+	tick       = 10 * time.Second
+	speedKmh   = 25.0
+	poolShare  = 0.3
+	pendingTTL = 10 * time.Minute
+
+	poolMaxDetourRatio = 0.5
+	poolMaxDetourKm    = 3.0
+	poolMaxPickupKm    = 5.0
+)
+
+func main() {
+	seed := flag.Int64("seed", 1, "seed for generated scenarios and driver movement")
+	drivers := flag.Int("drivers", 100, "number of generated drivers")
+	requests := flag.Int("requests", 1500, "number of generated ride requests")
+	hours := flag.Float64("hours", 24, "simulated time in hours")
+	scenarioPath := flag.String("scenario", "", "load the scenario from a JSON file instead of generating it")
+	driversCSV := flag.String("drivers-csv", "", "load drivers from a CSV file (id,lat,long,seats)")
+	requestsCSV := flag.String("requests-csv", "", "load ride requests from a CSV file")
+	outPath := flag.String("out", "", "write the scenario to a JSON file for later replay")
+	verbose := flag.Bool("v", false, "show service logs")
+	flag.Parse()
+
+	duration := time.Duration(*hours * float64(time.Hour))
+
+	var scenario simulation.Scenario
+	var err error
+	if *scenarioPath != "" {
+		scenario, err = simulation.LoadJSON(*scenarioPath)
+		if err != nil {
+			log.Fatalf("❌ failed to load scenario: %v", err)
+		}
+	} else {
+		scenario = simulation.Generate(simulation.GenerateConfig{
+			Seed:       *seed,
+			Drivers:    *drivers,
+			Requests:   *requests,
+			Duration:   duration,
+			CenterLat:  centerLat,
+			CenterLong: centerLong,
+			RadiusKm:   cityRadius,
+			PoolShare:  poolShare,
+		})
+	}
+
+	if *driversCSV != "" {
+		if scenario.Drivers, err = simulation.LoadDriversCSV(*driversCSV); err != nil {
+			log.Fatalf("❌ failed to load drivers: %v", err)
+		}
+	}
+	if *requestsCSV != "" {
+		if scenario.Requests, err = simulation.LoadRequestsCSV(*requestsCSV); err != nil {
+			log.Fatalf("❌ failed to load ride requests: %v", err)
+		}
+	}
+
+	if *outPath != "" {
+		if err = simulation.SaveJSON(*outPath, scenario); err != nil {
+			log.Fatalf("❌ failed to write scenario: %v", err)
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	// The services log every decision; only show that when asked to.
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	sim := simulation.New(scenario, simulation.Config{
+		Seed:       *seed,
+		Start:      time.Date(2025, time.January, 6, 0, 0, 0, 0, time.UTC),
+		Duration:   duration,
+		Tick:       tick,
+		SpeedKmh:   speedKmh,
+		CenterLat:  centerLat,
+		CenterLong: centerLong,
+		RadiusKm:   cityRadius,
+		PendingTTL: pendingTTL,
+		Pool: service.PoolConfig{
+			MaxDetourRatio: poolMaxDetourRatio,
+			MaxDetourKm:    poolMaxDetourKm,
+			MaxPickupKm:    poolMaxPickupKm,
+		},
+	})
+
+	report, err := sim.Run(ctx)
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		log.Fatalf("❌ simulation failed: %v", err)
+	}
+
+	if err = report.Write(os.Stdout); err != nil {
+		log.Fatalf("❌ failed to print report: %v", err)
+	}
+}
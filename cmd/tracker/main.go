@@ -43,6 +43,7 @@ func main() {
 	log.Println("✅ Connected to Redis")
 
 	locationRepo := repository.NewRedisClientRepo(redisClient)
+	meterRepo := repository.NewRedisTripMeterRepo(redisClient)
 
 	// Initialize Kafka Producer
 	producer := kafka.NewProducer([]string{kafkaBroker})
@@ -68,7 +69,7 @@ func main() {
 	var wg sync.WaitGroup
 
 	// Start Kafka ingestion worker
-	worker := service.NewIngestionWorker(consumer, locationRepo)
+	worker := service.NewIngestionWorker(consumer, locationRepo, meterRepo)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -78,7 +79,7 @@ func main() {
 	}()
 
 	// Initialize gRPC server
-	srv := service.NewServer(producer, locationRepo)
+	srv := service.NewServer(producer, locationRepo, meterRepo)
 	grpcServer := grpc.NewServer()
 	tracker.RegisterTrackerServiceServer(grpcServer, srv)
 	reflection.Register(grpcServer)
@@ -0,0 +1,4 @@
+-- internal/dispatch/db/migration/000001_dispatch_audit.down.sql
+-- Rollback for 000001_dispatch_audit.up.sql
+
+DROP TABLE IF EXISTS dispatch_audits;
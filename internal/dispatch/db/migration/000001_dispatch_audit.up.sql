@@ -0,0 +1,20 @@
+-- internal/dispatch/db/migration/000001_dispatch_audit.up.sql
+CREATE TABLE dispatch_audits (
+    id                 BIGSERIAL PRIMARY KEY,
+    ride_id            VARCHAR(64)  NOT NULL, -- order ID the ride was requested for
+    passenger_id       VARCHAR(255) NOT NULL,
+    source             VARCHAR(20)  NOT NULL, -- REQUEST (RequestRide) or QUEUE (pending queue)
+    strategy           VARCHAR(20)  NOT NULL, -- NEAREST or POOL_INSERTION
+    request            JSONB        NOT NULL,
+    candidates         JSONB        NOT NULL DEFAULT '[]',
+    offers             JSONB        NOT NULL DEFAULT '[]',
+    outcome            VARCHAR(20)  NOT NULL, -- DRIVERS_FOUND, SEARCHING, EXPIRED, FAILED
+    driver_id          VARCHAR(255),
+    started_at         TIMESTAMPTZ  NOT NULL,
+    tracker_latency_ms INT          NOT NULL DEFAULT 0,
+    duration_ms        INT          NOT NULL DEFAULT 0,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Support tools always look up the audit trail of a single ride
+CREATE INDEX idx_dispatch_audits_ride ON dispatch_audits (ride_id, started_at);
@@ -0,0 +1,15 @@
+-- internal/dispatch/db/query/dispatch.sql
+
+-- name: CreateDispatchAudit :exec
+INSERT INTO dispatch_audits (
+    ride_id, passenger_id, source, strategy,
+    request, candidates, offers,
+    outcome, driver_id, started_at, tracker_latency_ms, duration_ms
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+         );
+
+-- name: ListDispatchAudits :many
+SELECT * FROM dispatch_audits
+WHERE ride_id = $1
+ORDER BY started_at;
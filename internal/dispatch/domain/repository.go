@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+)
+
+type PendingRideRepository interface {
+	// Add queues a ride request that could not be matched yet.
+	Add(ctx context.Context, ride model.PendingRide) error
+
+	// List returns all queued ride requests, oldest first.
+	List(ctx context.Context) ([]model.PendingRide, error)
+
+	// Claim removes a ride request from the queue. It returns false when the
+	// request was already claimed (matched or expired) by another worker.
+	Claim(ctx context.Context, rideID string) (bool, error)
+
+	// ClaimExpired removes and returns every ride request that expired before now.
+	ClaimExpired(ctx context.Context, now time.Time) ([]model.PendingRide, error)
+}
+
+type PoolTripRepository interface {
+	// Get returns the shared trip a driver is on, or nil when there is none.
+	Get(ctx context.Context, driverID string) (*model.PoolTrip, error)
+
+	// Save stores a trip if nobody updated it since it was read, i.e. the
+	// stored version is trip.Version-1. It returns false on a conflict.
+	// A trip without stops is finished; the driver's next shared ride starts
+	// a new one.
+	Save(ctx context.Context, trip model.PoolTrip) (bool, error)
+}
+
+type AuditRepository interface {
+	// Record stores the audit record of a dispatch attempt.
+	Record(ctx context.Context, audit model.DispatchAudit) error
+
+	// ListByRide returns every dispatch attempt of a ride, oldest first.
+	ListByRide(ctx context.Context, rideID string) ([]model.DispatchAudit, error)
+}
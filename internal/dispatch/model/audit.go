@@ -0,0 +1,98 @@
+package model
+
+import "time"
+
+const (
+	AuditSourceRequest = "REQUEST"
+	AuditSourceQueue   = "QUEUE"
+
+	StrategyNearest       = "NEAREST"
+	StrategyPoolInsertion = "POOL_INSERTION"
+
+	AuditOutcomeFound  = "DRIVERS_FOUND"
+	AuditOutcomeFailed = "FAILED"
+
+	OfferReserved = "RESERVED"
+	OfferBusy     = "BUSY"
+	OfferConflict = "CONFLICT"
+	OfferError    = "ERROR"
+)
+
+// DispatchAudit records how a single dispatch attempt was decided: which
+// drivers the tracker returned, how they were scored, who was offered the
+// ride and what came of it.
+type DispatchAudit struct {
+	RideID         string           `json:"ride_id"`
+	PassengerID    string           `json:"passenger_id"`
+	Source         string           `json:"source"`   // REQUEST, QUEUE
+	Strategy       string           `json:"strategy"` // NEAREST, POOL_INSERTION
+	Request        AuditRequest     `json:"request"`
+	Candidates     []AuditCandidate `json:"candidates"`
+	Offers         []AuditOffer     `json:"offers"`
+	Outcome        string           `json:"outcome"` // DRIVERS_FOUND, SEARCHING, EXPIRED, FAILED
+	DriverID       string           `json:"driver_id,omitempty"`
+	StartedAt      time.Time        `json:"started_at"`
+	TrackerLatency time.Duration    `json:"tracker_latency"`
+	Duration       time.Duration    `json:"duration"`
+}
+
+// AuditRequest is the ride request as dispatch understood it.
+type AuditRequest struct {
+	PickupLat   float64 `json:"pickup_lat"`
+	PickupLong  float64 `json:"pickup_long"`
+	DropoffLat  float64 `json:"dropoff_lat"`
+	DropoffLong float64 `json:"dropoff_long"`
+	VehicleType string  `json:"vehicle_type"`
+	Seats       int     `json:"seats"`
+	RadiusKm    float64 `json:"radius_km"`
+}
+
+// AuditCandidate is a driver the tracker offered, with its score. Lower scores
+// win: the pickup distance for NEAREST, the added route length for
+// POOL_INSERTION, plus a penalty for every star the driver's average rating is
+// short of 5. Queued rides are offered to one driver and scored by distance.
+type AuditCandidate struct {
+	DriverID       string  `json:"driver_id"`
+	DistanceKm     float64 `json:"distance_km"`
+	RemainingSeats int32   `json:"remaining_seats"`
+	Rating         float64 `json:"rating,omitempty"`
+	Score          float64 `json:"score"`
+	Eligible       bool    `json:"eligible"`
+	Note           string  `json:"note,omitempty"`
+}
+
+// AuditOffer is one attempt to reserve a driver for the ride.
+type AuditOffer struct {
+	DriverID  string    `json:"driver_id"`
+	Outcome   string    `json:"outcome"` // RESERVED, BUSY, CONFLICT, ERROR
+	OfferedAt time.Time `json:"offered_at"`
+}
+
+// NewAudit starts the audit record of a dispatch attempt for a ride.
+func NewAudit(ride PendingRide, source string) *DispatchAudit {
+	strategy := StrategyNearest
+	if ride.Pooled() {
+		strategy = StrategyPoolInsertion
+	}
+	return &DispatchAudit{
+		RideID:      ride.RideID,
+		PassengerID: ride.PassengerID,
+		Source:      source,
+		Strategy:    strategy,
+		Request: AuditRequest{
+			PickupLat:   ride.PickupLat,
+			PickupLong:  ride.PickupLong,
+			DropoffLat:  ride.DropoffLat,
+			DropoffLong: ride.DropoffLong,
+			VehicleType: ride.VehicleType,
+			Seats:       ride.Seats,
+			RadiusKm:    ride.RadiusKm,
+		},
+		StartedAt: time.Now(),
+	}
+}
+
+// Offer records a reservation attempt.
+func (a *DispatchAudit) Offer(driverID string, outcome string) {
+	a.Offers = append(a.Offers, AuditOffer{DriverID: driverID, Outcome: outcome, OfferedAt: time.Now()})
+}
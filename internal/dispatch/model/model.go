@@ -1,5 +1,20 @@
 package model
 
+const (
+	RideStatusSearching = "SEARCHING"
+	RideStatusMatched   = "MATCHED"
+	RideStatusExpired   = "EXPIRED"
+)
+
+// VehicleTypePool marks a shared ride that may be combined with other
+// passengers heading the same way.
+const VehicleTypePool = "go-pool"
+
+const (
+	StopPickup  = "PICKUP"
+	StopDropoff = "DROPOFF"
+)
+
 type RideDispatchedEvent struct {
 	RideID      string  `json:"ride_id"`
 	PassengerID string  `json:"passenger_id"`
@@ -7,4 +22,65 @@ type RideDispatchedEvent struct {
 	PickupLat   float64 `json:"pickup_lat"`
 	PickupLong  float64 `json:"pickup_long"`
 	Timestamp   int64   `json:"timestamp"`
+	// PoolTripID and Stops are only set for shared rides. Stops is the
+	// driver's full pickup/drop-off sequence after this ride was added.
+	PoolTripID string     `json:"pool_trip_id,omitempty"`
+	Stops      []PoolStop `json:"stops,omitempty"`
+}
+
+// RideStatusEvent notifies the passenger (and the Order Service) about the
+// progress of a ride request that could not be matched immediately.
+type RideStatusEvent struct {
+	RideID      string `json:"ride_id"`
+	PassengerID string `json:"passenger_id"`
+	DriverID    string `json:"driver_id,omitempty"`
+	Status      string `json:"status"` // SEARCHING, MATCHED, EXPIRED
+	Timestamp   int64  `json:"timestamp"`
+}
+
+// PendingRide is a ride request waiting in the queue for a driver to come online.
+type PendingRide struct {
+	RideID      string  `json:"ride_id"`
+	PassengerID string  `json:"passenger_id"`
+	PickupLat   float64 `json:"pickup_lat"`
+	PickupLong  float64 `json:"pickup_long"`
+	DropoffLat  float64 `json:"dropoff_lat"`
+	DropoffLong float64 `json:"dropoff_long"`
+	VehicleType string  `json:"vehicle_type"`
+	RadiusKm    float64 `json:"radius_km"`
+	Seats       int     `json:"seats,omitempty"`
+	CreatedAt   int64   `json:"created_at"`
+	ExpiresAt   int64   `json:"expires_at"`
+}
+
+// Pooled reports whether the ride may share the vehicle with other passengers.
+func (r PendingRide) Pooled() bool {
+	return r.VehicleType == VehicleTypePool
+}
+
+// PoolStop is one pickup or drop-off on a shared trip.
+type PoolStop struct {
+	RideID      string  `json:"ride_id"`
+	PassengerID string  `json:"passenger_id"`
+	Type        string  `json:"type"` // PICKUP, DROPOFF
+	Lat         float64 `json:"lat"`
+	Long        float64 `json:"long"`
+}
+
+// PoolTrip is the ordered list of stops a driver still has to visit on a
+// shared trip. Version guards concurrent updates of the same trip.
+type PoolTrip struct {
+	TripID   string     `json:"trip_id"`
+	DriverID string     `json:"driver_id"`
+	Stops    []PoolStop `json:"stops"`
+	Version  int64      `json:"version"`
+}
+
+// RideIDs returns the rides that still have a stop on the trip.
+func (t PoolTrip) RideIDs() map[string]bool {
+	ids := make(map[string]bool)
+	for _, stop := range t.Stops {
+		ids[stop.RideID] = true
+	}
+	return ids
 }
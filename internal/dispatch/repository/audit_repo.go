@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/db"
+	"github.com/dwikikusuma/atlas/internal/dispatch/domain"
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type PostgresAuditRepo struct {
+	store db.Querier
+}
+
+func NewPostgresAuditRepo(store db.Querier) domain.AuditRepository {
+	return &PostgresAuditRepo{
+		store: store,
+	}
+}
+
+func (r *PostgresAuditRepo) Record(ctx context.Context, audit model.DispatchAudit) error {
+	request, err := json.Marshal(audit.Request)
+	if err != nil {
+		return err
+	}
+	candidates, err := json.Marshal(nonNil(audit.Candidates))
+	if err != nil {
+		return err
+	}
+	offers, err := json.Marshal(nonNil(audit.Offers))
+	if err != nil {
+		return err
+	}
+
+	err = r.store.CreateDispatchAudit(ctx, db.CreateDispatchAuditParams{
+		RideID:           audit.RideID,
+		PassengerID:      audit.PassengerID,
+		Source:           audit.Source,
+		Strategy:         audit.Strategy,
+		Request:          request,
+		Candidates:       candidates,
+		Offers:           offers,
+		Outcome:          audit.Outcome,
+		DriverID:         pgtype.Text{String: audit.DriverID, Valid: audit.DriverID != ""},
+		StartedAt:        pgtype.Timestamptz{Time: audit.StartedAt, Valid: true},
+		TrackerLatencyMs: int32(audit.TrackerLatency.Milliseconds()),
+		DurationMs:       int32(audit.Duration.Milliseconds()),
+	})
+	if err != nil {
+		log.Printf("postgres insert dispatch audit failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresAuditRepo) ListByRide(ctx context.Context, rideID string) ([]model.DispatchAudit, error) {
+	rows, err := r.store.ListDispatchAudits(ctx, rideID)
+	if err != nil {
+		log.Printf("postgres list dispatch audits failed: %v", err)
+		return nil, err
+	}
+
+	audits := make([]model.DispatchAudit, 0, len(rows))
+	for _, row := range rows {
+		audit := model.DispatchAudit{
+			RideID:         row.RideID,
+			PassengerID:    row.PassengerID,
+			Source:         row.Source,
+			Strategy:       row.Strategy,
+			Outcome:        row.Outcome,
+			DriverID:       row.DriverID.String,
+			StartedAt:      row.StartedAt.Time,
+			TrackerLatency: time.Duration(row.TrackerLatencyMs) * time.Millisecond,
+			Duration:       time.Duration(row.DurationMs) * time.Millisecond,
+		}
+		if err = json.Unmarshal(row.Request, &audit.Request); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(row.Candidates, &audit.Candidates); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(row.Offers, &audit.Offers); err != nil {
+			return nil, err
+		}
+		audits = append(audits, audit)
+	}
+
+	return audits, nil
+}
+
+// nonNil keeps empty lists as [] instead of null in the JSONB columns.
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/domain"
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+)
+
+// The in-memory repositories below mirror the Redis and Postgres ones so the
+// dispatch simulator can run the real DispatchService without any backing
+// infrastructure. Results are ordered deterministically.
+
+type MemoryPendingRepo struct {
+	mu    sync.Mutex
+	rides map[string]model.PendingRide
+}
+
+func NewMemoryPendingRepo() domain.PendingRideRepository {
+	return &MemoryPendingRepo{
+		rides: make(map[string]model.PendingRide),
+	}
+}
+
+func (r *MemoryPendingRepo) Add(ctx context.Context, ride model.PendingRide) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rides[ride.RideID] = ride
+	return nil
+}
+
+func (r *MemoryPendingRepo) List(ctx context.Context) ([]model.PendingRide, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rides := make([]model.PendingRide, 0, len(r.rides))
+	for _, ride := range r.rides {
+		rides = append(rides, ride)
+	}
+	sort.Slice(rides, func(i, j int) bool {
+		if rides[i].CreatedAt != rides[j].CreatedAt {
+			return rides[i].CreatedAt < rides[j].CreatedAt
+		}
+		return rides[i].RideID < rides[j].RideID
+	})
+	return rides, nil
+}
+
+func (r *MemoryPendingRepo) Claim(ctx context.Context, rideID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rides[rideID]; !ok {
+		return false, nil
+	}
+	delete(r.rides, rideID)
+	return true, nil
+}
+
+func (r *MemoryPendingRepo) ClaimExpired(ctx context.Context, now time.Time) ([]model.PendingRide, error) {
+	rides, _ := r.List(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []model.PendingRide
+	for _, ride := range rides {
+		if ride.ExpiresAt <= now.Unix() {
+			delete(r.rides, ride.RideID)
+			expired = append(expired, ride)
+		}
+	}
+	return expired, nil
+}
+
+type MemoryPoolTripRepo struct {
+	mu       sync.Mutex
+	trips    map[string]model.PoolTrip
+	versions map[string]int64
+}
+
+func NewMemoryPoolTripRepo() domain.PoolTripRepository {
+	return &MemoryPoolTripRepo{
+		trips:    make(map[string]model.PoolTrip),
+		versions: make(map[string]int64),
+	}
+}
+
+func (r *MemoryPoolTripRepo) Get(ctx context.Context, driverID string) (*model.PoolTrip, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[driverID]
+	if !ok {
+		return nil, nil
+	}
+	trip.Stops = append([]model.PoolStop(nil), trip.Stops...)
+	return &trip, nil
+}
+
+func (r *MemoryPoolTripRepo) Save(ctx context.Context, trip model.PoolTrip) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.versions[trip.DriverID] != trip.Version-1 {
+		return false, nil
+	}
+	trip.Stops = append([]model.PoolStop(nil), trip.Stops...)
+	r.trips[trip.DriverID] = trip
+	r.versions[trip.DriverID] = trip.Version
+	return true, nil
+}
+
+type MemoryAuditRepo struct {
+	mu     sync.Mutex
+	audits []model.DispatchAudit
+}
+
+func NewMemoryAuditRepo() domain.AuditRepository {
+	return &MemoryAuditRepo{}
+}
+
+func (r *MemoryAuditRepo) Record(ctx context.Context, audit model.DispatchAudit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.audits = append(r.audits, audit)
+	return nil
+}
+
+func (r *MemoryAuditRepo) ListByRide(ctx context.Context, rideID string) ([]model.DispatchAudit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var audits []model.DispatchAudit
+	for _, audit := range r.audits {
+		if audit.RideID == rideID {
+			audits = append(audits, audit)
+		}
+	}
+	return audits, nil
+}
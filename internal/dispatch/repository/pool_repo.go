@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/domain"
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPoolTrips    = "atlas:dispatch:pool:trips"    // HASH driverID -> PoolTrip JSON
+	keyPoolVersions = "atlas:dispatch:pool:versions" // HASH driverID -> trip version
+)
+
+// saveTripScript writes a trip only when the stored version is the one the
+// caller started from.
+//
+// KEYS[1] = trips hash, KEYS[2] = versions hash
+// ARGV[1] = driverID, ARGV[2] = expected version, ARGV[3] = new version, ARGV[4] = trip JSON
+var saveTripScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[2], ARGV[1]) or '0'
+if current ~= ARGV[2] then
+  return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[4])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+return 1
+`)
+
+type RedisPoolTripRepo struct {
+	client *redis.Client
+}
+
+func NewRedisPoolTripRepo(client *redis.Client) domain.PoolTripRepository {
+	return &RedisPoolTripRepo{
+		client: client,
+	}
+}
+
+func (r *RedisPoolTripRepo) Get(ctx context.Context, driverID string) (*model.PoolTrip, error) {
+	payload, err := r.client.HGet(ctx, keyPoolTrips, driverID).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("redis HGet failed: %v", err)
+		return nil, err
+	}
+
+	var trip model.PoolTrip
+	if err = json.Unmarshal([]byte(payload), &trip); err != nil {
+		return nil, err
+	}
+	return &trip, nil
+}
+
+func (r *RedisPoolTripRepo) Save(ctx context.Context, trip model.PoolTrip) (bool, error) {
+	payload, err := json.Marshal(trip)
+	if err != nil {
+		return false, err
+	}
+
+	keys := []string{keyPoolTrips, keyPoolVersions}
+	ok, err := saveTripScript.Run(ctx, r.client, keys, trip.DriverID, trip.Version-1, trip.Version, payload).Int()
+	if err != nil {
+		log.Printf("redis save trip script failed: %v", err)
+		return false, err
+	}
+
+	return ok == 1, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/domain"
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPendingExpiry = "atlas:dispatch:pending"       // ZSET rideID -> expires_at
+	keyPendingRides  = "atlas:dispatch:pending:rides" // HASH rideID -> PendingRide JSON
+)
+
+// claimScript removes a ride from the queue and returns its request, so only
+// the caller whose ZREM removed the ride gets it. It returns false when the
+// ride was already claimed, and "" when its request is missing.
+//
+// KEYS[1] = pending expiry ZSET, KEYS[2] = pending rides HASH
+// ARGV[1] = ride ID
+var claimScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+  return false
+end
+local payload = redis.call('HGET', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+return payload or ''
+`)
+
+type RedisPendingRepo struct {
+	client *redis.Client
+}
+
+func NewRedisPendingRepo(client *redis.Client) domain.PendingRideRepository {
+	return &RedisPendingRepo{
+		client: client,
+	}
+}
+
+func (r *RedisPendingRepo) Add(ctx context.Context, ride model.PendingRide) error {
+	payload, err := json.Marshal(ride)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, keyPendingRides, ride.RideID, payload)
+		pipe.ZAdd(ctx, keyPendingExpiry, redis.Z{
+			Score:  float64(ride.ExpiresAt),
+			Member: ride.RideID,
+		})
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis pending add failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *RedisPendingRepo) List(ctx context.Context) ([]model.PendingRide, error) {
+	res, err := r.client.HGetAll(ctx, keyPendingRides).Result()
+	if err != nil {
+		log.Printf("redis HGetAll failed: %v", err)
+		return nil, err
+	}
+
+	rides := make([]model.PendingRide, 0, len(res))
+	for rideID, payload := range res {
+		var ride model.PendingRide
+		if err = json.Unmarshal([]byte(payload), &ride); err != nil {
+			log.Printf("skipping malformed pending ride %s: %v", rideID, err)
+			continue
+		}
+		rides = append(rides, ride)
+	}
+
+	sort.Slice(rides, func(i, j int) bool {
+		return rides[i].CreatedAt < rides[j].CreatedAt
+	})
+
+	return rides, nil
+}
+
+func (r *RedisPendingRepo) Claim(ctx context.Context, rideID string) (bool, error) {
+	_, claimed, err := r.claim(ctx, rideID)
+	return claimed, err
+}
+
+// claim runs claimScript for a ride and returns its request JSON.
+func (r *RedisPendingRepo) claim(ctx context.Context, rideID string) (string, bool, error) {
+	payload, err := claimScript.Run(ctx, r.client, []string{keyPendingExpiry, keyPendingRides}, rideID).Text()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		log.Printf("redis pending claim failed: %v", err)
+		return "", false, err
+	}
+	return payload, true, nil
+}
+
+func (r *RedisPendingRepo) ClaimExpired(ctx context.Context, now time.Time) ([]model.PendingRide, error) {
+	rideIDs, err := r.client.ZRangeByScore(ctx, keyPendingExpiry, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", now.Unix()),
+	}).Result()
+	if err != nil {
+		log.Printf("redis ZRangeByScore failed: %v", err)
+		return nil, err
+	}
+
+	var expired []model.PendingRide
+	for _, rideID := range rideIDs {
+		payload, claimed, err := r.claim(ctx, rideID)
+		if err != nil {
+			return expired, err
+		}
+		if !claimed || payload == "" {
+			continue
+		}
+
+		var ride model.PendingRide
+		if err = json.Unmarshal([]byte(payload), &ride); err != nil {
+			log.Printf("skipping malformed pending ride %s: %v", rideID, err)
+			continue
+		}
+		expired = append(expired, ride)
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/dispatch"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// auditTimeout bounds how long a dispatch waits for its audit record to be stored.
+const auditTimeout = 2 * time.Second
+
+// recordAudit stores the audit record of a dispatch attempt. The audit trail
+// is for support tooling only, so a failure is logged and never fails the ride.
+func (s *DispatchService) recordAudit(ctx context.Context, audit *model.DispatchAudit) {
+	audit.Duration = time.Since(audit.StartedAt)
+
+	dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditTimeout)
+	defer cancel()
+
+	if err := s.audits.Record(dbCtx, *audit); err != nil {
+		log.Printf("❌ Failed to record dispatch audit for ride %s: %v", audit.RideID, err)
+	}
+}
+
+func (s *DispatchService) GetDispatchAudit(ctx context.Context, req *dispatch.GetDispatchAuditRequest) (*dispatch.GetDispatchAuditResponse, error) {
+	if req.OrderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "order ID is required")
+	}
+
+	audits, err := s.audits.ListByRide(ctx, req.OrderId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load dispatch audit: %v", err)
+	}
+	if len(audits) == 0 {
+		return nil, status.Error(codes.NotFound, "no dispatch audit for this order")
+	}
+
+	res := &dispatch.GetDispatchAuditResponse{}
+	for _, a := range audits {
+		res.Audits = append(res.Audits, toProtoAudit(a))
+	}
+	return res, nil
+}
+
+func toProtoAudit(a model.DispatchAudit) *dispatch.DispatchAudit {
+	out := &dispatch.DispatchAudit{
+		RideId:   a.RideID,
+		Source:   a.Source,
+		Strategy: a.Strategy,
+		Request: &dispatch.RequestRideRequest{
+			OrderId:        a.RideID,
+			PassengerId:    a.PassengerID,
+			PickupLat:      a.Request.PickupLat,
+			PickupLong:     a.Request.PickupLong,
+			DropoffLat:     a.Request.DropoffLat,
+			DropoffLong:    a.Request.DropoffLong,
+			VehicleType:    a.Request.VehicleType,
+			SearchRadiusKm: a.Request.RadiusKm,
+			Seats:          int32(a.Request.Seats),
+		},
+		Outcome:          a.Outcome,
+		DriverId:         a.DriverID,
+		StartedAt:        a.StartedAt.UTC().Format(time.RFC3339Nano),
+		TrackerLatencyMs: a.TrackerLatency.Milliseconds(),
+		DurationMs:       a.Duration.Milliseconds(),
+	}
+
+	for _, c := range a.Candidates {
+		out.Candidates = append(out.Candidates, &dispatch.AuditCandidate{
+			DriverId:       c.DriverID,
+			DistanceKm:     c.DistanceKm,
+			RemainingSeats: c.RemainingSeats,
+			Score:          c.Score,
+			Rating:         c.Rating,
+			Eligible:       c.Eligible,
+			Note:           c.Note,
+		})
+	}
+	for _, o := range a.Offers {
+		out.Offers = append(out.Offers, &dispatch.AuditOffer{
+			DriverId:  o.DriverID,
+			Outcome:   o.Outcome,
+			OfferedAt: o.OfferedAt.UTC().Format(time.RFC3339Nano),
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+	pkgModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
+)
+
+// stopArrivalKm is how close a driver has to get to a stop for it to count as visited.
+const stopArrivalKm = 0.15
+
+// PoolConfig bounds how much a shared ride may inconvenience its passengers.
+type PoolConfig struct {
+	// MaxDetourRatio is the extra in-vehicle distance a passenger accepts
+	// compared to riding alone, e.g. 0.5 allows a 50% longer ride.
+	MaxDetourRatio float64
+	// MaxDetourKm caps the extra distance per passenger regardless of the ratio.
+	MaxDetourKm float64
+	// MaxPickupKm caps how far the driver travels before picking up a waiting
+	// passenger, so a ride is not just appended to the end of a long trip.
+	MaxPickupKm float64
+}
+
+// planInsertion finds the cheapest place to add a ride's pickup and drop-off
+// to a driver's remaining stops. Every passenger on the resulting route,
+// including the new one, must stay within the configured detour. It returns
+// the new stop sequence and the extra distance the driver has to cover.
+func planInsertion(lat, long float64, stops []model.PoolStop, ride model.PendingRide, cfg PoolConfig) ([]model.PoolStop, float64, bool) {
+	pickup := model.PoolStop{
+		RideID:      ride.RideID,
+		PassengerID: ride.PassengerID,
+		Type:        model.StopPickup,
+		Lat:         ride.PickupLat,
+		Long:        ride.PickupLong,
+	}
+	dropoff := model.PoolStop{
+		RideID:      ride.RideID,
+		PassengerID: ride.PassengerID,
+		Type:        model.StopDropoff,
+		Lat:         ride.DropoffLat,
+		Long:        ride.DropoffLong,
+	}
+
+	var best []model.PoolStop
+	bestKm := math.Inf(1)
+	for i := 0; i <= len(stops); i++ {
+		for j := i; j <= len(stops); j++ {
+			candidate := make([]model.PoolStop, 0, len(stops)+2)
+			candidate = append(candidate, stops[:i]...)
+			candidate = append(candidate, pickup)
+			candidate = append(candidate, stops[i:j]...)
+			candidate = append(candidate, dropoff)
+			candidate = append(candidate, stops[j:]...)
+
+			if !withinDetour(lat, long, candidate, cfg) {
+				continue
+			}
+			if km := routeKm(lat, long, candidate); km < bestKm {
+				best, bestKm = candidate, km
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, 0, false
+	}
+	return best, bestKm - routeKm(lat, long, stops), true
+}
+
+// routeKm is the length of the route from the driver through every stop.
+func routeKm(lat, long float64, stops []model.PoolStop) float64 {
+	total := 0.0
+	for _, stop := range stops {
+		total += distanceKm(lat, long, stop.Lat, stop.Long)
+		lat, long = stop.Lat, stop.Long
+	}
+	return total
+}
+
+// withinDetour checks that no passenger waits too long for the pickup or
+// rides much longer than they would alone. Passengers already on board (no
+// pickup left) are measured from the driver's current position.
+func withinDetour(lat, long float64, stops []model.PoolStop, cfg PoolConfig) bool {
+	type leg struct {
+		lat, long float64
+		atKm      float64
+	}
+	pickups := make(map[string]leg)
+
+	travelled := 0.0
+	prevLat, prevLong := lat, long
+	for _, stop := range stops {
+		travelled += distanceKm(prevLat, prevLong, stop.Lat, stop.Long)
+		prevLat, prevLong = stop.Lat, stop.Long
+
+		if stop.Type == model.StopPickup {
+			if cfg.MaxPickupKm > 0 && travelled > cfg.MaxPickupKm {
+				return false
+			}
+			pickups[stop.RideID] = leg{lat: stop.Lat, long: stop.Long, atKm: travelled}
+			continue
+		}
+
+		start, ok := pickups[stop.RideID]
+		if !ok {
+			start = leg{lat: lat, long: long}
+		}
+		direct := distanceKm(start.lat, start.long, stop.Lat, stop.Long)
+		extra := travelled - start.atKm - direct
+		if extra > direct*cfg.MaxDetourRatio+1e-9 {
+			return false
+		}
+		if cfg.MaxDetourKm > 0 && extra > cfg.MaxDetourKm {
+			return false
+		}
+	}
+	return true
+}
+
+// planTrip adds a ride to the driver's current shared trip. activeRideIDs
+// comes from the tracker and drops stops of rides that ended elsewhere
+// (finished or cancelled); nil keeps the trip as stored.
+func (s *DispatchService) planTrip(ctx context.Context, driverID string, lat, long float64, activeRideIDs []string, ride model.PendingRide) (*model.PoolTrip, float64, bool, error) {
+	trip, err := s.pools.Get(ctx, driverID)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if trip == nil {
+		trip = &model.PoolTrip{DriverID: driverID}
+	}
+
+	if activeRideIDs != nil {
+		active := make(map[string]bool, len(activeRideIDs))
+		for _, id := range activeRideIDs {
+			active[id] = true
+		}
+		kept := trip.Stops[:0]
+		for _, stop := range trip.Stops {
+			if active[stop.RideID] {
+				kept = append(kept, stop)
+			}
+		}
+		trip.Stops = kept
+	}
+
+	stops, addedKm, ok := planInsertion(lat, long, trip.Stops, ride, s.poolCfg)
+	if !ok {
+		return nil, 0, false, nil
+	}
+
+	if len(trip.Stops) == 0 {
+		trip.TripID = "pool-" + ride.RideID
+	}
+	trip.Stops = stops
+	trip.Version++
+	return trip, addedKm, true, nil
+}
+
+// assignPool adds a shared ride to the nearby trip that grows the least,
+// weighed against the rating of its driver.
+// It returns a nil trip when no driver can take the ride.
+func (s *DispatchService) assignPool(ctx context.Context, ride model.PendingRide, drivers []*tracker.Driver, audit *model.DispatchAudit) (string, *model.PoolTrip, error) {
+	type plan struct {
+		driverID string
+		trip     *model.PoolTrip
+		score    float64
+	}
+
+	driverIDs := make([]string, 0, len(drivers))
+	for _, d := range drivers {
+		driverIDs = append(driverIDs, d.DriverId)
+	}
+	ratings := s.driverRatings(ctx, driverIDs)
+
+	var plans []plan
+	for _, d := range drivers {
+		active := d.ActiveRideIds
+		if active == nil {
+			active = []string{}
+		}
+		trip, addedKm, ok, err := s.planTrip(ctx, d.DriverId, d.Latitude, d.Longitude, active, ride)
+		if err != nil {
+			return "", nil, err
+		}
+
+		rating := ratingOf(ratings, d.DriverId)
+		candidate := model.AuditCandidate{
+			DriverID:       d.DriverId,
+			DistanceKm:     distanceKm(ride.PickupLat, ride.PickupLong, d.Latitude, d.Longitude),
+			RemainingSeats: d.RemainingSeats,
+			Rating:         rating,
+			Score:          score(addedKm, rating),
+			Eligible:       ok,
+		}
+		if !ok {
+			candidate.Note = "detour or pickup limit exceeded"
+		}
+		audit.Candidates = append(audit.Candidates, candidate)
+
+		if ok {
+			plans = append(plans, plan{driverID: d.DriverId, trip: trip, score: candidate.Score})
+		}
+	}
+
+	sort.SliceStable(plans, func(i, j int) bool {
+		return plans[i].score < plans[j].score
+	})
+
+	for _, p := range plans {
+		reserved, err := s.reserveDriver(ctx, p.driverID, ride.RideID, ride.Seats)
+		if err != nil {
+			audit.Offer(p.driverID, model.OfferError)
+			return "", nil, err
+		}
+		if !reserved {
+			audit.Offer(p.driverID, model.OfferBusy)
+			continue
+		}
+
+		saved, err := s.pools.Save(ctx, *p.trip)
+		if err != nil || !saved {
+			// Another ride joined this trip in the meantime; try the next driver.
+			s.releaseDriver(ctx, p.driverID, ride.RideID)
+			if err != nil {
+				audit.Offer(p.driverID, model.OfferError)
+				return "", nil, err
+			}
+			audit.Offer(p.driverID, model.OfferConflict)
+			continue
+		}
+		audit.Offer(p.driverID, model.OfferReserved)
+
+		if err = s.dispatchRide(ctx, ride, p.driverID, p.trip); err != nil {
+			s.leaveTrip(ctx, p.driverID, ride.RideID)
+			return "", nil, err
+		}
+		return p.driverID, p.trip, nil
+	}
+
+	return "", nil, nil
+}
+
+// AdvancePoolTrip drops the stops a driver has reached from its shared trip.
+func (s *DispatchService) AdvancePoolTrip(ctx context.Context, driver pkgModel.LocationEvent) error {
+	trip, err := s.pools.Get(ctx, driver.UserID)
+	if err != nil || trip == nil || len(trip.Stops) == 0 {
+		return err
+	}
+
+	visited := 0
+	for visited < len(trip.Stops) {
+		stop := trip.Stops[visited]
+		if distanceKm(driver.Latitude, driver.Longitude, stop.Lat, stop.Long) > stopArrivalKm {
+			break
+		}
+		visited++
+	}
+	if visited == 0 {
+		return nil
+	}
+
+	trip.Stops = trip.Stops[visited:]
+	trip.Version++
+	if _, err = s.pools.Save(ctx, *trip); err != nil {
+		return err
+	}
+	// On a conflict the next location update tries again.
+	return nil
+}
+
+// LeavePoolTrip removes a cancelled ride from the driver's shared trip.
+func (s *DispatchService) LeavePoolTrip(ctx context.Context, driverID string, rideID string) error {
+	const maxAttempts = 3
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		trip, err := s.pools.Get(ctx, driverID)
+		if err != nil || trip == nil {
+			return err
+		}
+
+		kept := make([]model.PoolStop, 0, len(trip.Stops))
+		for _, stop := range trip.Stops {
+			if stop.RideID != rideID {
+				kept = append(kept, stop)
+			}
+		}
+		if len(kept) == len(trip.Stops) {
+			return nil
+		}
+
+		trip.Stops = kept
+		trip.Version++
+		saved, err := s.pools.Save(ctx, *trip)
+		if err != nil {
+			return err
+		}
+		if saved {
+			log.Printf("🚫 Ride %s left shared trip %s", rideID, trip.TripID)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("pool trip of driver %s kept changing while removing ride %s", driverID, rideID)
+}
+
+// leaveTrip undoes a pooled assignment that could not be announced.
+func (s *DispatchService) leaveTrip(ctx context.Context, driverID string, rideID string) {
+	if err := s.LeavePoolTrip(ctx, driverID, rideID); err != nil {
+		log.Printf("❌ Failed to remove ride %s from pool trip: %v", rideID, err)
+	}
+	s.releaseDriver(ctx, driverID, rideID)
+}
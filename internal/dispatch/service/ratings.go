@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+)
+
+const (
+	// driverRole is the side drivers are rated as by the Order Service.
+	driverRole = "DRIVER"
+	maxRating  = 5.0
+	// unratedAverage stands in for drivers nobody rated yet, so a new driver
+	// neither beats a well rated one nor is left without rides.
+	unratedAverage = 4.5
+	// ratingWeightKm is what one star is worth in kilometres: a driver rated
+	// a star lower must be that much closer, or add that much less to a shared
+	// trip, to be offered the ride first.
+	ratingWeightKm = 0.5
+)
+
+// driverRatings looks up the average rating of the candidates from the Order
+// Service. Ratings only refine the order drivers are offered a ride in, so
+// when the lookup fails every driver counts as unrated and dispatch goes on.
+func (s *DispatchService) driverRatings(ctx context.Context, driverIDs []string) map[string]float64 {
+	if len(driverIDs) == 0 {
+		return nil
+	}
+
+	res, err := s.orderClient.GetUserRatings(ctx, &order.GetUserRatingsRequest{UserIds: driverIDs, Role: driverRole})
+	if err != nil {
+		log.Printf("⚠️ Failed to get driver ratings, scoring by distance only: %v", err)
+		return nil
+	}
+
+	averages := make(map[string]float64, len(res.Ratings))
+	for _, r := range res.Ratings {
+		averages[r.UserId] = r.Average
+	}
+	return averages
+}
+
+// ratingOf returns a driver's average rating, or unratedAverage.
+func ratingOf(averages map[string]float64, driverID string) float64 {
+	if average, ok := averages[driverID]; ok {
+		return average
+	}
+	return unratedAverage
+}
+
+// score ranks a candidate, lower first: km is the pickup distance, or the
+// route added to a shared trip, and every star short of 5 adds ratingWeightKm.
+func score(km float64, rating float64) float64 {
+	return km + (maxRating-rating)*ratingWeightKm
+}
@@ -4,74 +4,410 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"sort"
 	"time"
 
+	"github.com/dwikikusuma/atlas/internal/dispatch/domain"
 	"github.com/dwikikusuma/atlas/internal/dispatch/model"
 	"github.com/dwikikusuma/atlas/pkg/kafka"
+	pkgModel "github.com/dwikikusuma/atlas/pkg/model"
 	"github.com/dwikikusuma/atlas/pkg/pb/dispatch"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
 	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 const (
-	dispatchTopic = "ride-dispatch"
+	dispatchTopic   = "ride-dispatch"
+	rideStatusTopic = "ride-status"
+	searchRadiusKm  = 5.0
 )
 
 type DispatchService struct {
 	dispatch.UnimplementedDispatchServiceServer
 	trackerClient tracker.TrackerServiceClient
-	producer      *kafka.Producer
+	orderClient   order.OrderServiceClient
+	producer      kafka.EventProducer
+	pending       domain.PendingRideRepository
+	pools         domain.PoolTripRepository
+	audits        domain.AuditRepository
+	pendingTTL    time.Duration
+	poolCfg       PoolConfig
+	now           func() time.Time
 }
 
-func NewDispatchService(trackerClient tracker.TrackerServiceClient, producer *kafka.Producer) *DispatchService {
+func NewDispatchService(trackerClient tracker.TrackerServiceClient, orderClient order.OrderServiceClient, producer kafka.EventProducer, pending domain.PendingRideRepository, pools domain.PoolTripRepository, audits domain.AuditRepository, pendingTTL time.Duration, poolCfg PoolConfig) *DispatchService {
 	return &DispatchService{
 		trackerClient: trackerClient,
+		orderClient:   orderClient,
 		producer:      producer,
+		pending:       pending,
+		pools:         pools,
+		audits:        audits,
+		pendingTTL:    pendingTTL,
+		poolCfg:       poolCfg,
+		now:           time.Now,
 	}
 }
 
+// UseClock replaces the wall clock used for queue expiry and event
+// timestamps, so the simulator can run the service on virtual time.
+func (s *DispatchService) UseClock(now func() time.Time) {
+	s.now = now
+}
+
 func (s *DispatchService) RequestRide(ctx context.Context, req *dispatch.RequestRideRequest) (*dispatch.RequestRideResponse, error) {
+	if req.OrderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "order ID is required")
+	}
+
+	radius := req.SearchRadiusKm
+	if radius <= 0 {
+		radius = searchRadiusKm
+	}
+
+	// Exclusive rides book the whole vehicle (0 seats); shared rides book
+	// one seat per passenger.
+	seats := 0
+	if req.VehicleType == model.VehicleTypePool {
+		seats = int(req.Seats)
+		if seats <= 0 {
+			seats = 1
+		}
+	}
+
+	now := s.now()
+	ride := model.PendingRide{
+		RideID:      req.OrderId,
+		PassengerID: req.PassengerId,
+		PickupLat:   req.PickupLat,
+		PickupLong:  req.PickupLong,
+		DropoffLat:  req.DropoffLat,
+		DropoffLong: req.DropoffLong,
+		VehicleType: req.VehicleType,
+		RadiusKm:    radius,
+		Seats:       seats,
+		CreatedAt:   now.Unix(),
+		ExpiresAt:   now.Add(s.pendingTTL).Unix(),
+	}
+
+	audit := model.NewAudit(ride, model.AuditSourceRequest)
+	resp, err := s.findDriver(ctx, ride, audit)
+	if err != nil {
+		audit.Outcome = model.AuditOutcomeFailed
+	} else {
+		audit.Outcome = resp.Status
+		audit.DriverID = resp.DriverId
+	}
+	s.recordAudit(ctx, audit)
+
+	return resp, err
+}
+
+func (s *DispatchService) findDriver(ctx context.Context, ride model.PendingRide, audit *model.DispatchAudit) (*dispatch.RequestRideResponse, error) {
+	trackerStart := time.Now()
 	res, err := s.trackerClient.GetNearbyDrivers(ctx, &tracker.GetNearbyDriverRequest{
-		Longitude: req.PickupLong,
-		Latitude:  req.PickupLat,
-		Radius:    5, // 5 km radius
+		Longitude: ride.PickupLong,
+		Latitude:  ride.PickupLat,
+		Radius:    ride.RadiusKm,
+		MinSeats:  int32(ride.Seats),
 	})
+	audit.TrackerLatency = time.Since(trackerStart)
 
 	if err != nil {
 		log.Printf("❌ Failed to query tracker: %v", err)
 		return nil, status.Errorf(codes.Unavailable, "failed to query tracker: %v", err)
 	}
 
-	var selectedDriverId string
-	searchStatus := "DRIVERS_NOT_FOUND"
-	if len(res.Drivers) > 0 {
-		searchStatus = "DRIVERS_FOUND"
-		selectedDriverId = res.Drivers[0].DriverId
-
-		msg := model.RideDispatchedEvent{
-			RideID:      req.PassengerId,
-			PassengerID: req.PassengerId,
-			DriverID:    selectedDriverId,
-			PickupLat:   req.PickupLat,
-			PickupLong:  req.PickupLong,
-			Timestamp:   time.Now().Unix(),
+	if ride.Pooled() {
+		driverID, trip, err := s.assignPool(ctx, ride, res.Drivers, audit)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to assign shared ride: %v", err)
+		}
+		if trip == nil {
+			return s.queueRide(ctx, ride)
 		}
+		return &dispatch.RequestRideResponse{
+			Status:     "DRIVERS_FOUND",
+			RideId:     ride.RideID,
+			DriverId:   driverID,
+			PoolTripId: trip.TripID,
+		}, nil
+	}
 
-		payload, _ := json.Marshal(&msg)
+	driverIDs := make([]string, 0, len(res.Drivers))
+	for _, driver := range res.Drivers {
+		driverIDs = append(driverIDs, driver.DriverId)
+	}
+	ratings := s.driverRatings(ctx, driverIDs)
+
+	scores := make(map[string]float64, len(res.Drivers))
+	for _, driver := range res.Drivers {
+		distance := distanceKm(ride.PickupLat, ride.PickupLong, driver.Latitude, driver.Longitude)
+		rating := ratingOf(ratings, driver.DriverId)
+		scores[driver.DriverId] = score(distance, rating)
+		audit.Candidates = append(audit.Candidates, model.AuditCandidate{
+			DriverID:       driver.DriverId,
+			DistanceKm:     distance,
+			RemainingSeats: driver.RemainingSeats,
+			Rating:         rating,
+			Score:          scores[driver.DriverId],
+			Eligible:       true,
+		})
+	}
 
-		err = s.producer.Publish(ctx, dispatchTopic, selectedDriverId, payload)
+	// Drivers come back sorted by distance; offer the ride by score, so the
+	// better rated of two drivers about as close gets it first.
+	drivers := append([]*tracker.Driver(nil), res.Drivers...)
+	sort.SliceStable(drivers, func(i, j int) bool {
+		return scores[drivers[i].DriverId] < scores[drivers[j].DriverId]
+	})
+
+	// Take the best scored driver that is still free.
+	for _, driver := range drivers {
+		reserved, err := s.reserveDriver(ctx, driver.DriverId, ride.RideID, ride.Seats)
 		if err != nil {
-			log.Printf("❌ Failed to publish dispatch event: %v", err)
+			audit.Offer(driver.DriverId, model.OfferError)
+			return nil, status.Errorf(codes.Unavailable, "failed to reserve driver: %v", err)
+		}
+		if !reserved {
+			audit.Offer(driver.DriverId, model.OfferBusy)
+			continue
+		}
+		audit.Offer(driver.DriverId, model.OfferReserved)
+
+		if err = s.dispatchRide(ctx, ride, driver.DriverId, nil); err != nil {
+			s.releaseDriver(ctx, driver.DriverId, ride.RideID)
 			return nil, status.Errorf(codes.Internal, "failed to publish dispatch event: %v", err)
-		} else {
-			log.Printf("✅ Event Published: Passenger %s -> Driver %s", req.PassengerId, selectedDriverId)
 		}
+
+		return &dispatch.RequestRideResponse{
+			Status:   "DRIVERS_FOUND",
+			RideId:   ride.RideID,
+			DriverId: driver.DriverId,
+		}, nil
+	}
+
+	return s.queueRide(ctx, ride)
+}
+
+func (s *DispatchService) queueRide(ctx context.Context, ride model.PendingRide) (*dispatch.RequestRideResponse, error) {
+	// Nobody is around right now: park the request until supply shows up.
+	if err := s.pending.Add(ctx, ride); err != nil {
+		log.Printf("❌ Failed to queue ride %s: %v", ride.RideID, err)
+		return nil, status.Errorf(codes.Internal, "failed to queue ride request: %v", err)
 	}
 
+	if err := s.publishStatus(ctx, ride, "", model.RideStatusSearching); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to publish ride status: %v", err)
+	}
+
+	log.Printf("⏳ No drivers near ride %s, queued until %s", ride.RideID, time.Unix(ride.ExpiresAt, 0).Format(time.RFC3339))
 	return &dispatch.RequestRideResponse{
-		Status:   searchStatus,
-		RideId:   req.PassengerId,
-		DriverId: selectedDriverId,
+		Status: model.RideStatusSearching,
+		RideId: ride.RideID,
 	}, nil
 }
+
+// MatchPending offers the oldest queued ride within reach to a driver that just
+// reported its position. At most one ride is matched per location update, and
+// drivers already reserved for another ride are skipped. Shared rides may join
+// the driver's current trip if the detour limits allow it.
+func (s *DispatchService) MatchPending(ctx context.Context, driver pkgModel.LocationEvent) error {
+	rides, err := s.pending.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().Unix()
+	for _, ride := range rides {
+		if ride.ExpiresAt <= now {
+			// Left for ExpirePending so the passenger gets notified.
+			continue
+		}
+
+		radius := ride.RadiusKm
+		if radius <= 0 {
+			radius = searchRadiusKm
+		}
+		distance := distanceKm(driver.Latitude, driver.Longitude, ride.PickupLat, ride.PickupLong)
+		if distance > radius {
+			continue
+		}
+
+		audit := model.NewAudit(ride, model.AuditSourceQueue)
+		candidate := model.AuditCandidate{DriverID: driver.UserID, DistanceKm: distance, Score: distance, Eligible: true}
+
+		var trip *model.PoolTrip
+		if ride.Pooled() {
+			planned, addedKm, ok, err := s.planTrip(ctx, driver.UserID, driver.Latitude, driver.Longitude, nil, ride)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			trip = planned
+			candidate.Score = addedKm
+		}
+		audit.Candidates = append(audit.Candidates, candidate)
+
+		reserved, err := s.reserveDriver(ctx, driver.UserID, ride.RideID, ride.Seats)
+		if err != nil {
+			return err
+		}
+		if !reserved {
+			if ride.Pooled() {
+				// Not enough free seats; a smaller party may still fit.
+				continue
+			}
+			// The driver is already on a ride.
+			return nil
+		}
+
+		claimed, err := s.pending.Claim(ctx, ride.RideID)
+		if err != nil || !claimed {
+			s.releaseDriver(ctx, driver.UserID, ride.RideID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if trip != nil {
+			saved, err := s.pools.Save(ctx, *trip)
+			if err != nil || !saved {
+				s.releaseDriver(ctx, driver.UserID, ride.RideID)
+				s.requeue(ctx, ride)
+				return err
+			}
+		}
+
+		if err = s.dispatchRide(ctx, ride, driver.UserID, trip); err != nil {
+			// Put the ride back so the next driver update can pick it up.
+			if trip != nil {
+				s.leaveTrip(ctx, driver.UserID, ride.RideID)
+			} else {
+				s.releaseDriver(ctx, driver.UserID, ride.RideID)
+			}
+			s.requeue(ctx, ride)
+			return err
+		}
+
+		audit.Offer(driver.UserID, model.OfferReserved)
+		audit.Outcome = model.AuditOutcomeFound
+		audit.DriverID = driver.UserID
+		s.recordAudit(ctx, audit)
+		return nil
+	}
+
+	return nil
+}
+
+// ExpirePending drops every queued ride past its expiry and notifies the passenger.
+func (s *DispatchService) ExpirePending(ctx context.Context) error {
+	rides, err := s.pending.ClaimExpired(ctx, s.now())
+	if err != nil {
+		return err
+	}
+
+	for _, ride := range rides {
+		if err = s.publishStatus(ctx, ride, "", model.RideStatusExpired); err != nil {
+			return err
+		}
+		log.Printf("⌛ Ride %s expired without a driver", ride.RideID)
+
+		audit := model.NewAudit(ride, model.AuditSourceQueue)
+		audit.Outcome = model.RideStatusExpired
+		s.recordAudit(ctx, audit)
+	}
+
+	return nil
+}
+
+// CancelPending drops a cancelled order from the queue so it is never matched.
+func (s *DispatchService) CancelPending(ctx context.Context, rideID string) error {
+	claimed, err := s.pending.Claim(ctx, rideID)
+	if err != nil {
+		return err
+	}
+	if claimed {
+		log.Printf("🚫 Ride %s cancelled while searching, removed from queue", rideID)
+	}
+	return nil
+}
+
+func (s *DispatchService) requeue(ctx context.Context, ride model.PendingRide) {
+	if err := s.pending.Add(ctx, ride); err != nil {
+		log.Printf("❌ Failed to re-queue ride %s: %v", ride.RideID, err)
+	}
+}
+
+func (s *DispatchService) reserveDriver(ctx context.Context, driverID string, rideID string, seats int) (bool, error) {
+	res, err := s.trackerClient.ReserveDriver(ctx, &tracker.ReserveDriverRequest{
+		DriverId: driverID,
+		RideId:   rideID,
+		Seats:    int32(seats),
+	})
+	if err != nil {
+		log.Printf("❌ Failed to reserve driver %s: %v", driverID, err)
+		return false, err
+	}
+	return res.Reserved, nil
+}
+
+func (s *DispatchService) releaseDriver(ctx context.Context, driverID string, rideID string) {
+	if _, err := s.trackerClient.ReleaseDriver(ctx, &tracker.ReleaseDriverRequest{DriverId: driverID, RideId: rideID}); err != nil {
+		log.Printf("❌ Failed to release driver %s: %v", driverID, err)
+	}
+}
+
+func (s *DispatchService) dispatchRide(ctx context.Context, ride model.PendingRide, driverID string, trip *model.PoolTrip) error {
+	msg := model.RideDispatchedEvent{
+		RideID:      ride.RideID,
+		PassengerID: ride.PassengerID,
+		DriverID:    driverID,
+		PickupLat:   ride.PickupLat,
+		PickupLong:  ride.PickupLong,
+		Timestamp:   s.now().Unix(),
+	}
+	if trip != nil {
+		msg.PoolTripID = trip.TripID
+		msg.Stops = trip.Stops
+	}
+
+	payload, err := json.Marshal(&msg)
+	if err != nil {
+		return err
+	}
+
+	if err = s.producer.Publish(ctx, dispatchTopic, driverID, payload); err != nil {
+		log.Printf("❌ Failed to publish dispatch event: %v", err)
+		return err
+	}
+	log.Printf("✅ Event Published: Passenger %s -> Driver %s", ride.PassengerID, driverID)
+
+	return s.publishStatus(ctx, ride, driverID, model.RideStatusMatched)
+}
+
+func (s *DispatchService) publishStatus(ctx context.Context, ride model.PendingRide, driverID string, rideStatus string) error {
+	msg := model.RideStatusEvent{
+		RideID:      ride.RideID,
+		PassengerID: ride.PassengerID,
+		DriverID:    driverID,
+		Status:      rideStatus,
+		Timestamp:   s.now().Unix(),
+	}
+
+	payload, err := json.Marshal(&msg)
+	if err != nil {
+		return err
+	}
+
+	if err = s.producer.Publish(ctx, rideStatusTopic, ride.PassengerID, payload); err != nil {
+		log.Printf("❌ Failed to publish ride status event: %v", err)
+		return err
+	}
+	return nil
+}
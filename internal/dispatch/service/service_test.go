@@ -0,0 +1,473 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+	pkgModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/dispatch"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// =============================================================================
+// MOCKS
+// =============================================================================
+
+type MockTrackerClient struct {
+	tracker.TrackerServiceClient
+	mock.Mock
+}
+
+func (m *MockTrackerClient) GetNearbyDrivers(ctx context.Context, in *tracker.GetNearbyDriverRequest, opts ...grpc.CallOption) (*tracker.GetNearbyDriverResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*tracker.GetNearbyDriverResponse), args.Error(1)
+}
+
+func (m *MockTrackerClient) ReserveDriver(ctx context.Context, in *tracker.ReserveDriverRequest, opts ...grpc.CallOption) (*tracker.ReserveDriverResponse, error) {
+	args := m.Called(ctx, in.DriverId, in.RideId, in.Seats)
+	return &tracker.ReserveDriverResponse{Reserved: args.Bool(0)}, args.Error(1)
+}
+
+func (m *MockTrackerClient) ReleaseDriver(ctx context.Context, in *tracker.ReleaseDriverRequest, opts ...grpc.CallOption) (*tracker.ReleaseDriverResponse, error) {
+	args := m.Called(ctx, in.DriverId, in.RideId)
+	return &tracker.ReleaseDriverResponse{Success: true}, args.Error(0)
+}
+
+type MockOrderClient struct {
+	order.OrderServiceClient
+	mock.Mock
+}
+
+func (m *MockOrderClient) GetUserRatings(ctx context.Context, in *order.GetUserRatingsRequest, opts ...grpc.CallOption) (*order.GetUserRatingsResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*order.GetUserRatingsResponse), args.Error(1)
+}
+
+// newMockOrderClient knows no ratings, for tests that do not score by them.
+func newMockOrderClient() *MockOrderClient {
+	m := new(MockOrderClient)
+	m.On("GetUserRatings", mock.Anything, mock.Anything).Return(&order.GetUserRatingsResponse{}, nil).Maybe()
+	return m
+}
+
+type MockEventProducer struct {
+	mock.Mock
+}
+
+func (m *MockEventProducer) Publish(ctx context.Context, topic string, key string, value []byte) error {
+	args := m.Called(ctx, topic, key, value)
+	return args.Error(0)
+}
+
+func (m *MockEventProducer) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+type MockPendingRepo struct {
+	mock.Mock
+}
+
+func (m *MockPendingRepo) Add(ctx context.Context, ride model.PendingRide) error {
+	args := m.Called(ctx, ride)
+	return args.Error(0)
+}
+
+func (m *MockPendingRepo) List(ctx context.Context) ([]model.PendingRide, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.PendingRide), args.Error(1)
+}
+
+func (m *MockPendingRepo) Claim(ctx context.Context, rideID string) (bool, error) {
+	args := m.Called(ctx, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPendingRepo) ClaimExpired(ctx context.Context, now time.Time) ([]model.PendingRide, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]model.PendingRide), args.Error(1)
+}
+
+type MockPoolRepo struct {
+	mock.Mock
+}
+
+func (m *MockPoolRepo) Get(ctx context.Context, driverID string) (*model.PoolTrip, error) {
+	args := m.Called(ctx, driverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PoolTrip), args.Error(1)
+}
+
+func (m *MockPoolRepo) Save(ctx context.Context, trip model.PoolTrip) (bool, error) {
+	args := m.Called(ctx, trip)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepo) Record(ctx context.Context, audit model.DispatchAudit) error {
+	args := m.Called(ctx, audit)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepo) ListByRide(ctx context.Context, rideID string) ([]model.DispatchAudit, error) {
+	args := m.Called(ctx, rideID)
+	return args.Get(0).([]model.DispatchAudit), args.Error(1)
+}
+
+// newMockAuditRepo accepts any audit record, for tests that do not inspect them.
+func newMockAuditRepo() *MockAuditRepo {
+	m := new(MockAuditRepo)
+	m.On("Record", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return m
+}
+
+var testPoolConfig = PoolConfig{MaxDetourRatio: 0.5, MaxDetourKm: 3, MaxPickupKm: 5}
+
+func statusIs(want string) interface{} {
+	return mock.MatchedBy(func(payload []byte) bool {
+		var event model.RideStatusEvent
+		return json.Unmarshal(payload, &event) == nil && event.Status == want
+	})
+}
+
+// =============================================================================
+// TESTS
+// =============================================================================
+
+func TestRequestRide_QueuesWhenNoDrivers(t *testing.T) {
+	trackerClient := new(MockTrackerClient)
+	producer := new(MockEventProducer)
+	pending := new(MockPendingRepo)
+	svc := NewDispatchService(trackerClient, newMockOrderClient(), producer, pending, new(MockPoolRepo), newMockAuditRepo(), 10*time.Minute, testPoolConfig)
+	ctx := context.Background()
+
+	req := &dispatch.RequestRideRequest{
+		OrderId:     "550e8400-e29b-41d4-a716-446655440000",
+		PassengerId: "customer-123",
+		PickupLat:   -6.2088,
+		PickupLong:  106.8456,
+	}
+
+	trackerClient.On("GetNearbyDrivers", ctx, mock.Anything).Return(&tracker.GetNearbyDriverResponse{}, nil).Once()
+	pending.On("Add", ctx, mock.MatchedBy(func(ride model.PendingRide) bool {
+		return ride.RideID == req.OrderId && ride.ExpiresAt > ride.CreatedAt
+	})).Return(nil).Once()
+	producer.On("Publish", ctx, rideStatusTopic, "customer-123", statusIs(model.RideStatusSearching)).Return(nil).Once()
+
+	resp, err := svc.RequestRide(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.RideStatusSearching, resp.Status)
+	assert.Equal(t, req.OrderId, resp.RideId)
+	assert.Empty(t, resp.DriverId)
+	pending.AssertExpectations(t)
+	producer.AssertExpectations(t)
+}
+
+func TestMatchPending(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	near := model.PendingRide{RideID: "ride-near", PassengerID: "customer-1", PickupLat: -6.2090, PickupLong: 106.8460, ExpiresAt: now + 600}
+	far := model.PendingRide{RideID: "ride-far", PassengerID: "customer-2", PickupLat: -6.9, PickupLong: 107.6, ExpiresAt: now + 600}
+	driver := pkgModel.LocationEvent{UserID: "driver-1", Latitude: -6.2088, Longitude: 106.8456}
+
+	t.Run("Matches nearby ride", func(t *testing.T) {
+		trackerClient := new(MockTrackerClient)
+		producer := new(MockEventProducer)
+		pending := new(MockPendingRepo)
+		svc := NewDispatchService(trackerClient, newMockOrderClient(), producer, pending, new(MockPoolRepo), newMockAuditRepo(), 10*time.Minute, testPoolConfig)
+
+		pending.On("List", ctx).Return([]model.PendingRide{far, near}, nil).Once()
+		trackerClient.On("ReserveDriver", ctx, "driver-1", "ride-near", int32(0)).Return(true, nil).Once()
+		pending.On("Claim", ctx, "ride-near").Return(true, nil).Once()
+		producer.On("Publish", ctx, dispatchTopic, "driver-1", mock.Anything).Return(nil).Once()
+		producer.On("Publish", ctx, rideStatusTopic, "customer-1", statusIs(model.RideStatusMatched)).Return(nil).Once()
+
+		assert.NoError(t, svc.MatchPending(ctx, driver))
+		pending.AssertNotCalled(t, "Claim", ctx, "ride-far")
+		pending.AssertExpectations(t)
+		producer.AssertExpectations(t)
+	})
+
+	t.Run("Skips ride claimed by another worker", func(t *testing.T) {
+		trackerClient := new(MockTrackerClient)
+		producer := new(MockEventProducer)
+		pending := new(MockPendingRepo)
+		svc := NewDispatchService(trackerClient, newMockOrderClient(), producer, pending, new(MockPoolRepo), newMockAuditRepo(), 10*time.Minute, testPoolConfig)
+
+		pending.On("List", ctx).Return([]model.PendingRide{near}, nil).Once()
+		trackerClient.On("ReserveDriver", ctx, "driver-1", "ride-near", int32(0)).Return(true, nil).Once()
+		pending.On("Claim", ctx, "ride-near").Return(false, nil).Once()
+		trackerClient.On("ReleaseDriver", ctx, "driver-1", "ride-near").Return(nil).Once()
+
+		assert.NoError(t, svc.MatchPending(ctx, driver))
+		trackerClient.AssertExpectations(t)
+		producer.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Skips busy driver", func(t *testing.T) {
+		trackerClient := new(MockTrackerClient)
+		producer := new(MockEventProducer)
+		pending := new(MockPendingRepo)
+		svc := NewDispatchService(trackerClient, newMockOrderClient(), producer, pending, new(MockPoolRepo), newMockAuditRepo(), 10*time.Minute, testPoolConfig)
+
+		pending.On("List", ctx).Return([]model.PendingRide{near}, nil).Once()
+		trackerClient.On("ReserveDriver", ctx, "driver-1", "ride-near", int32(0)).Return(false, nil).Once()
+
+		assert.NoError(t, svc.MatchPending(ctx, driver))
+		pending.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything)
+		producer.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestExpirePending(t *testing.T) {
+	producer := new(MockEventProducer)
+	pending := new(MockPendingRepo)
+	svc := NewDispatchService(new(MockTrackerClient), newMockOrderClient(), producer, pending, new(MockPoolRepo), newMockAuditRepo(), 10*time.Minute, testPoolConfig)
+	ctx := context.Background()
+
+	expired := model.PendingRide{RideID: "ride-1", PassengerID: "customer-1"}
+	pending.On("ClaimExpired", ctx, mock.Anything).Return([]model.PendingRide{expired}, nil).Once()
+	producer.On("Publish", ctx, rideStatusTopic, "customer-1", statusIs(model.RideStatusExpired)).Return(nil).Once()
+
+	assert.NoError(t, svc.ExpirePending(ctx))
+	producer.AssertExpectations(t)
+}
+
+func TestPlanInsertion(t *testing.T) {
+	// Driver heading east along the same road as the passengers.
+	driverLat, driverLong := -6.2000, 106.8000
+	onTrip := []model.PoolStop{
+		{RideID: "ride-1", Type: model.StopPickup, Lat: -6.2000, Long: 106.8100},
+		{RideID: "ride-1", Type: model.StopDropoff, Lat: -6.2000, Long: 106.8600},
+	}
+
+	t.Run("Inserts ride along the route", func(t *testing.T) {
+		ride := model.PendingRide{RideID: "ride-2", PickupLat: -6.2000, PickupLong: 106.8200, DropoffLat: -6.2000, DropoffLong: 106.8500}
+
+		stops, addedKm, ok := planInsertion(driverLat, driverLong, onTrip, ride, testPoolConfig)
+
+		assert.True(t, ok)
+		assert.InDelta(t, 0, addedKm, 1e-6, "pickup and drop-off lie on the existing route")
+		var order []string
+		for _, stop := range stops {
+			order = append(order, stop.RideID+":"+stop.Type)
+		}
+		assert.Equal(t, []string{
+			"ride-1:" + model.StopPickup,
+			"ride-2:" + model.StopPickup,
+			"ride-2:" + model.StopDropoff,
+			"ride-1:" + model.StopDropoff,
+		}, order)
+	})
+
+	t.Run("Rejects ride that breaks the detour limit", func(t *testing.T) {
+		// Picking up on the way would more than double ride-1's trip, and
+		// doing it after ride-1 leaves the new passenger waiting too long.
+		ride := model.PendingRide{RideID: "ride-2", PickupLat: -6.2000, PickupLong: 106.7900, DropoffLat: -6.2000, DropoffLong: 106.7500}
+
+		_, _, ok := planInsertion(driverLat, driverLong, onTrip, ride, testPoolConfig)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("Empty trip takes any ride", func(t *testing.T) {
+		ride := model.PendingRide{RideID: "ride-2", PickupLat: -6.2000, PickupLong: 106.7900, DropoffLat: -6.2000, DropoffLong: 106.7500}
+
+		stops, _, ok := planInsertion(driverLat, driverLong, nil, ride, testPoolConfig)
+
+		assert.True(t, ok)
+		assert.Len(t, stops, 2)
+	})
+}
+
+func TestRequestRide_JoinsPoolTrip(t *testing.T) {
+	trackerClient := new(MockTrackerClient)
+	producer := new(MockEventProducer)
+	pools := new(MockPoolRepo)
+	svc := NewDispatchService(trackerClient, newMockOrderClient(), producer, new(MockPendingRepo), pools, newMockAuditRepo(), 10*time.Minute, testPoolConfig)
+	ctx := context.Background()
+
+	trip := &model.PoolTrip{
+		TripID:   "pool-ride-1",
+		DriverID: "driver-1",
+		Version:  3,
+		Stops: []model.PoolStop{
+			{RideID: "ride-1", Type: model.StopPickup, Lat: -6.2000, Long: 106.8100},
+			{RideID: "ride-1", Type: model.StopDropoff, Lat: -6.2000, Long: 106.8600},
+			{RideID: "ride-gone", Type: model.StopDropoff, Lat: -6.3000, Long: 106.9000},
+		},
+	}
+	req := &dispatch.RequestRideRequest{
+		OrderId:     "ride-2",
+		PassengerId: "customer-2",
+		PickupLat:   -6.2000,
+		PickupLong:  106.8200,
+		DropoffLat:  -6.2000,
+		DropoffLong: 106.8500,
+		VehicleType: model.VehicleTypePool,
+		Seats:       2,
+	}
+
+	trackerClient.On("GetNearbyDrivers", ctx, mock.MatchedBy(func(in *tracker.GetNearbyDriverRequest) bool {
+		return in.MinSeats == 2
+	})).Return(&tracker.GetNearbyDriverResponse{Drivers: []*tracker.Driver{
+		{DriverId: "driver-1", Latitude: -6.2000, Longitude: 106.8000, RemainingSeats: 3, ActiveRideIds: []string{"ride-1"}},
+	}}, nil).Once()
+	pools.On("Get", ctx, "driver-1").Return(trip, nil).Once()
+	trackerClient.On("ReserveDriver", ctx, "driver-1", "ride-2", int32(2)).Return(true, nil).Once()
+	pools.On("Save", ctx, mock.MatchedBy(func(saved model.PoolTrip) bool {
+		// The stale stop of a ride the tracker no longer knows about is dropped.
+		return saved.Version == 4 && len(saved.Stops) == 4 && !saved.RideIDs()["ride-gone"]
+	})).Return(true, nil).Once()
+	producer.On("Publish", ctx, dispatchTopic, "driver-1", mock.MatchedBy(func(payload []byte) bool {
+		var event model.RideDispatchedEvent
+		return json.Unmarshal(payload, &event) == nil && event.PoolTripID == "pool-ride-1" && len(event.Stops) == 4
+	})).Return(nil).Once()
+	producer.On("Publish", ctx, rideStatusTopic, "customer-2", statusIs(model.RideStatusMatched)).Return(nil).Once()
+
+	resp, err := svc.RequestRide(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-1", resp.DriverId)
+	assert.Equal(t, "pool-ride-1", resp.PoolTripId)
+	trackerClient.AssertExpectations(t)
+	pools.AssertExpectations(t)
+	producer.AssertExpectations(t)
+}
+
+func TestRequestRide_RecordsAudit(t *testing.T) {
+	trackerClient := new(MockTrackerClient)
+	producer := new(MockEventProducer)
+	audits := new(MockAuditRepo)
+	svc := NewDispatchService(trackerClient, newMockOrderClient(), producer, new(MockPendingRepo), new(MockPoolRepo), audits, 10*time.Minute, testPoolConfig)
+	ctx := context.Background()
+
+	req := &dispatch.RequestRideRequest{
+		OrderId:     "550e8400-e29b-41d4-a716-446655440000",
+		PassengerId: "customer-123",
+		PickupLat:   -6.2088,
+		PickupLong:  106.8456,
+	}
+
+	trackerClient.On("GetNearbyDrivers", ctx, mock.Anything).Return(&tracker.GetNearbyDriverResponse{Drivers: []*tracker.Driver{
+		{DriverId: "driver-busy", Latitude: -6.2090, Longitude: 106.8460},
+		{DriverId: "driver-free", Latitude: -6.2100, Longitude: 106.8470},
+	}}, nil).Once()
+	trackerClient.On("ReserveDriver", ctx, "driver-busy", req.OrderId, int32(0)).Return(false, nil).Once()
+	trackerClient.On("ReserveDriver", ctx, "driver-free", req.OrderId, int32(0)).Return(true, nil).Once()
+	producer.On("Publish", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	audits.On("Record", mock.Anything, mock.MatchedBy(func(audit model.DispatchAudit) bool {
+		return audit.RideID == req.OrderId &&
+			audit.Source == model.AuditSourceRequest &&
+			audit.Strategy == model.StrategyNearest &&
+			len(audit.Candidates) == 2 &&
+			audit.Candidates[0].Score < audit.Candidates[1].Score &&
+			len(audit.Offers) == 2 &&
+			audit.Offers[0].Outcome == model.OfferBusy &&
+			audit.Offers[1].Outcome == model.OfferReserved &&
+			audit.Outcome == model.AuditOutcomeFound &&
+			audit.DriverID == "driver-free"
+	})).Return(nil).Once()
+
+	resp, err := svc.RequestRide(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-free", resp.DriverId)
+	audits.AssertExpectations(t)
+}
+
+func TestRequestRide_PrefersBetterRatedDriver(t *testing.T) {
+	trackerClient := new(MockTrackerClient)
+	orderClient := new(MockOrderClient)
+	producer := new(MockEventProducer)
+	audits := new(MockAuditRepo)
+	svc := NewDispatchService(trackerClient, orderClient, producer, new(MockPendingRepo), new(MockPoolRepo), audits, 10*time.Minute, testPoolConfig)
+	ctx := context.Background()
+
+	req := &dispatch.RequestRideRequest{
+		OrderId:     "ride-1",
+		PassengerId: "customer-1",
+		PickupLat:   -6.2000,
+		PickupLong:  106.8200,
+	}
+
+	// Both drivers are the same distance from the pickup, on either side.
+	trackerClient.On("GetNearbyDrivers", ctx, mock.Anything).Return(&tracker.GetNearbyDriverResponse{Drivers: []*tracker.Driver{
+		{DriverId: "driver-ok", Latitude: -6.2000, Longitude: 106.8100},
+		{DriverId: "driver-great", Latitude: -6.2000, Longitude: 106.8300},
+	}}, nil).Once()
+	orderClient.On("GetUserRatings", ctx, mock.MatchedBy(func(in *order.GetUserRatingsRequest) bool {
+		return in.Role == driverRole && len(in.UserIds) == 2
+	})).Return(&order.GetUserRatingsResponse{Ratings: []*order.UserRating{
+		{UserId: "driver-ok", Role: driverRole, Average: 3.9, RatingCount: 12},
+		{UserId: "driver-great", Role: driverRole, Average: 4.9, RatingCount: 40},
+	}}, nil).Once()
+	trackerClient.On("ReserveDriver", ctx, "driver-great", "ride-1", int32(0)).Return(true, nil).Once()
+	producer.On("Publish", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	audits.On("Record", mock.Anything, mock.MatchedBy(func(audit model.DispatchAudit) bool {
+		return len(audit.Candidates) == 2 &&
+			audit.Candidates[0].Rating == 3.9 &&
+			audit.Candidates[1].Rating == 4.9 &&
+			audit.Candidates[1].Score < audit.Candidates[0].Score &&
+			audit.DriverID == "driver-great"
+	})).Return(nil).Once()
+
+	resp, err := svc.RequestRide(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "driver-great", resp.DriverId)
+	trackerClient.AssertExpectations(t)
+	orderClient.AssertExpectations(t)
+	audits.AssertExpectations(t)
+}
+
+func TestGetDispatchAudit(t *testing.T) {
+	audits := new(MockAuditRepo)
+	svc := NewDispatchService(new(MockTrackerClient), newMockOrderClient(), new(MockEventProducer), new(MockPendingRepo), new(MockPoolRepo), audits, 10*time.Minute, testPoolConfig)
+	ctx := context.Background()
+
+	t.Run("Returns every attempt", func(t *testing.T) {
+		audits.On("ListByRide", ctx, "ride-1").Return([]model.DispatchAudit{
+			{RideID: "ride-1", Outcome: model.RideStatusSearching},
+			{RideID: "ride-1", Outcome: model.AuditOutcomeFound, DriverID: "driver-1", Offers: []model.AuditOffer{{DriverID: "driver-1", Outcome: model.OfferReserved}}},
+		}, nil).Once()
+
+		resp, err := svc.GetDispatchAudit(ctx, &dispatch.GetDispatchAuditRequest{OrderId: "ride-1"})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Audits, 2)
+		assert.Equal(t, "driver-1", resp.Audits[1].DriverId)
+		assert.Len(t, resp.Audits[1].Offers, 1)
+	})
+
+	t.Run("Unknown order", func(t *testing.T) {
+		audits.On("ListByRide", ctx, "ride-2").Return([]model.DispatchAudit{}, nil).Once()
+
+		_, err := svc.GetDispatchAudit(ctx, &dispatch.GetDispatchAuditRequest{OrderId: "ride-2"})
+
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.NotFound, st.Code())
+	})
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/pkg/kafka"
+	"github.com/dwikikusuma/atlas/pkg/model"
+)
+
+// MatcherWorker re-attempts queued ride requests whenever a driver reports
+// its position on the driver-gps topic. It also moves shared trips forward
+// as drivers reach their stops.
+type MatcherWorker struct {
+	consumer kafka.EventConsumer
+	service  *DispatchService
+}
+
+func NewMatcherWorker(consumer kafka.EventConsumer, service *DispatchService) *MatcherWorker {
+	return &MatcherWorker{
+		consumer: consumer,
+		service:  service,
+	}
+}
+
+func (w *MatcherWorker) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Matcher worker stopping...")
+			return
+		default:
+		}
+
+		msg, err := w.consumer.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error fetching message: %v", err)
+			continue
+		}
+
+		var event model.LocationEvent
+		if err = json.Unmarshal(msg.Value, &event); err != nil {
+			log.Printf("Error unmarshaling message: %v", err)
+			if err = w.consumer.CommitMessages(ctx, msg); err != nil {
+				log.Printf("Error committing message: %v", err)
+			}
+			continue
+		}
+
+		if err = w.Handle(ctx, event); err != nil {
+			continue
+		}
+
+		if err = w.consumer.CommitMessages(ctx, msg); err != nil {
+			log.Printf("Error committing message: %v", err)
+		}
+	}
+}
+
+// Handle processes a single driver location update.
+func (w *MatcherWorker) Handle(ctx context.Context, event model.LocationEvent) error {
+	if err := w.service.AdvancePoolTrip(ctx, event); err != nil {
+		log.Printf("Error advancing pool trip of driver %s: %v", event.UserID, err)
+		return err
+	}
+
+	if err := w.service.MatchPending(ctx, event); err != nil {
+		log.Printf("Error matching pending rides for driver %s: %v", event.UserID, err)
+		return err
+	}
+
+	return nil
+}
+
+// ExpiryWorker periodically expires queued ride requests nobody picked up.
+type ExpiryWorker struct {
+	service  *DispatchService
+	interval time.Duration
+}
+
+func NewExpiryWorker(service *DispatchService, interval time.Duration) *ExpiryWorker {
+	return &ExpiryWorker{
+		service:  service,
+		interval: interval,
+	}
+}
+
+func (w *ExpiryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Expiry worker stopping...")
+			return
+		case <-ticker.C:
+			if err := w.service.ExpirePending(ctx); err != nil {
+				log.Printf("Error expiring pending rides: %v", err)
+			}
+		}
+	}
+}
+
+// CancellationWorker removes cancelled orders from the pending queue and
+// from the shared trip they were assigned to.
+type CancellationWorker struct {
+	consumer kafka.EventConsumer
+	service  *DispatchService
+}
+
+func NewCancellationWorker(consumer kafka.EventConsumer, service *DispatchService) *CancellationWorker {
+	return &CancellationWorker{
+		consumer: consumer,
+		service:  service,
+	}
+}
+
+func (w *CancellationWorker) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Cancellation worker stopping...")
+			return
+		default:
+		}
+
+		msg, err := w.consumer.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error fetching message: %v", err)
+			continue
+		}
+
+		var event model.OrderCancelledEvent
+		if err = json.Unmarshal(msg.Value, &event); err != nil {
+			log.Printf("Error unmarshaling message: %v", err)
+			if err = w.consumer.CommitMessages(ctx, msg); err != nil {
+				log.Printf("Error committing message: %v", err)
+			}
+			continue
+		}
+
+		if err = w.service.CancelPending(ctx, event.OrderID); err != nil {
+			log.Printf("Error cancelling pending ride %s: %v", event.OrderID, err)
+			continue
+		}
+
+		if event.DriverID != "" {
+			if err = w.service.LeavePoolTrip(ctx, event.DriverID, event.OrderID); err != nil {
+				log.Printf("Error removing ride %s from pool trip: %v", event.OrderID, err)
+				continue
+			}
+		}
+
+		if err = w.consumer.CommitMessages(ctx, msg); err != nil {
+			log.Printf("Error committing message: %v", err)
+		}
+	}
+}
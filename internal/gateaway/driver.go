@@ -22,7 +22,14 @@ func NewDriverHandler(trackerClient tracker.TrackerServiceClient, orderClient or
 func (h *DriverHandler) RegisterRoutes(mux *http.ServeMux) {
 	// Prefix: /driver
 	mux.HandleFunc("POST /driver/location", h.UpdateLocation)
+	mux.HandleFunc("GET /driver/order", h.GetOrder)
 	mux.HandleFunc("PUT /driver/order/status", h.UpdateOrderStatus)
+	mux.HandleFunc("POST /driver/order/stop/arrived", h.ArriveAtStop)
+	mux.HandleFunc("POST /driver/order/cancel", h.CancelOrder)
+	mux.HandleFunc("GET /driver/orders", h.ListOrders)
+	mux.HandleFunc("POST /driver/order/rating", h.RateOrder)
+	mux.HandleFunc("GET /driver/rating", h.GetRating)
+	mux.HandleFunc("GET /driver/earnings", h.GetEarnings)
 }
 
 func (h *DriverHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
@@ -46,10 +53,138 @@ func (h *DriverHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Drivers usually only trigger STARTED or FINISHED
-	resp, err := h.order.UpdateOrderStatus(r.Context(), &req)
+	// Drivers report DRIVER_ARRIVED, STARTED and FINISHED
+	resp, err := h.order.UpdateOrderStatus(idempotentContext(r), &req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to update status: "+err.Error())
+		writeError(w, idempotentStatus(err), "failed to update status: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *DriverHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing order id")
+		return
+	}
+
+	// The driver app reads the ride's stops from here.
+	resp, err := h.order.GetOrder(r.Context(), &order.GetOrderRequest{OrderId: id})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get order: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *DriverHandler) ArriveAtStop(w http.ResponseWriter, r *http.Request) {
+	var req order.ArriveAtStopRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.order.ArriveAtStop(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to arrive at stop: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *DriverHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	var req order.CancelOrderRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	req.CancelledBy = "DRIVER"
+
+	resp, err := h.order.CancelOrder(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to cancel order: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *DriverHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	driverID := r.URL.Query().Get("driver_id")
+	if driverID == "" {
+		writeError(w, http.StatusBadRequest, "missing driver id")
+		return
+	}
+
+	req, err := listOrdersRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.DriverId = driverID
+
+	resp, err := h.order.ListOrders(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list orders: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *DriverHandler) RateOrder(w http.ResponseWriter, r *http.Request) {
+	var req order.RateOrderRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	req.RaterRole = "DRIVER"
+
+	resp, err := h.order.RateOrder(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to rate order: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *DriverHandler) GetRating(w http.ResponseWriter, r *http.Request) {
+	driverID := r.URL.Query().Get("driver_id")
+	if driverID == "" {
+		writeError(w, http.StatusBadRequest, "missing driver id")
+		return
+	}
+
+	resp, err := h.order.GetUserRatings(r.Context(), &order.GetUserRatingsRequest{UserIds: []string{driverID}, Role: "DRIVER"})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get rating: "+err.Error())
+		return
+	}
+	if len(resp.Ratings) == 0 {
+		writeError(w, http.StatusNotFound, "driver has no ratings yet")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp.Ratings[0])
+}
+
+func (h *DriverHandler) GetEarnings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	driverID := query.Get("driver_id")
+	if driverID == "" {
+		writeError(w, http.StatusBadRequest, "missing driver id")
+		return
+	}
+
+	resp, err := h.order.GetDriverEarnings(r.Context(), &order.GetDriverEarningsRequest{
+		DriverId: driverID,
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get earnings: "+err.Error())
 		return
 	}
 
@@ -1,13 +1,45 @@
 package gateaway
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dwikikusuma/atlas/internal/order/idempotency"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Proto messages keep their snake_case field names and render enums by name,
+// e.g. "status": "MATCHED".
+var (
+	protoMarshal   = protojson.MarshalOptions{UseProtoNames: true}
+	protoUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
 )
 
 // writeJSON writes a JSON response with the specific status code
 func writeJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
+
+	if msg, ok := data.(proto.Message); ok {
+		payload, err := protoMarshal.Marshal(msg)
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(payload)
+		return
+	}
+
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -19,11 +51,79 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	writeJSON(w, status, map[string]string{"error": message})
 }
 
+// idempotentContext forwards the Idempotency-Key header of a request to the
+// Order Service, so a client retrying after a timeout gets the first response
+// back instead of repeating the change.
+func idempotentContext(r *http.Request) context.Context {
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		return r.Context()
+	}
+	return metadata.AppendToOutgoingContext(r.Context(), idempotency.MetadataKey, key)
+}
+
+// idempotentStatus tells a key reused for another request (422) and a retry
+// still racing the first request (409) apart from an invalid request (400)
+// and other failures.
+func idempotentStatus(err error) int {
+	if idempotency.KeyReused(err) {
+		return http.StatusUnprocessableEntity
+	}
+	switch status.Code(err) {
+	case codes.Aborted:
+		return http.StatusConflict
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // readJSON decodes the body and handles the error if it fails
 func readJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
-	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+	var err error
+	if msg, ok := dst.(proto.Message); ok {
+		var body []byte
+		if body, err = io.ReadAll(r.Body); err == nil {
+			err = protoUnmarshal.Unmarshal(body, msg)
+		}
+	} else {
+		err = json.NewDecoder(r.Body).Decode(dst)
+	}
+
+	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return false
 	}
 	return true
 }
+
+// listOrdersRequest reads the filters of an order listing from the query:
+// status (comma separated), from, to (RFC3339), page_size and page_token.
+func listOrdersRequest(r *http.Request) (*order.ListOrdersRequest, error) {
+	query := r.URL.Query()
+	req := &order.ListOrdersRequest{
+		CreatedFrom: query.Get("from"),
+		CreatedTo:   query.Get("to"),
+		PageToken:   query.Get("page_token"),
+	}
+
+	if raw := query.Get("status"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			value, ok := order.OrderStatus_value[strings.ToUpper(strings.TrimSpace(name))]
+			if !ok {
+				return nil, fmt.Errorf("unknown status %q", name)
+			}
+			req.Statuses = append(req.Statuses, order.OrderStatus(value))
+		}
+	}
+
+	if raw := query.Get("page_size"); raw != "" {
+		size, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page_size: %w", err)
+		}
+		req.PageSize = int32(size)
+	}
+	return req, nil
+}
@@ -21,9 +21,48 @@ func NewCustomerHandler(orderClient order.OrderServiceClient, dispatchClient dis
 
 func (h *CustomerHandler) RegisterRoutes(mux *http.ServeMux) {
 	// Prefix: /customer
+	mux.HandleFunc("POST /customer/fare/quote", h.GetFareQuote)
+	mux.HandleFunc("POST /customer/promo/validate", h.ValidatePromo)
 	mux.HandleFunc("POST /customer/order", h.CreateOrder)
 	mux.HandleFunc("POST /customer/ride/request", h.RequestRide)
 	mux.HandleFunc("GET /customer/order", h.GetOrder)
+	mux.HandleFunc("GET /customer/order/history", h.GetOrderHistory)
+	mux.HandleFunc("GET /customer/orders", h.ListOrders)
+	mux.HandleFunc("POST /customer/order/rating", h.RateOrder)
+	mux.HandleFunc("POST /customer/order/tip", h.TipDriver)
+	mux.HandleFunc("POST /customer/order/payment/retry", h.RetryPayment)
+	mux.HandleFunc("GET /customer/order/receipt", h.GetReceipt)
+	mux.HandleFunc("POST /customer/order/cancel", h.CancelOrder)
+}
+
+func (h *CustomerHandler) GetFareQuote(w http.ResponseWriter, r *http.Request) {
+	var req order.GetFareQuoteRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.order.GetFareQuote(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get fare quote: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *CustomerHandler) ValidatePromo(w http.ResponseWriter, r *http.Request) {
+	var req order.ValidatePromoRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.order.ValidatePromo(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to validate promo: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (h *CustomerHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
@@ -32,9 +71,9 @@ func (h *CustomerHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.order.CreateOrder(r.Context(), &req)
+	resp, err := h.order.CreateOrder(idempotentContext(r), &req)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to create order: "+err.Error())
+		writeError(w, idempotentStatus(err), "failed to create order: "+err.Error())
 		return
 	}
 
@@ -71,3 +110,134 @@ func (h *CustomerHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, resp)
 }
+
+func (h *CustomerHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing order id")
+		return
+	}
+
+	resp, err := h.order.GetOrderHistory(r.Context(), &order.GetOrderHistoryRequest{OrderId: id})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get order history: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *CustomerHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, "missing user id")
+		return
+	}
+
+	req, err := listOrdersRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.PassengerId = userID
+
+	resp, err := h.order.ListOrders(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list orders: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *CustomerHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	var req order.CancelOrderRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	req.CancelledBy = "PASSENGER"
+
+	resp, err := h.order.CancelOrder(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to cancel order: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *CustomerHandler) RateOrder(w http.ResponseWriter, r *http.Request) {
+	var req order.RateOrderRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	req.RaterRole = "PASSENGER"
+
+	resp, err := h.order.RateOrder(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to rate order: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *CustomerHandler) TipDriver(w http.ResponseWriter, r *http.Request) {
+	var req order.TipDriverRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.order.TipDriver(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to tip driver: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *CustomerHandler) RetryPayment(w http.ResponseWriter, r *http.Request) {
+	var req order.RetryPaymentRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	resp, err := h.order.RetryPayment(r.Context(), &req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to retry payment: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetReceipt returns the rendered receipt itself, so it can be opened in a
+// browser or attached to an expense claim as it is.
+func (h *CustomerHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	id := query.Get("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing order id")
+		return
+	}
+	userID := query.Get("user_id")
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, "missing user id")
+		return
+	}
+
+	resp, err := h.order.GetReceipt(r.Context(), &order.GetReceiptRequest{
+		OrderId:     id,
+		PassengerId: userID,
+		Format:      query.Get("format"),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get receipt: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.ContentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(resp.Document))
+}
@@ -0,0 +1,9 @@
+-- internal/order/db/migration/000002_order_cancellation.down.sql
+-- Rollback for 000002_order_cancellation.up.sql
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS cancellation_fee,
+    DROP COLUMN IF EXISTS cancel_reason,
+    DROP COLUMN IF EXISTS cancelled_by,
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS matched_at;
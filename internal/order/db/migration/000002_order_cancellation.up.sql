@@ -0,0 +1,7 @@
+-- internal/order/db/migration/000002_order_cancellation.up.sql
+ALTER TABLE orders
+    ADD COLUMN matched_at       TIMESTAMPTZ,
+    ADD COLUMN cancelled_at     TIMESTAMPTZ,
+    ADD COLUMN cancelled_by     VARCHAR(20), -- PASSENGER, DRIVER
+    ADD COLUMN cancel_reason    TEXT,
+    ADD COLUMN cancellation_fee DOUBLE PRECISION NOT NULL DEFAULT 0;
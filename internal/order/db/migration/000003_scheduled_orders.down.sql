@@ -0,0 +1,9 @@
+-- internal/order/db/migration/000003_scheduled_orders.down.sql
+-- Rollback for 000003_scheduled_orders.up.sql
+
+DROP INDEX IF EXISTS idx_orders_scheduled;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS last_dispatch_at,
+    DROP COLUMN IF EXISTS dispatch_attempts,
+    DROP COLUMN IF EXISTS reminder_sent_at,
+    DROP COLUMN IF EXISTS scheduled_at;
@@ -0,0 +1,10 @@
+-- internal/order/db/migration/000003_scheduled_orders.up.sql
+ALTER TABLE orders
+    ADD COLUMN scheduled_at      TIMESTAMPTZ, -- NULL for immediate rides
+    ADD COLUMN reminder_sent_at  TIMESTAMPTZ,
+    ADD COLUMN dispatch_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_dispatch_at  TIMESTAMPTZ;
+
+-- The scheduler only ever scans rides that are still waiting for a driver
+CREATE INDEX idx_orders_scheduled ON orders (scheduled_at)
+    WHERE scheduled_at IS NOT NULL AND status IN ('SCHEDULED', 'SEARCHING');
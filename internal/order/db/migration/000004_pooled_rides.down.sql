@@ -0,0 +1,10 @@
+-- internal/order/db/migration/000004_pooled_rides.down.sql
+-- Rollback for 000004_pooled_rides.up.sql
+
+DROP INDEX IF EXISTS idx_orders_pool_trip;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS dropoff_sequence,
+    DROP COLUMN IF EXISTS pickup_sequence,
+    DROP COLUMN IF EXISTS pool_trip_id,
+    DROP COLUMN IF EXISTS seats,
+    DROP COLUMN IF EXISTS vehicle_type;
@@ -0,0 +1,9 @@
+-- internal/order/db/migration/000004_pooled_rides.up.sql
+ALTER TABLE orders
+    ADD COLUMN vehicle_type     VARCHAR(20) NOT NULL DEFAULT 'go-ride',
+    ADD COLUMN seats            INT         NOT NULL DEFAULT 1,
+    ADD COLUMN pool_trip_id     VARCHAR(64),  -- NULL unless the ride shares the vehicle
+    ADD COLUMN pickup_sequence  INT,          -- 1-based position of the pickup on the shared trip
+    ADD COLUMN dropoff_sequence INT;
+
+CREATE INDEX idx_orders_pool_trip ON orders (pool_trip_id) WHERE pool_trip_id IS NOT NULL;
@@ -0,0 +1,6 @@
+-- internal/order/db/migration/000005_order_status_history.down.sql
+-- Rollback for 000005_order_status_history.up.sql
+
+DROP TABLE IF EXISTS order_status_history;
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS orders_status_check;
@@ -0,0 +1,21 @@
+-- internal/order/db/migration/000005_order_status_history.up.sql
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN (
+        'CREATED', 'SCHEDULED', 'SEARCHING', 'MATCHED', 'DRIVER_ARRIVED',
+        'STARTED', 'FINISHED', 'CANCELLED', 'EXPIRED'
+    ));
+
+-- Every status change of an order, written in the same statement as the change itself
+CREATE TABLE order_status_history
+(
+    id          BIGSERIAL PRIMARY KEY,
+    order_id    UUID        NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status   VARCHAR(20) NOT NULL,
+    actor       VARCHAR(20) NOT NULL, -- PASSENGER, DRIVER, DISPATCH, SYSTEM
+    actor_id    VARCHAR(50),
+    reason      TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_status_history_order ON order_status_history (order_id, created_at);
@@ -0,0 +1,4 @@
+-- internal/order/db/migration/000006_outbox.down.sql
+-- Rollback for 000006_outbox.up.sql
+
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,5 @@
+-- internal/order/db/migration/000007_tariff_version.down.sql
+-- Rollback for 000007_tariff_version.up.sql
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS tariff_version;
@@ -0,0 +1,3 @@
+-- internal/order/db/migration/000007_tariff_version.up.sql
+ALTER TABLE orders
+    ADD COLUMN tariff_version VARCHAR(50); -- Version of config/tariffs.json that priced the order
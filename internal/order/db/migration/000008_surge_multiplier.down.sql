@@ -0,0 +1,5 @@
+-- internal/order/db/migration/000008_surge_multiplier.down.sql
+-- Rollback for 000008_surge_multiplier.up.sql
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS surge_multiplier;
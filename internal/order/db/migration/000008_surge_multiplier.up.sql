@@ -0,0 +1,3 @@
+-- internal/order/db/migration/000008_surge_multiplier.up.sql
+ALTER TABLE orders
+    ADD COLUMN surge_multiplier DOUBLE PRECISION NOT NULL DEFAULT 1; -- Demand multiplier of the pickup zone when the order was priced
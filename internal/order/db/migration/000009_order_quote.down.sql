@@ -0,0 +1,5 @@
+-- internal/order/db/migration/000009_order_quote.down.sql
+-- Rollback for 000009_order_quote.up.sql
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS quote_id;
@@ -0,0 +1,3 @@
+-- internal/order/db/migration/000009_order_quote.up.sql
+ALTER TABLE orders
+    ADD COLUMN quote_id VARCHAR(32); -- Fare quote the order was placed with, NULL when priced at creation
@@ -0,0 +1,9 @@
+-- internal/order/db/migration/000010_promotions.down.sql
+-- Rollback for 000010_promotions.up.sql
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS discount,
+    DROP COLUMN IF EXISTS promo_code;
+
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_campaigns;
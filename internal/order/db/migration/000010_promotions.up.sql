@@ -0,0 +1,42 @@
+-- internal/order/db/migration/000010_promotions.up.sql
+
+-- Discount campaigns, redeemed with their code when ordering.
+CREATE TABLE promo_campaigns
+(
+    id              BIGSERIAL PRIMARY KEY,
+    code            VARCHAR(32)      NOT NULL UNIQUE, -- Upper case
+    name            VARCHAR(100)     NOT NULL,
+    discount_type   VARCHAR(10)      NOT NULL CHECK (discount_type IN ('PERCENT', 'FIXED')),
+    discount_value  DOUBLE PRECISION NOT NULL CHECK (discount_value > 0),
+    max_discount    DOUBLE PRECISION,                 -- Caps a PERCENT discount, in IDR
+    min_fare        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    starts_at       TIMESTAMPTZ      NOT NULL,
+    ends_at         TIMESTAMPTZ      NOT NULL,
+    max_redemptions INT,                              -- Across all users, NULL for unlimited
+    max_per_user    INT              NOT NULL DEFAULT 1,
+    vehicle_types   TEXT[]           NOT NULL DEFAULT '{}', -- Empty for every vehicle type
+    cities          TEXT[]           NOT NULL DEFAULT '{}', -- Tariff cities of the pickup, empty for everywhere
+    redemptions     INT              NOT NULL DEFAULT 0,    -- Applied, not reversed
+    active          BOOLEAN          NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+-- One row per order that used a promo. A cancelled or expired order reverses
+-- its redemption, giving the use back to the user and the campaign.
+CREATE TABLE promo_redemptions
+(
+    id          BIGSERIAL PRIMARY KEY,
+    campaign_id BIGINT           NOT NULL REFERENCES promo_campaigns (id),
+    user_id     VARCHAR(50)      NOT NULL,
+    order_id    UUID             NOT NULL UNIQUE REFERENCES orders (id),
+    discount    DOUBLE PRECISION NOT NULL,
+    status      VARCHAR(10)      NOT NULL DEFAULT 'APPLIED' CHECK (status IN ('APPLIED', 'REVERSED')),
+    created_at  TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    reversed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_promo_redemptions_user ON promo_redemptions (campaign_id, user_id) WHERE status = 'APPLIED';
+
+ALTER TABLE orders
+    ADD COLUMN promo_code VARCHAR(32),
+    ADD COLUMN discount   DOUBLE PRECISION NOT NULL DEFAULT 0; -- Taken off the fare; price is after the discount
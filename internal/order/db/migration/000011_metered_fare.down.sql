@@ -0,0 +1,9 @@
+-- internal/order/db/migration/000011_metered_fare.down.sql
+-- Rollback for 000011_metered_fare.up.sql
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS fare_basis,
+    DROP COLUMN IF EXISTS final_price,
+    DROP COLUMN IF EXISTS metered_fare,
+    DROP COLUMN IF EXISTS metered_duration_min,
+    DROP COLUMN IF EXISTS metered_distance_km;
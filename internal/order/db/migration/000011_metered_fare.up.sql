@@ -0,0 +1,7 @@
+-- internal/order/db/migration/000011_metered_fare.up.sql
+ALTER TABLE orders
+    ADD COLUMN metered_distance_km  DOUBLE PRECISION, -- Distance the tracker measured, NULL without telemetry
+    ADD COLUMN metered_duration_min DOUBLE PRECISION, -- Time from start to finish
+    ADD COLUMN metered_fare         DOUBLE PRECISION, -- Tariff applied to the measured trip, before discount
+    ADD COLUMN final_price          DOUBLE PRECISION, -- What the passenger is charged, set when the ride finishes
+    ADD COLUMN fare_basis           VARCHAR(20);      -- UPFRONT, METERED, CAPPED or NO_TELEMETRY
@@ -0,0 +1,8 @@
+-- internal/order/db/migration/000012_order_listing.down.sql
+-- Rollback for 000012_order_listing.up.sql
+
+DROP INDEX IF EXISTS idx_orders_driver;
+DROP INDEX IF EXISTS idx_orders_passenger;
+
+CREATE INDEX idx_orders_passenger ON orders (passenger_id);
+CREATE INDEX idx_orders_driver ON orders (driver_id);
@@ -0,0 +1,8 @@
+-- internal/order/db/migration/000012_order_listing.up.sql
+-- Listing pages through a user's orders by (created_at, id), newest first. The
+-- composite indexes serve those pages and still cover lookups by user alone.
+DROP INDEX IF EXISTS idx_orders_passenger;
+DROP INDEX IF EXISTS idx_orders_driver;
+
+CREATE INDEX idx_orders_passenger ON orders (passenger_id, created_at DESC, id DESC);
+CREATE INDEX idx_orders_driver ON orders (driver_id, created_at DESC, id DESC);
@@ -0,0 +1,5 @@
+-- internal/order/db/migration/000013_ratings.down.sql
+-- Rollback for 000013_ratings.up.sql
+
+DROP TABLE IF EXISTS user_ratings;
+DROP TABLE IF EXISTS ratings;
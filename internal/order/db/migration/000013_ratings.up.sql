@@ -0,0 +1,29 @@
+-- internal/order/db/migration/000013_ratings.up.sql
+CREATE TABLE ratings
+(
+    id         BIGSERIAL PRIMARY KEY,
+    order_id   UUID        NOT NULL REFERENCES orders (id),
+    rater_id   VARCHAR(50) NOT NULL,
+    ratee_id   VARCHAR(50) NOT NULL,
+    ratee_role VARCHAR(20) NOT NULL, -- DRIVER when the passenger rates, PASSENGER when the driver rates
+    stars      SMALLINT    NOT NULL CHECK (stars BETWEEN 1 AND 5),
+    tags       TEXT[]      NOT NULL DEFAULT '{}',
+    comment    TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (order_id, ratee_role) -- Each side rates an order once
+);
+
+CREATE INDEX idx_ratings_ratee ON ratings (ratee_id, ratee_role, created_at DESC, id DESC);
+
+-- Rolling average of every user's most recent ratings, kept up to date with
+-- every rating so GetUserRatings, which dispatch scores drivers with, reads
+-- it by primary key.
+CREATE TABLE user_ratings
+(
+    user_id      VARCHAR(50)      NOT NULL,
+    role         VARCHAR(20)      NOT NULL, -- DRIVER or PASSENGER, the side the user was rated as
+    rating_count INT              NOT NULL DEFAULT 0, -- All ratings ever received
+    average      DOUBLE PRECISION NOT NULL DEFAULT 0, -- Over the most recent ratings only
+    updated_at   TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
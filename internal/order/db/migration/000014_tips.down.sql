@@ -0,0 +1,7 @@
+-- internal/order/db/migration/000014_tips.down.sql
+-- Rollback for 000014_tips.up.sql
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS tipped_at,
+    DROP COLUMN IF EXISTS tip,
+    DROP COLUMN IF EXISTS finished_at;
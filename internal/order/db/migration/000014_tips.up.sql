@@ -0,0 +1,10 @@
+-- internal/order/db/migration/000014_tips.up.sql
+ALTER TABLE orders
+    ADD COLUMN finished_at TIMESTAMPTZ,      -- When the ride finished, opens the tipping window
+    ADD COLUMN tip         DOUBLE PRECISION, -- Tip the passenger gave the driver, paid from wallet to wallet
+    ADD COLUMN tipped_at   TIMESTAMPTZ;
+
+UPDATE orders o
+SET finished_at = h.created_at
+FROM order_status_history h
+WHERE h.order_id = o.id AND h.to_status = 'FINISHED';
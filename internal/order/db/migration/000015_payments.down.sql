@@ -0,0 +1,19 @@
+-- internal/order/db/migration/000015_payments.down.sql
+-- Rollback for 000015_payments.up.sql
+
+DROP TABLE IF EXISTS passenger_debts;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS paid_at,
+    DROP COLUMN IF EXISTS payment_error,
+    DROP COLUMN IF EXISTS payment_retry_at,
+    DROP COLUMN IF EXISTS payment_attempts;
+
+UPDATE orders SET status = 'FINISHED' WHERE status IN ('PAID', 'PAYMENT_FAILED');
+
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN (
+        'CREATED', 'SCHEDULED', 'SEARCHING', 'MATCHED', 'DRIVER_ARRIVED',
+        'STARTED', 'FINISHED', 'CANCELLED', 'EXPIRED'
+    ));
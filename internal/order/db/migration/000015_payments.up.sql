@@ -0,0 +1,29 @@
+-- internal/order/db/migration/000015_payments.up.sql
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN (
+        'CREATED', 'SCHEDULED', 'SEARCHING', 'MATCHED', 'DRIVER_ARRIVED',
+        'STARTED', 'FINISHED', 'PAID', 'PAYMENT_FAILED', 'CANCELLED', 'EXPIRED'
+    ));
+
+ALTER TABLE orders
+    ADD COLUMN payment_attempts INT NOT NULL DEFAULT 0, -- Failed attempts to charge the fare
+    ADD COLUMN payment_retry_at TIMESTAMPTZ,            -- When the fare is charged again after a failed attempt
+    ADD COLUMN payment_error    TEXT,                   -- Why the last attempt failed
+    ADD COLUMN paid_at          TIMESTAMPTZ;
+
+CREATE INDEX idx_orders_payment_retry ON orders (payment_retry_at) WHERE payment_retry_at IS NOT NULL;
+
+-- Fares that could not be charged. A passenger with an unsettled debt can't
+-- order rides until the fare is paid.
+CREATE TABLE passenger_debts
+(
+    order_id     UUID PRIMARY KEY REFERENCES orders (id) ON DELETE CASCADE,
+    passenger_id VARCHAR(50)      NOT NULL,
+    amount       DOUBLE PRECISION NOT NULL CHECK (amount > 0),
+    reason       TEXT,
+    created_at   TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    settled_at   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_passenger_debts_unsettled ON passenger_debts (passenger_id) WHERE settled_at IS NULL;
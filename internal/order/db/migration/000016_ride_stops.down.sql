@@ -0,0 +1,4 @@
+-- internal/order/db/migration/000016_ride_stops.down.sql
+-- Rollback for 000016_ride_stops.up.sql
+
+DROP TABLE IF EXISTS ride_stops;
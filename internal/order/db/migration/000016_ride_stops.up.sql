@@ -0,0 +1,11 @@
+-- internal/order/db/migration/000016_ride_stops.up.sql
+-- Places a ride visits between pickup and drop-off, in sequence from 1.
+CREATE TABLE ride_stops
+(
+    order_id   UUID             NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    sequence   INT              NOT NULL CHECK (sequence > 0),
+    lat        DOUBLE PRECISION NOT NULL,
+    long       DOUBLE PRECISION NOT NULL,
+    arrived_at TIMESTAMPTZ,     -- When the driver reached the stop
+    PRIMARY KEY (order_id, sequence)
+);
@@ -0,0 +1,5 @@
+-- internal/order/db/migration/000017_receipts.down.sql
+-- Rollback for 000017_receipts.up.sql
+
+DROP TABLE IF EXISTS receipts;
+DROP TABLE IF EXISTS receipt_counters;
@@ -0,0 +1,20 @@
+-- internal/order/db/migration/000017_receipts.up.sql
+-- Receipt numbers run per year without gaps: the counter is bumped in the
+-- transaction that issues the receipt, so a rolled back ride doesn't use one up.
+CREATE TABLE receipt_counters
+(
+    year        INT PRIMARY KEY,
+    last_number BIGINT NOT NULL
+);
+
+-- One receipt per finished ride, kept as it was issued.
+CREATE TABLE receipts
+(
+    order_id       UUID PRIMARY KEY REFERENCES orders (id) ON DELETE CASCADE,
+    receipt_number VARCHAR(32) NOT NULL UNIQUE,
+    passenger_id   VARCHAR(50) NOT NULL,
+    document       JSONB       NOT NULL, -- receipt.Receipt
+    issued_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_receipts_passenger ON receipts (passenger_id, issued_at DESC);
@@ -0,0 +1,9 @@
+-- internal/order/db/migration/000018_driver_earnings.down.sql
+-- Rollback for 000018_driver_earnings.up.sql
+
+DROP INDEX IF EXISTS idx_orders_driver_paid;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS driver_earnings,
+    DROP COLUMN IF EXISTS commission,
+    DROP COLUMN IF EXISTS commission_rate;
@@ -0,0 +1,9 @@
+-- internal/order/db/migration/000018_driver_earnings.up.sql
+-- How the fare of a finished ride is split between the driver and the
+-- platform. The driver is paid once the fare is.
+ALTER TABLE orders
+    ADD COLUMN commission_rate DOUBLE PRECISION, -- Share of the fare the platform keeps
+    ADD COLUMN commission      DOUBLE PRECISION, -- Kept by the platform, in IDR
+    ADD COLUMN driver_earnings DOUBLE PRECISION; -- Fare before discount minus commission
+
+CREATE INDEX idx_orders_driver_paid ON orders (driver_id, paid_at) WHERE paid_at IS NOT NULL;
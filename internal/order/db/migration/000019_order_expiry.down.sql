@@ -0,0 +1,4 @@
+-- internal/order/db/migration/000019_order_expiry.down.sql
+-- Rollback for 000019_order_expiry.up.sql
+
+DROP INDEX IF EXISTS idx_orders_unmatched;
@@ -0,0 +1,5 @@
+-- internal/order/db/migration/000019_order_expiry.up.sql
+-- Finds the orders still waiting for a driver by when their search began, for
+-- the expirer to give up on them.
+CREATE INDEX idx_orders_unmatched ON orders ((COALESCE(scheduled_at, created_at)))
+    WHERE status IN ('CREATED', 'SEARCHING');
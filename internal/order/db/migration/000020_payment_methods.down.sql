@@ -0,0 +1,4 @@
+-- internal/order/db/migration/000020_payment_methods.down.sql
+-- Rollback for 000020_payment_methods.up.sql
+
+ALTER TABLE orders DROP COLUMN IF EXISTS payment_method;
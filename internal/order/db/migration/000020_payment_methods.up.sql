@@ -0,0 +1,6 @@
+-- internal/order/db/migration/000020_payment_methods.up.sql
+-- How the passenger pays: from their wallet, in cash to the driver, or by card.
+-- Orders placed before were all paid from the wallet.
+ALTER TABLE orders
+    ADD COLUMN payment_method VARCHAR(10) NOT NULL DEFAULT 'WALLET'
+        CHECK (payment_method IN ('WALLET', 'CASH', 'CARD'));
@@ -9,15 +9,148 @@ import (
 )
 
 type Order struct {
-	ID          pgtype.UUID        `json:"id"`
+	ID                 pgtype.UUID        `json:"id"`
+	PassengerID        string             `json:"passenger_id"`
+	DriverID           pgtype.Text        `json:"driver_id"`
+	PickupLat          float64            `json:"pickup_lat"`
+	PickupLong         float64            `json:"pickup_long"`
+	DropoffLat         float64            `json:"dropoff_lat"`
+	DropoffLong        float64            `json:"dropoff_long"`
+	Status             string             `json:"status"`
+	Price              float64            `json:"price"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	MatchedAt          pgtype.Timestamptz `json:"matched_at"`
+	CancelledAt        pgtype.Timestamptz `json:"cancelled_at"`
+	CancelledBy        pgtype.Text        `json:"cancelled_by"`
+	CancelReason       pgtype.Text        `json:"cancel_reason"`
+	CancellationFee    float64            `json:"cancellation_fee"`
+	ScheduledAt        pgtype.Timestamptz `json:"scheduled_at"`
+	ReminderSentAt     pgtype.Timestamptz `json:"reminder_sent_at"`
+	DispatchAttempts   int32              `json:"dispatch_attempts"`
+	LastDispatchAt     pgtype.Timestamptz `json:"last_dispatch_at"`
+	VehicleType        string             `json:"vehicle_type"`
+	Seats              int32              `json:"seats"`
+	PoolTripID         pgtype.Text        `json:"pool_trip_id"`
+	PickupSequence     pgtype.Int4        `json:"pickup_sequence"`
+	DropoffSequence    pgtype.Int4        `json:"dropoff_sequence"`
+	TariffVersion      pgtype.Text        `json:"tariff_version"`
+	SurgeMultiplier    float64            `json:"surge_multiplier"`
+	QuoteID            pgtype.Text        `json:"quote_id"`
+	PromoCode          pgtype.Text        `json:"promo_code"`
+	Discount           float64            `json:"discount"`
+	MeteredDistanceKm  pgtype.Float8      `json:"metered_distance_km"`
+	MeteredDurationMin pgtype.Float8      `json:"metered_duration_min"`
+	MeteredFare        pgtype.Float8      `json:"metered_fare"`
+	FinalPrice         pgtype.Float8      `json:"final_price"`
+	FareBasis          pgtype.Text        `json:"fare_basis"`
+	FinishedAt         pgtype.Timestamptz `json:"finished_at"`
+	Tip                pgtype.Float8      `json:"tip"`
+	TippedAt           pgtype.Timestamptz `json:"tipped_at"`
+	PaymentAttempts    int32              `json:"payment_attempts"`
+	PaymentRetryAt     pgtype.Timestamptz `json:"payment_retry_at"`
+	PaymentError       pgtype.Text        `json:"payment_error"`
+	PaidAt             pgtype.Timestamptz `json:"paid_at"`
+	CommissionRate     pgtype.Float8      `json:"commission_rate"`
+	Commission         pgtype.Float8      `json:"commission"`
+	DriverEarnings     pgtype.Float8      `json:"driver_earnings"`
+	PaymentMethod      string             `json:"payment_method"`
+}
+
+type OrderStatusHistory struct {
+	ID         int64              `json:"id"`
+	OrderID    pgtype.UUID        `json:"order_id"`
+	FromStatus string             `json:"from_status"`
+	ToStatus   string             `json:"to_status"`
+	Actor      string             `json:"actor"`
+	ActorID    pgtype.Text        `json:"actor_id"`
+	Reason     pgtype.Text        `json:"reason"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type Outbox struct {
+	ID            int64              `json:"id"`
+	Topic         string             `json:"topic"`
+	MessageKey    string             `json:"message_key"`
+	Payload       []byte             `json:"payload"`
+	Attempts      int32              `json:"attempts"`
+	LastError     pgtype.Text        `json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	SentAt        pgtype.Timestamptz `json:"sent_at"`
+}
+
+type PassengerDebt struct {
+	OrderID     pgtype.UUID        `json:"order_id"`
 	PassengerID string             `json:"passenger_id"`
-	DriverID    pgtype.Text        `json:"driver_id"`
-	PickupLat   float64            `json:"pickup_lat"`
-	PickupLong  float64            `json:"pickup_long"`
-	DropoffLat  float64            `json:"dropoff_lat"`
-	DropoffLong float64            `json:"dropoff_long"`
-	Status      string             `json:"status"`
-	Price       float64            `json:"price"`
+	Amount      float64            `json:"amount"`
+	Reason      pgtype.Text        `json:"reason"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	SettledAt   pgtype.Timestamptz `json:"settled_at"`
+}
+
+type PromoCampaign struct {
+	ID             int64              `json:"id"`
+	Code           string             `json:"code"`
+	Name           string             `json:"name"`
+	DiscountType   string             `json:"discount_type"`
+	DiscountValue  float64            `json:"discount_value"`
+	MaxDiscount    pgtype.Float8      `json:"max_discount"`
+	MinFare        float64            `json:"min_fare"`
+	StartsAt       pgtype.Timestamptz `json:"starts_at"`
+	EndsAt         pgtype.Timestamptz `json:"ends_at"`
+	MaxRedemptions pgtype.Int4        `json:"max_redemptions"`
+	MaxPerUser     int32              `json:"max_per_user"`
+	VehicleTypes   []string           `json:"vehicle_types"`
+	Cities         []string           `json:"cities"`
+	Redemptions    int32              `json:"redemptions"`
+	Active         bool               `json:"active"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+type PromoRedemption struct {
+	ID         int64              `json:"id"`
+	CampaignID int64              `json:"campaign_id"`
+	UserID     string             `json:"user_id"`
+	OrderID    pgtype.UUID        `json:"order_id"`
+	Discount   float64            `json:"discount"`
+	Status     string             `json:"status"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	ReversedAt pgtype.Timestamptz `json:"reversed_at"`
+}
+
+type Rating struct {
+	ID        int64              `json:"id"`
+	OrderID   pgtype.UUID        `json:"order_id"`
+	RaterID   string             `json:"rater_id"`
+	RateeID   string             `json:"ratee_id"`
+	RateeRole string             `json:"ratee_role"`
+	Stars     int16              `json:"stars"`
+	Tags      []string           `json:"tags"`
+	Comment   pgtype.Text        `json:"comment"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type Receipt struct {
+	OrderID       pgtype.UUID        `json:"order_id"`
+	ReceiptNumber string             `json:"receipt_number"`
+	PassengerID   string             `json:"passenger_id"`
+	Document      []byte             `json:"document"`
+	IssuedAt      pgtype.Timestamptz `json:"issued_at"`
+}
+
+type RideStop struct {
+	OrderID   pgtype.UUID        `json:"order_id"`
+	Sequence  int32              `json:"sequence"`
+	Lat       float64            `json:"lat"`
+	Long      float64            `json:"long"`
+	ArrivedAt pgtype.Timestamptz `json:"arrived_at"`
+}
+
+type UserRating struct {
+	UserID      string             `json:"user_id"`
+	Role        string             `json:"role"`
+	RatingCount int32              `json:"rating_count"`
+	Average     float64            `json:"average"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
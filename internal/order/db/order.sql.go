@@ -11,31 +11,724 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const arriveAtRideStop = `-- name: ArriveAtRideStop :one
+UPDATE ride_stops s
+SET arrived_at = NOW()
+WHERE s.order_id = $1
+  AND s.sequence = $2
+  AND s.arrived_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM ride_stops earlier
+    WHERE earlier.order_id = s.order_id
+      AND earlier.sequence < s.sequence
+      AND earlier.arrived_at IS NULL
+)
+RETURNING order_id, sequence, lat, long, arrived_at
+`
+
+type ArriveAtRideStopParams struct {
+	OrderID  pgtype.UUID `json:"order_id"`
+	Sequence int32       `json:"sequence"`
+}
+
+// Records that the driver reached a stop. Returns no row when the stop was
+// reached before, or an earlier stop was not reached yet.
+func (q *Queries) ArriveAtRideStop(ctx context.Context, arg ArriveAtRideStopParams) (RideStop, error) {
+	row := q.db.QueryRow(ctx, arriveAtRideStop, arg.OrderID, arg.Sequence)
+	var i RideStop
+	err := row.Scan(
+		&i.OrderID,
+		&i.Sequence,
+		&i.Lat,
+		&i.Long,
+		&i.ArrivedAt,
+	)
+	return i, err
+}
+
+const cancelOrder = `-- name: CancelOrder :one
+WITH prev AS (
+    SELECT id, status FROM orders
+    WHERE id = $1 AND status = ANY($2::text[])
+    FOR UPDATE
+), updated AS (
+    UPDATE orders o
+    SET status           = 'CANCELLED',
+        cancelled_by     = $3,
+        cancel_reason    = $4,
+        cancellation_fee = $5,
+        cancelled_at     = NOW(),
+        updated_at       = NOW()
+    FROM prev
+    WHERE o.id = prev.id
+    RETURNING o.id, o.passenger_id, o.driver_id, o.status, o.cancelled_by, o.cancel_reason, o.cancelled_at,
+              prev.status AS from_status
+), history AS (
+    INSERT INTO order_status_history (order_id, from_status, to_status, actor, actor_id, reason)
+    SELECT id, from_status, status, cancelled_by, $6::text, cancel_reason
+    FROM updated
+)
+SELECT id, passenger_id, driver_id, status, cancelled_at, from_status FROM updated
+`
+
+type CancelOrderRow struct {
+	ID          pgtype.UUID        `json:"id"`
+	PassengerID string             `json:"passenger_id"`
+	DriverID    pgtype.Text        `json:"driver_id"`
+	Status      string             `json:"status"`
+	CancelledAt pgtype.Timestamptz `json:"cancelled_at"`
+	FromStatus  string             `json:"from_status"`
+}
+
+type CancelOrderParams struct {
+	ID              pgtype.UUID `json:"id"`
+	FromStatuses    []string    `json:"from_statuses"`
+	CancelledBy     pgtype.Text `json:"cancelled_by"`
+	CancelReason    pgtype.Text `json:"cancel_reason"`
+	CancellationFee float64     `json:"cancellation_fee"`
+	ActorID         pgtype.Text `json:"actor_id"`
+}
+
+func (q *Queries) CancelOrder(ctx context.Context, arg CancelOrderParams) (CancelOrderRow, error) {
+	row := q.db.QueryRow(ctx, cancelOrder,
+		arg.ID,
+		arg.FromStatuses,
+		arg.CancelledBy,
+		arg.CancelReason,
+		arg.CancellationFee,
+		arg.ActorID,
+	)
+	var i CancelOrderRow
+	err := row.Scan(
+		&i.ID,
+		&i.PassengerID,
+		&i.DriverID,
+		&i.Status,
+		&i.CancelledAt,
+		&i.FromStatus,
+	)
+	return i, err
+}
+
+const claimDueReminders = `-- name: ClaimDueReminders :many
+UPDATE orders
+SET reminder_sent_at = NOW(), updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM orders
+    WHERE status = 'SCHEDULED'
+      AND reminder_sent_at IS NULL
+      AND scheduled_at <= $1::timestamptz
+    ORDER BY scheduled_at
+    LIMIT $2::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method
+`
+
+type ClaimDueRemindersParams struct {
+	RemindBefore pgtype.Timestamptz `json:"remind_before"`
+	BatchSize    int32              `json:"batch_size"`
+}
+
+// Marks scheduled rides whose reminder is due as reminded. SKIP LOCKED makes
+// sure every reminder is claimed by exactly one replica.
+func (q *Queries) ClaimDueReminders(ctx context.Context, arg ClaimDueRemindersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, claimDueReminders, arg.RemindBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.PassengerID,
+			&i.DriverID,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.Status,
+			&i.Price,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MatchedAt,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.DispatchAttempts,
+			&i.LastDispatchAt,
+			&i.VehicleType,
+			&i.Seats,
+			&i.PoolTripID,
+			&i.PickupSequence,
+			&i.DropoffSequence,
+			&i.TariffVersion,
+			&i.SurgeMultiplier,
+			&i.QuoteID,
+			&i.PromoCode,
+			&i.Discount,
+			&i.MeteredDistanceKm,
+			&i.MeteredDurationMin,
+			&i.MeteredFare,
+			&i.FinalPrice,
+			&i.FareBasis,
+			&i.FinishedAt,
+			&i.Tip,
+			&i.TippedAt,
+			&i.PaymentAttempts,
+			&i.PaymentRetryAt,
+			&i.PaymentError,
+			&i.PaidAt,
+			&i.CommissionRate,
+			&i.Commission,
+			&i.DriverEarnings,
+			&i.PaymentMethod,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimDueScheduledOrders = `-- name: ClaimDueScheduledOrders :many
+UPDATE orders
+SET dispatch_attempts = dispatch_attempts + 1,
+    last_dispatch_at  = NOW(),
+    updated_at        = NOW()
+WHERE id IN (
+    SELECT id FROM orders
+    WHERE scheduled_at IS NOT NULL
+      AND status IN ('SCHEDULED', 'SEARCHING')
+      AND dispatch_attempts < $1::int
+      AND scheduled_at <= $2::timestamptz
+      AND (last_dispatch_at IS NULL OR last_dispatch_at <= $3::timestamptz)
+    ORDER BY scheduled_at
+    LIMIT $4::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method
+`
+
+type ClaimDueScheduledOrdersParams struct {
+	MaxAttempts    int32              `json:"max_attempts"`
+	DispatchBefore pgtype.Timestamptz `json:"dispatch_before"`
+	RetryBefore    pgtype.Timestamptz `json:"retry_before"`
+	BatchSize      int32              `json:"batch_size"`
+}
+
+// Claims scheduled rides that are due for a dispatch attempt: either the first
+// one (pickup within the lead time) or a retry after the previous attempt found
+// no driver.
+func (q *Queries) ClaimDueScheduledOrders(ctx context.Context, arg ClaimDueScheduledOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, claimDueScheduledOrders,
+		arg.MaxAttempts,
+		arg.DispatchBefore,
+		arg.RetryBefore,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.PassengerID,
+			&i.DriverID,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.Status,
+			&i.Price,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MatchedAt,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.DispatchAttempts,
+			&i.LastDispatchAt,
+			&i.VehicleType,
+			&i.Seats,
+			&i.PoolTripID,
+			&i.PickupSequence,
+			&i.DropoffSequence,
+			&i.TariffVersion,
+			&i.SurgeMultiplier,
+			&i.QuoteID,
+			&i.PromoCode,
+			&i.Discount,
+			&i.MeteredDistanceKm,
+			&i.MeteredDurationMin,
+			&i.MeteredFare,
+			&i.FinalPrice,
+			&i.FareBasis,
+			&i.FinishedAt,
+			&i.Tip,
+			&i.TippedAt,
+			&i.PaymentAttempts,
+			&i.PaymentRetryAt,
+			&i.PaymentError,
+			&i.PaidAt,
+			&i.CommissionRate,
+			&i.Commission,
+			&i.DriverEarnings,
+			&i.PaymentMethod,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimExhaustedScheduledOrders = `-- name: ClaimExhaustedScheduledOrders :many
+SELECT id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method FROM orders
+WHERE scheduled_at IS NOT NULL
+  AND status IN ('SCHEDULED', 'SEARCHING')
+  AND dispatch_attempts >= $1::int
+  AND last_dispatch_at <= $2::timestamptz
+ORDER BY scheduled_at
+LIMIT $3::int
+FOR UPDATE SKIP LOCKED
+`
+
+type ClaimExhaustedScheduledOrdersParams struct {
+	MaxAttempts int32              `json:"max_attempts"`
+	RetryBefore pgtype.Timestamptz `json:"retry_before"`
+	BatchSize   int32              `json:"batch_size"`
+}
+
+// Locks scheduled rides whose last dispatch attempt found no driver either, so
+// they can be given up on. SKIP LOCKED makes sure every ride is claimed by
+// exactly one replica.
+func (q *Queries) ClaimExhaustedScheduledOrders(ctx context.Context, arg ClaimExhaustedScheduledOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, claimExhaustedScheduledOrders, arg.MaxAttempts, arg.RetryBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.PassengerID,
+			&i.DriverID,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.Status,
+			&i.Price,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MatchedAt,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.DispatchAttempts,
+			&i.LastDispatchAt,
+			&i.VehicleType,
+			&i.Seats,
+			&i.PoolTripID,
+			&i.PickupSequence,
+			&i.DropoffSequence,
+			&i.TariffVersion,
+			&i.SurgeMultiplier,
+			&i.QuoteID,
+			&i.PromoCode,
+			&i.Discount,
+			&i.MeteredDistanceKm,
+			&i.MeteredDurationMin,
+			&i.MeteredFare,
+			&i.FinalPrice,
+			&i.FareBasis,
+			&i.FinishedAt,
+			&i.Tip,
+			&i.TippedAt,
+			&i.PaymentAttempts,
+			&i.PaymentRetryAt,
+			&i.PaymentError,
+			&i.PaidAt,
+			&i.CommissionRate,
+			&i.Commission,
+			&i.DriverEarnings,
+			&i.PaymentMethod,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+UPDATE outbox
+SET next_attempt_at = NOW() + make_interval(secs => $1::int)
+WHERE id IN (
+    SELECT o.id FROM outbox o
+    WHERE o.sent_at IS NULL
+      AND o.next_attempt_at <= NOW()
+      AND NOT EXISTS (
+          SELECT 1 FROM outbox e
+          WHERE e.topic = o.topic
+            AND e.message_key = o.message_key
+            AND e.sent_at IS NULL
+            AND e.id < o.id
+      )
+    ORDER BY o.id
+    LIMIT $2::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, topic, message_key, payload, attempts, last_error, next_attempt_at, created_at, sent_at
+`
+
+type ClaimOutboxEventsParams struct {
+	LeaseSeconds int32 `json:"lease_seconds"`
+	BatchSize    int32 `json:"batch_size"`
+}
+
+// Leases the oldest unsent event of every topic and key that is due. Later
+// events of the same key wait until it is sent, so consumers see them in order.
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEvents, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.MessageKey,
+			&i.Payload,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.SentAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimPaymentRetries = `-- name: ClaimPaymentRetries :many
+UPDATE orders
+SET payment_retry_at = NULL, updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM orders
+    WHERE status = 'FINISHED'
+      AND payment_retry_at <= $1::timestamptz
+    ORDER BY payment_retry_at
+    LIMIT $2::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method
+`
+
+type ClaimPaymentRetriesParams struct {
+	DueBefore pgtype.Timestamptz `json:"due_before"`
+	BatchSize int32              `json:"batch_size"`
+}
+
+// Claims finished rides whose payment is due to be retried. SKIP LOCKED makes
+// sure every retry is claimed by exactly one replica.
+func (q *Queries) ClaimPaymentRetries(ctx context.Context, arg ClaimPaymentRetriesParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, claimPaymentRetries, arg.DueBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.PassengerID,
+			&i.DriverID,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.Status,
+			&i.Price,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MatchedAt,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.DispatchAttempts,
+			&i.LastDispatchAt,
+			&i.VehicleType,
+			&i.Seats,
+			&i.PoolTripID,
+			&i.PickupSequence,
+			&i.DropoffSequence,
+			&i.TariffVersion,
+			&i.SurgeMultiplier,
+			&i.QuoteID,
+			&i.PromoCode,
+			&i.Discount,
+			&i.MeteredDistanceKm,
+			&i.MeteredDurationMin,
+			&i.MeteredFare,
+			&i.FinalPrice,
+			&i.FareBasis,
+			&i.FinishedAt,
+			&i.Tip,
+			&i.TippedAt,
+			&i.PaymentAttempts,
+			&i.PaymentRetryAt,
+			&i.PaymentError,
+			&i.PaidAt,
+			&i.CommissionRate,
+			&i.Commission,
+			&i.DriverEarnings,
+			&i.PaymentMethod,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimPromoCampaign = `-- name: ClaimPromoCampaign :one
+UPDATE promo_campaigns
+SET redemptions = redemptions + 1
+WHERE id = $1
+  AND (max_redemptions IS NULL OR redemptions < max_redemptions)
+RETURNING redemptions
+`
+
+// Counts a redemption against the campaign's global limit and locks the
+// campaign until the transaction ends, so concurrent redemptions of the same
+// campaign are counted one at a time. Returns no rows when it is used up.
+func (q *Queries) ClaimPromoCampaign(ctx context.Context, id int64) (int32, error) {
+	row := q.db.QueryRow(ctx, claimPromoCampaign, id)
+	var redemptions int32
+	err := row.Scan(&redemptions)
+	return redemptions, err
+}
+
+const claimUnmatchedOrders = `-- name: ClaimUnmatchedOrders :many
+SELECT id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method FROM orders
+WHERE status IN ('CREATED', 'SEARCHING')
+  AND COALESCE(scheduled_at, created_at) < $1::timestamptz
+ORDER BY COALESCE(scheduled_at, created_at)
+LIMIT $2::int
+FOR UPDATE SKIP LOCKED
+`
+
+type ClaimUnmatchedOrdersParams struct {
+	SearchBefore pgtype.Timestamptz `json:"search_before"`
+	BatchSize    int32              `json:"batch_size"`
+}
+
+// Locks orders that have been waiting for a driver since before search_before:
+// immediate rides from creation, scheduled ones from their pickup time. SKIP
+// LOCKED makes sure every order is claimed by exactly one replica.
+func (q *Queries) ClaimUnmatchedOrders(ctx context.Context, arg ClaimUnmatchedOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, claimUnmatchedOrders, arg.SearchBefore, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.PassengerID,
+			&i.DriverID,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.Status,
+			&i.Price,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MatchedAt,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.DispatchAttempts,
+			&i.LastDispatchAt,
+			&i.VehicleType,
+			&i.Seats,
+			&i.PoolTripID,
+			&i.PickupSequence,
+			&i.DropoffSequence,
+			&i.TariffVersion,
+			&i.SurgeMultiplier,
+			&i.QuoteID,
+			&i.PromoCode,
+			&i.Discount,
+			&i.MeteredDistanceKm,
+			&i.MeteredDurationMin,
+			&i.MeteredFare,
+			&i.FinalPrice,
+			&i.FareBasis,
+			&i.FinishedAt,
+			&i.Tip,
+			&i.TippedAt,
+			&i.PaymentAttempts,
+			&i.PaymentRetryAt,
+			&i.PaymentError,
+			&i.PaidAt,
+			&i.CommissionRate,
+			&i.Commission,
+			&i.DriverEarnings,
+			&i.PaymentMethod,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countOrders = `-- name: CountOrders :one
+SELECT COUNT(*) AS total_count,
+       COALESCE(SUM(COALESCE(final_price, price)) FILTER (WHERE status IN ('FINISHED', 'PAID', 'PAYMENT_FAILED')), 0)::float8 AS total_amount,
+       COALESCE(SUM(tip), 0)::float8 AS total_tips
+FROM orders
+WHERE ($1::text IS NULL OR passenger_id = $1::text)
+  AND ($2::text IS NULL OR driver_id = $2::text)
+  AND (cardinality($3::text[]) = 0 OR status = ANY($3::text[]))
+  AND ($4::timestamptz IS NULL OR created_at >= $4::timestamptz)
+  AND ($5::timestamptz IS NULL OR created_at < $5::timestamptz)
+`
+
+type CountOrdersRow struct {
+	TotalCount  int64   `json:"total_count"`
+	TotalAmount float64 `json:"total_amount"`
+	TotalTips   float64 `json:"total_tips"`
+}
+
+type CountOrdersParams struct {
+	PassengerID pgtype.Text        `json:"passenger_id"`
+	DriverID    pgtype.Text        `json:"driver_id"`
+	Statuses    []string           `json:"statuses"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+}
+
+// Totals of the orders ListOrders pages through with the same filters.
+func (q *Queries) CountOrders(ctx context.Context, arg CountOrdersParams) (CountOrdersRow, error) {
+	row := q.db.QueryRow(ctx, countOrders,
+		arg.PassengerID,
+		arg.DriverID,
+		arg.Statuses,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+	)
+	var i CountOrdersRow
+	err := row.Scan(&i.TotalCount, &i.TotalAmount, &i.TotalTips)
+	return i, err
+}
+
+const countUserPromoRedemptions = `-- name: CountUserPromoRedemptions :one
+SELECT COUNT(*) FROM promo_redemptions
+WHERE campaign_id = $1 AND user_id = $2 AND status = 'APPLIED'
+`
+
+type CountUserPromoRedemptionsParams struct {
+	CampaignID int64  `json:"campaign_id"`
+	UserID     string `json:"user_id"`
+}
+
+func (q *Queries) CountUserPromoRedemptions(ctx context.Context, arg CountUserPromoRedemptionsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUserPromoRedemptions, arg.CampaignID, arg.UserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createOrder = `-- name: CreateOrder :one
 
 INSERT INTO orders (
-    passenger_id, driver_id,
+    id, passenger_id, driver_id,
     pickup_lat, pickup_long, dropoff_lat, dropoff_long,
-    status, price
+    status, price, scheduled_at, vehicle_type, seats, tariff_version, surge_multiplier, quote_id,
+    promo_code, discount, payment_method
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8
-         ) RETURNING id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+         ) RETURNING id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method
 `
 
 type CreateOrderParams struct {
-	PassengerID string      `json:"passenger_id"`
-	DriverID    pgtype.Text `json:"driver_id"`
-	PickupLat   float64     `json:"pickup_lat"`
-	PickupLong  float64     `json:"pickup_long"`
-	DropoffLat  float64     `json:"dropoff_lat"`
-	DropoffLong float64     `json:"dropoff_long"`
-	Status      string      `json:"status"`
-	Price       float64     `json:"price"`
+	ID              pgtype.UUID        `json:"id"`
+	PassengerID     string             `json:"passenger_id"`
+	DriverID        pgtype.Text        `json:"driver_id"`
+	PickupLat       float64            `json:"pickup_lat"`
+	PickupLong      float64            `json:"pickup_long"`
+	DropoffLat      float64            `json:"dropoff_lat"`
+	DropoffLong     float64            `json:"dropoff_long"`
+	Status          string             `json:"status"`
+	Price           float64            `json:"price"`
+	ScheduledAt     pgtype.Timestamptz `json:"scheduled_at"`
+	VehicleType     string             `json:"vehicle_type"`
+	Seats           int32              `json:"seats"`
+	TariffVersion   pgtype.Text        `json:"tariff_version"`
+	SurgeMultiplier float64            `json:"surge_multiplier"`
+	QuoteID         pgtype.Text        `json:"quote_id"`
+	PromoCode       pgtype.Text        `json:"promo_code"`
+	Discount        float64            `json:"discount"`
+	PaymentMethod   string             `json:"payment_method"`
 }
 
 // internal/order/db/query/order.sql
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
 	row := q.db.QueryRow(ctx, createOrder,
+		arg.ID,
 		arg.PassengerID,
 		arg.DriverID,
 		arg.PickupLat,
@@ -44,6 +737,15 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		arg.DropoffLong,
 		arg.Status,
 		arg.Price,
+		arg.ScheduledAt,
+		arg.VehicleType,
+		arg.Seats,
+		arg.TariffVersion,
+		arg.SurgeMultiplier,
+		arg.QuoteID,
+		arg.PromoCode,
+		arg.Discount,
+		arg.PaymentMethod,
 	)
 	var i Order
 	err := row.Scan(
@@ -58,12 +760,218 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MatchedAt,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.DispatchAttempts,
+		&i.LastDispatchAt,
+		&i.VehicleType,
+		&i.Seats,
+		&i.PoolTripID,
+		&i.PickupSequence,
+		&i.DropoffSequence,
+		&i.TariffVersion,
+		&i.SurgeMultiplier,
+		&i.QuoteID,
+		&i.PromoCode,
+		&i.Discount,
+		&i.MeteredDistanceKm,
+		&i.MeteredDurationMin,
+		&i.MeteredFare,
+		&i.FinalPrice,
+		&i.FareBasis,
+		&i.FinishedAt,
+		&i.Tip,
+		&i.TippedAt,
+		&i.PaymentAttempts,
+		&i.PaymentRetryAt,
+		&i.PaymentError,
+		&i.PaidAt,
+		&i.CommissionRate,
+		&i.Commission,
+		&i.DriverEarnings,
+		&i.PaymentMethod,
+	)
+	return i, err
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :exec
+INSERT INTO outbox (topic, message_key, payload)
+VALUES ($1, $2, $3)
+`
+
+type CreateOutboxEventParams struct {
+	Topic      string `json:"topic"`
+	MessageKey string `json:"message_key"`
+	Payload    []byte `json:"payload"`
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error {
+	_, err := q.db.Exec(ctx, createOutboxEvent, arg.Topic, arg.MessageKey, arg.Payload)
+	return err
+}
+
+const createPassengerDebt = `-- name: CreatePassengerDebt :exec
+INSERT INTO passenger_debts (order_id, passenger_id, amount, reason)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (order_id) DO NOTHING
+`
+
+type CreatePassengerDebtParams struct {
+	OrderID     pgtype.UUID `json:"order_id"`
+	PassengerID string      `json:"passenger_id"`
+	Amount      float64     `json:"amount"`
+	Reason      pgtype.Text `json:"reason"`
+}
+
+func (q *Queries) CreatePassengerDebt(ctx context.Context, arg CreatePassengerDebtParams) error {
+	_, err := q.db.Exec(ctx, createPassengerDebt,
+		arg.OrderID,
+		arg.PassengerID,
+		arg.Amount,
+		arg.Reason,
+	)
+	return err
+}
+
+const createPromoRedemption = `-- name: CreatePromoRedemption :exec
+INSERT INTO promo_redemptions (campaign_id, user_id, order_id, discount)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreatePromoRedemptionParams struct {
+	CampaignID int64       `json:"campaign_id"`
+	UserID     string      `json:"user_id"`
+	OrderID    pgtype.UUID `json:"order_id"`
+	Discount   float64     `json:"discount"`
+}
+
+func (q *Queries) CreatePromoRedemption(ctx context.Context, arg CreatePromoRedemptionParams) error {
+	_, err := q.db.Exec(ctx, createPromoRedemption,
+		arg.CampaignID,
+		arg.UserID,
+		arg.OrderID,
+		arg.Discount,
+	)
+	return err
+}
+
+const createRating = `-- name: CreateRating :one
+INSERT INTO ratings (order_id, rater_id, ratee_id, ratee_role, stars, tags, comment)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (order_id, ratee_role) DO NOTHING
+RETURNING id, order_id, rater_id, ratee_id, ratee_role, stars, tags, comment, created_at
+`
+
+type CreateRatingParams struct {
+	OrderID   pgtype.UUID `json:"order_id"`
+	RaterID   string      `json:"rater_id"`
+	RateeID   string      `json:"ratee_id"`
+	RateeRole string      `json:"ratee_role"`
+	Stars     int16       `json:"stars"`
+	Tags      []string    `json:"tags"`
+	Comment   pgtype.Text `json:"comment"`
+}
+
+// Returns no rows when this side already rated the order.
+func (q *Queries) CreateRating(ctx context.Context, arg CreateRatingParams) (Rating, error) {
+	row := q.db.QueryRow(ctx, createRating,
+		arg.OrderID,
+		arg.RaterID,
+		arg.RateeID,
+		arg.RateeRole,
+		arg.Stars,
+		arg.Tags,
+		arg.Comment,
+	)
+	var i Rating
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.RaterID,
+		&i.RateeID,
+		&i.RateeRole,
+		&i.Stars,
+		&i.Tags,
+		&i.Comment,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const createReceipt = `-- name: CreateReceipt :one
+INSERT INTO receipts (order_id, receipt_number, passenger_id, document, issued_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING order_id, receipt_number, passenger_id, document, issued_at
+`
+
+type CreateReceiptParams struct {
+	OrderID       pgtype.UUID        `json:"order_id"`
+	ReceiptNumber string             `json:"receipt_number"`
+	PassengerID   string             `json:"passenger_id"`
+	Document      []byte             `json:"document"`
+	IssuedAt      pgtype.Timestamptz `json:"issued_at"`
+}
+
+func (q *Queries) CreateReceipt(ctx context.Context, arg CreateReceiptParams) (Receipt, error) {
+	row := q.db.QueryRow(ctx, createReceipt,
+		arg.OrderID,
+		arg.ReceiptNumber,
+		arg.PassengerID,
+		arg.Document,
+		arg.IssuedAt,
+	)
+	var i Receipt
+	err := row.Scan(
+		&i.OrderID,
+		&i.ReceiptNumber,
+		&i.PassengerID,
+		&i.Document,
+		&i.IssuedAt,
+	)
+	return i, err
+}
+
+const createRideStops = `-- name: CreateRideStops :exec
+INSERT INTO ride_stops (order_id, sequence, lat, long)
+SELECT $1::uuid, s.sequence::int, s.lat, s.long
+FROM unnest(
+    $2::float8[],
+    $3::float8[]
+) WITH ORDINALITY AS s(lat, long, sequence)
+`
+
+type CreateRideStopsParams struct {
+	OrderID pgtype.UUID `json:"order_id"`
+	Lats    []float64   `json:"lats"`
+	Longs   []float64   `json:"longs"`
+}
+
+// Stores the stops of a new order, numbered in the order they are given.
+func (q *Queries) CreateRideStops(ctx context.Context, arg CreateRideStopsParams) error {
+	_, err := q.db.Exec(ctx, createRideStops, arg.OrderID, arg.Lats, arg.Longs)
+	return err
+}
+
+const deleteSentOutboxEvents = `-- name: DeleteSentOutboxEvents :execrows
+DELETE FROM outbox
+WHERE sent_at < $1::timestamptz
+`
+
+func (q *Queries) DeleteSentOutboxEvents(ctx context.Context, sentBefore pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSentOutboxEvents, sentBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getOrder = `-- name: GetOrder :one
-SELECT id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at FROM orders
+SELECT id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method FROM orders
 WHERE id = $1 LIMIT 1
 `
 
@@ -82,38 +990,865 @@ func (q *Queries) GetOrder(ctx context.Context, id pgtype.UUID) (Order, error) {
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MatchedAt,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.DispatchAttempts,
+		&i.LastDispatchAt,
+		&i.VehicleType,
+		&i.Seats,
+		&i.PoolTripID,
+		&i.PickupSequence,
+		&i.DropoffSequence,
+		&i.TariffVersion,
+		&i.SurgeMultiplier,
+		&i.QuoteID,
+		&i.PromoCode,
+		&i.Discount,
+		&i.MeteredDistanceKm,
+		&i.MeteredDurationMin,
+		&i.MeteredFare,
+		&i.FinalPrice,
+		&i.FareBasis,
+		&i.FinishedAt,
+		&i.Tip,
+		&i.TippedAt,
+		&i.PaymentAttempts,
+		&i.PaymentRetryAt,
+		&i.PaymentError,
+		&i.PaidAt,
+		&i.CommissionRate,
+		&i.Commission,
+		&i.DriverEarnings,
+		&i.PaymentMethod,
 	)
 	return i, err
 }
 
-const updateOrderDriver = `-- name: UpdateOrderDriver :exec
+const getPassengerDebt = `-- name: GetPassengerDebt :one
+SELECT COALESCE(SUM(amount), 0)::float8 AS debt
+FROM passenger_debts
+WHERE passenger_id = $1 AND settled_at IS NULL
+`
+
+// Sums what a passenger still owes for rides that could not be charged.
+func (q *Queries) GetPassengerDebt(ctx context.Context, passengerID string) (float64, error) {
+	row := q.db.QueryRow(ctx, getPassengerDebt, passengerID)
+	var debt float64
+	err := row.Scan(&debt)
+	return debt, err
+}
+
+const getPromoCampaignByCode = `-- name: GetPromoCampaignByCode :one
+SELECT id, code, name, discount_type, discount_value, max_discount, min_fare, starts_at, ends_at, max_redemptions, max_per_user, vehicle_types, cities, redemptions, active, created_at FROM promo_campaigns
+WHERE code = $1 LIMIT 1
+`
+
+func (q *Queries) GetPromoCampaignByCode(ctx context.Context, code string) (PromoCampaign, error) {
+	row := q.db.QueryRow(ctx, getPromoCampaignByCode, code)
+	var i PromoCampaign
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.DiscountType,
+		&i.DiscountValue,
+		&i.MaxDiscount,
+		&i.MinFare,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.MaxRedemptions,
+		&i.MaxPerUser,
+		&i.VehicleTypes,
+		&i.Cities,
+		&i.Redemptions,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getReceipt = `-- name: GetReceipt :one
+SELECT order_id, receipt_number, passenger_id, document, issued_at FROM receipts
+WHERE order_id = $1
+`
+
+func (q *Queries) GetReceipt(ctx context.Context, orderID pgtype.UUID) (Receipt, error) {
+	row := q.db.QueryRow(ctx, getReceipt, orderID)
+	var i Receipt
+	err := row.Scan(
+		&i.OrderID,
+		&i.ReceiptNumber,
+		&i.PassengerID,
+		&i.Document,
+		&i.IssuedAt,
+	)
+	return i, err
+}
+
+const getUserRatings = `-- name: GetUserRatings :many
+SELECT user_id, role, rating_count, average, updated_at FROM user_ratings
+WHERE user_id = ANY($1::text[]) AND role = $2
+ORDER BY user_id
+`
+
+type GetUserRatingsParams struct {
+	UserIds []string `json:"user_ids"`
+	Role    string   `json:"role"`
+}
+
+func (q *Queries) GetUserRatings(ctx context.Context, arg GetUserRatingsParams) ([]UserRating, error) {
+	rows, err := q.db.Query(ctx, getUserRatings, arg.UserIds, arg.Role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserRating
+	for rows.Next() {
+		var i UserRating
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Role,
+			&i.RatingCount,
+			&i.Average,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDriverEarnings = `-- name: ListDriverEarnings :many
+SELECT id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method FROM orders
+WHERE driver_id = $1
+  AND paid_at >= $2
+  AND paid_at < $3
+  AND driver_earnings IS NOT NULL
+ORDER BY paid_at DESC, id DESC
+`
+
+type ListDriverEarningsParams struct {
+	DriverID pgtype.Text        `json:"driver_id"`
+	PaidFrom pgtype.Timestamptz `json:"paid_from"`
+	PaidTo   pgtype.Timestamptz `json:"paid_to"`
+}
+
+// Lists the paid rides of a driver, newest first, by when they were paid.
+func (q *Queries) ListDriverEarnings(ctx context.Context, arg ListDriverEarningsParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listDriverEarnings, arg.DriverID, arg.PaidFrom, arg.PaidTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.PassengerID,
+			&i.DriverID,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.Status,
+			&i.Price,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MatchedAt,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.DispatchAttempts,
+			&i.LastDispatchAt,
+			&i.VehicleType,
+			&i.Seats,
+			&i.PoolTripID,
+			&i.PickupSequence,
+			&i.DropoffSequence,
+			&i.TariffVersion,
+			&i.SurgeMultiplier,
+			&i.QuoteID,
+			&i.PromoCode,
+			&i.Discount,
+			&i.MeteredDistanceKm,
+			&i.MeteredDurationMin,
+			&i.MeteredFare,
+			&i.FinalPrice,
+			&i.FareBasis,
+			&i.FinishedAt,
+			&i.Tip,
+			&i.TippedAt,
+			&i.PaymentAttempts,
+			&i.PaymentRetryAt,
+			&i.PaymentError,
+			&i.PaidAt,
+			&i.CommissionRate,
+			&i.Commission,
+			&i.DriverEarnings,
+			&i.PaymentMethod,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderStatusHistory = `-- name: ListOrderStatusHistory :many
+SELECT id, order_id, from_status, to_status, actor, actor_id, reason, created_at FROM order_status_history
+WHERE order_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListOrderStatusHistory(ctx context.Context, orderID pgtype.UUID) ([]OrderStatusHistory, error) {
+	rows, err := q.db.Query(ctx, listOrderStatusHistory, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderStatusHistory
+	for rows.Next() {
+		var i OrderStatusHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.Actor,
+			&i.ActorID,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrders = `-- name: ListOrders :many
+SELECT id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method FROM orders
+WHERE ($1::text IS NULL OR passenger_id = $1::text)
+  AND ($2::text IS NULL OR driver_id = $2::text)
+  AND (cardinality($3::text[]) = 0 OR status = ANY($3::text[]))
+  AND ($4::timestamptz IS NULL OR created_at >= $4::timestamptz)
+  AND ($5::timestamptz IS NULL OR created_at < $5::timestamptz)
+  AND ($6::timestamptz IS NULL
+       OR (created_at, id) < ($6::timestamptz, $7::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $8::int
+`
+
+type ListOrdersParams struct {
+	PassengerID    pgtype.Text        `json:"passenger_id"`
+	DriverID       pgtype.Text        `json:"driver_id"`
+	Statuses       []string           `json:"statuses"`
+	CreatedFrom    pgtype.Timestamptz `json:"created_from"`
+	CreatedTo      pgtype.Timestamptz `json:"created_to"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        pgtype.UUID        `json:"after_id"`
+	PageSize       int32              `json:"page_size"`
+}
+
+// Pages through the orders of a passenger or driver, newest first. A page
+// starts after the (created_at, id) of the last order of the previous one.
+func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOrders,
+		arg.PassengerID,
+		arg.DriverID,
+		arg.Statuses,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.PassengerID,
+			&i.DriverID,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.Status,
+			&i.Price,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MatchedAt,
+			&i.CancelledAt,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.DispatchAttempts,
+			&i.LastDispatchAt,
+			&i.VehicleType,
+			&i.Seats,
+			&i.PoolTripID,
+			&i.PickupSequence,
+			&i.DropoffSequence,
+			&i.TariffVersion,
+			&i.SurgeMultiplier,
+			&i.QuoteID,
+			&i.PromoCode,
+			&i.Discount,
+			&i.MeteredDistanceKm,
+			&i.MeteredDurationMin,
+			&i.MeteredFare,
+			&i.FinalPrice,
+			&i.FareBasis,
+			&i.FinishedAt,
+			&i.Tip,
+			&i.TippedAt,
+			&i.PaymentAttempts,
+			&i.PaymentRetryAt,
+			&i.PaymentError,
+			&i.PaidAt,
+			&i.CommissionRate,
+			&i.Commission,
+			&i.DriverEarnings,
+			&i.PaymentMethod,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRideStops = `-- name: ListRideStops :many
+SELECT order_id, sequence, lat, long, arrived_at FROM ride_stops
+WHERE order_id = $1
+ORDER BY sequence
+`
+
+func (q *Queries) ListRideStops(ctx context.Context, orderID pgtype.UUID) ([]RideStop, error) {
+	rows, err := q.db.Query(ctx, listRideStops, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RideStop
+	for rows.Next() {
+		var i RideStop
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.Sequence,
+			&i.Lat,
+			&i.Long,
+			&i.ArrivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUserRating = `-- name: LockUserRating :exec
+INSERT INTO user_ratings (user_id, role)
+VALUES ($1, $2)
+ON CONFLICT (user_id, role) DO UPDATE SET updated_at = NOW()
+`
+
+type LockUserRatingParams struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// Creates the user's rating row if needed and locks it until the transaction
+// ends, so concurrent ratings of the same user refresh it one at a time.
+func (q *Queries) LockUserRating(ctx context.Context, arg LockUserRatingParams) error {
+	_, err := q.db.Exec(ctx, lockUserRating, arg.UserID, arg.Role)
+	return err
+}
+
+const markOrderPaid = `-- name: MarkOrderPaid :exec
 UPDATE orders
-SET driver_id = $2, status = 'MATCHED', updated_at = NOW()
+SET paid_at          = NOW(),
+    payment_retry_at = NULL,
+    payment_error    = NULL,
+    updated_at       = NOW()
 WHERE id = $1
 `
 
-type UpdateOrderDriverParams struct {
-	ID       pgtype.UUID `json:"id"`
-	DriverID pgtype.Text `json:"driver_id"`
+// Records that the fare of a ride was charged.
+func (q *Queries) MarkOrderPaid(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markOrderPaid, id)
+	return err
 }
 
-func (q *Queries) UpdateOrderDriver(ctx context.Context, arg UpdateOrderDriverParams) error {
-	_, err := q.db.Exec(ctx, updateOrderDriver, arg.ID, arg.DriverID)
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET attempts        = attempts + 1,
+    last_error      = $1::text,
+    next_attempt_at = NOW() + make_interval(secs => $2::int)
+WHERE id = $3
+`
+
+type MarkOutboxEventFailedParams struct {
+	LastError      string `json:"last_error"`
+	RetryInSeconds int32  `json:"retry_in_seconds"`
+	ID             int64  `json:"id"`
+}
+
+// Records a failed publish and postpones the next attempt.
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEventFailed, arg.LastError, arg.RetryInSeconds, arg.ID)
 	return err
 }
 
-const updateOrderStatus = `-- name: UpdateOrderStatus :exec
-UPDATE orders
-SET status = $2, updated_at = NOW()
+const markOutboxEventSent = `-- name: MarkOutboxEventSent :exec
+UPDATE outbox
+SET sent_at = NOW()
 WHERE id = $1
 `
 
+func (q *Queries) MarkOutboxEventSent(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markOutboxEventSent, id)
+	return err
+}
+
+const nextReceiptNumber = `-- name: NextReceiptNumber :one
+INSERT INTO receipt_counters (year, last_number)
+VALUES ($1, 1)
+ON CONFLICT (year) DO UPDATE SET last_number = receipt_counters.last_number + 1
+RETURNING last_number
+`
+
+// Takes the next receipt number of the year. The counter row stays locked
+// until the transaction ends, so numbers are handed out in order.
+func (q *Queries) NextReceiptNumber(ctx context.Context, year int32) (int64, error) {
+	row := q.db.QueryRow(ctx, nextReceiptNumber, year)
+	var last_number int64
+	err := row.Scan(&last_number)
+	return last_number, err
+}
+
+const recordOrderMeter = `-- name: RecordOrderMeter :exec
+UPDATE orders
+SET metered_distance_km  = $1,
+    metered_duration_min = $2,
+    updated_at           = NOW()
+WHERE id = $3
+  AND status = 'STARTED'
+  AND metered_distance_km IS NULL
+`
+
+type RecordOrderMeterParams struct {
+	MeteredDistanceKm  pgtype.Float8 `json:"metered_distance_km"`
+	MeteredDurationMin pgtype.Float8 `json:"metered_duration_min"`
+	ID                 pgtype.UUID   `json:"id"`
+}
+
+// Keeps what the tracker measured for a ride that is finishing, so a retried
+// finish charges the same trip. The first measurement stays.
+func (q *Queries) RecordOrderMeter(ctx context.Context, arg RecordOrderMeterParams) error {
+	_, err := q.db.Exec(ctx, recordOrderMeter, arg.MeteredDistanceKm, arg.MeteredDurationMin, arg.ID)
+	return err
+}
+
+const recordPaymentFailure = `-- name: RecordPaymentFailure :exec
+UPDATE orders
+SET payment_attempts = payment_attempts + 1,
+    payment_retry_at = $1,
+    payment_error    = $2::text,
+    updated_at       = NOW()
+WHERE id = $3
+`
+
+type RecordPaymentFailureParams struct {
+	RetryAt      pgtype.Timestamptz `json:"retry_at"`
+	PaymentError string             `json:"payment_error"`
+	ID           pgtype.UUID        `json:"id"`
+}
+
+// Counts a failed attempt to charge a ride, and sets when to try again unless
+// retry_at is NULL.
+func (q *Queries) RecordPaymentFailure(ctx context.Context, arg RecordPaymentFailureParams) error {
+	_, err := q.db.Exec(ctx, recordPaymentFailure, arg.RetryAt, arg.PaymentError, arg.ID)
+	return err
+}
+
+const refreshUserRating = `-- name: RefreshUserRating :one
+UPDATE user_ratings
+SET rating_count = (
+        SELECT COUNT(*) FROM ratings
+        WHERE ratee_id = $1 AND ratee_role = $2
+    ),
+    average = COALESCE((
+        SELECT AVG(recent.stars) FROM (
+            SELECT stars FROM ratings
+            WHERE ratee_id = $1 AND ratee_role = $2
+            ORDER BY created_at DESC, id DESC
+            LIMIT $3::int
+        ) recent
+    ), 0)::float8,
+    updated_at = NOW()
+WHERE user_id = $1 AND role = $2
+RETURNING user_id, role, rating_count, average, updated_at
+`
+
+type RefreshUserRatingParams struct {
+	UserID     string `json:"user_id"`
+	Role       string `json:"role"`
+	WindowSize int32  `json:"window_size"`
+}
+
+// Recounts the user's ratings and averages the most recent window_size of them.
+func (q *Queries) RefreshUserRating(ctx context.Context, arg RefreshUserRatingParams) (UserRating, error) {
+	row := q.db.QueryRow(ctx, refreshUserRating, arg.UserID, arg.Role, arg.WindowSize)
+	var i UserRating
+	err := row.Scan(
+		&i.UserID,
+		&i.Role,
+		&i.RatingCount,
+		&i.Average,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const reversePromoRedemption = `-- name: ReversePromoRedemption :execrows
+WITH reversed AS (
+    UPDATE promo_redemptions
+    SET status = 'REVERSED', reversed_at = NOW()
+    WHERE order_id = $1 AND status = 'APPLIED'
+    RETURNING campaign_id
+)
+UPDATE promo_campaigns
+SET redemptions = redemptions - 1
+WHERE id IN (SELECT campaign_id FROM reversed)
+`
+
+// Gives back the promo an order used, if any.
+func (q *Queries) ReversePromoRedemption(ctx context.Context, orderID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, reversePromoRedemption, orderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setOrderFinalFare = `-- name: SetOrderFinalFare :one
+UPDATE orders
+SET metered_distance_km  = $1,
+    metered_duration_min = $2,
+    metered_fare         = $3,
+    final_price          = $4,
+    fare_basis           = $5,
+    commission_rate      = $6,
+    commission           = $7,
+    driver_earnings      = $8,
+    finished_at          = NOW(),
+    updated_at           = NOW()
+WHERE id = $9
+RETURNING id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method
+`
+
+type SetOrderFinalFareParams struct {
+	MeteredDistanceKm  pgtype.Float8 `json:"metered_distance_km"`
+	MeteredDurationMin pgtype.Float8 `json:"metered_duration_min"`
+	MeteredFare        pgtype.Float8 `json:"metered_fare"`
+	FinalPrice         pgtype.Float8 `json:"final_price"`
+	FareBasis          pgtype.Text   `json:"fare_basis"`
+	CommissionRate     pgtype.Float8 `json:"commission_rate"`
+	Commission         pgtype.Float8 `json:"commission"`
+	DriverEarnings     pgtype.Float8 `json:"driver_earnings"`
+	ID                 pgtype.UUID   `json:"id"`
+}
+
+// Records what the finished ride measured, what the passenger pays for it and
+// what the driver earns.
+func (q *Queries) SetOrderFinalFare(ctx context.Context, arg SetOrderFinalFareParams) (Order, error) {
+	row := q.db.QueryRow(ctx, setOrderFinalFare,
+		arg.MeteredDistanceKm,
+		arg.MeteredDurationMin,
+		arg.MeteredFare,
+		arg.FinalPrice,
+		arg.FareBasis,
+		arg.CommissionRate,
+		arg.Commission,
+		arg.DriverEarnings,
+		arg.ID,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.PassengerID,
+		&i.DriverID,
+		&i.PickupLat,
+		&i.PickupLong,
+		&i.DropoffLat,
+		&i.DropoffLong,
+		&i.Status,
+		&i.Price,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MatchedAt,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.DispatchAttempts,
+		&i.LastDispatchAt,
+		&i.VehicleType,
+		&i.Seats,
+		&i.PoolTripID,
+		&i.PickupSequence,
+		&i.DropoffSequence,
+		&i.TariffVersion,
+		&i.SurgeMultiplier,
+		&i.QuoteID,
+		&i.PromoCode,
+		&i.Discount,
+		&i.MeteredDistanceKm,
+		&i.MeteredDurationMin,
+		&i.MeteredFare,
+		&i.FinalPrice,
+		&i.FareBasis,
+		&i.FinishedAt,
+		&i.Tip,
+		&i.TippedAt,
+		&i.PaymentAttempts,
+		&i.PaymentRetryAt,
+		&i.PaymentError,
+		&i.PaidAt,
+		&i.CommissionRate,
+		&i.Commission,
+		&i.DriverEarnings,
+		&i.PaymentMethod,
+	)
+	return i, err
+}
+
+const setOrderTip = `-- name: SetOrderTip :one
+UPDATE orders
+SET tip        = $1,
+    tipped_at  = NOW(),
+    updated_at = NOW()
+WHERE id = $2 AND status IN ('FINISHED', 'PAID', 'PAYMENT_FAILED') AND tip IS NULL
+RETURNING id, passenger_id, driver_id, pickup_lat, pickup_long, dropoff_lat, dropoff_long, status, price, created_at, updated_at, matched_at, cancelled_at, cancelled_by, cancel_reason, cancellation_fee, scheduled_at, reminder_sent_at, dispatch_attempts, last_dispatch_at, vehicle_type, seats, pool_trip_id, pickup_sequence, dropoff_sequence, tariff_version, surge_multiplier, quote_id, promo_code, discount, metered_distance_km, metered_duration_min, metered_fare, final_price, fare_basis, finished_at, tip, tipped_at, payment_attempts, payment_retry_at, payment_error, paid_at, commission_rate, commission, driver_earnings, payment_method
+`
+
+type SetOrderTipParams struct {
+	Tip pgtype.Float8 `json:"tip"`
+	ID  pgtype.UUID   `json:"id"`
+}
+
+// Records the tip of a finished ride. Returns no rows when it already has one.
+func (q *Queries) SetOrderTip(ctx context.Context, arg SetOrderTipParams) (Order, error) {
+	row := q.db.QueryRow(ctx, setOrderTip, arg.Tip, arg.ID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.PassengerID,
+		&i.DriverID,
+		&i.PickupLat,
+		&i.PickupLong,
+		&i.DropoffLat,
+		&i.DropoffLong,
+		&i.Status,
+		&i.Price,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MatchedAt,
+		&i.CancelledAt,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.DispatchAttempts,
+		&i.LastDispatchAt,
+		&i.VehicleType,
+		&i.Seats,
+		&i.PoolTripID,
+		&i.PickupSequence,
+		&i.DropoffSequence,
+		&i.TariffVersion,
+		&i.SurgeMultiplier,
+		&i.QuoteID,
+		&i.PromoCode,
+		&i.Discount,
+		&i.MeteredDistanceKm,
+		&i.MeteredDurationMin,
+		&i.MeteredFare,
+		&i.FinalPrice,
+		&i.FareBasis,
+		&i.FinishedAt,
+		&i.Tip,
+		&i.TippedAt,
+		&i.PaymentAttempts,
+		&i.PaymentRetryAt,
+		&i.PaymentError,
+		&i.PaidAt,
+		&i.CommissionRate,
+		&i.Commission,
+		&i.DriverEarnings,
+		&i.PaymentMethod,
+	)
+	return i, err
+}
+
+const settlePassengerDebt = `-- name: SettlePassengerDebt :exec
+UPDATE passenger_debts
+SET settled_at = NOW()
+WHERE order_id = $1 AND settled_at IS NULL
+`
+
+func (q *Queries) SettlePassengerDebt(ctx context.Context, orderID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, settlePassengerDebt, orderID)
+	return err
+}
+
+const updateOrderDriver = `-- name: UpdateOrderDriver :one
+WITH prev AS (
+    SELECT id, status FROM orders
+    WHERE id = $1 AND status = ANY($2::text[])
+    FOR UPDATE
+), updated AS (
+    UPDATE orders o
+    SET driver_id = $3, status = 'MATCHED', matched_at = NOW(), updated_at = NOW()
+    FROM prev
+    WHERE o.id = prev.id
+    RETURNING o.id, prev.status AS from_status, o.status AS to_status, o.driver_id
+)
+INSERT INTO order_status_history (order_id, from_status, to_status, actor, actor_id)
+SELECT id, from_status, to_status, $4::text, driver_id
+FROM updated
+RETURNING from_status
+`
+
+type UpdateOrderDriverParams struct {
+	ID           pgtype.UUID `json:"id"`
+	FromStatuses []string    `json:"from_statuses"`
+	DriverID     pgtype.Text `json:"driver_id"`
+	Actor        string      `json:"actor"`
+}
+
+// Assigns the matched driver unless the order already moved past searching.
+// Returns the status it was matched from, or no rows when it moved on.
+func (q *Queries) UpdateOrderDriver(ctx context.Context, arg UpdateOrderDriverParams) (string, error) {
+	row := q.db.QueryRow(ctx, updateOrderDriver,
+		arg.ID,
+		arg.FromStatuses,
+		arg.DriverID,
+		arg.Actor,
+	)
+	var from_status string
+	err := row.Scan(&from_status)
+	return from_status, err
+}
+
+const updateOrderStatus = `-- name: UpdateOrderStatus :one
+WITH prev AS (
+    SELECT id, status FROM orders
+    WHERE id = $1 AND status = ANY($2::text[])
+    FOR UPDATE
+), updated AS (
+    UPDATE orders o
+    SET status = $3::text, updated_at = NOW()
+    FROM prev
+    WHERE o.id = prev.id
+    RETURNING o.id, prev.status AS from_status, o.status AS to_status
+)
+INSERT INTO order_status_history (order_id, from_status, to_status, actor, actor_id, reason)
+SELECT id, from_status, to_status, $4::text, $5::text, $6::text
+FROM updated
+RETURNING from_status
+`
+
 type UpdateOrderStatusParams struct {
-	ID     pgtype.UUID `json:"id"`
-	Status string      `json:"status"`
+	ID           pgtype.UUID `json:"id"`
+	FromStatuses []string    `json:"from_statuses"`
+	Status       string      `json:"status"`
+	Actor        string      `json:"actor"`
+	ActorID      pgtype.Text `json:"actor_id"`
+	Reason       pgtype.Text `json:"reason"`
+}
+
+// Moves an order to a new status if it currently is in one of from_statuses
+// and records the change. Returns no rows when the transition is not allowed.
+func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (string, error) {
+	row := q.db.QueryRow(ctx, updateOrderStatus,
+		arg.ID,
+		arg.FromStatuses,
+		arg.Status,
+		arg.Actor,
+		arg.ActorID,
+		arg.Reason,
+	)
+	var from_status string
+	err := row.Scan(&from_status)
+	return from_status, err
 }
 
-func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) error {
-	_, err := q.db.Exec(ctx, updateOrderStatus, arg.ID, arg.Status)
+const updatePoolSequences = `-- name: UpdatePoolSequences :exec
+UPDATE orders o
+SET pool_trip_id     = $1::text,
+    pickup_sequence  = NULLIF(s.pickup_sequence, 0),
+    dropoff_sequence = NULLIF(s.dropoff_sequence, 0),
+    updated_at       = NOW()
+FROM unnest(
+    $2::uuid[],
+    $3::int[],
+    $4::int[]
+) AS s(order_id, pickup_sequence, dropoff_sequence)
+WHERE o.id = s.order_id
+`
+
+type UpdatePoolSequencesParams struct {
+	PoolTripID       string        `json:"pool_trip_id"`
+	OrderIds         []pgtype.UUID `json:"order_ids"`
+	PickupSequences  []int32       `json:"pickup_sequences"`
+	DropoffSequences []int32       `json:"dropoff_sequences"`
+}
+
+// Stores where every ride of a shared trip sits in the driver's stop sequence.
+// A sequence of 0 means the stop was already visited and is stored as NULL.
+func (q *Queries) UpdatePoolSequences(ctx context.Context, arg UpdatePoolSequencesParams) error {
+	_, err := q.db.Exec(ctx, updatePoolSequences,
+		arg.PoolTripID,
+		arg.OrderIds,
+		arg.PickupSequences,
+		arg.DropoffSequences,
+	)
 	return err
 }
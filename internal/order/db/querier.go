@@ -11,11 +11,98 @@ import (
 )
 
 type Querier interface {
+	// Records that the driver reached a stop. Returns no row when the stop was
+	// reached before, or an earlier stop was not reached yet.
+	ArriveAtRideStop(ctx context.Context, arg ArriveAtRideStopParams) (RideStop, error)
+	CancelOrder(ctx context.Context, arg CancelOrderParams) (CancelOrderRow, error)
+	// Marks scheduled rides whose reminder is due as reminded. SKIP LOCKED makes
+	// sure every reminder is claimed by exactly one replica.
+	ClaimDueReminders(ctx context.Context, arg ClaimDueRemindersParams) ([]Order, error)
+	// Claims scheduled rides that are due for a dispatch attempt: either the first
+	// one (pickup within the lead time) or a retry after the previous attempt found
+	// no driver.
+	ClaimDueScheduledOrders(ctx context.Context, arg ClaimDueScheduledOrdersParams) ([]Order, error)
+	// Locks scheduled rides whose last dispatch attempt found no driver either, so
+	// they can be given up on. SKIP LOCKED makes sure every ride is claimed by
+	// exactly one replica.
+	ClaimExhaustedScheduledOrders(ctx context.Context, arg ClaimExhaustedScheduledOrdersParams) ([]Order, error)
+	// Leases the oldest unsent event of every topic and key that is due. Later
+	// events of the same key wait until it is sent, so consumers see them in order.
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error)
+	// Claims finished rides whose payment is due to be retried. SKIP LOCKED makes
+	// sure every retry is claimed by exactly one replica.
+	ClaimPaymentRetries(ctx context.Context, arg ClaimPaymentRetriesParams) ([]Order, error)
+	// Counts a redemption against the campaign's global limit and locks the
+	// campaign until the transaction ends, so concurrent redemptions of the same
+	// campaign are counted one at a time. Returns no rows when it is used up.
+	ClaimPromoCampaign(ctx context.Context, id int64) (int32, error)
+	// Locks orders that have been waiting for a driver since before search_before:
+	// immediate rides from creation, scheduled ones from their pickup time. SKIP
+	// LOCKED makes sure every order is claimed by exactly one replica.
+	ClaimUnmatchedOrders(ctx context.Context, arg ClaimUnmatchedOrdersParams) ([]Order, error)
+	// Totals of the orders ListOrders pages through with the same filters.
+	CountOrders(ctx context.Context, arg CountOrdersParams) (CountOrdersRow, error)
+	CountUserPromoRedemptions(ctx context.Context, arg CountUserPromoRedemptionsParams) (int64, error)
 	// internal/order/db/query/order.sql
 	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error
+	CreatePassengerDebt(ctx context.Context, arg CreatePassengerDebtParams) error
+	CreatePromoRedemption(ctx context.Context, arg CreatePromoRedemptionParams) error
+	// Returns no rows when this side already rated the order.
+	CreateRating(ctx context.Context, arg CreateRatingParams) (Rating, error)
+	CreateReceipt(ctx context.Context, arg CreateReceiptParams) (Receipt, error)
+	// Stores the stops of a new order, numbered in the order they are given.
+	CreateRideStops(ctx context.Context, arg CreateRideStopsParams) error
+	DeleteSentOutboxEvents(ctx context.Context, sentBefore pgtype.Timestamptz) (int64, error)
 	GetOrder(ctx context.Context, id pgtype.UUID) (Order, error)
-	UpdateOrderDriver(ctx context.Context, arg UpdateOrderDriverParams) error
-	UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) error
+	// Sums what a passenger still owes for rides that could not be charged.
+	GetPassengerDebt(ctx context.Context, passengerID string) (float64, error)
+	GetPromoCampaignByCode(ctx context.Context, code string) (PromoCampaign, error)
+	GetReceipt(ctx context.Context, orderID pgtype.UUID) (Receipt, error)
+	GetUserRatings(ctx context.Context, arg GetUserRatingsParams) ([]UserRating, error)
+	// Lists the paid rides of a driver, newest first, by when they were paid.
+	ListDriverEarnings(ctx context.Context, arg ListDriverEarningsParams) ([]Order, error)
+	ListOrderStatusHistory(ctx context.Context, orderID pgtype.UUID) ([]OrderStatusHistory, error)
+	// Pages through the orders of a passenger or driver, newest first. A page
+	// starts after the (created_at, id) of the last order of the previous one.
+	ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error)
+	ListRideStops(ctx context.Context, orderID pgtype.UUID) ([]RideStop, error)
+	// Creates the user's rating row if needed and locks it until the transaction
+	// ends, so concurrent ratings of the same user refresh it one at a time.
+	LockUserRating(ctx context.Context, arg LockUserRatingParams) error
+	// Records that the fare of a ride was charged.
+	MarkOrderPaid(ctx context.Context, id pgtype.UUID) error
+	// Records a failed publish and postpones the next attempt.
+	MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error
+	MarkOutboxEventSent(ctx context.Context, id int64) error
+	// Takes the next receipt number of the year. The counter row stays locked
+	// until the transaction ends, so numbers are handed out in order.
+	NextReceiptNumber(ctx context.Context, year int32) (int64, error)
+	// Keeps what the tracker measured for a ride that is finishing, so a retried
+	// finish charges the same trip. The first measurement stays.
+	RecordOrderMeter(ctx context.Context, arg RecordOrderMeterParams) error
+	// Counts a failed attempt to charge a ride, and sets when to try again unless
+	// retry_at is NULL.
+	RecordPaymentFailure(ctx context.Context, arg RecordPaymentFailureParams) error
+	// Recounts the user's ratings and averages the most recent window_size of them.
+	RefreshUserRating(ctx context.Context, arg RefreshUserRatingParams) (UserRating, error)
+	// Gives back the promo an order used, if any.
+	ReversePromoRedemption(ctx context.Context, orderID pgtype.UUID) (int64, error)
+	// Records what the finished ride measured, what the passenger pays for it and
+	// what the driver earns.
+	SetOrderFinalFare(ctx context.Context, arg SetOrderFinalFareParams) (Order, error)
+	// Records the tip of a finished ride. Returns no rows when it already has one.
+	SetOrderTip(ctx context.Context, arg SetOrderTipParams) (Order, error)
+	SettlePassengerDebt(ctx context.Context, orderID pgtype.UUID) error
+	// Assigns the matched driver unless the order already moved past searching.
+	// Returns the status it was matched from, or no rows when it moved on.
+	UpdateOrderDriver(ctx context.Context, arg UpdateOrderDriverParams) (string, error)
+	// Moves an order to a new status if it currently is in one of from_statuses
+	// and records the change. Returns no rows when the transition is not allowed.
+	UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (string, error)
+	// Stores where every ride of a shared trip sits in the driver's stop sequence.
+	// A sequence of 0 means the stop was already visited and is stored as NULL.
+	UpdatePoolSequences(ctx context.Context, arg UpdatePoolSequencesParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -2,23 +2,441 @@
 
 -- name: CreateOrder :one
 INSERT INTO orders (
-    passenger_id, driver_id,
+    id, passenger_id, driver_id,
     pickup_lat, pickup_long, dropoff_lat, dropoff_long,
-    status, price
+    status, price, scheduled_at, vehicle_type, seats, tariff_version, surge_multiplier, quote_id,
+    promo_code, discount, payment_method
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
          ) RETURNING *;
 
 -- name: GetOrder :one
 SELECT * FROM orders
 WHERE id = $1 LIMIT 1;
 
--- name: UpdateOrderStatus :exec
+-- name: UpdateOrderStatus :one
+-- Moves an order to a new status if it currently is in one of from_statuses
+-- and records the change. Returns no rows when the transition is not allowed.
+WITH prev AS (
+    SELECT id, status FROM orders
+    WHERE id = sqlc.arg(id) AND status = ANY(sqlc.arg(from_statuses)::text[])
+    FOR UPDATE
+), updated AS (
+    UPDATE orders o
+    SET status = sqlc.arg(status)::text, updated_at = NOW()
+    FROM prev
+    WHERE o.id = prev.id
+    RETURNING o.id, prev.status AS from_status, o.status AS to_status
+)
+INSERT INTO order_status_history (order_id, from_status, to_status, actor, actor_id, reason)
+SELECT id, from_status, to_status, sqlc.arg(actor)::text, sqlc.narg(actor_id)::text, sqlc.narg(reason)::text
+FROM updated
+RETURNING from_status;
+
+-- name: UpdateOrderDriver :one
+-- Assigns the matched driver unless the order already moved past searching.
+-- Returns the status it was matched from, or no rows when it moved on.
+WITH prev AS (
+    SELECT id, status FROM orders
+    WHERE id = sqlc.arg(id) AND status = ANY(sqlc.arg(from_statuses)::text[])
+    FOR UPDATE
+), updated AS (
+    UPDATE orders o
+    SET driver_id = sqlc.arg(driver_id), status = 'MATCHED', matched_at = NOW(), updated_at = NOW()
+    FROM prev
+    WHERE o.id = prev.id
+    RETURNING o.id, prev.status AS from_status, o.status AS to_status, o.driver_id
+)
+INSERT INTO order_status_history (order_id, from_status, to_status, actor, actor_id)
+SELECT id, from_status, to_status, sqlc.arg(actor)::text, driver_id
+FROM updated
+RETURNING from_status;
+
+-- name: RecordOrderMeter :exec
+-- Keeps what the tracker measured for a ride that is finishing, so a retried
+-- finish charges the same trip. The first measurement stays.
 UPDATE orders
-SET status = $2, updated_at = NOW()
-WHERE id = $1;
+SET metered_distance_km  = sqlc.arg(metered_distance_km),
+    metered_duration_min = sqlc.arg(metered_duration_min),
+    updated_at           = NOW()
+WHERE id = sqlc.arg(id)
+  AND status = 'STARTED'
+  AND metered_distance_km IS NULL;
+
+-- name: SetOrderFinalFare :one
+-- Records what the finished ride measured, what the passenger pays for it and
+-- what the driver earns.
+UPDATE orders
+SET metered_distance_km  = sqlc.narg(metered_distance_km),
+    metered_duration_min = sqlc.narg(metered_duration_min),
+    metered_fare         = sqlc.narg(metered_fare),
+    final_price          = sqlc.arg(final_price),
+    fare_basis           = sqlc.arg(fare_basis),
+    commission_rate      = sqlc.narg(commission_rate),
+    commission           = sqlc.narg(commission),
+    driver_earnings      = sqlc.narg(driver_earnings),
+    finished_at          = NOW(),
+    updated_at           = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetOrderTip :one
+-- Records the tip of a finished ride. Returns no rows when it already has one.
+UPDATE orders
+SET tip        = sqlc.arg(tip),
+    tipped_at  = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND status IN ('FINISHED', 'PAID', 'PAYMENT_FAILED') AND tip IS NULL
+RETURNING *;
 
--- name: UpdateOrderDriver :exec
+-- name: MarkOrderPaid :exec
+-- Records that the fare of a ride was charged.
 UPDATE orders
-SET driver_id = $2, status = 'MATCHED', updated_at = NOW()
-WHERE id = $1;
\ No newline at end of file
+SET paid_at          = NOW(),
+    payment_retry_at = NULL,
+    payment_error    = NULL,
+    updated_at       = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: RecordPaymentFailure :exec
+-- Counts a failed attempt to charge a ride, and sets when to try again unless
+-- retry_at is NULL.
+UPDATE orders
+SET payment_attempts = payment_attempts + 1,
+    payment_retry_at = sqlc.narg(retry_at),
+    payment_error    = sqlc.arg(payment_error)::text,
+    updated_at       = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: ClaimPaymentRetries :many
+-- Claims finished rides whose payment is due to be retried. SKIP LOCKED makes
+-- sure every retry is claimed by exactly one replica.
+UPDATE orders
+SET payment_retry_at = NULL, updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM orders
+    WHERE status = 'FINISHED'
+      AND payment_retry_at <= sqlc.arg(due_before)::timestamptz
+    ORDER BY payment_retry_at
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ClaimUnmatchedOrders :many
+-- Locks orders that have been waiting for a driver since before search_before:
+-- immediate rides from creation, scheduled ones from their pickup time. SKIP
+-- LOCKED makes sure every order is claimed by exactly one replica.
+SELECT * FROM orders
+WHERE status IN ('CREATED', 'SEARCHING')
+  AND COALESCE(scheduled_at, created_at) < sqlc.arg(search_before)::timestamptz
+ORDER BY COALESCE(scheduled_at, created_at)
+LIMIT sqlc.arg(batch_size)::int
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreatePassengerDebt :exec
+INSERT INTO passenger_debts (order_id, passenger_id, amount, reason)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (order_id) DO NOTHING;
+
+-- name: SettlePassengerDebt :exec
+UPDATE passenger_debts
+SET settled_at = NOW()
+WHERE order_id = $1 AND settled_at IS NULL;
+
+-- name: GetPassengerDebt :one
+-- Sums what a passenger still owes for rides that could not be charged.
+SELECT COALESCE(SUM(amount), 0)::float8 AS debt
+FROM passenger_debts
+WHERE passenger_id = $1 AND settled_at IS NULL;
+
+-- name: CreateRideStops :exec
+-- Stores the stops of a new order, numbered in the order they are given.
+INSERT INTO ride_stops (order_id, sequence, lat, long)
+SELECT sqlc.arg(order_id)::uuid, s.sequence::int, s.lat, s.long
+FROM unnest(
+    sqlc.arg(lats)::float8[],
+    sqlc.arg(longs)::float8[]
+) WITH ORDINALITY AS s(lat, long, sequence);
+
+-- name: ListRideStops :many
+SELECT * FROM ride_stops
+WHERE order_id = $1
+ORDER BY sequence;
+
+-- name: ArriveAtRideStop :one
+-- Records that the driver reached a stop. Returns no row when the stop was
+-- reached before, or an earlier stop was not reached yet.
+UPDATE ride_stops s
+SET arrived_at = NOW()
+WHERE s.order_id = $1
+  AND s.sequence = $2
+  AND s.arrived_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM ride_stops earlier
+    WHERE earlier.order_id = s.order_id
+      AND earlier.sequence < s.sequence
+      AND earlier.arrived_at IS NULL
+)
+RETURNING *;
+
+-- name: UpdatePoolSequences :exec
+-- Stores where every ride of a shared trip sits in the driver's stop sequence.
+-- A sequence of 0 means the stop was already visited and is stored as NULL.
+UPDATE orders o
+SET pool_trip_id     = sqlc.arg(pool_trip_id)::text,
+    pickup_sequence  = NULLIF(s.pickup_sequence, 0),
+    dropoff_sequence = NULLIF(s.dropoff_sequence, 0),
+    updated_at       = NOW()
+FROM unnest(
+    sqlc.arg(order_ids)::uuid[],
+    sqlc.arg(pickup_sequences)::int[],
+    sqlc.arg(dropoff_sequences)::int[]
+) AS s(order_id, pickup_sequence, dropoff_sequence)
+WHERE o.id = s.order_id;
+
+-- name: CancelOrder :one
+WITH prev AS (
+    SELECT id, status FROM orders
+    WHERE id = sqlc.arg(id) AND status = ANY(sqlc.arg(from_statuses)::text[])
+    FOR UPDATE
+), updated AS (
+    UPDATE orders o
+    SET status           = 'CANCELLED',
+        cancelled_by     = sqlc.arg(cancelled_by),
+        cancel_reason    = sqlc.arg(cancel_reason),
+        cancellation_fee = sqlc.arg(cancellation_fee),
+        cancelled_at     = NOW(),
+        updated_at       = NOW()
+    FROM prev
+    WHERE o.id = prev.id
+    RETURNING o.id, o.passenger_id, o.driver_id, o.status, o.cancelled_by, o.cancel_reason, o.cancelled_at,
+              prev.status AS from_status
+), history AS (
+    INSERT INTO order_status_history (order_id, from_status, to_status, actor, actor_id, reason)
+    SELECT id, from_status, status, cancelled_by, sqlc.narg(actor_id)::text, cancel_reason
+    FROM updated
+)
+SELECT id, passenger_id, driver_id, status, cancelled_at, from_status FROM updated;
+
+-- name: ListOrders :many
+-- Pages through the orders of a passenger or driver, newest first. A page
+-- starts after the (created_at, id) of the last order of the previous one.
+SELECT * FROM orders
+WHERE (sqlc.narg(passenger_id)::text IS NULL OR passenger_id = sqlc.narg(passenger_id)::text)
+  AND (sqlc.narg(driver_id)::text IS NULL OR driver_id = sqlc.narg(driver_id)::text)
+  AND (cardinality(sqlc.arg(statuses)::text[]) = 0 OR status = ANY(sqlc.arg(statuses)::text[]))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from)::timestamptz)
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to)::timestamptz)
+  AND (sqlc.narg(after_created_at)::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size)::int;
+
+-- name: CountOrders :one
+-- Totals of the orders ListOrders pages through with the same filters.
+SELECT COUNT(*) AS total_count,
+       COALESCE(SUM(COALESCE(final_price, price)) FILTER (WHERE status IN ('FINISHED', 'PAID', 'PAYMENT_FAILED')), 0)::float8 AS total_amount,
+       COALESCE(SUM(tip), 0)::float8 AS total_tips
+FROM orders
+WHERE (sqlc.narg(passenger_id)::text IS NULL OR passenger_id = sqlc.narg(passenger_id)::text)
+  AND (sqlc.narg(driver_id)::text IS NULL OR driver_id = sqlc.narg(driver_id)::text)
+  AND (cardinality(sqlc.arg(statuses)::text[]) = 0 OR status = ANY(sqlc.arg(statuses)::text[]))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from)::timestamptz)
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to)::timestamptz);
+
+-- name: ListOrderStatusHistory :many
+SELECT * FROM order_status_history
+WHERE order_id = $1
+ORDER BY created_at, id;
+
+-- name: ClaimDueReminders :many
+-- Marks scheduled rides whose reminder is due as reminded. SKIP LOCKED makes
+-- sure every reminder is claimed by exactly one replica.
+UPDATE orders
+SET reminder_sent_at = NOW(), updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM orders
+    WHERE status = 'SCHEDULED'
+      AND reminder_sent_at IS NULL
+      AND scheduled_at <= sqlc.arg(remind_before)::timestamptz
+    ORDER BY scheduled_at
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ClaimDueScheduledOrders :many
+-- Claims scheduled rides that are due for a dispatch attempt: either the first
+-- one (pickup within the lead time) or a retry after the previous attempt found
+-- no driver.
+UPDATE orders
+SET dispatch_attempts = dispatch_attempts + 1,
+    last_dispatch_at  = NOW(),
+    updated_at        = NOW()
+WHERE id IN (
+    SELECT id FROM orders
+    WHERE scheduled_at IS NOT NULL
+      AND status IN ('SCHEDULED', 'SEARCHING')
+      AND dispatch_attempts < sqlc.arg(max_attempts)::int
+      AND scheduled_at <= sqlc.arg(dispatch_before)::timestamptz
+      AND (last_dispatch_at IS NULL OR last_dispatch_at <= sqlc.arg(retry_before)::timestamptz)
+    ORDER BY scheduled_at
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ClaimExhaustedScheduledOrders :many
+-- Locks scheduled rides whose last dispatch attempt found no driver either, so
+-- they can be given up on. SKIP LOCKED makes sure every ride is claimed by
+-- exactly one replica.
+SELECT * FROM orders
+WHERE scheduled_at IS NOT NULL
+  AND status IN ('SCHEDULED', 'SEARCHING')
+  AND dispatch_attempts >= sqlc.arg(max_attempts)::int
+  AND last_dispatch_at <= sqlc.arg(retry_before)::timestamptz
+ORDER BY scheduled_at
+LIMIT sqlc.arg(batch_size)::int
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox (topic, message_key, payload)
+VALUES ($1, $2, $3);
+
+-- name: ClaimOutboxEvents :many
+-- Leases the oldest unsent event of every topic and key that is due. Later
+-- events of the same key wait until it is sent, so consumers see them in order.
+UPDATE outbox
+SET next_attempt_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id IN (
+    SELECT o.id FROM outbox o
+    WHERE o.sent_at IS NULL
+      AND o.next_attempt_at <= NOW()
+      AND NOT EXISTS (
+          SELECT 1 FROM outbox e
+          WHERE e.topic = o.topic
+            AND e.message_key = o.message_key
+            AND e.sent_at IS NULL
+            AND e.id < o.id
+      )
+    ORDER BY o.id
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventSent :exec
+UPDATE outbox
+SET sent_at = NOW()
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+-- Records a failed publish and postpones the next attempt.
+UPDATE outbox
+SET attempts        = attempts + 1,
+    last_error      = sqlc.arg(last_error)::text,
+    next_attempt_at = NOW() + make_interval(secs => sqlc.arg(retry_in_seconds)::int)
+WHERE id = sqlc.arg(id);
+
+-- name: DeleteSentOutboxEvents :execrows
+DELETE FROM outbox
+WHERE sent_at < sqlc.arg(sent_before)::timestamptz;
+
+-- name: GetPromoCampaignByCode :one
+SELECT * FROM promo_campaigns
+WHERE code = $1 LIMIT 1;
+
+-- name: CountUserPromoRedemptions :one
+SELECT COUNT(*) FROM promo_redemptions
+WHERE campaign_id = $1 AND user_id = $2 AND status = 'APPLIED';
+
+-- name: ClaimPromoCampaign :one
+-- Counts a redemption against the campaign's global limit and locks the
+-- campaign until the transaction ends, so concurrent redemptions of the same
+-- campaign are counted one at a time. Returns no rows when it is used up.
+UPDATE promo_campaigns
+SET redemptions = redemptions + 1
+WHERE id = $1
+  AND (max_redemptions IS NULL OR redemptions < max_redemptions)
+RETURNING redemptions;
+
+-- name: CreatePromoRedemption :exec
+INSERT INTO promo_redemptions (campaign_id, user_id, order_id, discount)
+VALUES ($1, $2, $3, $4);
+
+-- name: ReversePromoRedemption :execrows
+-- Gives back the promo an order used, if any.
+WITH reversed AS (
+    UPDATE promo_redemptions
+    SET status = 'REVERSED', reversed_at = NOW()
+    WHERE order_id = $1 AND status = 'APPLIED'
+    RETURNING campaign_id
+)
+UPDATE promo_campaigns
+SET redemptions = redemptions - 1
+WHERE id IN (SELECT campaign_id FROM reversed);
+
+-- name: CreateRating :one
+-- Returns no rows when this side already rated the order.
+INSERT INTO ratings (order_id, rater_id, ratee_id, ratee_role, stars, tags, comment)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (order_id, ratee_role) DO NOTHING
+RETURNING *;
+
+-- name: LockUserRating :exec
+-- Creates the user's rating row if needed and locks it until the transaction
+-- ends, so concurrent ratings of the same user refresh it one at a time.
+INSERT INTO user_ratings (user_id, role)
+VALUES ($1, $2)
+ON CONFLICT (user_id, role) DO UPDATE SET updated_at = NOW();
+
+-- name: RefreshUserRating :one
+-- Recounts the user's ratings and averages the most recent window_size of them.
+UPDATE user_ratings
+SET rating_count = (
+        SELECT COUNT(*) FROM ratings
+        WHERE ratee_id = sqlc.arg(user_id) AND ratee_role = sqlc.arg(role)
+    ),
+    average = COALESCE((
+        SELECT AVG(recent.stars) FROM (
+            SELECT stars FROM ratings
+            WHERE ratee_id = sqlc.arg(user_id) AND ratee_role = sqlc.arg(role)
+            ORDER BY created_at DESC, id DESC
+            LIMIT sqlc.arg(window_size)::int
+        ) recent
+    ), 0)::float8,
+    updated_at = NOW()
+WHERE user_id = sqlc.arg(user_id) AND role = sqlc.arg(role)
+RETURNING *;
+
+-- name: GetUserRatings :many
+SELECT * FROM user_ratings
+WHERE user_id = ANY(sqlc.arg(user_ids)::text[]) AND role = sqlc.arg(role)
+ORDER BY user_id;
+
+-- name: NextReceiptNumber :one
+-- Takes the next receipt number of the year. The counter row stays locked
+-- until the transaction ends, so numbers are handed out in order.
+INSERT INTO receipt_counters (year, last_number)
+VALUES ($1, 1)
+ON CONFLICT (year) DO UPDATE SET last_number = receipt_counters.last_number + 1
+RETURNING last_number;
+
+-- name: CreateReceipt :one
+INSERT INTO receipts (order_id, receipt_number, passenger_id, document, issued_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetReceipt :one
+SELECT * FROM receipts
+WHERE order_id = $1;
+
+-- name: ListDriverEarnings :many
+-- Lists the paid rides of a driver, newest first, by when they were paid.
+SELECT * FROM orders
+WHERE driver_id = sqlc.arg(driver_id)
+  AND paid_at >= sqlc.arg(paid_from)
+  AND paid_at < sqlc.arg(paid_to)
+  AND driver_earnings IS NOT NULL
+ORDER BY paid_at DESC, id DESC;
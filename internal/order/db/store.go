@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store runs queries on their own or grouped in a single transaction.
+type Store interface {
+	Querier
+	// ExecTx runs fn in a transaction that is committed when fn returns nil
+	// and rolled back otherwise.
+	ExecTx(ctx context.Context, fn func(Querier) error) error
+}
+
+type SQLStore struct {
+	*Queries
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *SQLStore {
+	return &SQLStore{
+		Queries: New(pool),
+		pool:    pool,
+	}
+}
+
+func (s *SQLStore) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(s.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			log.Println("failed to rollback transaction:", rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,177 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// MetadataKey is the gRPC metadata a client sends its idempotency key in. The
+// gateway fills it from the Idempotency-Key header.
+const MetadataKey = "idempotency-key"
+
+// ReasonKeyReused is the ErrorInfo reason on the error of a request whose key
+// was already used for a different request. Check for it with KeyReused.
+const ReasonKeyReused = "IDEMPOTENCY_KEY_REUSED"
+
+const (
+	maxKeyLength = 128
+
+	// pendingTTL bounds how long a key stays reserved by a request still
+	// being handled, so a replica dying mid-request doesn't block the key
+	// until it expires.
+	pendingTTL = time.Minute
+)
+
+// Keeper makes retries of registered gRPC methods safe. The first request
+// with a key is handled and its response kept; a retry with the same key and
+// request gets that response back instead of being handled again.
+type Keeper struct {
+	store   Store
+	ttl     time.Duration
+	methods map[string]func() proto.Message
+}
+
+// New returns a Keeper remembering responses for ttl.
+func New(store Store, ttl time.Duration) *Keeper {
+	return &Keeper{
+		store:   store,
+		ttl:     ttl,
+		methods: make(map[string]func() proto.Message),
+	}
+}
+
+// Register makes a method, e.g. "/order.OrderService/CreateOrder", honor
+// idempotency keys. newResponse returns an empty response of the method to
+// decode kept responses into. Register all methods before serving.
+func (k *Keeper) Register(method string, newResponse func() proto.Message) {
+	k.methods[method] = newResponse
+}
+
+// UnaryServerInterceptor applies the Keeper to requests of registered methods
+// that carry a key. Everything else is handled as usual.
+func (k *Keeper) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		newResponse, ok := k.methods[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+		key := keyFrom(ctx)
+		if key == "" {
+			return handler(ctx, req)
+		}
+		if len(key) > maxKeyLength {
+			return nil, status.Errorf(codes.InvalidArgument, "idempotency key must be at most %d characters", maxKeyLength)
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		fingerprint, err := fingerprintOf(msg)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to fingerprint request: %v", err)
+		}
+
+		// Keys are per method, so one key can't replay another method's response.
+		storeKey := info.FullMethod + ":" + key
+		rec, fresh, err := k.store.Begin(ctx, storeKey, fingerprint, pendingTTL)
+		if err != nil {
+			// Failing every order while Redis is down is worse than the odd
+			// duplicate, so the request goes through unprotected.
+			log.Printf("⚠️ Idempotency unavailable for %s: %v", info.FullMethod, err)
+			return handler(ctx, req)
+		}
+		if !fresh {
+			return replay(rec, fingerprint, newResponse)
+		}
+
+		// The client may have given up already, which is exactly when it
+		// retries, so the outcome is kept regardless of its context.
+		keepCtx := context.WithoutCancel(ctx)
+
+		res, err := handler(ctx, req)
+		if err != nil {
+			if relErr := k.store.Release(keepCtx, storeKey); relErr != nil {
+				log.Printf("⚠️ Failed to release idempotency key %s: %v", storeKey, relErr)
+			}
+			return nil, err
+		}
+
+		payload, err := proto.Marshal(res.(proto.Message))
+		if err == nil {
+			err = k.store.Complete(keepCtx, storeKey, Record{Fingerprint: fingerprint, Done: true, Response: payload}, k.ttl)
+		}
+		if err != nil {
+			log.Printf("⚠️ Failed to keep response for idempotency key %s: %v", storeKey, err)
+		}
+		return res, nil
+	}
+}
+
+// replay answers a request whose key was used before.
+func replay(rec Record, fingerprint string, newResponse func() proto.Message) (any, error) {
+	if rec.Fingerprint != fingerprint {
+		return nil, errKeyReused()
+	}
+	if !rec.Done {
+		return nil, status.Error(codes.Aborted, "a request with this idempotency key is still in progress")
+	}
+
+	res := newResponse()
+	if err := proto.Unmarshal(rec.Response, res); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode kept response: %v", err)
+	}
+	return res, nil
+}
+
+func errKeyReused() error {
+	st := status.New(codes.FailedPrecondition, "idempotency key was already used for a different request")
+	withInfo, err := st.WithDetails(&errdetails.ErrorInfo{Reason: ReasonKeyReused, Domain: MetadataKey})
+	if err != nil {
+		return st.Err()
+	}
+	return withInfo.Err()
+}
+
+// KeyReused reports whether err rejected a request for reusing the key of a
+// different request, as opposed to any other failure.
+func KeyReused(err error) bool {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Reason == ReasonKeyReused {
+			return true
+		}
+	}
+	return false
+}
+
+func keyFrom(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(MetadataKey); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// fingerprintOf hashes a request, so a key reused for another payload is told
+// apart from a retry.
+func fingerprintOf(msg proto.Message) (string, error) {
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,128 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const createOrder = "/order.OrderService/CreateOrder"
+
+func newKeeper(store Store) *Keeper {
+	keeper := New(store, 24*time.Hour)
+	keeper.Register(createOrder, func() proto.Message { return &order.CreateOrderResponse{} })
+	return keeper
+}
+
+func withKey(key string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, key))
+}
+
+// countingHandler creates a new order on every call.
+func countingHandler(calls *int) grpc.UnaryHandler {
+	return func(ctx context.Context, req any) (any, error) {
+		*calls++
+		return &order.CreateOrderResponse{OrderId: fmt.Sprintf("order-%d", *calls), Status: order.OrderStatus_SEARCHING}, nil
+	}
+}
+
+func TestKeeper(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: createOrder}
+	req := &order.CreateOrderRequest{UserId: "passenger-1", PickupLat: -6.2, PickupLong: 106.8}
+
+	t.Run("Retry Gets Original Response", func(t *testing.T) {
+		interceptor := newKeeper(NewMemoryStore()).UnaryServerInterceptor()
+		calls := 0
+
+		first, err := interceptor(withKey("key-1"), req, info, countingHandler(&calls))
+		assert.NoError(t, err)
+		retry, err := interceptor(withKey("key-1"), proto.Clone(req), info, countingHandler(&calls))
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, calls)
+		assert.True(t, proto.Equal(first.(proto.Message), retry.(proto.Message)))
+	})
+
+	t.Run("Key Reused For Another Request", func(t *testing.T) {
+		interceptor := newKeeper(NewMemoryStore()).UnaryServerInterceptor()
+		calls := 0
+
+		_, err := interceptor(withKey("key-1"), req, info, countingHandler(&calls))
+		assert.NoError(t, err)
+
+		other := proto.Clone(req).(*order.CreateOrderRequest)
+		other.DropoffLat = -6.3
+		_, err = interceptor(withKey("key-1"), other, info, countingHandler(&calls))
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.True(t, KeyReused(err))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Request In Progress", func(t *testing.T) {
+		store := NewMemoryStore()
+		interceptor := newKeeper(store).UnaryServerInterceptor()
+		fingerprint, err := fingerprintOf(req)
+		assert.NoError(t, err)
+		_, _, err = store.Begin(context.Background(), createOrder+":key-1", fingerprint, time.Minute)
+		assert.NoError(t, err)
+		calls := 0
+
+		_, err = interceptor(withKey("key-1"), req, info, countingHandler(&calls))
+
+		assert.Equal(t, codes.Aborted, status.Code(err))
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("Failure Can Be Retried", func(t *testing.T) {
+		interceptor := newKeeper(NewMemoryStore()).UnaryServerInterceptor()
+		failing := func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.Unavailable, "wallet is down")
+		}
+		calls := 0
+
+		_, err := interceptor(withKey("key-1"), req, info, failing)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		_, err = interceptor(withKey("key-1"), req, info, countingHandler(&calls))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Without Key", func(t *testing.T) {
+		interceptor := newKeeper(NewMemoryStore()).UnaryServerInterceptor()
+		calls := 0
+
+		for range 2 {
+			_, err := interceptor(context.Background(), req, info, countingHandler(&calls))
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Store Unavailable", func(t *testing.T) {
+		interceptor := newKeeper(failingStore{}).UnaryServerInterceptor()
+		calls := 0
+
+		_, err := interceptor(withKey("key-1"), req, info, countingHandler(&calls))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+type failingStore struct{ Store }
+
+func (failingStore) Begin(ctx context.Context, key string, fingerprint string, ttl time.Duration) (Record, bool, error) {
+	return Record{}, false, errors.New("connection refused")
+}
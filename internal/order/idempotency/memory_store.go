@@ -0,0 +1,43 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore mirrors RedisStore for tests. Keys do not expire from it.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func NewMemoryStore() Store {
+	return &MemoryStore{
+		records: make(map[string]Record),
+	}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key string, fingerprint string, ttl time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[key]; ok {
+		return rec, false, nil
+	}
+	s.records[key] = Record{Fingerprint: fingerprint}
+	return Record{}, true, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = rec
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
@@ -0,0 +1,69 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "atlas:idempotency:" // STRING Record JSON, per method and key, expires
+
+// beginScript stores a record unless the key exists and otherwise returns
+// the stored one, so checking and reserving a key is one step.
+//
+// KEYS[1] = idempotency key
+// ARGV[1] = Record JSON, ARGV[2] = TTL in milliseconds
+var beginScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return false
+end
+return redis.call('GET', KEYS[1])
+`)
+
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &RedisStore{
+		client: client,
+	}
+}
+
+func (r *RedisStore) Begin(ctx context.Context, key string, fingerprint string, ttl time.Duration) (Record, bool, error) {
+	payload, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	stored, err := beginScript.Run(ctx, r.client, []string{keyPrefix + key}, payload, ttl.Milliseconds()).Text()
+	if errors.Is(err, redis.Nil) {
+		return Record{}, true, nil
+	}
+	if err != nil {
+		log.Printf("redis idempotency begin failed: %v", err)
+		return Record{}, false, err
+	}
+
+	var rec Record
+	if err = json.Unmarshal([]byte(stored), &rec); err != nil {
+		return Record{}, false, err
+	}
+	return rec, false, nil
+}
+
+func (r *RedisStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, keyPrefix+key, payload, ttl).Err()
+}
+
+func (r *RedisStore) Release(ctx context.Context, key string) error {
+	return r.client.Del(ctx, keyPrefix+key).Err()
+}
@@ -0,0 +1,28 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Record is what is kept under an idempotency key.
+type Record struct {
+	// Fingerprint identifies the request that first used the key.
+	Fingerprint string `json:"fingerprint"`
+	// Done is set once that request was handled, with its encoded Response.
+	Done     bool   `json:"done,omitempty"`
+	Response []byte `json:"response,omitempty"`
+}
+
+type Store interface {
+	// Begin reserves a free key for ttl with a record holding only the
+	// fingerprint and returns true. When the key is taken it returns the
+	// record stored under it instead.
+	Begin(ctx context.Context, key string, fingerprint string, ttl time.Duration) (Record, bool, error)
+
+	// Complete stores the response of the request holding the key for ttl.
+	Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error
+
+	// Release frees a key whose request failed, so it can be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,85 @@
+package quote
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore mirrors RedisStore for tests. Quotes do not expire from it.
+type MemoryStore struct {
+	mu      sync.Mutex
+	quotes  map[string]Quote
+	claimed map[string]bool
+	stats   map[string]Stats
+}
+
+func NewMemoryStore() Store {
+	return &MemoryStore{
+		quotes:  make(map[string]Quote),
+		claimed: make(map[string]bool),
+		stats:   make(map[string]Stats),
+	}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, q Quote, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes[q.ID] = q
+	day := statsKey(time.Unix(q.CreatedAt, 0))
+	stats := s.stats[day]
+	stats.Issued++
+	s.stats[day] = stats
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Quote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, ok := s.quotes[id]
+	if !ok {
+		return nil, nil
+	}
+	return &q, nil
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claimed[id] {
+		return false, nil
+	}
+	s.claimed[id] = true
+	return true, nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claimed, id)
+	return nil
+}
+
+func (s *MemoryStore) RecordConverted(ctx context.Context, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats[statsKey(at)]
+	stats.Converted++
+	s.stats[statsKey(at)] = stats
+	return nil
+}
+
+func (s *MemoryStore) RecordExpired(ctx context.Context, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats[statsKey(at)]
+	stats.Expired++
+	s.stats[statsKey(at)] = stats
+	return nil
+}
+
+func (s *MemoryStore) Stats(ctx context.Context, at time.Time) (Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats[statsKey(at)], nil
+}
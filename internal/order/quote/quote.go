@@ -0,0 +1,220 @@
+package quote
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("quote not found")
+	ErrExpired  = errors.New("quote expired")
+	// ErrInvalid is returned for a quote that fails its signature check or
+	// was issued for another trip.
+	ErrInvalid = errors.New("quote is not valid for this trip")
+	ErrUsed    = errors.New("quote already used")
+)
+
+// Trip is what a quote prices.
+type Trip struct {
+	UserID      string  `json:"user_id"`
+	VehicleType string  `json:"vehicle_type"`
+	Seats       int32   `json:"seats"`
+	PickupLat   float64 `json:"pickup_lat"`
+	PickupLong  float64 `json:"pickup_long"`
+	DropoffLat  float64 `json:"dropoff_lat"`
+	DropoffLong float64 `json:"dropoff_long"`
+	Stops       []Stop  `json:"stops,omitempty"`
+}
+
+// Stop is a place the trip visits between pickup and drop-off.
+type Stop struct {
+	Lat  float64 `json:"lat"`
+	Long float64 `json:"long"`
+}
+
+// coordinateTolerance is how far (in degrees, about 11 m) the order's pickup
+// drop-off and stops may be from the quoted ones, to absorb GPS jitter in the app.
+const coordinateTolerance = 0.0001
+
+// Matches reports whether an order is for the trip that was quoted.
+func (t Trip) Matches(other Trip) bool {
+	near := func(a, b float64) bool { return math.Abs(a-b) <= coordinateTolerance }
+	if len(t.Stops) != len(other.Stops) {
+		return false
+	}
+	for i, stop := range t.Stops {
+		if !near(stop.Lat, other.Stops[i].Lat) || !near(stop.Long, other.Stops[i].Long) {
+			return false
+		}
+	}
+	return t.UserID == other.UserID &&
+		t.VehicleType == other.VehicleType &&
+		t.Seats == other.Seats &&
+		near(t.PickupLat, other.PickupLat) && near(t.PickupLong, other.PickupLong) &&
+		near(t.DropoffLat, other.DropoffLat) && near(t.DropoffLong, other.DropoffLong)
+}
+
+// Quote is a fare offered to a passenger before they order. An order placed
+// with the quote's ID pays exactly Price as long as the quote has not expired.
+type Quote struct {
+	ID string `json:"id"`
+	Trip
+
+	Price           float64   `json:"price"`
+	Breakdown       Breakdown `json:"breakdown"`
+	TariffVersion   string    `json:"tariff_version"`
+	City            string    `json:"city"`
+	SurgeMultiplier float64   `json:"surge_multiplier"`
+	DistanceKm      float64   `json:"distance_km"`
+	DurationMin     float64   `json:"duration_min"`
+	CreatedAt       int64     `json:"created_at"`
+	ExpiresAt       int64     `json:"expires_at"`
+
+	// Signature covers every other field. Quotes are looked up by ID, so it
+	// does not stand in for the stored copy: Redeem checks it against the
+	// stored fields, so a quote altered or planted in Redis (which other
+	// services share) without the key is rejected.
+	Signature string `json:"signature"`
+}
+
+// Breakdown explains how Price was reached, in IDR.
+type Breakdown struct {
+	BaseFare       float64 `json:"base_fare"`
+	DistanceFare   float64 `json:"distance_fare"`
+	TimeFare       float64 `json:"time_fare"`
+	Rule           string  `json:"rule,omitempty"`
+	RuleMultiplier float64 `json:"rule_multiplier"`
+	MinimumFare    float64 `json:"minimum_fare,omitempty"`
+	BookingFee     float64 `json:"booking_fee"`
+	PoolAdjustment float64 `json:"pool_adjustment,omitempty"`
+}
+
+// Expired reports whether the quote can no longer be ordered at now.
+func (q Quote) Expired(now time.Time) bool {
+	return !now.Before(time.Unix(q.ExpiresAt, 0))
+}
+
+// Issuer hands out signed quotes and redeems them, once each, for orders.
+type Issuer struct {
+	store    Store
+	key      []byte
+	validity time.Duration
+}
+
+// NewIssuer signs quotes with key. Quotes can be ordered for validity after
+// they are issued; they are kept a while longer so a late order is told the
+// quote expired rather than that it never existed.
+func NewIssuer(store Store, key []byte, validity time.Duration) *Issuer {
+	return &Issuer{
+		store:    store,
+		key:      key,
+		validity: validity,
+	}
+}
+
+// Issue assigns the quote an ID and expiry, signs and stores it.
+func (i *Issuer) Issue(ctx context.Context, q Quote, now time.Time) (Quote, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Quote{}, err
+	}
+	q.ID = hex.EncodeToString(id)
+	q.CreatedAt = now.Unix()
+	q.ExpiresAt = now.Add(i.validity).Unix()
+	q.Signature = i.sign(q)
+
+	if err := i.store.Save(ctx, q, 2*i.validity); err != nil {
+		return Quote{}, err
+	}
+	return q, nil
+}
+
+// Redeem returns the quote for an order of trip and reserves it, so a quote
+// pays for one order only. The stored quote must carry a valid signature and
+// its signed trip must match the order's. Call Release if the order is not created after all.
+func (i *Issuer) Redeem(ctx context.Context, id string, trip Trip, now time.Time) (Quote, error) {
+	q, err := i.store.Get(ctx, id)
+	if err != nil {
+		return Quote{}, err
+	}
+	if q == nil {
+		return Quote{}, ErrNotFound
+	}
+	if !hmac.Equal([]byte(q.Signature), []byte(i.sign(*q))) {
+		log.Printf("🚫 Quote %s failed its signature check", id)
+		return Quote{}, ErrInvalid
+	}
+	if !q.Trip.Matches(trip) {
+		return Quote{}, ErrInvalid
+	}
+	if q.Expired(now) {
+		if err = i.store.RecordExpired(ctx, now); err != nil {
+			log.Printf("⚠️ Failed to count expired quote %s: %v", id, err)
+		}
+		return Quote{}, ErrExpired
+	}
+
+	ok, err := i.store.Claim(ctx, id, 2*i.validity)
+	if err != nil {
+		return Quote{}, err
+	}
+	if !ok {
+		return Quote{}, ErrUsed
+	}
+	return *q, nil
+}
+
+// Release frees a redeemed quote whose order failed.
+func (i *Issuer) Release(ctx context.Context, id string) {
+	if err := i.store.Release(ctx, id); err != nil {
+		log.Printf("⚠️ Failed to release quote %s: %v", id, err)
+	}
+}
+
+// Converted counts a redeemed quote whose order was created.
+func (i *Issuer) Converted(ctx context.Context, now time.Time) {
+	if err := i.store.RecordConverted(ctx, now); err != nil {
+		log.Printf("⚠️ Failed to count converted quote: %v", err)
+	}
+}
+
+// Stats returns the quote counts of the day containing at.
+func (i *Issuer) Stats(ctx context.Context, at time.Time) (Stats, error) {
+	return i.store.Stats(ctx, at)
+}
+
+func (i *Issuer) sign(q Quote) string {
+	mac := hmac.New(sha256.New, i.key)
+	mac.Write([]byte(canonical(q)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// canonical is the signed form of a quote. Fields are listed explicitly, so
+// a field added to Quote is not signed until it is added here.
+func canonical(q Quote) string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	b := q.Breakdown
+	fields := []string{
+		q.ID, q.UserID, q.VehicleType, fmt.Sprint(q.Seats),
+		f(q.PickupLat), f(q.PickupLong), f(q.DropoffLat), f(q.DropoffLong),
+		f(q.Price), q.TariffVersion, q.City, f(q.SurgeMultiplier), f(q.DistanceKm), f(q.DurationMin),
+		fmt.Sprint(q.CreatedAt), fmt.Sprint(q.ExpiresAt),
+		f(b.BaseFare), f(b.DistanceFare), f(b.TimeFare), b.Rule, f(b.RuleMultiplier),
+		f(b.MinimumFare), f(b.BookingFee), f(b.PoolAdjustment),
+	}
+	// Stops come last, so quotes without any sign as they always did.
+	for _, stop := range q.Stops {
+		fields = append(fields, f(stop.Lat), f(stop.Long))
+	}
+	return strings.Join(fields, "|")
+}
@@ -0,0 +1,158 @@
+package quote
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTrip = Trip{
+	UserID:      "passenger-1",
+	VehicleType: "go-ride",
+	Seats:       1,
+	PickupLat:   -6.1754,
+	PickupLong:  106.8272,
+	DropoffLat:  -6.1950,
+	DropoffLong: 106.8230,
+}
+
+func issue(t *testing.T, issuer *Issuer, now time.Time) Quote {
+	q, err := issuer.Issue(context.Background(), Quote{
+		Trip:          testTrip,
+		Price:         18000,
+		TariffVersion: "2026-10-01",
+		Breakdown:     Breakdown{BaseFare: 5000, DistanceFare: 8000, TimeFare: 3000, RuleMultiplier: 1, BookingFee: 2000},
+	}, now)
+	assert.NoError(t, err)
+	return q
+}
+
+func TestIssuer_Redeem(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		issuer := NewIssuer(NewMemoryStore(), []byte("key"), 2*time.Minute)
+		issued := issue(t, issuer, now)
+		assert.Len(t, issued.ID, 32)
+		assert.Equal(t, now.Add(2*time.Minute).Unix(), issued.ExpiresAt)
+
+		// The app may report a slightly different GPS fix when ordering.
+		trip := testTrip
+		trip.PickupLat += 0.00005
+
+		q, err := issuer.Redeem(ctx, issued.ID, trip, now.Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, issued, q)
+	})
+
+	t.Run("Unknown Quote", func(t *testing.T) {
+		issuer := NewIssuer(NewMemoryStore(), []byte("key"), 2*time.Minute)
+		_, err := issuer.Redeem(ctx, "missing", testTrip, now)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		issuer := NewIssuer(NewMemoryStore(), []byte("key"), 2*time.Minute)
+		issued := issue(t, issuer, now)
+
+		_, err := issuer.Redeem(ctx, issued.ID, testTrip, now.Add(2*time.Minute))
+		assert.ErrorIs(t, err, ErrExpired)
+	})
+
+	t.Run("Other Trip", func(t *testing.T) {
+		issuer := NewIssuer(NewMemoryStore(), []byte("key"), 2*time.Minute)
+		issued := issue(t, issuer, now)
+
+		trip := testTrip
+		trip.DropoffLat = -6.3
+		_, err := issuer.Redeem(ctx, issued.ID, trip, now)
+		assert.ErrorIs(t, err, ErrInvalid)
+
+		trip = testTrip
+		trip.UserID = "passenger-2"
+		_, err = issuer.Redeem(ctx, issued.ID, trip, now)
+		assert.ErrorIs(t, err, ErrInvalid)
+
+		// A stop the quote didn't price.
+		trip = testTrip
+		trip.Stops = []Stop{{Lat: -6.18, Long: 106.825}}
+		_, err = issuer.Redeem(ctx, issued.ID, trip, now)
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+
+	t.Run("Tampered Stop", func(t *testing.T) {
+		store := NewMemoryStore()
+		issuer := NewIssuer(store, []byte("key"), 2*time.Minute)
+		trip := testTrip
+		trip.Stops = []Stop{{Lat: -6.18, Long: 106.825}}
+		issued, err := issuer.Issue(ctx, Quote{Trip: trip, Price: 25000}, now)
+		assert.NoError(t, err)
+
+		issued.Stops = []Stop{{Lat: -6.25, Long: 106.9}}
+		assert.NoError(t, store.Save(ctx, issued, time.Minute))
+
+		_, err = issuer.Redeem(ctx, issued.ID, issued.Trip, now)
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		store := NewMemoryStore()
+		issuer := NewIssuer(store, []byte("key"), 2*time.Minute)
+		issued := issue(t, issuer, now)
+
+		issued.Price = 1000
+		assert.NoError(t, store.Save(ctx, issued, time.Minute))
+
+		_, err := issuer.Redeem(ctx, issued.ID, testTrip, now)
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+
+	t.Run("Signed With Another Key", func(t *testing.T) {
+		store := NewMemoryStore()
+		issued := issue(t, NewIssuer(store, []byte("old-key"), 2*time.Minute), now)
+
+		_, err := NewIssuer(store, []byte("key"), 2*time.Minute).Redeem(ctx, issued.ID, testTrip, now)
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+
+	t.Run("Used Once", func(t *testing.T) {
+		issuer := NewIssuer(NewMemoryStore(), []byte("key"), 2*time.Minute)
+		issued := issue(t, issuer, now)
+
+		_, err := issuer.Redeem(ctx, issued.ID, testTrip, now)
+		assert.NoError(t, err)
+		_, err = issuer.Redeem(ctx, issued.ID, testTrip, now)
+		assert.ErrorIs(t, err, ErrUsed)
+
+		// An order that failed gives the quote back.
+		issuer.Release(ctx, issued.ID)
+		_, err = issuer.Redeem(ctx, issued.ID, testTrip, now)
+		assert.NoError(t, err)
+	})
+}
+
+func TestIssuer_Stats(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+	issuer := NewIssuer(NewMemoryStore(), []byte("key"), 2*time.Minute)
+
+	converted := issue(t, issuer, now)
+	expired := issue(t, issuer, now)
+	issue(t, issuer, now)
+	issue(t, issuer, now)
+
+	_, err := issuer.Redeem(ctx, converted.ID, testTrip, now)
+	assert.NoError(t, err)
+	issuer.Converted(ctx, now)
+
+	_, err = issuer.Redeem(ctx, expired.ID, testTrip, now.Add(time.Hour))
+	assert.ErrorIs(t, err, ErrExpired)
+
+	stats, err := issuer.Stats(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, Stats{Issued: 4, Converted: 1, Expired: 1}, stats)
+	assert.Equal(t, 0.25, stats.ConversionRate())
+}
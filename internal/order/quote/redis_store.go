@@ -0,0 +1,117 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyQuotePrefix = "atlas:quote:"       // STRING Quote JSON, expires
+	keyClaimSuffix = ":claim"             // STRING "1" while an order holds the quote
+	keyStatsPrefix = "atlas:quote:stats:" // HASH issued|converted|expired -> count, per day
+	statsRetention = 30 * 24 * time.Hour
+)
+
+const (
+	statIssued    = "issued"
+	statConverted = "converted"
+	statExpired   = "expired"
+)
+
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &RedisStore{
+		client: client,
+	}
+}
+
+func statsKey(at time.Time) string {
+	return keyStatsPrefix + at.UTC().Format(time.DateOnly)
+}
+
+func (r *RedisStore) Save(ctx context.Context, q Quote, ttl time.Duration) error {
+	payload, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+
+	key := statsKey(time.Unix(q.CreatedAt, 0))
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, keyQuotePrefix+q.ID, payload, ttl)
+		pipe.HIncrBy(ctx, key, statIssued, 1)
+		pipe.Expire(ctx, key, statsRetention)
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis quote save failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisStore) Get(ctx context.Context, id string) (*Quote, error) {
+	payload, err := r.client.Get(ctx, keyQuotePrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("redis quote get failed: %v", err)
+		return nil, err
+	}
+
+	var q Quote
+	if err = json.Unmarshal(payload, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (r *RedisStore) Claim(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, keyQuotePrefix+id+keyClaimSuffix, 1, ttl).Result()
+}
+
+func (r *RedisStore) Release(ctx context.Context, id string) error {
+	return r.client.Del(ctx, keyQuotePrefix+id+keyClaimSuffix).Err()
+}
+
+func (r *RedisStore) RecordConverted(ctx context.Context, at time.Time) error {
+	return r.count(ctx, at, statConverted)
+}
+
+func (r *RedisStore) RecordExpired(ctx context.Context, at time.Time) error {
+	return r.count(ctx, at, statExpired)
+}
+
+func (r *RedisStore) count(ctx context.Context, at time.Time, stat string) error {
+	key := statsKey(at)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, stat, 1)
+		pipe.Expire(ctx, key, statsRetention)
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis quote stats failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisStore) Stats(ctx context.Context, at time.Time) (Stats, error) {
+	var counts struct {
+		Issued    int64 `redis:"issued"`
+		Converted int64 `redis:"converted"`
+		Expired   int64 `redis:"expired"`
+	}
+	if err := r.client.HGetAll(ctx, statsKey(at)).Scan(&counts); err != nil {
+		return Stats{}, err
+	}
+	return Stats(counts), nil
+}
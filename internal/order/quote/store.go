@@ -0,0 +1,45 @@
+package quote
+
+import (
+	"context"
+	"time"
+)
+
+// Stats counts what happened to the quotes of one day (UTC).
+type Stats struct {
+	Issued    int64
+	Converted int64
+	Expired   int64
+}
+
+// ConversionRate is the share of issued quotes that became orders.
+func (s Stats) ConversionRate() float64 {
+	if s.Issued == 0 {
+		return 0
+	}
+	return float64(s.Converted) / float64(s.Issued)
+}
+
+type Store interface {
+	// Save stores an issued quote for ttl and counts it as issued.
+	Save(ctx context.Context, q Quote, ttl time.Duration) error
+
+	// Get returns a stored quote, or nil when it is unknown or was purged.
+	Get(ctx context.Context, id string) (*Quote, error)
+
+	// Claim reserves a quote for one order. It returns false when another
+	// order already holds it.
+	Claim(ctx context.Context, id string, ttl time.Duration) (bool, error)
+
+	// Release gives a claimed quote back when its order could not be created.
+	Release(ctx context.Context, id string) error
+
+	// RecordConverted counts a quote that became an order.
+	RecordConverted(ctx context.Context, at time.Time) error
+
+	// RecordExpired counts an order attempt with an expired quote.
+	RecordExpired(ctx context.Context, at time.Time) error
+
+	// Stats returns the counts of the day containing at.
+	Stats(ctx context.Context, at time.Time) (Stats, error)
+}
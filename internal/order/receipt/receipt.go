@@ -0,0 +1,135 @@
+package receipt
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	htmltemplate "html/template"
+	"math"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Formats a receipt is rendered in.
+const (
+	FormatJSON = "json"
+	FormatHTML = "html"
+	FormatText = "text"
+)
+
+var ErrUnknownFormat = errors.New("unknown receipt format")
+
+// Receipt is what a passenger gets for a finished ride, e.g. for an expense
+// claim. It is stored as issued, so later changes to the order or the tariffs
+// don't alter it.
+type Receipt struct {
+	Number      string    `json:"number"`
+	IssuedAt    time.Time `json:"issued_at"`
+	OrderID     string    `json:"order_id"`
+	PassengerID string    `json:"passenger_id"`
+	Driver      Driver    `json:"driver"`
+	Route       Route     `json:"route"`
+	Fare        Fare      `json:"fare"`
+	// PaymentMethod is how the fare is charged, e.g. "WALLET".
+	PaymentMethod string `json:"payment_method"`
+}
+
+// Driver is who drove the ride and with what.
+type Driver struct {
+	ID          string `json:"id"`
+	VehicleType string `json:"vehicle_type"`
+}
+
+// Route summarizes where the ride went.
+type Route struct {
+	Pickup  Point   `json:"pickup"`
+	Stops   []Point `json:"stops,omitempty"`
+	Dropoff Point   `json:"dropoff"`
+	// DistanceKm and DurationMin are measured by the tracker when Metered,
+	// otherwise DistanceKm is the straight-line route and DurationMin is 0.
+	DistanceKm  float64   `json:"distance_km"`
+	DurationMin float64   `json:"duration_min"`
+	Metered     bool      `json:"metered"`
+	FinishedAt  time.Time `json:"finished_at"`
+}
+
+type Point struct {
+	Lat  float64 `json:"lat"`
+	Long float64 `json:"long"`
+}
+
+// Fare is how the total was reached, in Currency. Prices include tax; Tax is
+// the part of Total that is tax.
+type Fare struct {
+	Currency    string  `json:"currency"`
+	UpfrontFare float64 `json:"upfront_fare"`
+	// MeteredFare is 0 when the ride was not metered.
+	MeteredFare     float64 `json:"metered_fare,omitempty"`
+	Basis           string  `json:"basis"`
+	SurgeMultiplier float64 `json:"surge_multiplier"`
+	PromoCode       string  `json:"promo_code,omitempty"`
+	Discount        float64 `json:"discount"`
+	Total           float64 `json:"total"`
+	TaxName         string  `json:"tax_name"`
+	TaxRate         float64 `json:"tax_rate"`
+	Tax             float64 `json:"tax"`
+}
+
+// TaxOf returns the tax included in a price at rate, rounded to a whole unit.
+func TaxOf(price, rate float64) float64 {
+	return math.Round(price * rate / (1 + rate))
+}
+
+//go:embed templates
+var templates embed.FS
+
+var funcs = map[string]any{
+	"money":   money,
+	"percent": func(rate float64) string { return strconv.FormatFloat(rate*100, 'f', -1, 64) + "%" },
+	"km":      func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) },
+	"time":    func(t time.Time) string { return t.UTC().Format("02 Jan 2006 15:04 MST") },
+	"inc":     func(i int) int { return i + 1 },
+}
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("receipt.html").Funcs(funcs).ParseFS(templates, "templates/receipt.html"))
+	textTemplate = texttemplate.Must(texttemplate.New("receipt.txt").Funcs(funcs).ParseFS(templates, "templates/receipt.txt"))
+)
+
+// Render returns the receipt as a document in format and its content type.
+func Render(r Receipt, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatJSON, "":
+		b, err := json.MarshalIndent(r, "", "  ")
+		return b, "application/json", err
+	case FormatHTML:
+		err := htmlTemplate.Execute(&buf, r)
+		return buf.Bytes(), "text/html; charset=utf-8", err
+	case FormatText:
+		err := textTemplate.Execute(&buf, r)
+		return buf.Bytes(), "text/plain; charset=utf-8", err
+	default:
+		return nil, "", ErrUnknownFormat
+	}
+}
+
+// money formats an amount in whole units with dots between thousands, the
+// way rupiah are written.
+func money(v float64) string {
+	digits := strconv.FormatInt(int64(math.Abs(math.Round(v))), 10)
+	var b strings.Builder
+	if v < 0 {
+		b.WriteByte('-')
+	}
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package receipt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testReceipt = Receipt{
+	Number:      "ATL-2026-00000042",
+	IssuedAt:    time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC),
+	OrderID:     "550e8400-e29b-41d4-a716-446655440000",
+	PassengerID: "passenger-1",
+	Driver:      Driver{ID: "driver-1", VehicleType: "go-car"},
+	Route: Route{
+		Pickup:      Point{Lat: -6.2088, Long: 106.8456},
+		Stops:       []Point{{Lat: -6.215, Long: 106.85}},
+		Dropoff:     Point{Lat: -6.23, Long: 106.865},
+		DistanceKm:  9.84,
+		DurationMin: 31,
+		Metered:     true,
+		FinishedAt:  time.Date(2026, 10, 19, 8, 29, 0, 0, time.UTC),
+	},
+	Fare: Fare{
+		Currency:        "IDR",
+		UpfrontFare:     25000,
+		MeteredFare:     29400,
+		Basis:           "METERED",
+		SurgeMultiplier: 1,
+		PromoCode:       "<b>HEMAT</b>",
+		Discount:        5000,
+		Total:           24400,
+		TaxName:         "PPN",
+		TaxRate:         0.11,
+		Tax:             2418,
+	},
+	PaymentMethod: "WALLET",
+}
+
+func TestTaxOf(t *testing.T) {
+	// 11% included in 24,400 is 24,400 * 0.11 / 1.11.
+	assert.Equal(t, 2418.0, TaxOf(24400, 0.11))
+	assert.Zero(t, TaxOf(24400, 0))
+}
+
+func TestMoney(t *testing.T) {
+	assert.Equal(t, "0", money(0))
+	assert.Equal(t, "999", money(999))
+	assert.Equal(t, "24.400", money(24400))
+	assert.Equal(t, "1.250.000", money(1249999.6))
+	assert.Equal(t, "-5.000", money(-5000))
+}
+
+func TestRender(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		doc, contentType, err := Render(testReceipt, FormatJSON)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "application/json", contentType)
+		var r Receipt
+		assert.NoError(t, json.Unmarshal(doc, &r))
+		assert.Equal(t, testReceipt, r)
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		doc, contentType, err := Render(testReceipt, FormatText)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "text/plain; charset=utf-8", contentType)
+		text := string(doc)
+		assert.Contains(t, text, "Receipt no.  ATL-2026-00000042")
+		assert.Contains(t, text, "Stop 1       -6.215, 106.85")
+		assert.Contains(t, text, "Distance     9.8 km\n")
+		assert.Contains(t, text, "Total                24.400")
+		assert.Contains(t, text, "Incl. PPN 11%       2.418")
+		assert.NotContains(t, text, "Surge")
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		doc, contentType, err := Render(testReceipt, FormatHTML)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "text/html; charset=utf-8", contentType)
+		html := string(doc)
+		assert.Contains(t, html, "<strong>ATL-2026-00000042</strong>")
+		assert.Contains(t, html, `<tr class="total"><td>Total</td><td class="amount">24.400</td></tr>`)
+		// Values are escaped.
+		assert.Contains(t, html, "&lt;b&gt;HEMAT&lt;/b&gt;")
+		assert.Equal(t, 1, strings.Count(html, "<html"))
+	})
+
+	t.Run("Unknown Format", func(t *testing.T) {
+		_, _, err := Render(testReceipt, "pdf")
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// earningsPeriod is the period GetDriverEarnings sums by default, and
+	// maxEarningsPeriod the longest it sums at once.
+	earningsPeriod    = 7 * 24 * time.Hour
+	maxEarningsPeriod = 31 * 24 * time.Hour
+)
+
+// splitFare works out the commission the platform keeps on a fare and what
+// the driver earns. The fare is the one before the passenger's discount: the
+// platform pays for promos, not the driver.
+func (s *Service) splitFare(o db.Order, fare float64) (rate, commission, earnings float64) {
+	rate, err := s.pricer.Commission(o.VehicleType, o.PickupLat, o.PickupLong)
+	if err != nil {
+		// The ride was priced, so this only happens when its city or vehicle
+		// type was dropped from the tariffs since. The driver keeps the fare.
+		log.Printf("⚠️ No commission for order %s, paying the whole fare: %v", o.ID.String(), err)
+	}
+	commission = math.Round(fare * rate)
+	return rate, commission, fare - commission
+}
+
+// payoutOf tells the Wallet Service whom to pay the fare of a finished order.
+// Rides finished before earnings were recorded pay nobody.
+func payoutOf(o db.Order) *orderModel.Payout {
+	if !o.DriverEarnings.Valid {
+		return nil
+	}
+	earnings, commission := o.DriverEarnings.Float64, o.Commission.Float64
+	return &orderModel.Payout{
+		DriverID:   o.DriverID.String,
+		Earnings:   earnings,
+		Commission: commission,
+		Subsidy:    earnings + commission - chargeFor(o),
+	}
+}
+
+// GetDriverEarnings lists what a driver earned on every ride paid in a period,
+// with the totals.
+func (s *Service) GetDriverEarnings(ctx context.Context, req *order.GetDriverEarningsRequest) (*order.GetDriverEarningsResponse, error) {
+	if req.DriverId == "" {
+		return nil, status.Error(codes.InvalidArgument, "driver_id is required")
+	}
+
+	to := time.Now()
+	if req.To != "" {
+		t, err := time.Parse(time.RFC3339, req.To)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid to: %v", err)
+		}
+		to = t
+	}
+	from := to.Add(-earningsPeriod)
+	if req.From != "" {
+		t, err := time.Parse(time.RFC3339, req.From)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid from: %v", err)
+		}
+		from = t
+	}
+	if !from.Before(to) || to.Sub(from) > maxEarningsPeriod {
+		return nil, status.Errorf(codes.InvalidArgument, "from must be before to, at most %d days apart", int(maxEarningsPeriod.Hours()/24))
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	orders, err := s.store.ListDriverEarnings(dbCtx, db.ListDriverEarningsParams{
+		DriverID: pgtype.Text{String: req.DriverId, Valid: true},
+		PaidFrom: pgtype.Timestamptz{Time: from, Valid: true},
+		PaidTo:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list earnings: %v", err)
+	}
+
+	res := &order.GetDriverEarningsResponse{
+		DriverId: req.DriverId,
+		From:     from.UTC().Format(time.RFC3339),
+		To:       to.UTC().Format(time.RFC3339),
+		Trips:    make([]*order.TripEarnings, 0, len(orders)),
+	}
+	for _, o := range orders {
+		payout := payoutOf(o)
+		trip := &order.TripEarnings{
+			OrderId:        o.ID.String(),
+			PaidAt:         formatTime(o.PaidAt),
+			VehicleType:    o.VehicleType,
+			Fare:           payout.Earnings + payout.Commission,
+			CommissionRate: o.CommissionRate.Float64,
+			Commission:     payout.Commission,
+			PromoSubsidy:   payout.Subsidy,
+			Earnings:       payout.Earnings,
+			Tip:            o.Tip.Float64,
+		}
+		res.Trips = append(res.Trips, trip)
+		res.TotalFares += trip.Fare
+		res.TotalCommission += trip.Commission
+		res.TotalEarnings += trip.Earnings
+		res.TotalTips += trip.Tip
+	}
+	return res, nil
+}
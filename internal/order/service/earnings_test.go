@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) ListDriverEarnings(ctx context.Context, arg db.ListDriverEarningsParams) ([]db.Order, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.Order), args.Error(1)
+}
+
+// paidOrder is finishedOrder paid out at 20% commission: 5000 off the
+// 26000 fare was paid for by a promo.
+func paidOrder() db.Order {
+	o := finishedOrder()
+	o.Discount = 5000
+	o.CommissionRate = pgtype.Float8{Float64: 0.2, Valid: true}
+	o.Commission = pgtype.Float8{Float64: 5200, Valid: true}
+	o.DriverEarnings = pgtype.Float8{Float64: 20800, Valid: true}
+	o.PaidAt = pgtype.Timestamptz{Time: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC), Valid: true}
+	return o
+}
+
+func TestSplitFare(t *testing.T) {
+	svc := NewOrderService(nil, testPricer(t), nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+	o := finishedOrder()
+	o.VehicleType = VehicleTypeRide
+
+	rate, commission, earnings := svc.splitFare(o, 21003)
+	assert.Equal(t, 0.2, rate)
+	assert.Equal(t, 4201.0, commission)
+	assert.Equal(t, 16802.0, earnings)
+
+	// Without a tariff, the driver keeps the whole fare.
+	o.PickupLat = 10
+	rate, commission, earnings = svc.splitFare(o, 21000)
+	assert.Zero(t, rate)
+	assert.Zero(t, commission)
+	assert.Equal(t, 21000.0, earnings)
+}
+
+func TestPayoutOf(t *testing.T) {
+	assert.Equal(t, &orderModel.Payout{DriverID: "driver-1", Earnings: 20800, Commission: 5200, Subsidy: 5000}, payoutOf(paidOrder()))
+	assert.Nil(t, payoutOf(finishedOrder()))
+}
+
+func TestGetDriverEarnings(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Sums Trips", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+		tipped := paidOrder()
+		tipped.Tip = pgtype.Float8{Float64: 3000, Valid: true}
+		plain := paidOrder()
+		plain.Discount = 0
+		plain.FinalPrice = pgtype.Float8{Float64: 10000, Valid: true}
+		plain.Commission = pgtype.Float8{Float64: 2000, Valid: true}
+		plain.DriverEarnings = pgtype.Float8{Float64: 8000, Valid: true}
+
+		store.On("ListDriverEarnings", mock.Anything, db.ListDriverEarningsParams{
+			DriverID: pgtype.Text{String: "driver-1", Valid: true},
+			PaidFrom: pgtype.Timestamptz{Time: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Valid: true},
+			PaidTo:   pgtype.Timestamptz{Time: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), Valid: true},
+		}).Return([]db.Order{tipped, plain}, nil).Once()
+
+		res, err := svc.GetDriverEarnings(ctx, &order.GetDriverEarningsRequest{DriverId: "driver-1", To: "2026-10-19T00:00:00Z"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2026-10-12T00:00:00Z", res.From)
+		assert.Len(t, res.Trips, 2)
+		assert.Equal(t, &order.TripEarnings{
+			OrderId:        tipped.ID.String(),
+			PaidAt:         "2026-10-18T09:00:00Z",
+			Fare:           26000,
+			CommissionRate: 0.2,
+			Commission:     5200,
+			PromoSubsidy:   5000,
+			Earnings:       20800,
+			Tip:            3000,
+		}, res.Trips[0])
+		assert.Equal(t, 36000.0, res.TotalFares)
+		assert.Equal(t, 7200.0, res.TotalCommission)
+		assert.Equal(t, 28800.0, res.TotalEarnings)
+		assert.Equal(t, 3000.0, res.TotalTips)
+		store.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name string
+		req  *order.GetDriverEarningsRequest
+	}{
+		{"Missing Driver", &order.GetDriverEarningsRequest{}},
+		{"Invalid Date", &order.GetDriverEarningsRequest{DriverId: "driver-1", From: "yesterday"}},
+		{"Backwards", &order.GetDriverEarningsRequest{DriverId: "driver-1", From: "2026-10-19T00:00:00Z", To: "2026-10-12T00:00:00Z"}},
+		{"Too Long", &order.GetDriverEarningsRequest{DriverId: "driver-1", From: "2026-01-01T00:00:00Z", To: "2026-10-19T00:00:00Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewOrderService(new(MockStore), nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+			_, err := svc.GetDriverEarnings(ctx, tt.req)
+
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
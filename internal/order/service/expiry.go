@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ExpiryConfig struct {
+	// Timeout is how long an order may wait for a driver, from its creation
+	// or, for a scheduled ride, its pickup time. It should outlast the
+	// dispatch attempts of scheduled rides.
+	Timeout time.Duration
+	// Interval is how often the expirer polls Postgres for unmatched orders.
+	Interval time.Duration
+	// BatchSize limits how many orders a single tick expires.
+	BatchSize int32
+}
+
+// OrderExpirer gives up on orders that nobody dispatched in time, whether the
+// dispatch request was lost or never found a driver. Orders are claimed in
+// Postgres with FOR UPDATE SKIP LOCKED, so each is expired exactly once even
+// with several replicas.
+type OrderExpirer struct {
+	store db.Store
+	cfg   ExpiryConfig
+}
+
+func NewOrderExpirer(store db.Store, cfg ExpiryConfig) *OrderExpirer {
+	return &OrderExpirer{
+		store: store,
+		cfg:   cfg,
+	}
+}
+
+func (e *OrderExpirer) Run(ctx context.Context) {
+	log.Println("Starting order expirer...")
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Order expirer stopping...")
+			return
+		case <-ticker.C:
+			if err := e.ExpireUnmatched(ctx, time.Now()); err != nil {
+				log.Printf("❌ Failed to expire orders: %v", err)
+			}
+		}
+	}
+}
+
+// ExpireUnmatched moves the orders waiting for a driver past the timeout to
+// EXPIRED in one transaction with what undoes them: the promo is given back,
+// the held fare released, order-cancelled takes the ride off dispatch's
+// pending queue and order-events tells the passenger. A driver that dispatch
+// matches before it hears of the expiry is released by the OrderWorker.
+func (e *OrderExpirer) ExpireUnmatched(ctx context.Context, now time.Time) error {
+	reason := pgtype.Text{String: fmt.Sprintf("no driver found within %s", e.cfg.Timeout), Valid: true}
+	return e.store.ExecTx(ctx, func(q db.Querier) error {
+		orders, err := q.ClaimUnmatchedOrders(ctx, db.ClaimUnmatchedOrdersParams{
+			SearchBefore: pgtype.Timestamptz{Time: now.Add(-e.cfg.Timeout), Valid: true},
+			BatchSize:    e.cfg.BatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, o := range orders {
+			fromStatus, err := expireOrder(ctx, q, o, reason, now)
+			if err != nil {
+				return err
+			}
+			log.Printf("⌛ Order %s expired in %s, no driver found", o.ID.String(), fromStatus)
+		}
+		return nil
+	})
+}
+
+// expireOrder moves an order nobody will drive to EXPIRED, with what undoes
+// it, and returns the status it left. Call it with the querier of the
+// transaction that claimed the order.
+func expireOrder(ctx context.Context, q db.Querier, o db.Order, reason pgtype.Text, now time.Time) (string, error) {
+	fromStatus, err := q.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
+		ID:           o.ID,
+		FromStatuses: sourcesOf(order.OrderStatus_EXPIRED),
+		Status:       order.OrderStatus_EXPIRED.String(),
+		Actor:        ActorSystem,
+		Reason:       reason,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err = reversePromo(ctx, q, o.ID); err != nil {
+		return "", err
+	}
+	if err = enqueueHoldRelease(ctx, q, o); err != nil {
+		return "", err
+	}
+	event := orderModel.OrderCancelledEvent{
+		OrderID:     o.ID.String(),
+		PassengerID: o.PassengerID,
+		CancelledBy: ActorSystem,
+		Reason:      reason.String,
+		CancelledAt: now.Unix(),
+	}
+	if err = enqueueJSON(ctx, q, cancelledTopic, event.OrderID, &event); err != nil {
+		return "", err
+	}
+	return fromStatus, enqueueTransition(ctx, q, o.ID, fromStatus, ActorSystem)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (m *MockStore) ClaimUnmatchedOrders(ctx context.Context, arg db.ClaimUnmatchedOrdersParams) ([]db.Order, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.Order), args.Error(1)
+}
+
+func TestOrderExpirer_ExpireUnmatched(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	o := finishedOrder()
+	o.Status = "SEARCHING"
+	o.DriverID = pgtype.Text{}
+	expired := o
+	expired.Status = "EXPIRED"
+	orderID := o.ID.String()
+
+	store := new(MockStore)
+	expirer := NewOrderExpirer(store, ExpiryConfig{Timeout: 10 * time.Minute, BatchSize: 10})
+
+	store.On("ClaimUnmatchedOrders", mock.Anything, db.ClaimUnmatchedOrdersParams{
+		SearchBefore: pgtype.Timestamptz{Time: now.Add(-10 * time.Minute), Valid: true},
+		BatchSize:    10,
+	}).Return([]db.Order{o}, nil).Once()
+	store.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(func(arg db.UpdateOrderStatusParams) bool {
+		return arg.ID == o.ID && arg.Status == "EXPIRED" && arg.Actor == ActorSystem &&
+			arg.Reason.String == "no driver found within 10m0s"
+	})).Return("SEARCHING", nil).Once()
+	store.On("ReversePromoRedemption", mock.Anything, o.ID).Return(int64(0), nil).Once()
+	store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var release orderModel.DebitBalanceEvent
+		return arg.Topic == walletTopic && json.Unmarshal(arg.Payload, &release) == nil &&
+			release.Amount == 0 && release.Hold == orderID && release.UserID == "passenger-1"
+	})).Return(nil).Once()
+	store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var cancelled orderModel.OrderCancelledEvent
+		return arg.Topic == cancelledTopic && arg.MessageKey == orderID && json.Unmarshal(arg.Payload, &cancelled) == nil &&
+			cancelled.OrderID == orderID && cancelled.CancelledBy == ActorSystem && cancelled.CancellationFee == 0
+	})).Return(nil).Once()
+	store.On("GetOrder", mock.Anything, o.ID).Return(expired, nil).Once()
+	store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var e orderModel.OrderEvent
+		return arg.Topic == orderEventsTopic && json.Unmarshal(arg.Payload, &e) == nil &&
+			e.Type == orderModel.OrderEventExpired && e.FromStatus == "SEARCHING" && e.Actor == ActorSystem
+	})).Return(nil).Once()
+
+	err := expirer.ExpireUnmatched(ctx, now)
+
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/internal/pricing"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// How the final price of a finished ride was decided.
+const (
+	// FareBasisUpfront charges the fare agreed when the order was placed.
+	FareBasisUpfront = "UPFRONT"
+	// FareBasisMetered charges what the measured trip cost.
+	FareBasisMetered = "METERED"
+	// FareBasisCapped charges the most the policy allows above the upfront fare.
+	FareBasisCapped = "CAPPED"
+	// FareBasisNoTelemetry charges the upfront fare because the trip was not measured.
+	FareBasisNoTelemetry = "NO_TELEMETRY"
+)
+
+// FarePolicy decides what a finished ride costs when the metered fare differs
+// from the upfront one.
+type FarePolicy struct {
+	// Tolerance is how far, as a share of the upfront fare, the metered fare may
+	// be off before it is charged instead.
+	Tolerance float64
+	// MaxIncrease caps the charge at this share above the upfront fare.
+	MaxIncrease float64
+}
+
+// Final returns what to charge for a ride, before discounts. A passenger who
+// booked with a fare quote was promised its price and never pays more, but
+// still benefits from a trip that turned out cheaper.
+func (p FarePolicy) Final(upfront, metered float64, quoted bool) (float64, string) {
+	switch {
+	case metered > upfront*(1+p.Tolerance):
+		if quoted {
+			return upfront, FareBasisUpfront
+		}
+		if limit := math.Round(upfront * (1 + p.MaxIncrease)); metered > limit {
+			return limit, FareBasisCapped
+		}
+		return metered, FareBasisMetered
+	case metered < upfront*(1-p.Tolerance):
+		return metered, FareBasisMetered
+	default:
+		return upfront, FareBasisUpfront
+	}
+}
+
+// startMeter asks the tracker to measure the ride from here on. A ride that is
+// not measured is charged its upfront fare, so a failure only gets logged.
+func (s *Service) startMeter(ctx context.Context, o db.Order) {
+	if o.VehicleType == VehicleTypePool {
+		return
+	}
+
+	_, err := s.trackerClient.StartTrip(ctx, &tracker.StartTripRequest{DriverId: o.DriverID.String, RideId: o.ID.String()})
+	if err != nil {
+		log.Printf("⚠️ Failed to start trip meter for order %s: %v", o.ID.String(), err)
+	}
+}
+
+// finalFare measures a finished ride and decides what the passenger pays and
+// the driver earns. The upfront fare is compared before the promo discount,
+// which is then taken off whatever is charged.
+func (s *Service) finalFare(ctx context.Context, o db.Order) db.SetOrderFinalFareParams {
+	upfront := o.Price + o.Discount
+	params := db.SetOrderFinalFareParams{ID: o.ID}
+	amount, basis := upfront, FareBasisNoTelemetry
+
+	// A shared ride detours for the other passengers, so its distance says
+	// nothing about the seat that was booked: it always pays upfront.
+	if o.VehicleType == VehicleTypePool {
+		basis = FareBasisUpfront
+	} else if q, ok := s.meterTrip(ctx, o); ok {
+		params.MeteredDistanceKm = pgtype.Float8{Float64: q.DistanceKm, Valid: true}
+		params.MeteredDurationMin = pgtype.Float8{Float64: q.DurationMin, Valid: true}
+		params.MeteredFare = pgtype.Float8{Float64: q.Fare, Valid: true}
+		amount, basis = s.farePolicy.Final(upfront, q.Fare, o.QuoteID.Valid)
+	}
+
+	params.FinalPrice = pgtype.Float8{Float64: math.Max(amount-o.Discount, 0), Valid: true}
+	params.FareBasis = pgtype.Text{String: basis, Valid: true}
+
+	rate, commission, earnings := s.splitFare(o, amount)
+	params.CommissionRate = pgtype.Float8{Float64: rate, Valid: true}
+	params.Commission = pgtype.Float8{Float64: commission, Valid: true}
+	params.DriverEarnings = pgtype.Float8{Float64: earnings, Valid: true}
+	return params
+}
+
+// meterTrip prices the distance and time the ride was measured at. It reports
+// false when the ride has no usable telemetry.
+func (s *Service) meterTrip(ctx context.Context, o db.Order) (pricing.Quote, bool) {
+	distanceKm, durationMin, ok := s.measureTrip(ctx, o)
+	if !ok {
+		return pricing.Quote{}, false
+	}
+
+	// The trip is priced with the rule and surge of the time it was booked for,
+	// so it differs from the estimate only in distance and time.
+	pickupTime := o.CreatedAt.Time
+	if o.ScheduledAt.Valid {
+		pickupTime = o.ScheduledAt.Time
+	}
+	q, err := s.pricer.Meter(pricing.Trip{
+		VehicleType: o.VehicleType,
+		PickupLat:   o.PickupLat,
+		PickupLong:  o.PickupLong,
+		DropoffLat:  o.DropoffLat,
+		DropoffLong: o.DropoffLong,
+		At:          pickupTime,
+		Surge:       o.SurgeMultiplier,
+	}, distanceKm, durationMin)
+	if err != nil {
+		log.Printf("⚠️ Failed to price metered trip of order %s, charging upfront: %v", o.ID.String(), err)
+		return pricing.Quote{}, false
+	}
+	return q, true
+}
+
+// measureTrip stops the tracker's meter and returns what it recorded. The
+// measurement is kept on the order before the ride is finished, so a finish
+// retried after its transaction failed charges the same trip, even once the
+// tracker has forgotten it.
+func (s *Service) measureTrip(ctx context.Context, o db.Order) (distanceKm, durationMin float64, ok bool) {
+	if o.MeteredDistanceKm.Valid && o.MeteredDurationMin.Valid {
+		return o.MeteredDistanceKm.Float64, o.MeteredDurationMin.Float64, true
+	}
+
+	res, err := s.trackerClient.EndTrip(ctx, &tracker.EndTripRequest{RideId: o.ID.String()})
+	if err != nil {
+		log.Printf("⚠️ No trip meter for order %s, charging upfront: %v", o.ID.String(), err)
+		return 0, 0, false
+	}
+	if res.Points == 0 {
+		log.Printf("⚠️ No GPS updates during order %s, charging upfront", o.ID.String())
+		return 0, 0, false
+	}
+
+	distanceKm, durationMin = res.DistanceKm, float64(res.DurationSeconds)/60
+	err = s.store.RecordOrderMeter(ctx, db.RecordOrderMeterParams{
+		ID:                 o.ID,
+		MeteredDistanceKm:  pgtype.Float8{Float64: distanceKm, Valid: true},
+		MeteredDurationMin: pgtype.Float8{Float64: durationMin, Valid: true},
+	})
+	if err != nil {
+		// The tracker keeps an ended meter for a day, so a retry soon after
+		// still finds it.
+		log.Printf("⚠️ Failed to keep trip meter of order %s: %v", o.ID.String(), err)
+	}
+	return distanceKm, durationMin, true
+}
+
+// chargeFor returns what the passenger pays for a finished order.
+func chargeFor(o db.Order) float64 {
+	if o.FinalPrice.Valid {
+		return o.FinalPrice.Float64
+	}
+	return o.Price
+}
+
+// finalFareOf describes the final fare of a finished order, nil before that.
+func finalFareOf(o db.Order) *order.FinalFare {
+	if !o.FinalPrice.Valid {
+		return nil
+	}
+	return &order.FinalFare{
+		UpfrontFare:        o.Price + o.Discount,
+		MeteredDistanceKm:  o.MeteredDistanceKm.Float64,
+		MeteredDurationMin: o.MeteredDurationMin.Float64,
+		MeteredFare:        o.MeteredFare.Float64,
+		Discount:           o.Discount,
+		FinalPrice:         o.FinalPrice.Float64,
+		Basis:              o.FareBasis.String,
+	}
+}
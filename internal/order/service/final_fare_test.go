@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/internal/pricing"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+)
+
+type MockTrackerClient struct {
+	tracker.TrackerServiceClient
+	mock.Mock
+}
+
+func (m *MockTrackerClient) EndTrip(ctx context.Context, in *tracker.EndTripRequest, opts ...grpc.CallOption) (*tracker.EndTripResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*tracker.EndTripResponse), args.Error(1)
+}
+
+func (m *MockTrackerClient) ReleaseDriver(ctx context.Context, in *tracker.ReleaseDriverRequest, opts ...grpc.CallOption) (*tracker.ReleaseDriverResponse, error) {
+	args := m.Called(ctx, in)
+	return &tracker.ReleaseDriverResponse{Success: true}, args.Error(0)
+}
+
+func (m *MockStore) RecordOrderMeter(ctx context.Context, arg db.RecordOrderMeterParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockStore) SetOrderFinalFare(ctx context.Context, arg db.SetOrderFinalFareParams) (db.Order, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Order), args.Error(1)
+}
+
+// testPricer drives at 60 km/h around (0, 0), charges
+// 1000 + 100/km + 10/min + 500 booking fee and keeps 20% commission.
+func testPricer(t *testing.T) *pricing.Engine {
+	cfg := &pricing.Config{
+		Version: "test-1",
+		Cities: []pricing.City{{
+			Name:        "test",
+			RadiusKm:    50,
+			Timezone:    "UTC",
+			AvgSpeedKmh: 60,
+			Tariffs: map[string]pricing.Tariff{
+				VehicleTypeRide: {BaseFare: 1000, PerKm: 100, PerMinute: 10, MinimumFare: 2000, BookingFee: 500, Commission: 0.2},
+			},
+		}},
+	}
+	assert.NoError(t, cfg.Validate())
+	return pricing.NewEngine(cfg)
+}
+
+func TestFarePolicy_Final(t *testing.T) {
+	policy := FarePolicy{Tolerance: 0.1, MaxIncrease: 0.25}
+
+	tests := []struct {
+		name      string
+		metered   float64
+		quoted    bool
+		want      float64
+		wantBasis string
+	}{
+		{"Within Tolerance Above", 10900, false, 10000, FareBasisUpfront},
+		{"Within Tolerance Below", 9100, false, 10000, FareBasisUpfront},
+		{"Longer Trip", 11500, false, 11500, FareBasisMetered},
+		{"Much Longer Trip Is Capped", 14000, false, 12500, FareBasisCapped},
+		{"Shorter Trip", 8000, false, 8000, FareBasisMetered},
+		{"Quote Never Costs More", 14000, true, 10000, FareBasisUpfront},
+		{"Quote Still Gets Cheaper", 8000, true, 8000, FareBasisMetered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, basis := policy.Final(10000, tt.metered, tt.quoted)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantBasis, basis)
+		})
+	}
+}
+
+func TestUpdateOrderStatus_ChargesFinalFare(t *testing.T) {
+	ctx := context.Background()
+	orderID := "550e8400-e29b-41d4-a716-446655440000"
+
+	var id pgtype.UUID
+	_ = id.Scan(orderID)
+	// A Wednesday noon, no time-of-day rule applies.
+	createdAt := pgtype.Timestamptz{Time: time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC), Valid: true}
+	started := db.Order{
+		ID:          id,
+		PassengerID: "passenger-1",
+		DriverID:    pgtype.Text{String: "driver-1", Valid: true},
+		DropoffLong: 0.1,
+		Status:      "STARTED",
+		VehicleType: VehicleTypeRide,
+		Seats:       1,
+		Price:       2100,
+		Discount:    500,
+		CreatedAt:   createdAt,
+	}
+
+	newService := func(store *MockStore, trackerClient *MockTrackerClient) *Service {
+		return NewOrderService(store, testPricer(t), nil, nil, nil, trackerClient, CancellationPolicy{}, FarePolicy{Tolerance: 0.1, MaxIncrease: 0.25})
+	}
+
+	// The driver earns the fare before the discount, minus the commission.
+	expect := func(store *MockStore, trackerClient *MockTrackerClient, started db.Order, wantFinal float64, wantBasis string, wantCommission float64) {
+		wantEarnings := wantFinal + started.Discount - wantCommission
+		finished := started
+		finished.Status = "FINISHED"
+		finished.FinalPrice = pgtype.Float8{Float64: wantFinal, Valid: true}
+		finished.FareBasis = pgtype.Text{String: wantBasis, Valid: true}
+		finished.CommissionRate = pgtype.Float8{Float64: 0.2, Valid: true}
+		finished.Commission = pgtype.Float8{Float64: wantCommission, Valid: true}
+		finished.DriverEarnings = pgtype.Float8{Float64: wantEarnings, Valid: true}
+
+		store.On("GetOrder", mock.Anything, id).Return(started, nil).Once()
+		store.On("UpdateOrderStatus", mock.Anything, mock.Anything).Return("STARTED", nil).Once()
+		store.On("SetOrderFinalFare", mock.Anything, mock.MatchedBy(func(arg db.SetOrderFinalFareParams) bool {
+			return arg.ID == id && arg.FinalPrice.Float64 == wantFinal && arg.FareBasis.String == wantBasis &&
+				arg.CommissionRate.Float64 == 0.2 && arg.Commission.Float64 == wantCommission && arg.DriverEarnings.Float64 == wantEarnings
+		})).Return(finished, nil).Once()
+		store.On("ListRideStops", mock.Anything, id).Return([]db.RideStop{}, nil).Once()
+		store.On("NextReceiptNumber", mock.Anything, mock.Anything).Return(int64(1), nil).Once()
+		store.On("CreateReceipt", mock.Anything, mock.Anything).Return(db.Receipt{}, nil).Once()
+		store.On("GetOrder", mock.Anything, id).Return(finished, nil).Once()
+		store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+			return arg.Topic == orderEventsTopic
+		})).Return(nil).Once()
+		store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+			var debit orderModel.DebitBalanceEvent
+			return arg.Topic == walletTopic &&
+				json.Unmarshal(arg.Payload, &debit) == nil && debit.Amount == wantFinal && debit.Hold == orderID && debit.Payout != nil &&
+				*debit.Payout == orderModel.Payout{DriverID: "driver-1", Earnings: wantEarnings, Commission: wantCommission, Subsidy: started.Discount}
+		})).Return(nil).Once()
+		trackerClient.On("ReleaseDriver", mock.Anything, mock.Anything).Return(nil).Once()
+	}
+
+	t.Run("Charges Capped Metered Fare", func(t *testing.T) {
+		store := new(MockStore)
+		trackerClient := new(MockTrackerClient)
+		svc := newService(store, trackerClient)
+
+		// 1000 + 15 km * 100 + 30 min * 10 + 500 = 3300 metered against an
+		// upfront 2600, capped at 3250, minus the 500 discount.
+		trackerClient.On("EndTrip", mock.Anything, &tracker.EndTripRequest{RideId: orderID}).
+			Return(&tracker.EndTripResponse{DistanceKm: 15, DurationSeconds: 1800, Points: 40}, nil).Once()
+		store.On("RecordOrderMeter", mock.Anything, db.RecordOrderMeterParams{
+			ID:                 id,
+			MeteredDistanceKm:  pgtype.Float8{Float64: 15, Valid: true},
+			MeteredDurationMin: pgtype.Float8{Float64: 30, Valid: true},
+		}).Return(nil).Once()
+		expect(store, trackerClient, started, 2750, FareBasisCapped, 650)
+
+		res, err := svc.UpdateOrderStatus(ctx, &order.UpdateOrderStatusRequest{OrderId: orderID, Status: order.OrderStatus_FINISHED})
+
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderStatus_FINISHED, res.Status)
+		store.AssertExpectations(t)
+		trackerClient.AssertExpectations(t)
+	})
+
+	t.Run("Retry Reuses Kept Meter", func(t *testing.T) {
+		store := new(MockStore)
+		trackerClient := new(MockTrackerClient)
+		svc := newService(store, trackerClient)
+
+		// The first finish measured the trip but its transaction failed; the
+		// tracker is not asked again.
+		measured := started
+		measured.MeteredDistanceKm = pgtype.Float8{Float64: 15, Valid: true}
+		measured.MeteredDurationMin = pgtype.Float8{Float64: 30, Valid: true}
+		expect(store, trackerClient, measured, 2750, FareBasisCapped, 650)
+
+		_, err := svc.UpdateOrderStatus(ctx, &order.UpdateOrderStatusRequest{OrderId: orderID, Status: order.OrderStatus_FINISHED})
+
+		assert.NoError(t, err)
+		store.AssertExpectations(t)
+		trackerClient.AssertNotCalled(t, "EndTrip", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Charges Upfront Without Telemetry", func(t *testing.T) {
+		store := new(MockStore)
+		trackerClient := new(MockTrackerClient)
+		svc := newService(store, trackerClient)
+
+		trackerClient.On("EndTrip", mock.Anything, mock.Anything).Return(nil, errors.New("trip was not metered")).Once()
+		expect(store, trackerClient, started, 2100, FareBasisNoTelemetry, 520)
+
+		_, err := svc.UpdateOrderStatus(ctx, &order.UpdateOrderStatusRequest{OrderId: orderID, Status: order.OrderStatus_FINISHED})
+
+		assert.NoError(t, err)
+		store.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// holdGrace is how long past pickup a fare stays held. A hold the Order
+// Service never got to close stops counting against the wallet after it.
+const holdGrace = 24 * time.Hour
+
+// newOrderID returns a random (version 4) UUID. Orders get their ID before
+// they are stored, so the fare can be held under it first.
+func newOrderID() (pgtype.UUID, error) {
+	var id pgtype.UUID
+	if _, err := rand.Read(id.Bytes[:]); err != nil {
+		return id, err
+	}
+	id.Bytes[6] = id.Bytes[6]&0x0f | 0x40
+	id.Bytes[8] = id.Bytes[8]&0x3f | 0x80
+	id.Valid = true
+	return id, nil
+}
+
+// paymentMethod validates how a new order is paid, by wallet by default.
+func paymentMethod(method string) (string, error) {
+	switch method {
+	case "":
+		return orderModel.PaymentMethodWallet, nil
+	case orderModel.PaymentMethodWallet, orderModel.PaymentMethodCash, orderModel.PaymentMethodCard:
+		return method, nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "invalid payment_method: %s", method)
+	}
+}
+
+// holdFare sets the fare of a new order aside on the passenger's wallet, or
+// authorizes it on their card, so it can't be spent before the ride is paid.
+// Cash is paid to the driver, so nothing is held for it.
+func (s *Service) holdFare(ctx context.Context, orderID pgtype.UUID, passengerID, method string, price float64, pickupTime time.Time) error {
+	if method == orderModel.PaymentMethodCash {
+		return nil
+	}
+	_, err := s.walletClient.PlaceHold(ctx, &wallet.PlaceHoldRequest{
+		UserId:        passengerID,
+		Amount:        price,
+		ReferenceId:   orderID.String(),
+		ExpiresAt:     pickupTime.Add(holdGrace).UTC().Format(time.RFC3339),
+		PaymentMethod: method,
+	})
+	if err != nil {
+		if status.Code(err) == codes.FailedPrecondition {
+			return err
+		}
+		return status.Errorf(codes.Internal, "failed to hold fare: %v", err)
+	}
+	return nil
+}
+
+// releaseHold gives back the fare held for an order that was not created.
+func (s *Service) releaseHold(ctx context.Context, orderID pgtype.UUID, method string) {
+	if method == orderModel.PaymentMethodCash {
+		return
+	}
+	if _, err := s.walletClient.ReleaseHold(ctx, &wallet.ReleaseHoldRequest{ReferenceId: orderID.String(), PaymentMethod: method}); err != nil {
+		log.Printf("⚠️ Failed to release fare hold of order %s: %v", orderID.String(), err)
+	}
+}
+
+// enqueueHoldRelease gives back the fare held for an order that ends without
+// being paid, through the outbox in the transaction of q.
+func enqueueHoldRelease(ctx context.Context, q db.Querier, o db.Order) error {
+	if o.PaymentMethod == orderModel.PaymentMethodCash {
+		return nil
+	}
+	orderString := o.ID.String()
+	event := orderModel.DebitBalanceEvent{
+		UserID:    o.PassengerID,
+		Reference: orderString,
+		Method:    o.PaymentMethod,
+		Hold:      orderString,
+	}
+	return enqueueJSON(ctx, q, walletTopic, orderString, &event)
+}
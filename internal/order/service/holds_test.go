@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockWalletClient) PlaceHold(ctx context.Context, in *wallet.PlaceHoldRequest, opts ...grpc.CallOption) (*wallet.HoldResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*wallet.HoldResponse), args.Error(1)
+}
+
+func TestNewOrderID(t *testing.T) {
+	a, err := newOrderID()
+	assert.NoError(t, err)
+	b, err := newOrderID()
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, a, b)
+	// Version 4, RFC 4122 variant.
+	assert.Equal(t, byte(0x40), a.Bytes[6]&0xf0)
+	assert.Equal(t, byte(0x80), a.Bytes[8]&0xc0)
+
+	var parsed pgtype.UUID
+	assert.NoError(t, parsed.Scan(a.String()))
+	assert.Equal(t, a, parsed)
+}
+
+func TestPaymentMethod(t *testing.T) {
+	method, err := paymentMethod("")
+	assert.NoError(t, err)
+	assert.Equal(t, orderModel.PaymentMethodWallet, method)
+
+	method, err = paymentMethod(orderModel.PaymentMethodCash)
+	assert.NoError(t, err)
+	assert.Equal(t, orderModel.PaymentMethodCash, method)
+
+	_, err = paymentMethod("BITCOIN")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestHoldFare(t *testing.T) {
+	ctx := context.Background()
+	orderID, _ := newOrderID()
+	pickupTime := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	t.Run("Authorizes Card", func(t *testing.T) {
+		walletClient := new(MockWalletClient)
+		svc := NewOrderService(nil, nil, nil, nil, walletClient, nil, CancellationPolicy{}, FarePolicy{})
+
+		walletClient.On("PlaceHold", mock.Anything, &wallet.PlaceHoldRequest{
+			UserId:        "passenger-1",
+			Amount:        18000,
+			ReferenceId:   orderID.String(),
+			ExpiresAt:     "2026-10-20T08:00:00Z",
+			PaymentMethod: orderModel.PaymentMethodCard,
+		}).Return(&wallet.HoldResponse{}, nil).Once()
+
+		err := svc.holdFare(ctx, orderID, "passenger-1", orderModel.PaymentMethodCard, 18000, pickupTime)
+
+		assert.NoError(t, err)
+		walletClient.AssertExpectations(t)
+	})
+
+	t.Run("Nothing Held For Cash", func(t *testing.T) {
+		walletClient := new(MockWalletClient)
+		svc := NewOrderService(nil, nil, nil, nil, walletClient, nil, CancellationPolicy{}, FarePolicy{})
+
+		err := svc.holdFare(ctx, orderID, "passenger-1", orderModel.PaymentMethodCash, 18000, pickupTime)
+
+		assert.NoError(t, err)
+		walletClient.AssertNotCalled(t, "PlaceHold", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCancelOrder_ClosesFareHold(t *testing.T) {
+	ctx := context.Background()
+	orderID := "550e8400-e29b-41d4-a716-446655440000"
+	var id pgtype.UUID
+	_ = id.Scan(orderID)
+
+	matched := db.Order{
+		ID:          id,
+		PassengerID: "passenger-1",
+		DriverID:    pgtype.Text{String: "driver-1", Valid: true},
+		Status:      "MATCHED",
+		MatchedAt:   pgtype.Timestamptz{Time: time.Now().Add(-5 * time.Minute), Valid: true},
+	}
+	cancelled := matched
+	cancelled.Status = "CANCELLED"
+
+	tests := []struct {
+		name          string
+		cancelledBy   string
+		wantAmount    float64
+		wantReference string
+	}{
+		// Late passenger cancellations pay the fee out of the held fare.
+		{"Captures Fee", CancelledByPassenger, 5000, "cancel-" + orderID},
+		{"Releases Without Fee", CancelledByDriver, 0, orderID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockStore)
+			trackerClient := new(MockTrackerClient)
+			svc := NewOrderService(store, nil, nil, nil, nil, trackerClient, CancellationPolicy{GracePeriod: 2 * time.Minute, Fee: 5000}, FarePolicy{})
+
+			store.On("GetOrder", mock.Anything, id).Return(matched, nil).Once()
+			store.On("CancelOrder", mock.Anything, mock.Anything).Return(db.CancelOrderRow{
+				ID:          id,
+				PassengerID: "passenger-1",
+				DriverID:    matched.DriverID,
+				Status:      "CANCELLED",
+				FromStatus:  "MATCHED",
+			}, nil).Once()
+			store.On("GetOrder", mock.Anything, id).Return(cancelled, nil).Once()
+			store.On("ReversePromoRedemption", mock.Anything, id).Return(int64(0), nil).Once()
+			store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+				return arg.Topic != walletTopic
+			})).Return(nil)
+			store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+				var debit orderModel.DebitBalanceEvent
+				return arg.Topic == walletTopic && json.Unmarshal(arg.Payload, &debit) == nil &&
+					debit.Hold == orderID && debit.Amount == tt.wantAmount && debit.Reference == tt.wantReference
+			})).Return(nil).Once()
+			trackerClient.On("ReleaseDriver", mock.Anything, mock.Anything).Return(nil).Once()
+
+			res, err := svc.CancelOrder(ctx, &order.CancelOrderRequest{OrderId: orderID, CancelledBy: tt.cancelledBy})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAmount, res.CancellationFee)
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCancelOrder_Cash(t *testing.T) {
+	ctx := context.Background()
+	orderID := "550e8400-e29b-41d4-a716-446655440000"
+	var id pgtype.UUID
+	_ = id.Scan(orderID)
+
+	matched := db.Order{
+		ID:            id,
+		PassengerID:   "passenger-1",
+		DriverID:      pgtype.Text{String: "driver-1", Valid: true},
+		Status:        "MATCHED",
+		MatchedAt:     pgtype.Timestamptz{Time: time.Now().Add(-5 * time.Minute), Valid: true},
+		PaymentMethod: orderModel.PaymentMethodCash,
+	}
+	cancelled := matched
+	cancelled.Status = "CANCELLED"
+
+	tests := []struct {
+		name        string
+		cancelledBy string
+		wantDebit   bool
+	}{
+		// Nothing was held, so the fee is debited from the wallet.
+		{"Debits Fee From Wallet", CancelledByPassenger, true},
+		{"Nothing To Release", CancelledByDriver, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockStore)
+			trackerClient := new(MockTrackerClient)
+			svc := NewOrderService(store, nil, nil, nil, nil, trackerClient, CancellationPolicy{GracePeriod: 2 * time.Minute, Fee: 5000}, FarePolicy{})
+
+			store.On("GetOrder", mock.Anything, id).Return(matched, nil).Once()
+			store.On("CancelOrder", mock.Anything, mock.Anything).Return(db.CancelOrderRow{
+				ID:          id,
+				PassengerID: "passenger-1",
+				DriverID:    matched.DriverID,
+				Status:      "CANCELLED",
+				FromStatus:  "MATCHED",
+			}, nil).Once()
+			store.On("GetOrder", mock.Anything, id).Return(cancelled, nil).Once()
+			store.On("ReversePromoRedemption", mock.Anything, id).Return(int64(0), nil).Once()
+			store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+				return arg.Topic != walletTopic
+			})).Return(nil)
+			if tt.wantDebit {
+				store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+					var debit orderModel.DebitBalanceEvent
+					return arg.Topic == walletTopic && json.Unmarshal(arg.Payload, &debit) == nil &&
+						debit.Method == orderModel.PaymentMethodWallet && debit.Hold == "" && debit.Amount == 5000
+				})).Return(nil).Once()
+			}
+			trackerClient.On("ReleaseDriver", mock.Anything, mock.Anything).Return(nil).Once()
+
+			_, err := svc.CancelOrder(ctx, &order.CancelOrderRequest{OrderId: orderID, CancelledBy: tt.cancelledBy})
+
+			assert.NoError(t, err)
+			store.AssertExpectations(t)
+			if !tt.wantDebit {
+				store.AssertNotCalled(t, "CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+					return arg.Topic == walletTopic
+				}))
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+)
+
+// transitions is the order lifecycle: the statuses an order may move to from
+// each status. PAID, CANCELLED and EXPIRED are final.
+var transitions = map[order.OrderStatus][]order.OrderStatus{
+	// Dispatch only reports SEARCHING when no driver is free right away, so a
+	// new order may be matched directly.
+	order.OrderStatus_CREATED:        {order.OrderStatus_SEARCHING, order.OrderStatus_MATCHED, order.OrderStatus_CANCELLED, order.OrderStatus_EXPIRED},
+	order.OrderStatus_SCHEDULED:      {order.OrderStatus_SEARCHING, order.OrderStatus_MATCHED, order.OrderStatus_CANCELLED, order.OrderStatus_EXPIRED},
+	order.OrderStatus_SEARCHING:      {order.OrderStatus_MATCHED, order.OrderStatus_CANCELLED, order.OrderStatus_EXPIRED},
+	order.OrderStatus_MATCHED:        {order.OrderStatus_DRIVER_ARRIVED, order.OrderStatus_CANCELLED},
+	order.OrderStatus_DRIVER_ARRIVED: {order.OrderStatus_STARTED, order.OrderStatus_CANCELLED},
+	order.OrderStatus_STARTED:        {order.OrderStatus_FINISHED},
+	// The Wallet Service reports whether the fare was charged. A failed
+	// payment is paid once the passenger settles it.
+	order.OrderStatus_FINISHED:       {order.OrderStatus_PAID, order.OrderStatus_PAYMENT_FAILED},
+	order.OrderStatus_PAYMENT_FAILED: {order.OrderStatus_PAID},
+}
+
+// canTransition reports whether an order may move from one status to another.
+func canTransition(from, to order.OrderStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// rideFinished reports whether an order is a ride that took place, whether or
+// not its fare was charged yet.
+func rideFinished(status order.OrderStatus) bool {
+	switch status {
+	case order.OrderStatus_FINISHED, order.OrderStatus_PAID, order.OrderStatus_PAYMENT_FAILED:
+		return true
+	}
+	return false
+}
+
+// sourcesOf lists the stored statuses an order may move to the given status
+// from. The queries only update orders in one of these, so a transition that
+// raced with another one fails instead of overwriting it.
+func sourcesOf(to order.OrderStatus) []string {
+	var sources []string
+	for from := order.OrderStatus(0); int(from) < len(order.OrderStatus_name); from++ {
+		if canTransition(from, to) {
+			sources = append(sources, from.String())
+		}
+	}
+	return sources
+}
+
+// parseStatus converts a status stored in Postgres to its proto enum.
+func parseStatus(s string) order.OrderStatus {
+	return order.OrderStatus(order.OrderStatus_value[s])
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) GetOrder(ctx context.Context, id pgtype.UUID) (db.Order, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(db.Order), args.Error(1)
+}
+
+func (m *MockStore) UpdateOrderStatus(ctx context.Context, arg db.UpdateOrderStatusParams) (string, error) {
+	args := m.Called(ctx, arg)
+	return args.String(0), args.Error(1)
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to order.OrderStatus
+		want     bool
+	}{
+		{order.OrderStatus_CREATED, order.OrderStatus_SEARCHING, true},
+		{order.OrderStatus_CREATED, order.OrderStatus_MATCHED, true},
+		{order.OrderStatus_MATCHED, order.OrderStatus_DRIVER_ARRIVED, true},
+		{order.OrderStatus_DRIVER_ARRIVED, order.OrderStatus_STARTED, true},
+		{order.OrderStatus_STARTED, order.OrderStatus_FINISHED, true},
+		{order.OrderStatus_CREATED, order.OrderStatus_FINISHED, false},
+		{order.OrderStatus_MATCHED, order.OrderStatus_STARTED, false},
+		{order.OrderStatus_STARTED, order.OrderStatus_CANCELLED, false},
+		{order.OrderStatus_FINISHED, order.OrderStatus_MATCHED, false},
+		{order.OrderStatus_FINISHED, order.OrderStatus_PAID, true},
+		{order.OrderStatus_FINISHED, order.OrderStatus_PAYMENT_FAILED, true},
+		{order.OrderStatus_PAYMENT_FAILED, order.OrderStatus_PAID, true},
+		{order.OrderStatus_PAID, order.OrderStatus_PAYMENT_FAILED, false},
+		{order.OrderStatus_CANCELLED, order.OrderStatus_MATCHED, false},
+		{order.OrderStatus_EXPIRED, order.OrderStatus_SEARCHING, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from.String()+"->"+tt.to.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, canTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestSourcesOf(t *testing.T) {
+	assert.Equal(t, []string{"CREATED", "SCHEDULED", "SEARCHING"}, sourcesOf(order.OrderStatus_MATCHED))
+	assert.Equal(t, []string{"CREATED", "SCHEDULED", "SEARCHING", "MATCHED", "DRIVER_ARRIVED"}, sourcesOf(order.OrderStatus_CANCELLED))
+	assert.Equal(t, []string{"STARTED"}, sourcesOf(order.OrderStatus_FINISHED))
+	assert.Equal(t, []string{"FINISHED", "PAYMENT_FAILED"}, sourcesOf(order.OrderStatus_PAID))
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	ctx := context.Background()
+	orderID := "550e8400-e29b-41d4-a716-446655440000"
+
+	var id pgtype.UUID
+	_ = id.Scan(orderID)
+	matched := db.Order{ID: id, Status: "MATCHED", DriverID: pgtype.Text{String: "driver-1", Valid: true}}
+
+	t.Run("Records Arrival", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		arrived := matched
+		arrived.Status = "DRIVER_ARRIVED"
+		store.On("GetOrder", mock.Anything, id).Return(matched, nil).Once()
+		store.On("GetOrder", mock.Anything, id).Return(arrived, nil).Once()
+		store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+			return arg.Topic == orderEventsTopic && arg.MessageKey == orderID
+		})).Return(nil).Once()
+		store.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(func(arg db.UpdateOrderStatusParams) bool {
+			return arg.Status == "DRIVER_ARRIVED" &&
+				assert.ObjectsAreEqual([]string{"MATCHED"}, arg.FromStatuses) &&
+				arg.Actor == ActorDriver && arg.ActorID.String == "driver-1"
+		})).Return("MATCHED", nil).Once()
+
+		res, err := svc.UpdateOrderStatus(ctx, &order.UpdateOrderStatusRequest{
+			OrderId: orderID,
+			Status:  order.OrderStatus_DRIVER_ARRIVED,
+			ActorId: "driver-1",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderStatus_DRIVER_ARRIVED, res.Status)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Rejects Skipping Ahead", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("GetOrder", mock.Anything, id).Return(matched, nil).Once()
+
+		_, err := svc.UpdateOrderStatus(ctx, &order.UpdateOrderStatusRequest{
+			OrderId: orderID,
+			Status:  order.OrderStatus_FINISHED,
+		})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		store.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects Other Driver", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("GetOrder", mock.Anything, id).Return(matched, nil).Once()
+
+		_, err := svc.UpdateOrderStatus(ctx, &order.UpdateOrderStatusRequest{
+			OrderId: orderID,
+			Status:  order.OrderStatus_DRIVER_ARRIVED,
+			ActorId: "driver-2",
+		})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var errInvalidPageToken = errors.New("invalid page token")
+
+// ListOrders pages through the rides of a passenger or a driver, newest first.
+// Pages are keyed by the last order of the previous page rather than an
+// offset, so orders created meanwhile neither shift nor repeat a page.
+func (s *Service) ListOrders(ctx context.Context, req *order.ListOrdersRequest) (*order.ListOrdersResponse, error) {
+	if req.PassengerId == "" && req.DriverId == "" {
+		return nil, status.Error(codes.InvalidArgument, "passenger_id or driver_id is required")
+	}
+
+	filters, err := orderFilters(req)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := req.PageSize
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	// One order more than the page tells whether there is a next one.
+	params := db.ListOrdersParams{
+		PassengerID: filters.PassengerID,
+		DriverID:    filters.DriverID,
+		Statuses:    filters.Statuses,
+		CreatedFrom: filters.CreatedFrom,
+		CreatedTo:   filters.CreatedTo,
+		PageSize:    pageSize + 1,
+	}
+	if req.PageToken != "" {
+		if params.AfterCreatedAt, params.AfterID, err = decodePageToken(req.PageToken); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	orders, err := s.store.ListOrders(dbCtx, params)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list orders: %v", err)
+	}
+	totals, err := s.store.CountOrders(dbCtx, filters)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to count orders: %v", err)
+	}
+
+	res := &order.ListOrdersResponse{
+		TotalCount:  totals.TotalCount,
+		TotalAmount: totals.TotalAmount,
+		TotalTips:   totals.TotalTips,
+	}
+	if len(orders) > int(pageSize) {
+		orders = orders[:pageSize]
+		res.NextPageToken = encodePageToken(orders[len(orders)-1])
+	}
+	for _, o := range orders {
+		res.Orders = append(res.Orders, orderResponse(o))
+	}
+	return res, nil
+}
+
+// orderFilters turns the filters of a listing request into query parameters.
+func orderFilters(req *order.ListOrdersRequest) (db.CountOrdersParams, error) {
+	// An empty list, unlike a nil one, is not NULL in the query and matches
+	// every status.
+	statuses := make([]string, 0, len(req.Statuses))
+	for _, st := range req.Statuses {
+		if st == order.OrderStatus_ORDER_STATUS_UNSPECIFIED {
+			return db.CountOrdersParams{}, status.Error(codes.InvalidArgument, "invalid status filter")
+		}
+		statuses = append(statuses, st.String())
+	}
+
+	filters := db.CountOrdersParams{
+		PassengerID: pgtype.Text{String: req.PassengerId, Valid: req.PassengerId != ""},
+		DriverID:    pgtype.Text{String: req.DriverId, Valid: req.DriverId != ""},
+		Statuses:    statuses,
+	}
+
+	var err error
+	if filters.CreatedFrom, err = parseTimeFilter("created_from", req.CreatedFrom); err != nil {
+		return db.CountOrdersParams{}, err
+	}
+	if filters.CreatedTo, err = parseTimeFilter("created_to", req.CreatedTo); err != nil {
+		return db.CountOrdersParams{}, err
+	}
+	return filters, nil
+}
+
+func parseTimeFilter(name, value string) (pgtype.Timestamptz, error) {
+	if value == "" {
+		return pgtype.Timestamptz{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return pgtype.Timestamptz{}, status.Errorf(codes.InvalidArgument, "invalid %s: %v", name, err)
+	}
+	return pgtype.Timestamptz{Time: t, Valid: true}, nil
+}
+
+// encodePageToken points at the order a page ended with. Postgres keeps
+// microseconds, so that is what the token carries.
+func encodePageToken(o db.Order) string {
+	raw := fmt.Sprintf("%d|%s", o.CreatedAt.Time.UnixMicro(), o.ID.String())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePageToken(token string) (pgtype.Timestamptz, pgtype.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pgtype.Timestamptz{}, pgtype.UUID{}, errInvalidPageToken
+	}
+
+	micros, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return pgtype.Timestamptz{}, pgtype.UUID{}, errInvalidPageToken
+	}
+	us, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return pgtype.Timestamptz{}, pgtype.UUID{}, errInvalidPageToken
+	}
+	var orderID pgtype.UUID
+	if err := orderID.Scan(id); err != nil {
+		return pgtype.Timestamptz{}, pgtype.UUID{}, errInvalidPageToken
+	}
+	return pgtype.Timestamptz{Time: time.UnixMicro(us), Valid: true}, orderID, nil
+}
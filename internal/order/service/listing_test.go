@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) ListOrders(ctx context.Context, arg db.ListOrdersParams) ([]db.Order, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.Order), args.Error(1)
+}
+
+func (m *MockStore) CountOrders(ctx context.Context, arg db.CountOrdersParams) (db.CountOrdersRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.CountOrdersRow), args.Error(1)
+}
+
+// passengerOrders returns n orders of passenger-1, newest first, a minute apart.
+func passengerOrders(n int) []db.Order {
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	orders := make([]db.Order, n)
+	for i := range orders {
+		var id pgtype.UUID
+		_ = id.Scan(fmt.Sprintf("550e8400-e29b-41d4-a716-4466554400%02d", i))
+		orders[i] = db.Order{
+			ID:          id,
+			PassengerID: "passenger-1",
+			Status:      "FINISHED",
+			CreatedAt:   pgtype.Timestamptz{Time: start.Add(-time.Duration(i) * time.Minute), Valid: true},
+		}
+	}
+	return orders
+}
+
+func TestListOrders(t *testing.T) {
+	ctx := context.Background()
+	orders := passengerOrders(3)
+	totals := db.CountOrdersRow{TotalCount: 3, TotalAmount: 54000}
+
+	t.Run("First Page", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("ListOrders", mock.Anything, mock.MatchedBy(func(arg db.ListOrdersParams) bool {
+			return arg.PassengerID.String == "passenger-1" && !arg.DriverID.Valid &&
+				assert.ObjectsAreEqual([]string{"FINISHED"}, arg.Statuses) &&
+				arg.CreatedFrom.Valid && !arg.CreatedTo.Valid &&
+				!arg.AfterCreatedAt.Valid && arg.PageSize == 3
+		})).Return(orders, nil).Once()
+		store.On("CountOrders", mock.Anything, mock.Anything).Return(totals, nil).Once()
+
+		res, err := svc.ListOrders(ctx, &order.ListOrdersRequest{
+			PassengerId: "passenger-1",
+			Statuses:    []order.OrderStatus{order.OrderStatus_FINISHED},
+			CreatedFrom: "2026-10-01T00:00:00Z",
+			PageSize:    2,
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, res.Orders, 2)
+		assert.Equal(t, orders[1].ID.String(), res.Orders[1].OrderId)
+		assert.NotEmpty(t, res.NextPageToken)
+		assert.Equal(t, int64(3), res.TotalCount)
+		assert.Equal(t, 54000.0, res.TotalAmount)
+		store.AssertExpectations(t)
+
+		at, id, err := decodePageToken(res.NextPageToken)
+		assert.NoError(t, err)
+		assert.True(t, orders[1].CreatedAt.Time.Equal(at.Time))
+		assert.Equal(t, orders[1].ID, id)
+	})
+
+	t.Run("Next Page", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("ListOrders", mock.Anything, mock.MatchedBy(func(arg db.ListOrdersParams) bool {
+			// No status filter is an empty list, never NULL.
+			return arg.Statuses != nil && len(arg.Statuses) == 0 &&
+				arg.AfterID == orders[1].ID && arg.PageSize == defaultPageSize+1
+		})).Return(orders[2:], nil).Once()
+		store.On("CountOrders", mock.Anything, mock.Anything).Return(totals, nil).Once()
+
+		res, err := svc.ListOrders(ctx, &order.ListOrdersRequest{
+			PassengerId: "passenger-1",
+			PageToken:   encodePageToken(orders[1]),
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, res.Orders, 1)
+		assert.Empty(t, res.NextPageToken)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Rejects Bad Requests", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		requests := []*order.ListOrdersRequest{
+			{},
+			{PassengerId: "passenger-1", PageToken: "not-a-token"},
+			{PassengerId: "passenger-1", CreatedTo: "yesterday"},
+			{PassengerId: "passenger-1", PageSize: -1},
+			{DriverId: "driver-1", Statuses: []order.OrderStatus{order.OrderStatus_ORDER_STATUS_UNSPECIFIED}},
+		}
+		for _, req := range requests {
+			_, err := svc.ListOrders(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+		store.AssertNotCalled(t, "ListOrders", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const orderEventsTopic = "order-events"
+
+// orderEventTypes names the event announcing that an order reached a status.
+var orderEventTypes = map[order.OrderStatus]string{
+	order.OrderStatus_CREATED:        orderModel.OrderEventCreated,
+	order.OrderStatus_SCHEDULED:      orderModel.OrderEventScheduled,
+	order.OrderStatus_SEARCHING:      orderModel.OrderEventSearching,
+	order.OrderStatus_MATCHED:        orderModel.OrderEventMatched,
+	order.OrderStatus_DRIVER_ARRIVED: orderModel.OrderEventDriverArrived,
+	order.OrderStatus_STARTED:        orderModel.OrderEventStarted,
+	order.OrderStatus_FINISHED:       orderModel.OrderEventFinished,
+	order.OrderStatus_PAID:           orderModel.OrderEventPaid,
+	order.OrderStatus_PAYMENT_FAILED: orderModel.OrderEventPaymentFailed,
+	order.OrderStatus_CANCELLED:      orderModel.OrderEventCancelled,
+	order.OrderStatus_EXPIRED:        orderModel.OrderEventExpired,
+}
+
+// newOrderEvent describes the status change that left the order as it is now.
+func newOrderEvent(o db.Order, fromStatus string, actor string, now time.Time) orderModel.OrderEvent {
+	return orderModel.OrderEvent{
+		Version:    orderModel.OrderEventVersion,
+		Type:       orderEventTypes[parseStatus(o.Status)],
+		OrderID:    o.ID.String(),
+		FromStatus: fromStatus,
+		Actor:      actor,
+		OccurredAt: now.Unix(),
+		Order:      orderSnapshot(o),
+	}
+}
+
+func orderSnapshot(o db.Order) orderModel.OrderSnapshot {
+	return orderModel.OrderSnapshot{
+		ID:              o.ID.String(),
+		PassengerID:     o.PassengerID,
+		DriverID:        o.DriverID.String,
+		Status:          o.Status,
+		VehicleType:     o.VehicleType,
+		Seats:           o.Seats,
+		PickupLat:       o.PickupLat,
+		PickupLong:      o.PickupLong,
+		DropoffLat:      o.DropoffLat,
+		DropoffLong:     o.DropoffLong,
+		Price:           o.Price,
+		TariffVersion:   o.TariffVersion.String,
+		SurgeMultiplier: o.SurgeMultiplier,
+		PromoCode:       o.PromoCode.String,
+		Discount:        o.Discount,
+		FinalPrice:      o.FinalPrice.Float64,
+		FareBasis:       o.FareBasis.String,
+		PoolTripID:      o.PoolTripID.String,
+		CancelledBy:     o.CancelledBy.String,
+		CancelReason:    o.CancelReason.String,
+		CancellationFee: o.CancellationFee,
+		CreatedAt:       unixTime(o.CreatedAt),
+		UpdatedAt:       unixTime(o.UpdatedAt),
+		ScheduledAt:     unixTime(o.ScheduledAt),
+		MatchedAt:       unixTime(o.MatchedAt),
+		CancelledAt:     unixTime(o.CancelledAt),
+		PaidAt:          unixTime(o.PaidAt),
+	}
+}
+
+// enqueueOrderEvent stores the event announcing the order's current status in
+// the outbox, in the transaction of q.
+func enqueueOrderEvent(ctx context.Context, q db.Querier, o db.Order, fromStatus string, actor string) error {
+	event := newOrderEvent(o, fromStatus, actor, time.Now())
+	return enqueueJSON(ctx, q, orderEventsTopic, event.OrderID, &event)
+}
+
+// enqueueTransition reads an order right after it changed status and stores
+// the event with the new snapshot.
+func enqueueTransition(ctx context.Context, q db.Querier, orderID pgtype.UUID, fromStatus string, actor string) error {
+	o, err := q.GetOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	return enqueueOrderEvent(ctx, q, o, fromStatus, actor)
+}
+
+// unixTime renders an optional timestamp as Unix seconds, 0 when unset.
+func unixTime(t pgtype.Timestamptz) int64 {
+	if !t.Valid {
+		return 0
+	}
+	return t.Time.Unix()
+}
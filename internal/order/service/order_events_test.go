@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+const orderEventSchemaPath = "../../../api/events/order-event.v1.schema.json"
+
+// cancelledOrder has every optional field set, so its event exercises the
+// whole schema.
+func cancelledOrder() db.Order {
+	var id pgtype.UUID
+	_ = id.Scan("550e8400-e29b-41d4-a716-446655440000")
+	at := func(sec int64) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: time.Unix(sec, 0), Valid: true}
+	}
+
+	return db.Order{
+		ID:              id,
+		PassengerID:     "passenger-1",
+		DriverID:        pgtype.Text{String: "driver-1", Valid: true},
+		PickupLat:       -6.2,
+		PickupLong:      106.8,
+		DropoffLat:      -6.25,
+		DropoffLong:     106.85,
+		Status:          "CANCELLED",
+		Price:           18000,
+		TariffVersion:   pgtype.Text{String: "2026-10-01", Valid: true},
+		SurgeMultiplier: 1.5,
+		PromoCode:       pgtype.Text{String: "HEMAT20", Valid: true},
+		Discount:        4500,
+		FinalPrice:      pgtype.Float8{Float64: 18000, Valid: true},
+		FareBasis:       pgtype.Text{String: FareBasisUpfront, Valid: true},
+		CreatedAt:       at(1760000000),
+		UpdatedAt:       at(1760000600),
+		MatchedAt:       at(1760000300),
+		CancelledAt:     at(1760000600),
+		CancelledBy:     pgtype.Text{String: CancelledByPassenger, Valid: true},
+		CancelReason:    pgtype.Text{String: "changed my mind", Valid: true},
+		CancellationFee: 5000,
+		ScheduledAt:     at(1759990000),
+		VehicleType:     VehicleTypePool,
+		Seats:           2,
+		PoolTripID:      pgtype.Text{String: "pool-1", Valid: true},
+	}
+}
+
+type eventSchema struct {
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+	Defs       struct {
+		Order struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"order"`
+	} `json:"$defs"`
+}
+
+func TestOrderEvent_Golden(t *testing.T) {
+	event := newOrderEvent(cancelledOrder(), "MATCHED", ActorPassenger, time.Unix(1760000601, 0))
+
+	payload, err := json.Marshal(event)
+	assert.NoError(t, err)
+
+	golden, err := os.ReadFile("testdata/order_event_v1.json")
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(golden), string(payload))
+
+	// Consumers built against v1 keep decoding it to the same event.
+	var decoded orderModel.OrderEvent
+	assert.NoError(t, json.Unmarshal(golden, &decoded))
+	assert.Equal(t, event, decoded)
+}
+
+func TestOrderEvent_MatchesSchema(t *testing.T) {
+	raw, err := os.ReadFile(orderEventSchemaPath)
+	assert.NoError(t, err)
+
+	var schema eventSchema
+	assert.NoError(t, json.Unmarshal(raw, &schema))
+
+	created := db.Order{ID: cancelledOrder().ID, PassengerID: "passenger-1", Status: "CREATED", VehicleType: VehicleTypeRide, Seats: 1}
+	events := map[string]orderModel.OrderEvent{
+		"full":    newOrderEvent(cancelledOrder(), "MATCHED", ActorPassenger, time.Unix(1760000601, 0)),
+		"minimal": newOrderEvent(created, "", ActorPassenger, time.Unix(1760000000, 0)),
+	}
+
+	for name, event := range events {
+		t.Run(name, func(t *testing.T) {
+			payload, err := json.Marshal(event)
+			assert.NoError(t, err)
+
+			var fields map[string]json.RawMessage
+			assert.NoError(t, json.Unmarshal(payload, &fields))
+			assertFields(t, fields, schema.Required, schema.Properties)
+
+			var snapshot map[string]json.RawMessage
+			assert.NoError(t, json.Unmarshal(fields["order"], &snapshot))
+			assertFields(t, snapshot, schema.Defs.Order.Required, schema.Defs.Order.Properties)
+		})
+	}
+}
+
+func TestOrderEvent_Types(t *testing.T) {
+	raw, err := os.ReadFile(orderEventSchemaPath)
+	assert.NoError(t, err)
+
+	var schema struct {
+		Properties struct {
+			Type struct {
+				Enum []string `json:"enum"`
+			} `json:"type"`
+		} `json:"properties"`
+	}
+	assert.NoError(t, json.Unmarshal(raw, &schema))
+
+	// Every status an order can reach has a documented event type.
+	for value, name := range order.OrderStatus_name {
+		if value == int32(order.OrderStatus_ORDER_STATUS_UNSPECIFIED) {
+			continue
+		}
+		assert.Contains(t, schema.Properties.Type.Enum, orderEventTypes[order.OrderStatus(value)], name)
+	}
+}
+
+func assertFields(t *testing.T, fields map[string]json.RawMessage, required []string, properties map[string]json.RawMessage) {
+	t.Helper()
+	for _, key := range required {
+		assert.Contains(t, fields, key, "required field missing")
+	}
+	for key := range fields {
+		assert.Contains(t, properties, key, "field not documented in the schema")
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/outbox"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// enqueueJSON stores an event in the outbox. Called with the querier of a
+// transaction, the event is only published if the transaction commits.
+func enqueueJSON(ctx context.Context, q db.Querier, topic string, key string, event any) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return q.CreateOutboxEvent(ctx, db.CreateOutboxEventParams{
+		Topic:      topic,
+		MessageKey: key,
+		Payload:    payload,
+	})
+}
+
+// NewOutboxStore exposes the order outbox table to an outbox.Relay.
+func NewOutboxStore(store db.Querier) outbox.Store {
+	return outbox.NewQueryStore(outbox.Queries[db.Outbox]{
+		Claim: func(ctx context.Context, leaseSeconds, batchSize int32) ([]db.Outbox, error) {
+			return store.ClaimOutboxEvents(ctx, db.ClaimOutboxEventsParams{
+				LeaseSeconds: leaseSeconds,
+				BatchSize:    batchSize,
+			})
+		},
+		MarkSent: store.MarkOutboxEventSent,
+		MarkFailed: func(ctx context.Context, id int64, retryInSeconds int32, lastError string) error {
+			return store.MarkOutboxEventFailed(ctx, db.MarkOutboxEventFailedParams{
+				LastError:      lastError,
+				RetryInSeconds: retryInSeconds,
+				ID:             id,
+			})
+		},
+		PurgeSent: func(ctx context.Context, before time.Time) (int64, error) {
+			return store.DeleteSentOutboxEvents(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+		},
+		Message: func(row db.Outbox) outbox.Message {
+			return outbox.Message{
+				ID:       row.ID,
+				Topic:    row.Topic,
+				Key:      row.MessageKey,
+				Payload:  row.Payload,
+				Attempts: row.Attempts,
+			}
+		},
+	})
+}
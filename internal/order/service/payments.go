@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/kafka"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Topics the Wallet Service reports the outcome of a DebitBalanceEvent on.
+const (
+	paymentSucceededTopic = "payment-succeeded"
+	paymentFailedTopic    = "payment-failed"
+)
+
+// PaymentPolicy decides how a fare that could not be charged is tried again.
+type PaymentPolicy struct {
+	// MaxAttempts is how many times a fare is charged, the first time
+	// included, before the order is PAYMENT_FAILED.
+	MaxAttempts int32
+	// RetryBase is the wait after the first failed attempt; it doubles with
+	// every further failure up to RetryMax.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Interval is how often the retrier polls Postgres for due retries.
+	Interval time.Duration
+	// BatchSize limits how many retries a single tick claims.
+	BatchSize int32
+}
+
+// retryIn is the wait before charging a fare again that already failed
+// attempts times.
+func (p PaymentPolicy) retryIn(attempts int32) time.Duration {
+	wait := p.RetryBase
+	for i := int32(1); i < attempts && wait < p.RetryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, p.RetryMax)
+}
+
+// retryable reports whether charging again may succeed after a failure with
+// the given gRPC code. Without a wallet there is nothing to retry; a balance
+// that is too low may be topped up in the meantime.
+func retryable(code string) bool {
+	switch code {
+	case codes.NotFound.String(), codes.InvalidArgument.String():
+		return false
+	}
+	return true
+}
+
+// PaymentWorker settles finished rides with the outcome of charging their
+// fare, which the Wallet Service publishes to payment-succeeded and
+// payment-failed. A successful charge makes the order PAID. A failed one is
+// retried with backoff, and once the attempts are used up the order becomes
+// PAYMENT_FAILED: the fare is recorded as the passenger's debt, which keeps
+// them from ordering until it is paid, and the held fare is released.
+type PaymentWorker struct {
+	consumer kafka.EventConsumer
+	store    db.Store
+	policy   PaymentPolicy
+}
+
+func NewPaymentWorker(consumer kafka.EventConsumer, store db.Store, policy PaymentPolicy) *PaymentWorker {
+	return &PaymentWorker{
+		consumer: consumer,
+		store:    store,
+		policy:   policy,
+	}
+}
+
+func (w *PaymentWorker) Start(ctx context.Context) error {
+	log.Println("Starting payment worker...")
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Payment worker stopping...")
+			return nil
+		default:
+		}
+
+		var event orderModel.PaymentResultEvent
+
+		m, err := w.consumer.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Print("Error fetching message")
+			continue
+		}
+
+		err = json.Unmarshal(m.Value, &event)
+		if err != nil {
+			log.Printf("❌ Failed to parse JSON for key=%s: %v", string(m.Key), err)
+			if err = w.consumer.CommitMessages(ctx, m); err != nil {
+				log.Printf("Failed to commit messages for key=%s: %v", string(m.Key), err)
+			}
+			continue
+		}
+
+		// Fares are charged under the order ID. Other charges, such as
+		// cancellation fees, don't change the order.
+		var orderID pgtype.UUID
+		if err = orderID.Scan(event.Reference); err != nil {
+			if err = w.consumer.CommitMessages(ctx, m); err != nil {
+				log.Printf("Failed to commit messages for key=%s: %v", string(m.Key), err)
+			}
+			continue
+		}
+
+		if m.Topic == paymentFailedTopic {
+			err = w.paymentFailed(ctx, orderID, event, time.Now())
+		} else {
+			err = w.paymentSucceeded(ctx, orderID)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("⚠️ Ignored payment result for order %s: order is not awaiting payment", event.Reference)
+		} else if err != nil {
+			log.Printf("❌ Failed to apply payment result for order %s: %v", event.Reference, err)
+			continue
+		}
+
+		if err = w.consumer.CommitMessages(ctx, m); err != nil {
+			log.Printf("Failed to commit messages for key=%s: %v", string(m.Key), err)
+		}
+	}
+}
+
+// paymentSucceeded marks an order PAID and settles the debt its failed
+// payment left, if any.
+func (w *PaymentWorker) paymentSucceeded(ctx context.Context, orderID pgtype.UUID) error {
+	return w.store.ExecTx(ctx, func(q db.Querier) error {
+		fromStatus, err := q.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
+			ID:           orderID,
+			FromStatuses: sourcesOf(order.OrderStatus_PAID),
+			Status:       order.OrderStatus_PAID.String(),
+			Actor:        ActorWallet,
+		})
+		if err != nil {
+			return err
+		}
+		if err = q.MarkOrderPaid(ctx, orderID); err != nil {
+			return err
+		}
+		if err = q.SettlePassengerDebt(ctx, orderID); err != nil {
+			return err
+		}
+		log.Printf("✅ Order %s is now PAID", orderID.String())
+		return enqueueTransition(ctx, q, orderID, fromStatus, ActorWallet)
+	})
+}
+
+// paymentFailed schedules another attempt to charge a finished ride, or gives
+// up on it once the attempts are used up or retrying can't help.
+func (w *PaymentWorker) paymentFailed(ctx context.Context, orderID pgtype.UUID, event orderModel.PaymentResultEvent, now time.Time) error {
+	return w.store.ExecTx(ctx, func(q db.Querier) error {
+		o, err := q.GetOrder(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		reason := event.Code + ": " + event.Reason
+
+		switch parseStatus(o.Status) {
+		case order.OrderStatus_FINISHED:
+		case order.OrderStatus_PAYMENT_FAILED:
+			// A payment retried by the passenger failed again; it stays owed.
+			return q.RecordPaymentFailure(ctx, db.RecordPaymentFailureParams{ID: orderID, PaymentError: reason})
+		default:
+			// Paid already, or charged for something else than the ride.
+			return pgx.ErrNoRows
+		}
+
+		attempts := o.PaymentAttempts + 1
+		if retryable(event.Code) && attempts < w.policy.MaxAttempts {
+			retryIn := w.policy.retryIn(attempts)
+			log.Printf("⚠️ Payment of order %s failed (attempt %d), retrying in %s: %s", orderID.String(), attempts, retryIn, reason)
+			return q.RecordPaymentFailure(ctx, db.RecordPaymentFailureParams{
+				ID:           orderID,
+				PaymentError: reason,
+				RetryAt:      pgtype.Timestamptz{Time: now.Add(retryIn), Valid: true},
+			})
+		}
+
+		if err = q.RecordPaymentFailure(ctx, db.RecordPaymentFailureParams{ID: orderID, PaymentError: reason}); err != nil {
+			return err
+		}
+		fromStatus, err := q.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
+			ID:           orderID,
+			FromStatuses: sourcesOf(order.OrderStatus_PAYMENT_FAILED),
+			Status:       order.OrderStatus_PAYMENT_FAILED.String(),
+			Actor:        ActorWallet,
+			Reason:       pgtype.Text{String: reason, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		err = q.CreatePassengerDebt(ctx, db.CreatePassengerDebtParams{
+			OrderID:     orderID,
+			PassengerID: o.PassengerID,
+			Amount:      chargeFor(o),
+			Reason:      pgtype.Text{String: reason, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		// The debt replaces the hold, so the passenger's money isn't tied up
+		// until it expires.
+		if err = enqueueHoldRelease(ctx, q, o); err != nil {
+			return err
+		}
+		log.Printf("❌ Payment of order %s failed after %d attempts: %s", orderID.String(), attempts, reason)
+		return enqueueTransition(ctx, q, orderID, fromStatus, ActorWallet)
+	})
+}
+
+// PaymentRetrier charges the fare of finished rides again once their retry is
+// due. Retries are claimed in Postgres with FOR UPDATE SKIP LOCKED, so each
+// runs exactly once even with several replicas.
+type PaymentRetrier struct {
+	store  db.Store
+	policy PaymentPolicy
+}
+
+func NewPaymentRetrier(store db.Store, policy PaymentPolicy) *PaymentRetrier {
+	return &PaymentRetrier{
+		store:  store,
+		policy: policy,
+	}
+}
+
+func (r *PaymentRetrier) Run(ctx context.Context) {
+	log.Println("Starting payment retrier...")
+	ticker := time.NewTicker(r.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Payment retrier stopping...")
+			return
+		case <-ticker.C:
+			if err := r.RetryDue(ctx, time.Now()); err != nil {
+				log.Printf("❌ Failed to retry payments: %v", err)
+			}
+		}
+	}
+}
+
+// RetryDue claims the payments due to be retried and queues their charge in
+// the same transaction, so a retry is neither lost nor charged twice.
+func (r *PaymentRetrier) RetryDue(ctx context.Context, now time.Time) error {
+	return r.store.ExecTx(ctx, func(q db.Querier) error {
+		orders, err := q.ClaimPaymentRetries(ctx, db.ClaimPaymentRetriesParams{
+			DueBefore: pgtype.Timestamptz{Time: now, Valid: true},
+			BatchSize: r.policy.BatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, o := range orders {
+			if err = enqueuePayment(ctx, q, o); err != nil {
+				return err
+			}
+			log.Printf("💳 Retrying payment of order %s (attempt %d)", o.ID.String(), o.PaymentAttempts+1)
+		}
+		return nil
+	})
+}
+
+// RetryPayment charges a ride whose payment failed again, e.g. once the
+// passenger topped up their wallet. The order becomes PAID, and the debt is
+// settled, when the Wallet Service reports the charge went through.
+func (s *Service) RetryPayment(ctx context.Context, req *order.RetryPaymentRequest) (*order.RetryPaymentResponse, error) {
+	var orderID pgtype.UUID
+	if err := orderID.Scan(req.OrderId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	orderDetail, err := s.store.GetOrder(dbCtx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "order not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
+	}
+	if orderDetail.PassengerID != req.PassengerId {
+		return nil, status.Error(codes.PermissionDenied, "order does not belong to the caller")
+	}
+	current := parseStatus(orderDetail.Status)
+	if current != order.OrderStatus_PAYMENT_FAILED {
+		return nil, status.Errorf(codes.FailedPrecondition, "order is %s, not %s", current, order.OrderStatus_PAYMENT_FAILED)
+	}
+
+	// The hold was released when the payment failed, so the fare is debited
+	// from the balance. The Wallet Service debits an order once, so retrying
+	// twice doesn't charge twice.
+	amount := chargeFor(orderDetail)
+	event := orderModel.DebitBalanceEvent{
+		Amount:    amount,
+		UserID:    orderDetail.PassengerID,
+		Reference: req.OrderId,
+		Method:    orderDetail.PaymentMethod,
+		Payout:    payoutOf(orderDetail),
+	}
+	if err = enqueueJSON(dbCtx, s.store, walletTopic, req.OrderId, &event); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to retry payment: %v", err)
+	}
+
+	return &order.RetryPaymentResponse{
+		OrderId: req.OrderId,
+		Status:  current,
+		Amount:  amount,
+	}, nil
+}
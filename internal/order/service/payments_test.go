@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) MarkOrderPaid(ctx context.Context, id pgtype.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStore) RecordPaymentFailure(ctx context.Context, arg db.RecordPaymentFailureParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockStore) ClaimPaymentRetries(ctx context.Context, arg db.ClaimPaymentRetriesParams) ([]db.Order, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.Order), args.Error(1)
+}
+
+func (m *MockStore) CreatePassengerDebt(ctx context.Context, arg db.CreatePassengerDebtParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockStore) SettlePassengerDebt(ctx context.Context, orderID pgtype.UUID) error {
+	args := m.Called(ctx, orderID)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetPassengerDebt(ctx context.Context, passengerID string) (float64, error) {
+	args := m.Called(ctx, passengerID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+var testPaymentPolicy = PaymentPolicy{MaxAttempts: 3, RetryBase: time.Minute, RetryMax: 10 * time.Minute, BatchSize: 10}
+
+func finishedOrder() db.Order {
+	var id pgtype.UUID
+	_ = id.Scan("550e8400-e29b-41d4-a716-446655440000")
+	return db.Order{
+		ID:            id,
+		PassengerID:   "passenger-1",
+		DriverID:      pgtype.Text{String: "driver-1", Valid: true},
+		Status:        "FINISHED",
+		Price:         18000,
+		FinalPrice:    pgtype.Float8{Float64: 21000, Valid: true},
+		PaymentMethod: "WALLET",
+	}
+}
+
+// orderEventOf matches the order-events message announcing the given type.
+func orderEventOf(eventType string) any {
+	return mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var e orderModel.OrderEvent
+		return arg.Topic == orderEventsTopic && json.Unmarshal(arg.Payload, &e) == nil && e.Type == eventType
+	})
+}
+
+func TestPaymentPolicy_RetryIn(t *testing.T) {
+	assert.Equal(t, time.Minute, testPaymentPolicy.retryIn(1))
+	assert.Equal(t, 2*time.Minute, testPaymentPolicy.retryIn(2))
+	assert.Equal(t, 4*time.Minute, testPaymentPolicy.retryIn(3))
+	assert.Equal(t, 10*time.Minute, testPaymentPolicy.retryIn(10))
+}
+
+func TestPaymentWorker_Succeeded(t *testing.T) {
+	ctx := context.Background()
+	o := finishedOrder()
+	paid := o
+	paid.Status = "PAID"
+
+	store := new(MockStore)
+	worker := NewPaymentWorker(nil, store, testPaymentPolicy)
+
+	store.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(func(arg db.UpdateOrderStatusParams) bool {
+		return arg.ID == o.ID && arg.Status == "PAID" && arg.Actor == ActorWallet &&
+			assert.ObjectsAreEqual([]string{"FINISHED", "PAYMENT_FAILED"}, arg.FromStatuses)
+	})).Return("FINISHED", nil).Once()
+	store.On("MarkOrderPaid", mock.Anything, o.ID).Return(nil).Once()
+	store.On("SettlePassengerDebt", mock.Anything, o.ID).Return(nil).Once()
+	store.On("GetOrder", mock.Anything, o.ID).Return(paid, nil).Once()
+	store.On("CreateOutboxEvent", mock.Anything, orderEventOf(orderModel.OrderEventPaid)).Return(nil).Once()
+
+	err := worker.paymentSucceeded(ctx, o.ID)
+
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestPaymentWorker_Failed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Retried With Backoff", func(t *testing.T) {
+		o := finishedOrder()
+		o.PaymentAttempts = 1
+		store := new(MockStore)
+		worker := NewPaymentWorker(nil, store, testPaymentPolicy)
+
+		store.On("GetOrder", mock.Anything, o.ID).Return(o, nil).Once()
+		store.On("RecordPaymentFailure", mock.Anything, db.RecordPaymentFailureParams{
+			ID:           o.ID,
+			PaymentError: "Unavailable: connection refused",
+			RetryAt:      pgtype.Timestamptz{Time: now.Add(2 * time.Minute), Valid: true},
+		}).Return(nil).Once()
+
+		err := worker.paymentFailed(ctx, o.ID, orderModel.PaymentResultEvent{Code: "Unavailable", Reason: "connection refused"}, now)
+
+		assert.NoError(t, err)
+		store.AssertExpectations(t)
+		store.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	})
+
+	tests := []struct {
+		name     string
+		attempts int32
+		code     string
+	}{
+		{"Attempts Used Up", 2, codes.FailedPrecondition.String()},
+		{"Not Retryable", 0, codes.NotFound.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := finishedOrder()
+			o.PaymentAttempts = tt.attempts
+			failed := o
+			failed.Status = "PAYMENT_FAILED"
+			reason := tt.code + ": no luck"
+
+			store := new(MockStore)
+			worker := NewPaymentWorker(nil, store, testPaymentPolicy)
+
+			store.On("GetOrder", mock.Anything, o.ID).Return(o, nil).Once()
+			store.On("RecordPaymentFailure", mock.Anything, db.RecordPaymentFailureParams{ID: o.ID, PaymentError: reason}).Return(nil).Once()
+			store.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(func(arg db.UpdateOrderStatusParams) bool {
+				return arg.Status == "PAYMENT_FAILED" && arg.Actor == ActorWallet && arg.Reason.String == reason
+			})).Return("FINISHED", nil).Once()
+			// The fare becomes the passenger's debt.
+			store.On("CreatePassengerDebt", mock.Anything, db.CreatePassengerDebtParams{
+				OrderID:     o.ID,
+				PassengerID: "passenger-1",
+				Amount:      21000,
+				Reason:      pgtype.Text{String: reason, Valid: true},
+			}).Return(nil).Once()
+			// And the held fare is released.
+			store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+				var debit orderModel.DebitBalanceEvent
+				return arg.Topic == walletTopic && json.Unmarshal(arg.Payload, &debit) == nil &&
+					debit.Amount == 0 && debit.Hold == o.ID.String()
+			})).Return(nil).Once()
+			store.On("GetOrder", mock.Anything, o.ID).Return(failed, nil).Once()
+			store.On("CreateOutboxEvent", mock.Anything, orderEventOf(orderModel.OrderEventPaymentFailed)).Return(nil).Once()
+
+			err := worker.paymentFailed(ctx, o.ID, orderModel.PaymentResultEvent{Code: tt.code, Reason: "no luck"}, now)
+
+			assert.NoError(t, err)
+			store.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Already Paid", func(t *testing.T) {
+		o := finishedOrder()
+		o.Status = "PAID"
+		store := new(MockStore)
+		worker := NewPaymentWorker(nil, store, testPaymentPolicy)
+
+		store.On("GetOrder", mock.Anything, o.ID).Return(o, nil).Once()
+
+		err := worker.paymentFailed(ctx, o.ID, orderModel.PaymentResultEvent{Code: "Unavailable"}, now)
+
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		store.AssertExpectations(t)
+	})
+}
+
+func TestPaymentRetrier_RetryDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	o := finishedOrder()
+	o.PaymentAttempts = 1
+
+	store := new(MockStore)
+	retrier := NewPaymentRetrier(store, testPaymentPolicy)
+
+	store.On("ClaimPaymentRetries", mock.Anything, db.ClaimPaymentRetriesParams{
+		DueBefore: pgtype.Timestamptz{Time: now, Valid: true},
+		BatchSize: 10,
+	}).Return([]db.Order{o}, nil).Once()
+	store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var debit orderModel.DebitBalanceEvent
+		return arg.Topic == walletTopic && json.Unmarshal(arg.Payload, &debit) == nil &&
+			debit.Amount == 21000 && debit.Reference == o.ID.String() && debit.Hold == o.ID.String()
+	})).Return(nil).Once()
+
+	err := retrier.RetryDue(ctx, now)
+
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestEnqueuePayment_Cash(t *testing.T) {
+	o := paidOrder()
+	o.PaymentMethod = orderModel.PaymentMethodCash
+
+	store := new(MockStore)
+	store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var debit orderModel.DebitBalanceEvent
+		return arg.Topic == walletTopic && json.Unmarshal(arg.Payload, &debit) == nil &&
+			debit.Method == orderModel.PaymentMethodCash && debit.Amount == 21000 && debit.Payout != nil &&
+			debit.Payout.Commission == 5200 && debit.Payout.Subsidy == 5000
+	})).Return(nil).Once()
+
+	err := enqueuePayment(context.Background(), store, o)
+
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+}
+
+func TestEnqueueHoldRelease_Cash(t *testing.T) {
+	o := finishedOrder()
+	o.PaymentMethod = orderModel.PaymentMethodCash
+
+	// Nothing was held, so nothing is written.
+	assert.NoError(t, enqueueHoldRelease(context.Background(), new(MockStore), o))
+}
+
+func TestRetryPayment(t *testing.T) {
+	ctx := context.Background()
+	failed := finishedOrder()
+	failed.Status = "PAYMENT_FAILED"
+	orderID := failed.ID.String()
+
+	t.Run("Charges The Balance", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("GetOrder", mock.Anything, failed.ID).Return(failed, nil).Once()
+		store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+			var debit orderModel.DebitBalanceEvent
+			return arg.Topic == walletTopic && json.Unmarshal(arg.Payload, &debit) == nil &&
+				debit.Amount == 21000 && debit.Reference == orderID && debit.Hold == ""
+		})).Return(nil).Once()
+
+		res, err := svc.RetryPayment(ctx, &order.RetryPaymentRequest{OrderId: orderID, PassengerId: "passenger-1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderStatus_PAYMENT_FAILED, res.Status)
+		assert.Equal(t, 21000.0, res.Amount)
+		store.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name      string
+		passenger string
+		status    string
+		wantCode  codes.Code
+	}{
+		{"Not The Passenger", "passenger-2", "PAYMENT_FAILED", codes.PermissionDenied},
+		{"Not Failed", "passenger-1", "PAID", codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := failed
+			o.Status = tt.status
+			store := new(MockStore)
+			svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+			store.On("GetOrder", mock.Anything, o.ID).Return(o, nil).Once()
+
+			_, err := svc.RetryPayment(ctx, &order.RetryPaymentRequest{OrderId: orderID, PassengerId: tt.passenger})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			store.AssertNotCalled(t, "CreateOutboxEvent", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateOrder_BlockedByDebt(t *testing.T) {
+	store := new(MockStore)
+	svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+	store.On("GetPassengerDebt", mock.Anything, "passenger-1").Return(21000.0, nil).Once()
+
+	_, err := svc.CreateOrder(context.Background(), &order.CreateOrderRequest{
+		UserId:      "passenger-1",
+		PickupLat:   -6.2,
+		PickupLong:  106.8,
+		DropoffLat:  -6.25,
+		DropoffLong: 106.85,
+	})
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	PromoPercent = "PERCENT"
+	PromoFixed   = "FIXED"
+)
+
+// promoRejection is why a promo code cannot be used for an order. It is shown
+// to the passenger, unlike other errors.
+type promoRejection struct {
+	reason string
+}
+
+func (r *promoRejection) Error() string {
+	return r.reason
+}
+
+func rejectPromo(format string, args ...any) error {
+	return &promoRejection{reason: fmt.Sprintf(format, args...)}
+}
+
+// ValidatePromo tells the passenger what a promo code takes off a trip,
+// without redeeming it.
+func (s *Service) ValidatePromo(ctx context.Context, req *order.ValidatePromoRequest) (*order.ValidatePromoResponse, error) {
+	trip, err := newTrip(req.UserId, req.VehicleType, req.Seats, req.PickupLat, req.PickupLong, req.DropoffLat, req.DropoffLong, req.Stops)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	f, err := s.priceTrip(ctx, trip, now, true)
+	if err != nil {
+		return nil, err
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res := &order.ValidatePromoResponse{Price: f.price, DiscountedPrice: f.price}
+	campaign, err := lookupPromo(dbCtx, s.store, req.Code, req.UserId)
+	if err == nil {
+		res.CampaignName = campaign.Name
+		var discount float64
+		if discount, err = checkPromo(campaign, trip.VehicleType, f.city, f.price, now); err == nil {
+			res.Valid = true
+			res.Discount = discount
+			res.DiscountedPrice = f.price - discount
+		}
+	}
+
+	var rejection *promoRejection
+	if errors.As(err, &rejection) {
+		res.Reason = rejection.reason
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to validate promo: %v", err)
+	}
+	return res, nil
+}
+
+// promoError turns a failure to order with a promo code into a gRPC status.
+func promoError(err error) error {
+	var rejection *promoRejection
+	if errors.As(err, &rejection) {
+		return status.Error(codes.FailedPrecondition, rejection.reason)
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// lookupPromo finds the campaign of a code the user may still redeem.
+func lookupPromo(ctx context.Context, q db.Querier, code, userID string) (db.PromoCampaign, error) {
+	campaign, err := q.GetPromoCampaignByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.PromoCampaign{}, rejectPromo("promo code %s does not exist", code)
+	}
+	if err != nil {
+		return db.PromoCampaign{}, err
+	}
+
+	used, err := q.CountUserPromoRedemptions(ctx, db.CountUserPromoRedemptionsParams{CampaignID: campaign.ID, UserID: userID})
+	if err != nil {
+		return db.PromoCampaign{}, err
+	}
+	if used >= int64(campaign.MaxPerUser) {
+		return db.PromoCampaign{}, rejectPromo("promo code %s was already used", campaign.Code)
+	}
+	return campaign, nil
+}
+
+// checkPromo returns the discount a campaign gives a fare, or why it does not
+// apply. city is the tariff city of the pickup.
+func checkPromo(c db.PromoCampaign, vehicleType, city string, fare float64, now time.Time) (float64, error) {
+	switch {
+	case !c.Active:
+		return 0, rejectPromo("promo code %s is no longer available", c.Code)
+	case now.Before(c.StartsAt.Time):
+		return 0, rejectPromo("promo code %s is not valid yet", c.Code)
+	case !now.Before(c.EndsAt.Time):
+		return 0, rejectPromo("promo code %s has expired", c.Code)
+	case len(c.VehicleTypes) > 0 && !slices.Contains(c.VehicleTypes, vehicleType):
+		return 0, rejectPromo("promo code %s is not valid for %s", c.Code, vehicleType)
+	case len(c.Cities) > 0 && !slices.Contains(c.Cities, city):
+		return 0, rejectPromo("promo code %s is not valid in this area", c.Code)
+	case fare < c.MinFare:
+		return 0, rejectPromo("promo code %s needs a fare of at least %.0f", c.Code, c.MinFare)
+	case c.MaxRedemptions.Valid && c.Redemptions >= c.MaxRedemptions.Int32:
+		return 0, rejectPromo("promo code %s has been fully redeemed", c.Code)
+	}
+	return promoDiscount(c, fare), nil
+}
+
+// promoDiscount is what a campaign takes off a fare, never more than the fare.
+func promoDiscount(c db.PromoCampaign, fare float64) float64 {
+	discount := c.DiscountValue
+	if c.DiscountType == PromoPercent {
+		discount = fare * c.DiscountValue / 100
+		if c.MaxDiscount.Valid {
+			discount = math.Min(discount, c.MaxDiscount.Float64)
+		}
+	}
+	return math.Round(math.Min(discount, fare))
+}
+
+// claimPromo counts a redemption against the campaign inside the order's
+// transaction. The claim locks the campaign row, so the usage limits are
+// checked again here against every redemption committed before it.
+func claimPromo(ctx context.Context, q db.Querier, c db.PromoCampaign, userID string) error {
+	if _, err := q.ClaimPromoCampaign(ctx, c.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return rejectPromo("promo code %s has been fully redeemed", c.Code)
+		}
+		return err
+	}
+
+	used, err := q.CountUserPromoRedemptions(ctx, db.CountUserPromoRedemptionsParams{CampaignID: c.ID, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if used >= int64(c.MaxPerUser) {
+		return rejectPromo("promo code %s was already used", c.Code)
+	}
+	return nil
+}
+
+// reversePromo gives back the promo a cancelled or expired order used.
+func reversePromo(ctx context.Context, q db.Querier, orderID pgtype.UUID) error {
+	_, err := q.ReversePromoRedemption(ctx, orderID)
+	return err
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) CountUserPromoRedemptions(ctx context.Context, arg db.CountUserPromoRedemptionsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStore) ClaimPromoCampaign(ctx context.Context, id int64) (int32, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockStore) CancelOrder(ctx context.Context, arg db.CancelOrderParams) (db.CancelOrderRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.CancelOrderRow), args.Error(1)
+}
+
+func (m *MockStore) ReversePromoRedemption(ctx context.Context, orderID pgtype.UUID) (int64, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func testCampaign() db.PromoCampaign {
+	return db.PromoCampaign{
+		ID:            7,
+		Code:          "HEMAT20",
+		DiscountType:  PromoPercent,
+		DiscountValue: 20,
+		MaxDiscount:   pgtype.Float8{Float64: 10000, Valid: true},
+		MinFare:       15000,
+		StartsAt:      pgtype.Timestamptz{Time: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		EndsAt:        pgtype.Timestamptz{Time: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		MaxPerUser:    1,
+		VehicleTypes:  []string{VehicleTypeRide, VehicleTypeCar},
+		Cities:        []string{"jakarta"},
+		Active:        true,
+	}
+}
+
+func TestCheckPromo(t *testing.T) {
+	now := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		edit         func(c *db.PromoCampaign)
+		vehicleType  string
+		city         string
+		fare         float64
+		wantDiscount float64
+		wantErr      string
+	}{
+		{"Percent", nil, VehicleTypeRide, "jakarta", 25000, 5000, ""},
+		{"Percent Capped", nil, VehicleTypeCar, "jakarta", 80000, 10000, ""},
+		{"Fixed", func(c *db.PromoCampaign) { c.DiscountType, c.DiscountValue = PromoFixed, 7500 }, VehicleTypeRide, "jakarta", 25000, 7500, ""},
+		{"Fixed Above Fare", func(c *db.PromoCampaign) { c.DiscountType, c.DiscountValue, c.MinFare = PromoFixed, 30000, 0 }, VehicleTypeRide, "jakarta", 25000, 25000, ""},
+		{"Inactive", func(c *db.PromoCampaign) { c.Active = false }, VehicleTypeRide, "jakarta", 25000, 0, "promo code HEMAT20 is no longer available"},
+		{"Not Started", func(c *db.PromoCampaign) { c.StartsAt.Time = now.Add(time.Hour) }, VehicleTypeRide, "jakarta", 25000, 0, "promo code HEMAT20 is not valid yet"},
+		{"Ended", func(c *db.PromoCampaign) { c.EndsAt.Time = now }, VehicleTypeRide, "jakarta", 25000, 0, "promo code HEMAT20 has expired"},
+		{"Vehicle Type", nil, VehicleTypePool, "jakarta", 25000, 0, "promo code HEMAT20 is not valid for go-pool"},
+		{"City", nil, VehicleTypeRide, "bandung", 25000, 0, "promo code HEMAT20 is not valid in this area"},
+		{"Everywhere", func(c *db.PromoCampaign) { c.Cities = nil }, VehicleTypeRide, "bandung", 25000, 5000, ""},
+		{"Minimum Fare", nil, VehicleTypeRide, "jakarta", 10000, 0, "promo code HEMAT20 needs a fare of at least 15000"},
+		{"Used Up", func(c *db.PromoCampaign) { c.MaxRedemptions, c.Redemptions = pgtype.Int4{Int32: 100, Valid: true}, 100 }, VehicleTypeRide, "jakarta", 25000, 0, "promo code HEMAT20 has been fully redeemed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCampaign()
+			if tt.edit != nil {
+				tt.edit(&c)
+			}
+
+			discount, err := checkPromo(c, tt.vehicleType, tt.city, tt.fare, now)
+
+			if tt.wantErr != "" {
+				var rejection *promoRejection
+				assert.ErrorAs(t, err, &rejection)
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDiscount, discount)
+		})
+	}
+}
+
+func TestClaimPromo(t *testing.T) {
+	ctx := context.Background()
+	c := testCampaign()
+	count := db.CountUserPromoRedemptionsParams{CampaignID: c.ID, UserID: "passenger-1"}
+
+	t.Run("Success", func(t *testing.T) {
+		store := new(MockStore)
+		store.On("ClaimPromoCampaign", ctx, c.ID).Return(int32(1), nil).Once()
+		store.On("CountUserPromoRedemptions", ctx, count).Return(int64(0), nil).Once()
+
+		assert.NoError(t, claimPromo(ctx, store, c, "passenger-1"))
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Fully Redeemed", func(t *testing.T) {
+		store := new(MockStore)
+		store.On("ClaimPromoCampaign", ctx, c.ID).Return(int32(0), pgx.ErrNoRows).Once()
+
+		err := claimPromo(ctx, store, c, "passenger-1")
+		assert.EqualError(t, err, "promo code HEMAT20 has been fully redeemed")
+		assert.Equal(t, codes.FailedPrecondition, status.Code(promoError(err)))
+	})
+
+	// Another order of the same user committed while this one waited for the campaign lock.
+	t.Run("Used By The User Meanwhile", func(t *testing.T) {
+		store := new(MockStore)
+		store.On("ClaimPromoCampaign", ctx, c.ID).Return(int32(2), nil).Once()
+		store.On("CountUserPromoRedemptions", ctx, count).Return(int64(1), nil).Once()
+
+		err := claimPromo(ctx, store, c, "passenger-1")
+		assert.EqualError(t, err, "promo code HEMAT20 was already used")
+	})
+}
+
+func TestCancelOrder_ReversesPromo(t *testing.T) {
+	ctx := context.Background()
+	orderID := "550e8400-e29b-41d4-a716-446655440000"
+	var uuid pgtype.UUID
+	_ = uuid.Scan(orderID)
+
+	store := new(MockStore)
+	svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+	created := db.Order{ID: uuid, PassengerID: "passenger-1", Status: "CREATED", PromoCode: pgtype.Text{String: "HEMAT20", Valid: true}, Discount: 5000}
+	cancelled := created
+	cancelled.Status = "CANCELLED"
+
+	store.On("GetOrder", mock.Anything, uuid).Return(created, nil).Once()
+	store.On("CancelOrder", mock.Anything, mock.MatchedBy(func(arg db.CancelOrderParams) bool {
+		return arg.ID == uuid
+	})).Return(db.CancelOrderRow{ID: uuid, PassengerID: "passenger-1", Status: "CANCELLED", FromStatus: "CREATED"}, nil).Once()
+	store.On("GetOrder", mock.Anything, uuid).Return(cancelled, nil).Once()
+	store.On("ReversePromoRedemption", mock.Anything, uuid).Return(int64(1), nil).Once()
+	store.On("CreateOutboxEvent", mock.Anything, mock.Anything).Return(nil)
+
+	res, err := svc.CancelOrder(ctx, &order.CancelOrderRequest{OrderId: orderID, CancelledBy: CancelledByPassenger})
+
+	assert.NoError(t, err)
+	assert.Equal(t, order.OrderStatus_CANCELLED, res.Status)
+	store.AssertExpectations(t)
+}
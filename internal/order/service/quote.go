@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/quote"
+	"github.com/dwikikusuma/atlas/internal/pricing"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetFareQuote prices a trip without ordering it. The quote's ID, passed to
+// CreateOrder before it expires, orders the trip at the quoted price.
+func (s *Service) GetFareQuote(ctx context.Context, req *order.GetFareQuoteRequest) (*order.GetFareQuoteResponse, error) {
+	trip, err := newTrip(req.UserId, req.VehicleType, req.Seats, req.PickupLat, req.PickupLong, req.DropoffLat, req.DropoffLong, req.Stops)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	f, err := s.priceTrip(ctx, trip, now, true)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := s.quotes.Issue(ctx, quote.Quote{
+		Trip:            trip,
+		Price:           f.price,
+		TariffVersion:   f.tariffVersion,
+		City:            f.city,
+		SurgeMultiplier: f.surge,
+		DistanceKm:      f.quote.DistanceKm,
+		DurationMin:     f.quote.DurationMin,
+		Breakdown: quote.Breakdown{
+			BaseFare:       f.quote.BaseFare,
+			DistanceFare:   f.quote.DistanceFare,
+			TimeFare:       f.quote.TimeFare,
+			Rule:           f.quote.Rule,
+			RuleMultiplier: f.quote.Multiplier,
+			MinimumFare:    f.quote.MinimumFare,
+			BookingFee:     f.quote.BookingFee,
+			PoolAdjustment: f.price - f.quote.Fare,
+		},
+	}, now)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to issue quote: %v", err)
+	}
+
+	b := q.Breakdown
+	return &order.GetFareQuoteResponse{
+		QuoteId: q.ID,
+		Price:   q.Price,
+		Breakdown: &order.FareBreakdown{
+			BaseFare:       b.BaseFare,
+			DistanceFare:   b.DistanceFare,
+			TimeFare:       b.TimeFare,
+			Rule:           b.Rule,
+			RuleMultiplier: b.RuleMultiplier,
+			MinimumFare:    b.MinimumFare,
+			BookingFee:     b.BookingFee,
+			PoolAdjustment: b.PoolAdjustment,
+		},
+		TariffVersion:   q.TariffVersion,
+		SurgeMultiplier: q.SurgeMultiplier,
+		DistanceKm:      q.DistanceKm,
+		DurationMin:     q.DurationMin,
+		ExpiresAt:       time.Unix(q.ExpiresAt, 0).UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// newTrip validates the vehicle type, party size and stops of a trip.
+func newTrip(userID, vehicleType string, seats int32, pickupLat, pickupLong, dropoffLat, dropoffLong float64, stops []*order.Waypoint) (quote.Trip, error) {
+	trip := quote.Trip{
+		UserID:      userID,
+		VehicleType: vehicleType,
+		Seats:       1,
+		PickupLat:   pickupLat,
+		PickupLong:  pickupLong,
+		DropoffLat:  dropoffLat,
+		DropoffLong: dropoffLong,
+	}
+	if trip.VehicleType == "" {
+		trip.VehicleType = VehicleTypeRide
+	}
+
+	switch trip.VehicleType {
+	case VehicleTypeRide, VehicleTypeCar:
+	case VehicleTypePool:
+		if seats > 0 {
+			trip.Seats = seats
+		}
+		if trip.Seats > maxPoolSeats {
+			return quote.Trip{}, status.Errorf(codes.InvalidArgument, "shared rides take at most %d seats", maxPoolSeats)
+		}
+	default:
+		return quote.Trip{}, status.Errorf(codes.InvalidArgument, "invalid vehicle_type: %s", vehicleType)
+	}
+
+	if len(stops) > 0 && trip.VehicleType == VehicleTypePool {
+		// The shared trip's route is planned by dispatch around every rider.
+		return quote.Trip{}, status.Error(codes.InvalidArgument, "shared rides can't make stops")
+	}
+	if len(stops) > maxStops {
+		return quote.Trip{}, status.Errorf(codes.InvalidArgument, "a ride makes at most %d stops", maxStops)
+	}
+	for _, stop := range stops {
+		trip.Stops = append(trip.Stops, quote.Stop{Lat: stop.Lat, Long: stop.Long})
+	}
+	return trip, nil
+}
+
+// fare is what a trip costs the passenger.
+type fare struct {
+	quote         pricing.Quote
+	price         float64
+	tariffVersion string
+	// city is the tariff city of the pickup.
+	city  string
+	surge float64
+}
+
+// priceTrip prices a trip starting at pickupTime. Today's demand says nothing
+// about a ride booked for later, so only immediate rides surge.
+func (s *Service) priceTrip(ctx context.Context, trip quote.Trip, pickupTime time.Time, immediate bool) (fare, error) {
+	pt := pricing.Trip{
+		VehicleType: trip.VehicleType,
+		PickupLat:   trip.PickupLat,
+		PickupLong:  trip.PickupLong,
+		DropoffLat:  trip.DropoffLat,
+		DropoffLong: trip.DropoffLong,
+		At:          pickupTime,
+	}
+	for _, stop := range trip.Stops {
+		pt.Stops = append(pt.Stops, pricing.Point{Lat: stop.Lat, Long: stop.Long})
+	}
+	if immediate {
+		// A surge we cannot read is not worth refusing the ride over.
+		multiplier, err := s.surge.MultiplierAt(ctx, trip.PickupLat, trip.PickupLong)
+		if err != nil {
+			log.Printf("⚠️ Pricing without surge: %v", err)
+		}
+		pt.Surge = multiplier
+	}
+
+	q, err := s.pricer.Quote(pt)
+	if err != nil {
+		if errors.Is(err, pricing.ErrNoTariff) {
+			return fare{}, status.Errorf(codes.InvalidArgument, "%s is not available for this trip", trip.VehicleType)
+		}
+		return fare{}, status.Errorf(codes.Internal, "failed to price trip: %v", err)
+	}
+
+	price := q.Fare
+	if trip.VehicleType == VehicleTypePool {
+		price = poolFare(price, trip.Seats)
+	}
+	return fare{quote: q, price: price, tariffVersion: q.TariffVersion, city: q.City, surge: q.Surge}, nil
+}
+
+func quoteError(err error) error {
+	switch {
+	case errors.Is(err, quote.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, quote.ErrExpired):
+		return status.Error(codes.FailedPrecondition, "quote expired, request a new one")
+	case errors.Is(err, quote.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, quote.ErrUsed):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "failed to redeem quote: %v", err)
+	}
+}
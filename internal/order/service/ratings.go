@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	lowRatingsTopic = "low-ratings"
+
+	// ratingWindow is how many of a user's most recent ratings the average covers,
+	// so a bad month can be made up for.
+	ratingWindow = 100
+	// lowRatingStars and below are flagged for review.
+	lowRatingStars = 2
+
+	maxRatingTags      = 5
+	maxRatingTagLength = 32
+	maxRatingComment   = 500
+	maxRatingLookups   = 100
+)
+
+var errAlreadyRated = errors.New("order was already rated")
+
+// RateOrder records what one side of a finished ride thinks of the other and
+// updates the rated user's average in the same transaction.
+func (s *Service) RateOrder(ctx context.Context, req *order.RateOrderRequest) (*order.RateOrderResponse, error) {
+	if req.RaterRole != ActorPassenger && req.RaterRole != ActorDriver {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid rater_role: %s", req.RaterRole)
+	}
+	if req.RaterId == "" {
+		return nil, status.Error(codes.InvalidArgument, "rater_id is required")
+	}
+	if req.Stars < 1 || req.Stars > 5 {
+		return nil, status.Error(codes.InvalidArgument, "stars must be between 1 and 5")
+	}
+	tags, err := ratingTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Comment) > maxRatingComment {
+		return nil, status.Errorf(codes.InvalidArgument, "comment must be at most %d characters", maxRatingComment)
+	}
+
+	var orderID pgtype.UUID
+	if err := orderID.Scan(req.OrderId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	orderDetail, err := s.store.GetOrder(dbCtx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "order not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
+	}
+	if !rideFinished(parseStatus(orderDetail.Status)) {
+		return nil, status.Error(codes.FailedPrecondition, "only finished rides can be rated")
+	}
+
+	rater, ratee, rateeRole := orderDetail.PassengerID, orderDetail.DriverID.String, ActorDriver
+	if req.RaterRole == ActorDriver {
+		rater, ratee, rateeRole = orderDetail.DriverID.String, orderDetail.PassengerID, ActorPassenger
+	}
+	if req.RaterId != rater {
+		return nil, status.Error(codes.PermissionDenied, "order does not belong to the caller")
+	}
+
+	var standing db.UserRating
+	err = s.store.ExecTx(dbCtx, func(q db.Querier) error {
+		rating, err := q.CreateRating(dbCtx, db.CreateRatingParams{
+			OrderID:   orderID,
+			RaterID:   rater,
+			RateeID:   ratee,
+			RateeRole: rateeRole,
+			Stars:     int16(req.Stars),
+			Tags:      tags,
+			Comment:   pgtype.Text{String: req.Comment, Valid: req.Comment != ""},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errAlreadyRated
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = q.LockUserRating(dbCtx, db.LockUserRatingParams{UserID: ratee, Role: rateeRole}); err != nil {
+			return err
+		}
+		standing, err = q.RefreshUserRating(dbCtx, db.RefreshUserRatingParams{UserID: ratee, Role: rateeRole, WindowSize: ratingWindow})
+		if err != nil {
+			return err
+		}
+
+		if req.Stars > lowRatingStars {
+			return nil
+		}
+		event := orderModel.LowRatingEvent{
+			OrderID:      req.OrderId,
+			RaterID:      rater,
+			RateeID:      ratee,
+			RateeRole:    rateeRole,
+			Stars:        req.Stars,
+			Tags:         tags,
+			Comment:      req.Comment,
+			RateeAverage: standing.Average,
+			RatedAt:      rating.CreatedAt.Time.Unix(),
+		}
+		return enqueueJSON(dbCtx, q, lowRatingsTopic, req.OrderId, &event)
+	})
+	if err != nil {
+		if errors.Is(err, errAlreadyRated) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to rate order: %v", err)
+	}
+
+	return &order.RateOrderResponse{
+		OrderId: req.OrderId,
+		Ratee:   userRating(standing),
+	}, nil
+}
+
+// GetUserRatings returns the averages of several users at once, for dispatch
+// to score candidates and for support tools.
+func (s *Service) GetUserRatings(ctx context.Context, req *order.GetUserRatingsRequest) (*order.GetUserRatingsResponse, error) {
+	if req.Role != ActorPassenger && req.Role != ActorDriver {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid role: %s", req.Role)
+	}
+	if len(req.UserIds) == 0 || len(req.UserIds) > maxRatingLookups {
+		return nil, status.Errorf(codes.InvalidArgument, "between 1 and %d user IDs are required", maxRatingLookups)
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	ratings, err := s.store.GetUserRatings(dbCtx, db.GetUserRatingsParams{UserIds: req.UserIds, Role: req.Role})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get user ratings: %v", err)
+	}
+
+	res := &order.GetUserRatingsResponse{}
+	for _, r := range ratings {
+		if r.RatingCount == 0 {
+			continue
+		}
+		res.Ratings = append(res.Ratings, userRating(r))
+	}
+	return res, nil
+}
+
+// ratingTags normalizes the tags of a rating to lower case and drops repeats.
+func ratingTags(raw []string) ([]string, error) {
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, tag := range raw {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxRatingTagLength {
+			return nil, status.Errorf(codes.InvalidArgument, "tags must be at most %d characters", maxRatingTagLength)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxRatingTags {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d tags are allowed", maxRatingTags)
+	}
+	return tags, nil
+}
+
+func userRating(r db.UserRating) *order.UserRating {
+	return &order.UserRating{
+		UserId:      r.UserID,
+		Role:        r.Role,
+		Average:     r.Average,
+		RatingCount: r.RatingCount,
+	}
+}
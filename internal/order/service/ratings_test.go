@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) CreateRating(ctx context.Context, arg db.CreateRatingParams) (db.Rating, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Rating), args.Error(1)
+}
+
+func (m *MockStore) LockUserRating(ctx context.Context, arg db.LockUserRatingParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockStore) RefreshUserRating(ctx context.Context, arg db.RefreshUserRatingParams) (db.UserRating, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.UserRating), args.Error(1)
+}
+
+func (m *MockStore) GetUserRatings(ctx context.Context, arg db.GetUserRatingsParams) ([]db.UserRating, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.UserRating), args.Error(1)
+}
+
+func TestRateOrder(t *testing.T) {
+	ctx := context.Background()
+	orderID := "550e8400-e29b-41d4-a716-446655440000"
+
+	var id pgtype.UUID
+	_ = id.Scan(orderID)
+	finished := db.Order{
+		ID:          id,
+		PassengerID: "passenger-1",
+		DriverID:    pgtype.Text{String: "driver-1", Valid: true},
+		Status:      "FINISHED",
+	}
+	driverStanding := db.UserRating{UserID: "driver-1", Role: ActorDriver, RatingCount: 12, Average: 4.5}
+
+	t.Run("Passenger Rates Driver", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("GetOrder", mock.Anything, id).Return(finished, nil).Once()
+		store.On("CreateRating", mock.Anything, mock.MatchedBy(func(arg db.CreateRatingParams) bool {
+			return arg.RaterID == "passenger-1" && arg.RateeID == "driver-1" && arg.RateeRole == ActorDriver &&
+				arg.Stars == 5 && assert.ObjectsAreEqual([]string{"clean_car", "friendly"}, arg.Tags)
+		})).Return(db.Rating{}, nil).Once()
+		store.On("LockUserRating", mock.Anything, db.LockUserRatingParams{UserID: "driver-1", Role: ActorDriver}).Return(nil).Once()
+		store.On("RefreshUserRating", mock.Anything, db.RefreshUserRatingParams{UserID: "driver-1", Role: ActorDriver, WindowSize: ratingWindow}).
+			Return(driverStanding, nil).Once()
+
+		res, err := svc.RateOrder(ctx, &order.RateOrderRequest{
+			OrderId:   orderID,
+			RaterRole: ActorPassenger,
+			RaterId:   "passenger-1",
+			Stars:     5,
+			Tags:      []string{"Clean_Car", "friendly", "clean_car "},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4.5, res.Ratee.Average)
+		assert.Equal(t, int32(12), res.Ratee.RatingCount)
+		store.AssertExpectations(t)
+		store.AssertNotCalled(t, "CreateOutboxEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Low Rating Is Flagged", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		passengerStanding := db.UserRating{UserID: "passenger-1", Role: ActorPassenger, RatingCount: 3, Average: 3.7}
+		store.On("GetOrder", mock.Anything, id).Return(finished, nil).Once()
+		store.On("CreateRating", mock.Anything, mock.MatchedBy(func(arg db.CreateRatingParams) bool {
+			return arg.RaterID == "driver-1" && arg.RateeID == "passenger-1" && arg.RateeRole == ActorPassenger
+		})).Return(db.Rating{}, nil).Once()
+		store.On("LockUserRating", mock.Anything, mock.Anything).Return(nil).Once()
+		store.On("RefreshUserRating", mock.Anything, mock.Anything).Return(passengerStanding, nil).Once()
+		store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+			var event orderModel.LowRatingEvent
+			return arg.Topic == lowRatingsTopic && arg.MessageKey == orderID &&
+				json.Unmarshal(arg.Payload, &event) == nil &&
+				event.Stars == 1 && event.RateeID == "passenger-1" && event.Comment == "rude" && event.RateeAverage == 3.7
+		})).Return(nil).Once()
+
+		_, err := svc.RateOrder(ctx, &order.RateOrderRequest{
+			OrderId:   orderID,
+			RaterRole: ActorDriver,
+			RaterId:   "driver-1",
+			Stars:     1,
+			Comment:   "rude",
+		})
+
+		assert.NoError(t, err)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Rates Once Per Side", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("GetOrder", mock.Anything, id).Return(finished, nil).Once()
+		store.On("CreateRating", mock.Anything, mock.Anything).Return(db.Rating{}, pgx.ErrNoRows).Once()
+
+		_, err := svc.RateOrder(ctx, &order.RateOrderRequest{OrderId: orderID, RaterRole: ActorPassenger, RaterId: "passenger-1", Stars: 4})
+
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		store.AssertNotCalled(t, "RefreshUserRating", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects Unfinished Ride", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		started := finished
+		started.Status = "STARTED"
+		store.On("GetOrder", mock.Anything, id).Return(started, nil).Once()
+
+		_, err := svc.RateOrder(ctx, &order.RateOrderRequest{OrderId: orderID, RaterRole: ActorPassenger, RaterId: "passenger-1", Stars: 4})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("Rejects Other Passenger", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("GetOrder", mock.Anything, id).Return(finished, nil).Once()
+
+		_, err := svc.RateOrder(ctx, &order.RateOrderRequest{OrderId: orderID, RaterRole: ActorPassenger, RaterId: "passenger-2", Stars: 4})
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Rejects Bad Requests", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		requests := []*order.RateOrderRequest{
+			{OrderId: orderID, RaterRole: "SUPPORT", RaterId: "agent-1", Stars: 4},
+			{OrderId: orderID, RaterRole: ActorPassenger, RaterId: "passenger-1", Stars: 6},
+			{OrderId: orderID, RaterRole: ActorPassenger, RaterId: "passenger-1", Stars: 0},
+			{OrderId: orderID, RaterRole: ActorPassenger, RaterId: "passenger-1", Stars: 3, Tags: []string{"a", "b", "c", "d", "e", "f"}},
+		}
+		for _, req := range requests {
+			_, err := svc.RateOrder(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+		store.AssertNotCalled(t, "GetOrder", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetUserRatings(t *testing.T) {
+	store := new(MockStore)
+	svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+	store.On("GetUserRatings", mock.Anything, db.GetUserRatingsParams{UserIds: []string{"driver-1", "driver-2", "driver-3"}, Role: ActorDriver}).
+		Return([]db.UserRating{
+			{UserID: "driver-1", Role: ActorDriver, RatingCount: 40, Average: 4.8},
+			// Locked once but never rated.
+			{UserID: "driver-2", Role: ActorDriver},
+		}, nil).Once()
+
+	res, err := svc.GetUserRatings(context.Background(), &order.GetUserRatingsRequest{
+		UserIds: []string{"driver-1", "driver-2", "driver-3"},
+		Role:    ActorDriver,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, res.Ratings, 1)
+	assert.Equal(t, "driver-1", res.Ratings[0].UserId)
+	assert.Equal(t, 4.8, res.Ratings[0].Average)
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/internal/order/receipt"
+	"github.com/dwikikusuma/atlas/internal/pricing"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Fares include VAT; receipts show how much of the total it is.
+	taxName  = "PPN"
+	taxRate  = 0.11
+	currency = "IDR"
+)
+
+// issueReceipt numbers and stores the receipt of a ride that just finished,
+// in the transaction of q.
+func issueReceipt(ctx context.Context, q db.Querier, o db.Order, now time.Time) error {
+	stops, err := q.ListRideStops(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+	year := now.UTC().Year()
+	n, err := q.NextReceiptNumber(ctx, int32(year))
+	if err != nil {
+		return err
+	}
+
+	r := newReceipt(o, stops, receiptNumber(year, n), now)
+	document, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = q.CreateReceipt(ctx, db.CreateReceiptParams{
+		OrderID:       o.ID,
+		ReceiptNumber: r.Number,
+		PassengerID:   o.PassengerID,
+		Document:      document,
+		IssuedAt:      pgtype.Timestamptz{Time: now, Valid: true},
+	})
+	return err
+}
+
+func receiptNumber(year int, n int64) string {
+	return fmt.Sprintf("ATL-%d-%08d", year, n)
+}
+
+// newReceipt describes a finished order for its passenger.
+func newReceipt(o db.Order, stops []db.RideStop, number string, issuedAt time.Time) receipt.Receipt {
+	route := receipt.Route{
+		Pickup:     receipt.Point{Lat: o.PickupLat, Long: o.PickupLong},
+		Dropoff:    receipt.Point{Lat: o.DropoffLat, Long: o.DropoffLong},
+		FinishedAt: o.FinishedAt.Time,
+	}
+	points := []pricing.Point{{Lat: o.PickupLat, Long: o.PickupLong}}
+	for _, stop := range stops {
+		route.Stops = append(route.Stops, receipt.Point{Lat: stop.Lat, Long: stop.Long})
+		points = append(points, pricing.Point{Lat: stop.Lat, Long: stop.Long})
+	}
+	points = append(points, pricing.Point{Lat: o.DropoffLat, Long: o.DropoffLong})
+
+	if o.MeteredDistanceKm.Valid {
+		route.Metered = true
+		route.DistanceKm = o.MeteredDistanceKm.Float64
+		route.DurationMin = o.MeteredDurationMin.Float64
+	} else {
+		route.DistanceKm = pricing.RouteKm(points...)
+	}
+
+	total := chargeFor(o)
+	return receipt.Receipt{
+		Number:      number,
+		IssuedAt:    issuedAt.UTC(),
+		OrderID:     o.ID.String(),
+		PassengerID: o.PassengerID,
+		Driver: receipt.Driver{
+			ID:          o.DriverID.String,
+			VehicleType: o.VehicleType,
+		},
+		Route: route,
+		Fare: receipt.Fare{
+			Currency:        currency,
+			UpfrontFare:     o.Price + o.Discount,
+			MeteredFare:     o.MeteredFare.Float64,
+			Basis:           o.FareBasis.String,
+			SurgeMultiplier: o.SurgeMultiplier,
+			PromoCode:       o.PromoCode.String,
+			Discount:        o.Discount,
+			Total:           total,
+			TaxName:         taxName,
+			TaxRate:         taxRate,
+			Tax:             receipt.TaxOf(total, taxRate),
+		},
+		PaymentMethod: o.PaymentMethod,
+	}
+}
+
+// GetReceipt returns the receipt of a finished ride, rendered as JSON, HTML or
+// plain text.
+func (s *Service) GetReceipt(ctx context.Context, req *order.GetReceiptRequest) (*order.GetReceiptResponse, error) {
+	var orderID pgtype.UUID
+	if err := orderID.Scan(req.OrderId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	stored, err := s.store.GetReceipt(dbCtx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "receipt not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get receipt: %v", err)
+	}
+	if stored.PassengerID != req.PassengerId {
+		return nil, status.Error(codes.PermissionDenied, "order does not belong to the caller")
+	}
+
+	var r receipt.Receipt
+	if err = json.Unmarshal(stored.Document, &r); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read receipt: %v", err)
+	}
+	document, contentType, err := receipt.Render(r, req.Format)
+	if err != nil {
+		if errors.Is(err, receipt.ErrUnknownFormat) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid format: %s", req.Format)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to render receipt: %v", err)
+	}
+
+	return &order.GetReceiptResponse{
+		ReceiptNumber: stored.ReceiptNumber,
+		OrderId:       req.OrderId,
+		IssuedAt:      formatTime(stored.IssuedAt),
+		ContentType:   contentType,
+		Document:      string(document),
+	}, nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/internal/order/receipt"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) NextReceiptNumber(ctx context.Context, year int32) (int64, error) {
+	args := m.Called(ctx, year)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStore) CreateReceipt(ctx context.Context, arg db.CreateReceiptParams) (db.Receipt, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Receipt), args.Error(1)
+}
+
+func (m *MockStore) GetReceipt(ctx context.Context, orderID pgtype.UUID) (db.Receipt, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).(db.Receipt), args.Error(1)
+}
+
+func TestIssueReceipt(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+	o := finishedOrder()
+	o.PickupLat, o.PickupLong = -6.2088, 106.8456
+	o.DropoffLat, o.DropoffLong = -6.23, 106.865
+	o.VehicleType = VehicleTypeCar
+	o.Price, o.Discount = 20000, 5000
+	o.PromoCode = pgtype.Text{String: "HEMAT", Valid: true}
+	o.SurgeMultiplier = 1
+	o.MeteredDistanceKm = pgtype.Float8{Float64: 9.8, Valid: true}
+	o.MeteredDurationMin = pgtype.Float8{Float64: 31, Valid: true}
+	o.MeteredFare = pgtype.Float8{Float64: 29400, Valid: true}
+	o.FinalPrice = pgtype.Float8{Float64: 24400, Valid: true}
+	o.FareBasis = pgtype.Text{String: FareBasisMetered, Valid: true}
+	o.FinishedAt = pgtype.Timestamptz{Time: now, Valid: true}
+	stops := []db.RideStop{{OrderID: o.ID, Sequence: 1, Lat: -6.215, Long: 106.85}}
+
+	store := new(MockStore)
+	store.On("ListRideStops", mock.Anything, o.ID).Return(stops, nil).Once()
+	store.On("NextReceiptNumber", mock.Anything, int32(2026)).Return(int64(42), nil).Once()
+	var issued receipt.Receipt
+	store.On("CreateReceipt", mock.Anything, mock.MatchedBy(func(arg db.CreateReceiptParams) bool {
+		return arg.OrderID == o.ID && arg.ReceiptNumber == "ATL-2026-00000042" && arg.PassengerID == "passenger-1" &&
+			json.Unmarshal(arg.Document, &issued) == nil
+	})).Return(db.Receipt{}, nil).Once()
+
+	err := issueReceipt(ctx, store, o, now)
+
+	assert.NoError(t, err)
+	store.AssertExpectations(t)
+	assert.Equal(t, receipt.Receipt{
+		Number:      "ATL-2026-00000042",
+		IssuedAt:    now,
+		OrderID:     o.ID.String(),
+		PassengerID: "passenger-1",
+		Driver:      receipt.Driver{ID: "driver-1", VehicleType: VehicleTypeCar},
+		Route: receipt.Route{
+			Pickup:      receipt.Point{Lat: -6.2088, Long: 106.8456},
+			Stops:       []receipt.Point{{Lat: -6.215, Long: 106.85}},
+			Dropoff:     receipt.Point{Lat: -6.23, Long: 106.865},
+			DistanceKm:  9.8,
+			DurationMin: 31,
+			Metered:     true,
+			FinishedAt:  now,
+		},
+		Fare: receipt.Fare{
+			Currency:        "IDR",
+			UpfrontFare:     25000,
+			MeteredFare:     29400,
+			Basis:           FareBasisMetered,
+			SurgeMultiplier: 1,
+			PromoCode:       "HEMAT",
+			Discount:        5000,
+			Total:           24400,
+			TaxName:         "PPN",
+			TaxRate:         0.11,
+			Tax:             2418,
+		},
+		PaymentMethod: "WALLET",
+	}, issued)
+}
+
+func TestNewReceipt_NotMetered(t *testing.T) {
+	o := finishedOrder()
+	o.DropoffLong = 0.1
+
+	r := newReceipt(o, nil, "ATL-2026-00000001", time.Now())
+
+	// The straight line from pickup to drop-off stands in for the distance.
+	assert.False(t, r.Route.Metered)
+	assert.InDelta(t, 11.12, r.Route.DistanceKm, 0.01)
+	assert.Zero(t, r.Route.DurationMin)
+	assert.Equal(t, 21000.0, r.Fare.Total)
+}
+
+func TestGetReceipt(t *testing.T) {
+	ctx := context.Background()
+	o := finishedOrder()
+	orderID := o.ID.String()
+	issuedAt := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+	document, _ := json.Marshal(newReceipt(o, nil, "ATL-2026-00000042", issuedAt))
+	stored := db.Receipt{
+		OrderID:       o.ID,
+		ReceiptNumber: "ATL-2026-00000042",
+		PassengerID:   "passenger-1",
+		Document:      document,
+		IssuedAt:      pgtype.Timestamptz{Time: issuedAt, Valid: true},
+	}
+
+	t.Run("Rendered", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("GetReceipt", mock.Anything, o.ID).Return(stored, nil).Once()
+
+		res, err := svc.GetReceipt(ctx, &order.GetReceiptRequest{OrderId: orderID, PassengerId: "passenger-1", Format: receipt.FormatText})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ATL-2026-00000042", res.ReceiptNumber)
+		assert.Equal(t, "2026-10-19T08:30:00Z", res.IssuedAt)
+		assert.Equal(t, "text/plain; charset=utf-8", res.ContentType)
+		assert.Contains(t, res.Document, "Total                21.000")
+	})
+
+	tests := []struct {
+		name      string
+		passenger string
+		format    string
+		err       error
+		wantCode  codes.Code
+	}{
+		{"Not Issued", "passenger-1", "", pgx.ErrNoRows, codes.NotFound},
+		{"Not The Passenger", "passenger-2", "", nil, codes.PermissionDenied},
+		{"Unknown Format", "passenger-1", "pdf", nil, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockStore)
+			svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+			store.On("GetReceipt", mock.Anything, o.ID).Return(stored, tt.err).Once()
+
+			_, err := svc.GetReceipt(ctx, &order.GetReceiptRequest{OrderId: orderID, PassengerId: tt.passenger, Format: tt.format})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
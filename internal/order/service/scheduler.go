@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/dispatch"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const reminderTopic = "ride-reminders"
+
+type SchedulerConfig struct {
+	// Interval is how often the scheduler polls Postgres for due rides.
+	Interval time.Duration
+	// ReminderLead is how long before pickup the passenger gets a reminder.
+	ReminderLead time.Duration
+	// DispatchLead is how long before pickup the first dispatch attempt happens.
+	DispatchLead time.Duration
+	// RetryInterval is the wait between dispatch attempts while no driver is found.
+	RetryInterval time.Duration
+	// MaxAttempts caps the number of dispatch attempts per scheduled ride. A
+	// ride the last one finds no driver for expires RetryInterval later.
+	MaxAttempts int32
+	// BaseRadiusKm is the search radius of the first attempt; every retry adds
+	// RadiusStepKm until MaxRadiusKm is reached.
+	BaseRadiusKm float64
+	RadiusStepKm float64
+	MaxRadiusKm  float64
+	// BatchSize limits how many rides a single tick claims.
+	BatchSize int32
+}
+
+// Scheduler turns SCHEDULED orders into dispatch requests shortly before pickup.
+// Due rides are claimed in Postgres with FOR UPDATE SKIP LOCKED, so each
+// reminder and dispatch attempt runs exactly once even with several replicas.
+type Scheduler struct {
+	store          db.Store
+	dispatchClient dispatch.DispatchServiceClient
+	cfg            SchedulerConfig
+}
+
+func NewScheduler(store db.Store, dispatchClient dispatch.DispatchServiceClient, cfg SchedulerConfig) *Scheduler {
+	return &Scheduler{
+		store:          store,
+		dispatchClient: dispatchClient,
+		cfg:            cfg,
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Println("Starting ride scheduler...")
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Ride scheduler stopping...")
+			return
+		case <-ticker.C:
+			now := time.Now()
+			if err := s.SendReminders(ctx, now); err != nil {
+				log.Printf("❌ Failed to send ride reminders: %v", err)
+			}
+			if err := s.DispatchDue(ctx, now); err != nil {
+				log.Printf("❌ Failed to dispatch scheduled rides: %v", err)
+			}
+			if err := s.ExpireExhausted(ctx, now); err != nil {
+				log.Printf("❌ Failed to expire scheduled rides: %v", err)
+			}
+		}
+	}
+}
+
+// SendReminders claims due reminders and queues them in the same transaction,
+// so a reminder is neither lost nor sent twice.
+func (s *Scheduler) SendReminders(ctx context.Context, now time.Time) error {
+	return s.store.ExecTx(ctx, func(q db.Querier) error {
+		orders, err := q.ClaimDueReminders(ctx, db.ClaimDueRemindersParams{
+			RemindBefore: pgtype.Timestamptz{Time: now.Add(s.cfg.ReminderLead), Valid: true},
+			BatchSize:    s.cfg.BatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, o := range orders {
+			orderID := o.ID.String()
+			event := orderModel.RideReminderEvent{
+				OrderID:     orderID,
+				PassengerID: o.PassengerID,
+				ScheduledAt: o.ScheduledAt.Time.Unix(),
+				Timestamp:   now.Unix(),
+			}
+			if err = enqueueJSON(ctx, q, reminderTopic, orderID, &event); err != nil {
+				return err
+			}
+			log.Printf("⏰ Reminder queued for scheduled ride %s", orderID)
+		}
+		return nil
+	})
+}
+
+func (s *Scheduler) DispatchDue(ctx context.Context, now time.Time) error {
+	orders, err := s.store.ClaimDueScheduledOrders(ctx, db.ClaimDueScheduledOrdersParams{
+		MaxAttempts:    s.cfg.MaxAttempts,
+		DispatchBefore: pgtype.Timestamptz{Time: now.Add(s.cfg.DispatchLead), Valid: true},
+		RetryBefore:    pgtype.Timestamptz{Time: now.Add(-s.cfg.RetryInterval), Valid: true},
+		BatchSize:      s.cfg.BatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, o := range orders {
+		orderID := o.ID.String()
+		radius := s.radiusFor(o.DispatchAttempts)
+
+		res, err := s.dispatchClient.RequestRide(ctx, &dispatch.RequestRideRequest{
+			OrderId:        orderID,
+			PassengerId:    o.PassengerID,
+			PickupLat:      o.PickupLat,
+			PickupLong:     o.PickupLong,
+			DropoffLat:     o.DropoffLat,
+			DropoffLong:    o.DropoffLong,
+			SearchRadiusKm: radius,
+			VehicleType:    o.VehicleType,
+			Seats:          o.Seats,
+		})
+		if err != nil {
+			// The attempt is already counted; the next one runs after RetryInterval.
+			log.Printf("❌ Dispatch attempt %d for scheduled ride %s failed: %v", o.DispatchAttempts, orderID, err)
+			continue
+		}
+
+		log.Printf("🗓️ Scheduled ride %s dispatched (attempt %d, radius %.1f km): %s", orderID, o.DispatchAttempts, radius, res.Status)
+	}
+
+	return nil
+}
+
+// ExpireExhausted gives up on scheduled rides whose last dispatch attempt
+// found no driver either, RetryInterval after it. They become EXPIRED like
+// any order that waited too long, which tells the passenger.
+func (s *Scheduler) ExpireExhausted(ctx context.Context, now time.Time) error {
+	reason := pgtype.Text{String: fmt.Sprintf("no driver found in %d dispatch attempts", s.cfg.MaxAttempts), Valid: true}
+	return s.store.ExecTx(ctx, func(q db.Querier) error {
+		orders, err := q.ClaimExhaustedScheduledOrders(ctx, db.ClaimExhaustedScheduledOrdersParams{
+			MaxAttempts: s.cfg.MaxAttempts,
+			RetryBefore: pgtype.Timestamptz{Time: now.Add(-s.cfg.RetryInterval), Valid: true},
+			BatchSize:   s.cfg.BatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, o := range orders {
+			fromStatus, err := expireOrder(ctx, q, o, reason, now)
+			if err != nil {
+				return err
+			}
+			log.Printf("⌛ Scheduled ride %s expired in %s after %d dispatch attempts", o.ID.String(), fromStatus, o.DispatchAttempts)
+		}
+		return nil
+	})
+}
+
+// radiusFor widens the search radius with every attempt that found no driver.
+func (s *Scheduler) radiusFor(attempt int32) float64 {
+	if attempt < 1 {
+		attempt = 1
+	}
+	radius := s.cfg.BaseRadiusKm + float64(attempt-1)*s.cfg.RadiusStepKm
+	return math.Min(radius, s.cfg.MaxRadiusKm)
+}
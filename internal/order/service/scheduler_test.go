@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/dispatch"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+)
+
+type MockDispatchClient struct {
+	dispatch.DispatchServiceClient
+	mock.Mock
+}
+
+func (m *MockDispatchClient) RequestRide(ctx context.Context, in *dispatch.RequestRideRequest, opts ...grpc.CallOption) (*dispatch.RequestRideResponse, error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(*dispatch.RequestRideResponse), args.Error(1)
+}
+
+func (m *MockStore) ClaimDueScheduledOrders(ctx context.Context, arg db.ClaimDueScheduledOrdersParams) ([]db.Order, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.Order), args.Error(1)
+}
+
+func (m *MockStore) ClaimDueReminders(ctx context.Context, arg db.ClaimDueRemindersParams) ([]db.Order, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.Order), args.Error(1)
+}
+
+func (m *MockStore) ClaimExhaustedScheduledOrders(ctx context.Context, arg db.ClaimExhaustedScheduledOrdersParams) ([]db.Order, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]db.Order), args.Error(1)
+}
+
+var testSchedulerConfig = SchedulerConfig{
+	ReminderLead:  time.Hour,
+	DispatchLead:  15 * time.Minute,
+	RetryInterval: 2 * time.Minute,
+	MaxAttempts:   5,
+	BaseRadiusKm:  5,
+	RadiusStepKm:  2.5,
+	MaxRadiusKm:   10,
+	BatchSize:     50,
+}
+
+func TestScheduler_RadiusEscalation(t *testing.T) {
+	s := NewScheduler(nil, nil, testSchedulerConfig)
+
+	assert.Equal(t, 5.0, s.radiusFor(1))
+	assert.Equal(t, 7.5, s.radiusFor(2))
+	assert.Equal(t, 10.0, s.radiusFor(3))
+	assert.Equal(t, 10.0, s.radiusFor(5), "radius is capped at MaxRadiusKm")
+}
+
+func TestScheduler_DispatchDue(t *testing.T) {
+	mockStore := new(MockStore)
+	mockDispatch := new(MockDispatchClient)
+	s := NewScheduler(mockStore, mockDispatch, testSchedulerConfig)
+	ctx := context.Background()
+	now := time.Now()
+
+	var orderID pgtype.UUID
+	_ = orderID.Scan("550e8400-e29b-41d4-a716-446655440000")
+	due := db.Order{
+		ID:               orderID,
+		PassengerID:      "customer-123",
+		PickupLat:        -6.2088,
+		PickupLong:       106.8456,
+		Status:           "SEARCHING",
+		DispatchAttempts: 2,
+	}
+
+	mockStore.On("ClaimDueScheduledOrders", ctx, mock.MatchedBy(func(arg db.ClaimDueScheduledOrdersParams) bool {
+		return arg.DispatchBefore.Time.Equal(now.Add(15*time.Minute)) &&
+			arg.RetryBefore.Time.Equal(now.Add(-2*time.Minute)) &&
+			arg.MaxAttempts == 5
+	})).Return([]db.Order{due}, nil).Once()
+
+	mockDispatch.On("RequestRide", ctx, mock.MatchedBy(func(req *dispatch.RequestRideRequest) bool {
+		return req.OrderId == "550e8400-e29b-41d4-a716-446655440000" && req.SearchRadiusKm == 7.5
+	})).Return(&dispatch.RequestRideResponse{Status: "SEARCHING"}, nil).Once()
+
+	assert.NoError(t, s.DispatchDue(ctx, now))
+	mockStore.AssertExpectations(t)
+	mockDispatch.AssertExpectations(t)
+}
+
+func TestScheduler_SendReminders(t *testing.T) {
+	mockStore := new(MockStore)
+	s := NewScheduler(mockStore, nil, testSchedulerConfig)
+	ctx := context.Background()
+	now := time.Now()
+	o := finishedOrder()
+	o.Status = "SCHEDULED"
+	o.ScheduledAt = pgtype.Timestamptz{Time: now.Add(time.Hour), Valid: true}
+
+	mockStore.On("ClaimDueReminders", mock.Anything, db.ClaimDueRemindersParams{
+		RemindBefore: pgtype.Timestamptz{Time: now.Add(time.Hour), Valid: true},
+		BatchSize:    50,
+	}).Return([]db.Order{o}, nil).Once()
+	// The reminder goes through the outbox of the claiming transaction, so a
+	// reminder marked sent is always published.
+	mockStore.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var reminder orderModel.RideReminderEvent
+		return arg.Topic == reminderTopic && json.Unmarshal(arg.Payload, &reminder) == nil &&
+			reminder.OrderID == o.ID.String() && reminder.ScheduledAt == o.ScheduledAt.Time.Unix()
+	})).Return(nil).Once()
+
+	assert.NoError(t, s.SendReminders(ctx, now))
+	mockStore.AssertExpectations(t)
+}
+
+func TestScheduler_ExpireExhausted(t *testing.T) {
+	mockStore := new(MockStore)
+	s := NewScheduler(mockStore, nil, testSchedulerConfig)
+	ctx := context.Background()
+	now := time.Now()
+	o := finishedOrder()
+	o.Status = "SEARCHING"
+	o.DriverID = pgtype.Text{}
+	o.DispatchAttempts = 5
+	expired := o
+	expired.Status = "EXPIRED"
+	orderID := o.ID.String()
+
+	mockStore.On("ClaimExhaustedScheduledOrders", mock.Anything, db.ClaimExhaustedScheduledOrdersParams{
+		MaxAttempts: 5,
+		RetryBefore: pgtype.Timestamptz{Time: now.Add(-2 * time.Minute), Valid: true},
+		BatchSize:   50,
+	}).Return([]db.Order{o}, nil).Once()
+	mockStore.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(func(arg db.UpdateOrderStatusParams) bool {
+		return arg.ID == o.ID && arg.Status == "EXPIRED" && arg.Actor == ActorSystem &&
+			arg.Reason.String == "no driver found in 5 dispatch attempts"
+	})).Return("SEARCHING", nil).Once()
+	mockStore.On("ReversePromoRedemption", mock.Anything, o.ID).Return(int64(0), nil).Once()
+	mockStore.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		return arg.Topic == walletTopic && arg.MessageKey == orderID
+	})).Return(nil).Once()
+	mockStore.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		return arg.Topic == cancelledTopic && arg.MessageKey == orderID
+	})).Return(nil).Once()
+	mockStore.On("GetOrder", mock.Anything, o.ID).Return(expired, nil).Once()
+	mockStore.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var e orderModel.OrderEvent
+		return arg.Topic == orderEventsTopic && json.Unmarshal(arg.Payload, &e) == nil &&
+			e.Type == orderModel.OrderEventExpired && e.Order.PassengerID == "passenger-1"
+	})).Return(nil).Once()
+
+	assert.NoError(t, s.ExpireExhausted(ctx, now))
+	mockStore.AssertExpectations(t)
+}
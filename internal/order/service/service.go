@@ -2,68 +2,260 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"log"
 	"math"
 	"time"
 
 	"github.com/dwikikusuma/atlas/internal/order/db"
-	"github.com/dwikikusuma/atlas/pkg/kafka"
+	"github.com/dwikikusuma/atlas/internal/order/quote"
+	"github.com/dwikikusuma/atlas/internal/pricing"
+	"github.com/dwikikusuma/atlas/internal/surge"
 	orderModel "github.com/dwikikusuma/atlas/pkg/model"
 	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
 	wallet "github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	walletTopic    = "wallet-transactions"
+	cancelledTopic = "order-cancelled"
+
+	// Actors recorded in the status history of an order.
+	ActorPassenger = "PASSENGER"
+	ActorDriver    = "DRIVER"
+	ActorDispatch  = "DISPATCH"
+	ActorWallet    = "WALLET"
+	ActorSystem    = "SYSTEM"
+
+	CancelledByPassenger = ActorPassenger
+	CancelledByDriver    = ActorDriver
+
+	VehicleTypeRide = "go-ride"
+	VehicleTypeCar  = "go-car"
+	VehicleTypePool = "go-pool"
+
+	// maxPoolSeats is the largest party that may book a shared ride.
+	maxPoolSeats = 2
+	// maxStops is how many stops a ride may make between pickup and drop-off.
+	maxStops = 3
+	// A shared ride costs poolDiscount less than riding alone; every extra
+	// seat of the same party adds poolExtraSeatShare of that seat fare.
+	poolDiscount       = 0.3
+	poolExtraSeatShare = 0.5
+)
+
+// CancellationPolicy decides when a passenger pays for cancelling a ride.
+type CancellationPolicy struct {
+	// GracePeriod is how long after a driver is matched the passenger may cancel for free.
+	GracePeriod time.Duration
+	// Fee is debited from the passenger's wallet once the grace period is over.
+	Fee float64
+}
+
+// FeeFor returns the fee owed when an order is cancelled at the given time.
+// Drivers never trigger a fee, and neither do orders that were not matched yet.
+func (p CancellationPolicy) FeeFor(o db.Order, cancelledBy string, now time.Time) float64 {
+	if cancelledBy != CancelledByPassenger || !o.MatchedAt.Valid {
+		return 0
+	}
+	if now.Sub(o.MatchedAt.Time) <= p.GracePeriod {
+		return 0
+	}
+	return p.Fee
+}
+
 type Service struct {
 	order.UnimplementedOrderServiceServer
-	store        db.Querier
-	producer     *kafka.Producer
-	walletClient wallet.WalletServiceClient
+	store         db.Store
+	pricer        *pricing.Engine
+	surge         *surge.Surge
+	quotes        *quote.Issuer
+	walletClient  wallet.WalletServiceClient
+	trackerClient tracker.TrackerServiceClient
+	cancelPolicy  CancellationPolicy
+	farePolicy    FarePolicy
 }
 
-func NewOrderService(store db.Querier, producer *kafka.Producer, walletClient wallet.WalletServiceClient) *Service {
+func NewOrderService(store db.Store, pricer *pricing.Engine, surgeCalc *surge.Surge, quotes *quote.Issuer, walletClient wallet.WalletServiceClient, trackerClient tracker.TrackerServiceClient, cancelPolicy CancellationPolicy, farePolicy FarePolicy) *Service {
 	return &Service{
-		store:        store,
-		producer:     producer,
-		walletClient: walletClient,
+		store:         store,
+		pricer:        pricer,
+		surge:         surgeCalc,
+		quotes:        quotes,
+		walletClient:  walletClient,
+		trackerClient: trackerClient,
+		cancelPolicy:  cancelPolicy,
+		farePolicy:    farePolicy,
 	}
 }
 
 func (s *Service) CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (*order.CreateOrderResponse, error) {
-	price := calculatePrice(req.PickupLat, req.PickupLong, req.DropoffLat, req.DropoffLong)
-	balance, err := s.walletClient.GetBalance(ctx, &wallet.GetBalanceRequest{UserId: req.UserId})
+	orderStatus := order.OrderStatus_CREATED
+	now := time.Now()
+	pickupTime := now
+	var scheduledAt pgtype.Timestamptz
+	if req.ScheduledAt != "" {
+		var err error
+		if pickupTime, err = time.Parse(time.RFC3339, req.ScheduledAt); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid scheduled_at: %v", err)
+		}
+		if !pickupTime.After(now) {
+			return nil, status.Error(codes.InvalidArgument, "scheduled_at must be in the future")
+		}
+		if req.QuoteId != "" {
+			return nil, status.Error(codes.InvalidArgument, "quotes are for immediate rides only")
+		}
+		orderStatus = order.OrderStatus_SCHEDULED
+		scheduledAt = pgtype.Timestamptz{Time: pickupTime, Valid: true}
+	}
+
+	trip, err := newTrip(req.UserId, req.VehicleType, req.Seats, req.PickupLat, req.PickupLong, req.DropoffLat, req.DropoffLong, req.Stops)
+	if err != nil {
+		return nil, err
+	}
+	method, err := paymentMethod(req.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rides whose fare could not be charged are paid before a new one.
+	debt, err := s.store.GetPassengerDebt(ctx, req.UserId)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get user balance: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to get passenger debt: %v", err)
+	}
+	if debt > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "unpaid rides of %.0f must be paid first", debt)
+	}
+
+	var f fare
+	var quoteID pgtype.Text
+	created := false
+	if req.QuoteId != "" {
+		q, err := s.quotes.Redeem(ctx, req.QuoteId, trip, now)
+		if err != nil {
+			return nil, quoteError(err)
+		}
+		// A quote whose order fails is given back, so the passenger can retry with it.
+		defer func() {
+			if created {
+				s.quotes.Converted(ctx, now)
+			} else {
+				s.quotes.Release(ctx, q.ID)
+			}
+		}()
+		f = fare{price: q.Price, tariffVersion: q.TariffVersion, city: q.City, surge: q.SurgeMultiplier}
+		quoteID = pgtype.Text{String: q.ID, Valid: true}
+	} else if f, err = s.priceTrip(ctx, trip, pickupTime, !scheduledAt.Valid); err != nil {
+		return nil, err
+	}
+
+	var campaign *db.PromoCampaign
+	var discount float64
+	var promoCode pgtype.Text
+	if req.PromoCode != "" {
+		c, err := lookupPromo(ctx, s.store, req.PromoCode, req.UserId)
+		if err == nil {
+			discount, err = checkPromo(c, trip.VehicleType, f.city, f.price, now)
+		}
+		if err != nil {
+			return nil, promoError(err)
+		}
+		campaign = &c
+		promoCode = pgtype.Text{String: c.Code, Valid: true}
 	}
+	price := f.price - discount
 
-	if balance.Balance < price {
-		return nil, status.Errorf(codes.FailedPrecondition, "insufficient balance: %v", balance.Balance)
+	orderID, err := newOrderID()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate order ID: %v", err)
+	}
+	if err = s.holdFare(ctx, orderID, req.UserId, method, price, pickupTime); err != nil {
+		return nil, err
 	}
+	defer func() {
+		if !created {
+			s.releaseHold(context.WithoutCancel(ctx), orderID, method)
+		}
+	}()
 
 	createOrderParams := db.CreateOrderParams{
-		PassengerID: req.UserId,
-		PickupLong:  req.PickupLong,
-		PickupLat:   req.PickupLat,
-		DropoffLat:  req.DropoffLat,
-		DropoffLong: req.DropoffLong,
-		Status:      "CREATED",
-		Price:       price,
+		ID:              orderID,
+		PassengerID:     req.UserId,
+		PickupLong:      req.PickupLong,
+		PickupLat:       req.PickupLat,
+		DropoffLat:      req.DropoffLat,
+		DropoffLong:     req.DropoffLong,
+		Status:          orderStatus.String(),
+		Price:           price,
+		ScheduledAt:     scheduledAt,
+		VehicleType:     trip.VehicleType,
+		Seats:           trip.Seats,
+		TariffVersion:   pgtype.Text{String: f.tariffVersion, Valid: true},
+		SurgeMultiplier: f.surge,
+		QuoteID:         quoteID,
+		PromoCode:       promoCode,
+		Discount:        discount,
+		PaymentMethod:   method,
 	}
 
 	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	orderDetail, err := s.store.CreateOrder(dbCtx, createOrderParams)
+	var orderDetail db.Order
+	err = s.store.ExecTx(dbCtx, func(q db.Querier) error {
+		if campaign != nil {
+			if err := claimPromo(dbCtx, q, *campaign, req.UserId); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if orderDetail, err = q.CreateOrder(dbCtx, createOrderParams); err != nil {
+			return err
+		}
+		if len(trip.Stops) > 0 {
+			if err = q.CreateRideStops(dbCtx, rideStopsParams(orderDetail.ID, trip.Stops)); err != nil {
+				return err
+			}
+		}
+
+		if campaign != nil {
+			err = q.CreatePromoRedemption(dbCtx, db.CreatePromoRedemptionParams{
+				CampaignID: campaign.ID,
+				UserID:     req.UserId,
+				OrderID:    orderDetail.ID,
+				Discount:   discount,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return enqueueOrderEvent(dbCtx, q, orderDetail, "", ActorPassenger)
+	})
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, promoError(err)
+	}
+	created = true
+
+	if !scheduledAt.Valid {
+		if err = s.surge.RecordRequest(ctx, orderDetail.ID.String(), req.PickupLat, req.PickupLong, pickupTime); err != nil {
+			log.Printf("⚠️ Failed to record demand for order %s: %v", orderDetail.ID.String(), err)
+		}
 	}
 
 	return &order.CreateOrderResponse{
-		OrderId: orderDetail.ID.String(),
-		Status:  orderDetail.Status,
-		Price:   orderDetail.Price,
+		OrderId:         orderDetail.ID.String(),
+		Status:          parseStatus(orderDetail.Status),
+		Price:           orderDetail.Price,
+		TariffVersion:   orderDetail.TariffVersion.String,
+		SurgeMultiplier: orderDetail.SurgeMultiplier,
+		Discount:        orderDetail.Discount,
+		PaymentMethod:   orderDetail.PaymentMethod,
 	}, nil
 }
 
@@ -85,22 +277,58 @@ func (s *Service) GetOrder(ctx context.Context, req *order.GetOrderRequest) (*or
 		return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
 	}
 
+	stops, err := s.store.ListRideStops(dbCtx, orderID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get stops: %v", err)
+	}
+
+	res := orderResponse(orderDetail)
+	res.Stops = rideStops(stops)
+	return res, nil
+}
+
+// orderResponse describes an order the way GetOrder and ListOrders return it.
+// Only GetOrder adds the stops.
+func orderResponse(o db.Order) *order.GetOrderResponse {
 	return &order.GetOrderResponse{
-		OrderId:     orderDetail.ID.String(),
-		PassengerId: orderDetail.PassengerID,
-		DriverId:    orderDetail.DriverID.String,
-		PickupLat:   orderDetail.PickupLat,
-		PickupLong:  orderDetail.PickupLong,
-		DropoffLat:  orderDetail.DropoffLat,
-		DropoffLong: orderDetail.DropoffLong,
-		Status:      orderDetail.Status,
-		Price:       orderDetail.Price,
-		CreatedAt:   orderDetail.CreatedAt.Time.String(),
-	}, nil
+		OrderId:         o.ID.String(),
+		PassengerId:     o.PassengerID,
+		DriverId:        o.DriverID.String,
+		PickupLat:       o.PickupLat,
+		PickupLong:      o.PickupLong,
+		DropoffLat:      o.DropoffLat,
+		DropoffLong:     o.DropoffLong,
+		Status:          parseStatus(o.Status),
+		Price:           o.Price,
+		CreatedAt:       o.CreatedAt.Time.String(),
+		ScheduledAt:     formatTime(o.ScheduledAt),
+		VehicleType:     o.VehicleType,
+		Seats:           o.Seats,
+		PoolTripId:      o.PoolTripID.String,
+		PickupSequence:  o.PickupSequence.Int32,
+		DropoffSequence: o.DropoffSequence.Int32,
+		TariffVersion:   o.TariffVersion.String,
+		SurgeMultiplier: o.SurgeMultiplier,
+		QuoteId:         o.QuoteID.String,
+		PromoCode:       o.PromoCode.String,
+		Discount:        o.Discount,
+		FinalFare:       finalFareOf(o),
+		FinishedAt:      formatTime(o.FinishedAt),
+		Tip:             o.Tip.Float64,
+		TippedAt:        formatTime(o.TippedAt),
+		PaidAt:          formatTime(o.PaidAt),
+		PaymentAttempts: o.PaymentAttempts,
+		PaymentError:    o.PaymentError.String,
+		PaymentMethod:   o.PaymentMethod,
+	}
 }
 
+// UpdateOrderStatus moves an order along the trip: the driver reports arriving
+// at the pickup, starting and finishing the ride.
 func (s *Service) UpdateOrderStatus(ctx context.Context, req *order.UpdateOrderStatusRequest) (*order.UpdateOrderStatusResponse, error) {
-	if req.Status != "STARTED" && req.Status != "FINISHED" {
+	switch req.Status {
+	case order.OrderStatus_DRIVER_ARRIVED, order.OrderStatus_STARTED, order.OrderStatus_FINISHED:
+	default:
 		return nil, status.Errorf(codes.InvalidArgument, "invalid status: %s", req.Status)
 	}
 
@@ -111,18 +339,73 @@ func (s *Service) UpdateOrderStatus(ctx context.Context, req *order.UpdateOrderS
 
 	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
+
+	orderDetail, err := s.store.GetOrder(dbCtx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "order not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
+	}
+
+	if req.ActorId != "" && req.ActorId != orderDetail.DriverID.String {
+		return nil, status.Error(codes.PermissionDenied, "order is not assigned to the caller")
+	}
+
+	current := parseStatus(orderDetail.Status)
+	if !canTransition(current, req.Status) {
+		return nil, status.Errorf(codes.FailedPrecondition, "order cannot move from %s to %s", current, req.Status)
+	}
+
 	args := db.UpdateOrderStatusParams{
-		ID:     orderID,
-		Status: req.Status,
+		ID:           orderID,
+		FromStatuses: sourcesOf(req.Status),
+		Status:       req.Status.String(),
+		Actor:        ActorDriver,
+		ActorID:      pgtype.Text{String: req.ActorId, Valid: req.ActorId != ""},
+		Reason:       pgtype.Text{String: req.Reason, Valid: req.Reason != ""},
 	}
-	if err := s.store.UpdateOrderStatus(dbCtx, args); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+	var finalFare db.SetOrderFinalFareParams
+	if req.Status == order.OrderStatus_FINISHED {
+		finalFare = s.finalFare(dbCtx, orderDetail)
 	}
 
-	if req.Status == "FINISHED" {
-		if err := s.ProcessPayment(dbCtx, orderID); err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to process payment: %v", err)
+	// The fare is charged through the outbox, and the receipt issued, in the
+	// same transaction, so a finished ride is never left unpaid.
+	err = s.store.ExecTx(dbCtx, func(q db.Querier) error {
+		fromStatus, err := q.UpdateOrderStatus(dbCtx, args)
+		if err != nil {
+			return err
+		}
+		if req.Status != order.OrderStatus_FINISHED {
+			return enqueueTransition(dbCtx, q, orderID, fromStatus, ActorDriver)
+		}
+
+		finished, err := q.SetOrderFinalFare(dbCtx, finalFare)
+		if err != nil {
+			return err
+		}
+		if err = issueReceipt(dbCtx, q, finished, time.Now()); err != nil {
+			return err
+		}
+		if err = enqueueTransition(dbCtx, q, orderID, fromStatus, ActorDriver); err != nil {
+			return err
 		}
+		return enqueuePayment(dbCtx, q, finished)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Somebody else (e.g. a cancellation) changed the order after we read it.
+			return nil, status.Errorf(codes.FailedPrecondition, "order is no longer %s", current)
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	switch req.Status {
+	case order.OrderStatus_STARTED:
+		s.startMeter(dbCtx, orderDetail)
+	case order.OrderStatus_FINISHED:
+		s.releaseDriver(dbCtx, orderDetail.DriverID, req.OrderId)
 	}
 
 	return &order.UpdateOrderStatusResponse{
@@ -132,44 +415,181 @@ func (s *Service) UpdateOrderStatus(ctx context.Context, req *order.UpdateOrderS
 	}, nil
 }
 
-func (s *Service) ProcessPayment(dbCtx context.Context, orderID pgtype.UUID) error {
+func (s *Service) CancelOrder(ctx context.Context, req *order.CancelOrderRequest) (*order.CancelOrderResponse, error) {
+	if req.CancelledBy != CancelledByPassenger && req.CancelledBy != CancelledByDriver {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cancelled_by: %s", req.CancelledBy)
+	}
+
+	var orderID pgtype.UUID
+	if err := orderID.Scan(req.OrderId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
 	orderDetail, err := s.store.GetOrder(dbCtx, orderID)
 	if err != nil {
-		return err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "order not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
 	}
 
-	orderString := orderID.String()
-	debitEvent := orderModel.DebitBalanceEvent{
-		Amount:    orderDetail.Price,
-		UserID:    orderDetail.PassengerID,
-		Reference: orderString,
+	if req.ActorId != "" {
+		owner := orderDetail.PassengerID
+		if req.CancelledBy == CancelledByDriver {
+			owner = orderDetail.DriverID.String
+		}
+		if owner != req.ActorId {
+			return nil, status.Error(codes.PermissionDenied, "order does not belong to the caller")
+		}
+	}
+
+	fee := s.cancelPolicy.FeeFor(orderDetail, req.CancelledBy, time.Now())
+
+	var cancelled db.CancelOrderRow
+	err = s.store.ExecTx(dbCtx, func(q db.Querier) error {
+		var err error
+		cancelled, err = q.CancelOrder(dbCtx, db.CancelOrderParams{
+			ID:              orderID,
+			FromStatuses:    sourcesOf(order.OrderStatus_CANCELLED),
+			CancelledBy:     pgtype.Text{String: req.CancelledBy, Valid: true},
+			CancelReason:    pgtype.Text{String: req.Reason, Valid: req.Reason != ""},
+			CancellationFee: fee,
+			ActorID:         pgtype.Text{String: req.ActorId, Valid: req.ActorId != ""},
+		})
+		if err != nil {
+			return err
+		}
+		if err = enqueueTransition(dbCtx, q, orderID, cancelled.FromStatus, req.CancelledBy); err != nil {
+			return err
+		}
+		if err = reversePromo(dbCtx, q, orderID); err != nil {
+			return err
+		}
+
+		event := orderModel.OrderCancelledEvent{
+			OrderID:         req.OrderId,
+			PassengerID:     cancelled.PassengerID,
+			DriverID:        cancelled.DriverID.String,
+			CancelledBy:     req.CancelledBy,
+			Reason:          req.Reason,
+			CancellationFee: fee,
+			CancelledAt:     cancelled.CancelledAt.Time.Unix(),
+		}
+		if err = enqueueJSON(dbCtx, q, cancelledTopic, req.OrderId, &event); err != nil {
+			return err
+		}
+
+		// The fee is captured from the fare held for the ride, and without a
+		// fee the hold is released. Nothing is held for a cash ride, whose fee
+		// is taken from the wallet.
+		debitEvent := orderModel.DebitBalanceEvent{
+			Amount:    fee,
+			UserID:    cancelled.PassengerID,
+			Reference: req.OrderId,
+			Method:    orderDetail.PaymentMethod,
+			Hold:      req.OrderId,
+		}
+		if orderDetail.PaymentMethod == orderModel.PaymentMethodCash {
+			if fee == 0 {
+				return nil
+			}
+			debitEvent.Method, debitEvent.Hold = orderModel.PaymentMethodWallet, ""
+		}
+		if fee > 0 {
+			debitEvent.Reference = "cancel-" + req.OrderId
+		}
+		return enqueueJSON(dbCtx, q, walletTopic, req.OrderId, &debitEvent)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.FailedPrecondition, "order cannot be cancelled in status %s", orderDetail.Status)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to cancel order: %v", err)
+	}
+
+	s.releaseDriver(dbCtx, cancelled.DriverID, req.OrderId)
+
+	return &order.CancelOrderResponse{
+		OrderId:         req.OrderId,
+		Status:          parseStatus(cancelled.Status),
+		CancellationFee: fee,
+		CancelledAt:     cancelled.CancelledAt.Time.String(),
+	}, nil
+}
+
+func (s *Service) GetOrderHistory(ctx context.Context, req *order.GetOrderHistoryRequest) (*order.GetOrderHistoryResponse, error) {
+	var orderID pgtype.UUID
+	if err := orderID.Scan(req.OrderId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID")
 	}
 
-	debitByte, err := json.Marshal(&debitEvent)
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	history, err := s.store.ListOrderStatusHistory(dbCtx, orderID)
 	if err != nil {
-		return err
+		return nil, status.Errorf(codes.Internal, "failed to get order history: %v", err)
+	}
+	if len(history) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no status changes for order %s", req.OrderId)
 	}
 
-	if err = s.producer.Publish(dbCtx, "wallet-transactions", orderString, debitByte); err != nil {
-		return err
+	res := &order.GetOrderHistoryResponse{}
+	for _, h := range history {
+		res.Changes = append(res.Changes, &order.StatusChange{
+			FromStatus: parseStatus(h.FromStatus),
+			ToStatus:   parseStatus(h.ToStatus),
+			Actor:      h.Actor,
+			ActorId:    h.ActorID.String,
+			Reason:     h.Reason.String,
+			ChangedAt:  formatTime(h.CreatedAt),
+		})
 	}
-	return nil
+	return res, nil
 }
 
-func calculatePrice(lat1, lon1, lat2, lon2 float64) float64 {
-	// 1. Calculate Distance (Euclidean approximation for short distances)
-	// In production, use the Haversine formula for better accuracy.
-	// 1 degree of latitude ~= 111km
-	x := lat2 - lat1
-	y := lon2 - lon1
-	distanceKm := math.Sqrt(x*x+y*y) * 111.32
+// releaseDriver frees the seats an order held so dispatch can match the driver
+// again. Other passengers of a shared trip keep their seats.
+func (s *Service) releaseDriver(ctx context.Context, driverID pgtype.Text, orderID string) {
+	if !driverID.Valid || driverID.String == "" {
+		return
+	}
+
+	if _, err := s.trackerClient.ReleaseDriver(ctx, &tracker.ReleaseDriverRequest{DriverId: driverID.String, RideId: orderID}); err != nil {
+		log.Printf("❌ Failed to release driver %s: %v", driverID.String, err)
+	}
+}
 
-	// 2. Pricing Rules
-	baseFare := 10000.0  // IDR
-	pricePerKm := 3000.0 // IDR
+// enqueuePayment charges the passenger the final fare of a finished order and
+// pays it out to the driver.
+func enqueuePayment(ctx context.Context, q db.Querier, o db.Order) error {
+	orderString := o.ID.String()
+	debitEvent := orderModel.DebitBalanceEvent{
+		Amount:    chargeFor(o),
+		UserID:    o.PassengerID,
+		Reference: orderString,
+		Method:    o.PaymentMethod,
+		Hold:      orderString,
+		Payout:    payoutOf(o),
+	}
+	return enqueueJSON(ctx, q, walletTopic, orderString, &debitEvent)
+}
 
-	price := baseFare + (distanceKm * pricePerKm)
+// formatTime renders an optional timestamp, leaving it empty when unset.
+func formatTime(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
 
-	// Round to nearest whole number for clean display
-	return math.Round(price)
+// poolFare splits the cost of a shared ride: each passenger pays a discounted
+// share of the solo fare, and a party booking several seats pays a reduced
+// share for every extra seat.
+func poolFare(soloFare float64, seats int32) float64 {
+	seatFare := soloFare * (1 - poolDiscount)
+	return math.Round(seatFare * (1 + poolExtraSeatShare*float64(seats-1)))
 }
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancellationPolicy_FeeFor(t *testing.T) {
+	policy := CancellationPolicy{GracePeriod: 2 * time.Minute, Fee: 5000}
+	now := time.Now()
+
+	matchedAgo := func(d time.Duration) db.Order {
+		return db.Order{MatchedAt: pgtype.Timestamptz{Time: now.Add(-d), Valid: true}}
+	}
+
+	tests := []struct {
+		name        string
+		order       db.Order
+		cancelledBy string
+		want        float64
+	}{
+		{"not matched yet", db.Order{}, CancelledByPassenger, 0},
+		{"passenger within grace period", matchedAgo(time.Minute), CancelledByPassenger, 0},
+		{"passenger after grace period", matchedAgo(5 * time.Minute), CancelledByPassenger, 5000},
+		{"driver after grace period", matchedAgo(5 * time.Minute), CancelledByDriver, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.FeeFor(tt.order, tt.cancelledBy, now))
+		})
+	}
+}
+
+func TestPoolFare(t *testing.T) {
+	// 30% off the solo fare, extra seats of the same party pay half a seat.
+	assert.Equal(t, 14000.0, poolFare(20000, 1))
+	assert.Equal(t, 21000.0, poolFare(20000, 2))
+}
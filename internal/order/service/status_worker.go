@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/kafka"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	dispatchModel "github.com/dwikikusuma/atlas/internal/dispatch/model"
+)
+
+// RideStatusWorker mirrors the dispatch search progress (SEARCHING / EXPIRED)
+// onto the order. MATCHED is applied by OrderWorker from the ride-dispatch topic.
+type RideStatusWorker struct {
+	consumer kafka.EventConsumer
+	store    db.Store
+}
+
+func NewRideStatusWorker(consumer kafka.EventConsumer, store db.Store) *RideStatusWorker {
+	return &RideStatusWorker{
+		consumer: consumer,
+		store:    store,
+	}
+}
+
+func (w *RideStatusWorker) Start(ctx context.Context) error {
+	log.Println("Starting ride status worker...")
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Ride status worker stopping...")
+			return nil
+		default:
+		}
+
+		var event dispatchModel.RideStatusEvent
+
+		m, err := w.consumer.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Print("Error fetching message")
+			continue
+		}
+
+		err = json.Unmarshal(m.Value, &event)
+		if err != nil {
+			log.Printf("❌ Failed to parse JSON for key=%s: %v", string(m.Key), err)
+			if err = w.consumer.CommitMessages(ctx, m); err != nil {
+				log.Printf("Failed to commit messages for key=%s: %v", string(m.Key), err)
+			}
+			continue
+		}
+
+		if event.Status != dispatchModel.RideStatusSearching && event.Status != dispatchModel.RideStatusExpired {
+			if err = w.consumer.CommitMessages(ctx, m); err != nil {
+				log.Printf("Failed to commit messages for key=%s: %v", string(m.Key), err)
+			}
+			continue
+		}
+
+		var uuidOrder pgtype.UUID
+		if err = uuidOrder.Scan(event.RideID); err != nil {
+			log.Printf("❌ Invalid RideID format for key=%s: %v", string(m.Key), err)
+			if err = w.consumer.CommitMessages(ctx, m); err != nil {
+				log.Printf("Failed to commit messages for key=%s: %v", string(m.Key), err)
+			}
+			continue
+		}
+
+		to := order.OrderStatus_SEARCHING
+		var reason pgtype.Text
+		if event.Status == dispatchModel.RideStatusExpired {
+			to = order.OrderStatus_EXPIRED
+			reason = pgtype.Text{String: "no driver found", Valid: true}
+		}
+
+		err = w.store.ExecTx(ctx, func(q db.Querier) error {
+			fromStatus, err := q.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
+				ID:           uuidOrder,
+				FromStatuses: sourcesOf(to),
+				Status:       to.String(),
+				Actor:        ActorDispatch,
+				Reason:       reason,
+			})
+			if err != nil {
+				return err
+			}
+			if to == order.OrderStatus_EXPIRED {
+				if err = reversePromo(ctx, q, uuidOrder); err != nil {
+					return err
+				}
+				expired, err := q.GetOrder(ctx, uuidOrder)
+				if err != nil {
+					return err
+				}
+				if err = enqueueHoldRelease(ctx, q, expired); err != nil {
+					return err
+				}
+			}
+			return enqueueTransition(ctx, q, uuidOrder, fromStatus, ActorDispatch)
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("⚠️ Ignored %s for RideID=%s: order already moved on", event.Status, event.RideID)
+		} else if err != nil {
+			log.Printf("❌ Failed to update order for RideID=%s: %v", event.RideID, err)
+			continue
+		} else {
+			log.Printf("✅ Order %s is now %s", event.RideID, event.Status)
+		}
+
+		if err = w.consumer.CommitMessages(ctx, m); err != nil {
+			log.Printf("Failed to commit messages for key=%s: %v", string(m.Key), err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/internal/order/quote"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ArriveAtStop records the driver reaching a stop of a STARTED ride. Stops are
+// reached in sequence; reporting a stop again returns it unchanged, so the
+// driver app can retry.
+func (s *Service) ArriveAtStop(ctx context.Context, req *order.ArriveAtStopRequest) (*order.ArriveAtStopResponse, error) {
+	if req.DriverId == "" {
+		return nil, status.Error(codes.InvalidArgument, "driver_id is required")
+	}
+	if req.Sequence < 1 {
+		return nil, status.Error(codes.InvalidArgument, "sequence must be positive")
+	}
+
+	var orderID pgtype.UUID
+	if err := orderID.Scan(req.OrderId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	orderDetail, err := s.store.GetOrder(dbCtx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "order not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
+	}
+	if orderDetail.DriverID.String != req.DriverId {
+		return nil, status.Error(codes.PermissionDenied, "order is not assigned to the caller")
+	}
+	if current := parseStatus(orderDetail.Status); current != order.OrderStatus_STARTED {
+		return nil, status.Errorf(codes.FailedPrecondition, "order is %s, stops are reached once the ride %s", current, order.OrderStatus_STARTED)
+	}
+
+	stop, err := s.store.ArriveAtRideStop(dbCtx, db.ArriveAtRideStopParams{OrderID: orderID, Sequence: req.Sequence})
+	if errors.Is(err, pgx.ErrNoRows) {
+		stop, err = s.unreachedStop(dbCtx, orderID, req.Sequence)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📍 Order %s reached stop %d", req.OrderId, stop.Sequence)
+	return &order.ArriveAtStopResponse{
+		OrderId: req.OrderId,
+		Stop:    rideStop(stop),
+	}, nil
+}
+
+// unreachedStop explains why a stop could not be reached: it doesn't exist,
+// an earlier stop wasn't reached yet, or it was reached before, in which case
+// it is returned.
+func (s *Service) unreachedStop(ctx context.Context, orderID pgtype.UUID, sequence int32) (db.RideStop, error) {
+	stops, err := s.store.ListRideStops(ctx, orderID)
+	if err != nil {
+		return db.RideStop{}, status.Errorf(codes.Internal, "failed to get stops: %v", err)
+	}
+	if int(sequence) > len(stops) {
+		return db.RideStop{}, status.Errorf(codes.NotFound, "stop %d not found", sequence)
+	}
+	// Stops are numbered from 1 without gaps.
+	for _, stop := range stops[:sequence-1] {
+		if !stop.ArrivedAt.Valid {
+			return db.RideStop{}, status.Errorf(codes.FailedPrecondition, "stop %d must be reached first", stop.Sequence)
+		}
+	}
+	return stops[sequence-1], nil
+}
+
+// rideStopsParams stores the stops of a new order in the order they are visited.
+func rideStopsParams(orderID pgtype.UUID, stops []quote.Stop) db.CreateRideStopsParams {
+	params := db.CreateRideStopsParams{OrderID: orderID}
+	for _, stop := range stops {
+		params.Lats = append(params.Lats, stop.Lat)
+		params.Longs = append(params.Longs, stop.Long)
+	}
+	return params
+}
+
+func rideStop(stop db.RideStop) *order.RideStop {
+	return &order.RideStop{
+		Sequence:  stop.Sequence,
+		Lat:       stop.Lat,
+		Long:      stop.Long,
+		ArrivedAt: formatTime(stop.ArrivedAt),
+	}
+}
+
+func rideStops(stops []db.RideStop) []*order.RideStop {
+	res := make([]*order.RideStop, 0, len(stops))
+	for _, stop := range stops {
+		res = append(res, rideStop(stop))
+	}
+	return res
+}
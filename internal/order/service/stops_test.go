@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/internal/order/quote"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) CreateRideStops(ctx context.Context, arg db.CreateRideStopsParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockStore) ListRideStops(ctx context.Context, orderID pgtype.UUID) ([]db.RideStop, error) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).([]db.RideStop), args.Error(1)
+}
+
+func (m *MockStore) ArriveAtRideStop(ctx context.Context, arg db.ArriveAtRideStopParams) (db.RideStop, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.RideStop), args.Error(1)
+}
+
+func TestNewTrip_Stops(t *testing.T) {
+	stops := []*order.Waypoint{{Lat: -6.18, Long: 106.82}, {Lat: -6.19, Long: 106.83}}
+
+	trip, err := newTrip("passenger-1", "", 0, -6.2, 106.8, -6.25, 106.85, stops)
+	assert.NoError(t, err)
+	assert.Equal(t, []quote.Stop{{Lat: -6.18, Long: 106.82}, {Lat: -6.19, Long: 106.83}}, trip.Stops)
+
+	tests := []struct {
+		name        string
+		vehicleType string
+		stops       int
+	}{
+		{"Too Many Stops", VehicleTypeCar, maxStops + 1},
+		{"Shared Ride", VehicleTypePool, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTrip("passenger-1", tt.vehicleType, 0, -6.2, 106.8, -6.25, 106.85, make([]*order.Waypoint, tt.stops))
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestRideStopsParams(t *testing.T) {
+	orderID := finishedOrder().ID
+
+	params := rideStopsParams(orderID, []quote.Stop{{Lat: -6.18, Long: 106.82}, {Lat: -6.19, Long: 106.83}})
+
+	assert.Equal(t, db.CreateRideStopsParams{
+		OrderID: orderID,
+		Lats:    []float64{-6.18, -6.19},
+		Longs:   []float64{106.82, 106.83},
+	}, params)
+}
+
+func TestArriveAtStop(t *testing.T) {
+	ctx := context.Background()
+	started := finishedOrder()
+	started.Status = "STARTED"
+	orderID := started.ID.String()
+	arrivedAt := pgtype.Timestamptz{Time: time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), Valid: true}
+
+	t.Run("Reached", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("GetOrder", mock.Anything, started.ID).Return(started, nil).Once()
+		store.On("ArriveAtRideStop", mock.Anything, db.ArriveAtRideStopParams{OrderID: started.ID, Sequence: 1}).
+			Return(db.RideStop{OrderID: started.ID, Sequence: 1, Lat: -6.18, Long: 106.82, ArrivedAt: arrivedAt}, nil).Once()
+
+		res, err := svc.ArriveAtStop(ctx, &order.ArriveAtStopRequest{OrderId: orderID, DriverId: "driver-1", Sequence: 1})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), res.Stop.Sequence)
+		assert.Equal(t, "2026-10-19T08:00:00Z", res.Stop.ArrivedAt)
+		store.AssertExpectations(t)
+	})
+
+	stops := []db.RideStop{
+		{OrderID: started.ID, Sequence: 1, ArrivedAt: arrivedAt},
+		{OrderID: started.ID, Sequence: 2},
+		{OrderID: started.ID, Sequence: 3},
+	}
+
+	tests := []struct {
+		name     string
+		sequence int32
+		wantCode codes.Code
+	}{
+		// A retry of the driver app gets the stop back as it was reached.
+		{"Reached Before", 1, codes.OK},
+		{"Earlier Stop Not Reached", 3, codes.FailedPrecondition},
+		{"Unknown Stop", 4, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockStore)
+			svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+			store.On("GetOrder", mock.Anything, started.ID).Return(started, nil).Once()
+			store.On("ArriveAtRideStop", mock.Anything, mock.Anything).Return(db.RideStop{}, pgx.ErrNoRows).Once()
+			store.On("ListRideStops", mock.Anything, started.ID).Return(stops, nil).Once()
+
+			res, err := svc.ArriveAtStop(ctx, &order.ArriveAtStopRequest{OrderId: orderID, DriverId: "driver-1", Sequence: tt.sequence})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "2026-10-19T08:00:00Z", res.Stop.ArrivedAt)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+
+	rejected := []struct {
+		name     string
+		driver   string
+		status   string
+		wantCode codes.Code
+	}{
+		{"Not The Driver", "driver-2", "STARTED", codes.PermissionDenied},
+		{"Not Started", "driver-1", "DRIVER_ARRIVED", codes.FailedPrecondition},
+	}
+
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			o := started
+			o.Status = tt.status
+			store := new(MockStore)
+			svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+			store.On("GetOrder", mock.Anything, o.ID).Return(o, nil).Once()
+
+			_, err := svc.ArriveAtStop(ctx, &order.ArriveAtStopRequest{OrderId: orderID, DriverId: tt.driver, Sequence: 1})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			store.AssertNotCalled(t, "ArriveAtRideStop", mock.Anything, mock.Anything)
+		})
+	}
+}
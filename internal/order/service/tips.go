@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// tipWindow is how long after finishing a ride its passenger can tip.
+	tipWindow = 24 * time.Hour
+	maxTip    = 200000
+
+	// tipTransferType is the wallet transaction type of both sides of a tip.
+	tipTransferType = "TIP"
+)
+
+// TipDriver pays a tip from the passenger's wallet to the driver's. The wallet
+// moves the money in one transaction and only once per order, so a tip that
+// failed to be recorded here can simply be retried.
+func (s *Service) TipDriver(ctx context.Context, req *order.TipDriverRequest) (*order.TipDriverResponse, error) {
+	if req.PassengerId == "" {
+		return nil, status.Error(codes.InvalidArgument, "passenger_id is required")
+	}
+	if req.Amount <= 0 || req.Amount > maxTip {
+		return nil, status.Errorf(codes.InvalidArgument, "tip must be between 0 and %d", maxTip)
+	}
+
+	var orderID pgtype.UUID
+	if err := orderID.Scan(req.OrderId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid order ID")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	orderDetail, err := s.store.GetOrder(dbCtx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "order not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
+	}
+	if orderDetail.PassengerID != req.PassengerId {
+		return nil, status.Error(codes.PermissionDenied, "order does not belong to the caller")
+	}
+	if !rideFinished(parseStatus(orderDetail.Status)) || !orderDetail.DriverID.Valid {
+		return nil, status.Error(codes.FailedPrecondition, "only finished rides can be tipped")
+	}
+	if orderDetail.Tip.Valid {
+		return nil, status.Error(codes.AlreadyExists, "order was already tipped")
+	}
+	if !orderDetail.FinishedAt.Valid || time.Since(orderDetail.FinishedAt.Time) > tipWindow {
+		return nil, status.Error(codes.FailedPrecondition, "tipping window has closed")
+	}
+
+	transfer, err := s.walletClient.Transfer(ctx, &wallet.TransferRequest{
+		FromUserId:  orderDetail.PassengerID,
+		ToUserId:    orderDetail.DriverID.String,
+		Amount:      req.Amount,
+		ReferenceId: req.OrderId,
+		Type:        tipTransferType,
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.FailedPrecondition, codes.AlreadyExists, codes.NotFound:
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "failed to transfer tip: %v", err)
+	}
+
+	tipped, err := s.store.SetOrderTip(dbCtx, db.SetOrderTipParams{
+		ID:  orderID,
+		Tip: pgtype.Float8{Float64: req.Amount, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.AlreadyExists, "order was already tipped")
+		}
+		log.Printf("⚠️ Tip of order %s was paid but not recorded: %v", req.OrderId, err)
+		return nil, status.Errorf(codes.Internal, "failed to record tip: %v", err)
+	}
+
+	return &order.TipDriverResponse{
+		OrderId:  req.OrderId,
+		Tip:      tipped.Tip.Float64,
+		TippedAt: formatTime(tipped.TippedAt),
+		Balance:  transfer.FromBalance,
+	}, nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/order/db"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MockWalletClient struct {
+	wallet.WalletServiceClient
+	mock.Mock
+}
+
+func (m *MockWalletClient) Transfer(ctx context.Context, in *wallet.TransferRequest, opts ...grpc.CallOption) (*wallet.TransferResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*wallet.TransferResponse), args.Error(1)
+}
+
+func (m *MockStore) SetOrderTip(ctx context.Context, arg db.SetOrderTipParams) (db.Order, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Order), args.Error(1)
+}
+
+func TestTipDriver(t *testing.T) {
+	ctx := context.Background()
+	orderID := "550e8400-e29b-41d4-a716-446655440000"
+
+	var id pgtype.UUID
+	_ = id.Scan(orderID)
+	finished := db.Order{
+		ID:          id,
+		PassengerID: "passenger-1",
+		DriverID:    pgtype.Text{String: "driver-1", Valid: true},
+		Status:      "FINISHED",
+		FinishedAt:  pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true},
+	}
+
+	t.Run("Pays Driver", func(t *testing.T) {
+		store := new(MockStore)
+		walletClient := new(MockWalletClient)
+		svc := NewOrderService(store, nil, nil, nil, walletClient, nil, CancellationPolicy{}, FarePolicy{})
+
+		tipped := finished
+		tipped.Tip = pgtype.Float8{Float64: 10000, Valid: true}
+		tipped.TippedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+		store.On("GetOrder", mock.Anything, id).Return(finished, nil).Once()
+		walletClient.On("Transfer", mock.Anything, &wallet.TransferRequest{
+			FromUserId:  "passenger-1",
+			ToUserId:    "driver-1",
+			Amount:      10000,
+			ReferenceId: orderID,
+			Type:        tipTransferType,
+		}).Return(&wallet.TransferResponse{ReferenceId: orderID, FromBalance: 40000, ToBalance: 90000}, nil).Once()
+		store.On("SetOrderTip", mock.Anything, db.SetOrderTipParams{ID: id, Tip: pgtype.Float8{Float64: 10000, Valid: true}}).
+			Return(tipped, nil).Once()
+
+		res, err := svc.TipDriver(ctx, &order.TipDriverRequest{OrderId: orderID, PassengerId: "passenger-1", Amount: 10000})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 10000.0, res.Tip)
+		assert.Equal(t, 40000.0, res.Balance)
+		assert.NotEmpty(t, res.TippedAt)
+		store.AssertExpectations(t)
+		walletClient.AssertExpectations(t)
+	})
+
+	t.Run("Insufficient Balance", func(t *testing.T) {
+		store := new(MockStore)
+		walletClient := new(MockWalletClient)
+		svc := NewOrderService(store, nil, nil, nil, walletClient, nil, CancellationPolicy{}, FarePolicy{})
+
+		store.On("GetOrder", mock.Anything, id).Return(finished, nil).Once()
+		walletClient.On("Transfer", mock.Anything, mock.Anything).
+			Return(nil, status.Error(codes.FailedPrecondition, "insufficient balance: 5000")).Once()
+
+		_, err := svc.TipDriver(ctx, &order.TipDriverRequest{OrderId: orderID, PassengerId: "passenger-1", Amount: 10000})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		store.AssertNotCalled(t, "SetOrderTip", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		started := finished
+		started.Status = "STARTED"
+		late := finished
+		late.FinishedAt = pgtype.Timestamptz{Time: time.Now().Add(-tipWindow - time.Minute), Valid: true}
+		tipped := finished
+		tipped.Tip = pgtype.Float8{Float64: 5000, Valid: true}
+
+		tests := []struct {
+			name      string
+			order     db.Order
+			passenger string
+			want      codes.Code
+		}{
+			{"Unfinished Ride", started, "passenger-1", codes.FailedPrecondition},
+			{"After The Window", late, "passenger-1", codes.FailedPrecondition},
+			{"Tipped Already", tipped, "passenger-1", codes.AlreadyExists},
+			{"Other Passenger", finished, "passenger-2", codes.PermissionDenied},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				store := new(MockStore)
+				walletClient := new(MockWalletClient)
+				svc := NewOrderService(store, nil, nil, nil, walletClient, nil, CancellationPolicy{}, FarePolicy{})
+
+				store.On("GetOrder", mock.Anything, id).Return(tt.order, nil).Once()
+
+				_, err := svc.TipDriver(ctx, &order.TipDriverRequest{OrderId: orderID, PassengerId: tt.passenger, Amount: 10000})
+
+				assert.Equal(t, tt.want, status.Code(err))
+				walletClient.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("Rejects Bad Amounts", func(t *testing.T) {
+		store := new(MockStore)
+		svc := NewOrderService(store, nil, nil, nil, nil, nil, CancellationPolicy{}, FarePolicy{})
+
+		for _, amount := range []float64{0, -100, maxTip + 1} {
+			_, err := svc.TipDriver(ctx, &order.TipDriverRequest{OrderId: orderID, PassengerId: "passenger-1", Amount: amount})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+		store.AssertNotCalled(t, "GetOrder", mock.Anything, mock.Anything)
+	})
+}
@@ -3,24 +3,30 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 
 	"github.com/dwikikusuma/atlas/internal/order/db"
 	"github.com/dwikikusuma/atlas/pkg/kafka"
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 
 	dispatchModel "github.com/dwikikusuma/atlas/internal/dispatch/model"
 )
 
 type OrderWorker struct {
-	consumer kafka.EventConsumer
-	store    db.Querier
+	consumer      kafka.EventConsumer
+	store         db.Store
+	trackerClient tracker.TrackerServiceClient
 }
 
-func NewOrderWorker(consumer kafka.EventConsumer, store db.Querier) *OrderWorker {
+func NewOrderWorker(consumer kafka.EventConsumer, store db.Store, trackerClient tracker.TrackerServiceClient) *OrderWorker {
 	return &OrderWorker{
-		consumer: consumer,
-		store:    store,
+		consumer:      consumer,
+		store:         store,
+		trackerClient: trackerClient,
 	}
 }
 
@@ -64,11 +70,37 @@ func (o *OrderWorker) Start(ctx context.Context) error {
 		}
 
 		args := db.UpdateOrderDriverParams{
-			ID:       uuidOrder,
-			DriverID: pgtype.Text{String: model.DriverID, Valid: true},
+			ID:           uuidOrder,
+			FromStatuses: sourcesOf(order.OrderStatus_MATCHED),
+			DriverID:     pgtype.Text{String: model.DriverID, Valid: true},
+			Actor:        ActorDispatch,
 		}
 
-		if err = o.store.UpdateOrderDriver(ctx, args); err != nil {
+		err = o.store.ExecTx(ctx, func(q db.Querier) error {
+			fromStatus, err := q.UpdateOrderDriver(ctx, args)
+			if err != nil {
+				return err
+			}
+			if model.PoolTripID != "" {
+				if err = q.UpdatePoolSequences(ctx, poolSequences(model.PoolTripID, model.Stops)); err != nil {
+					return err
+				}
+			}
+			return enqueueTransition(ctx, q, uuidOrder, fromStatus, ActorDispatch)
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Cancelled or expired while dispatch was matching it: hand the
+			// seat reserved for this ride back.
+			log.Printf("⚠️ Ignored match of RideID=%s to DriverID=%s: order already moved on", model.RideID, model.DriverID)
+			if _, err = o.trackerClient.ReleaseDriver(ctx, &tracker.ReleaseDriverRequest{DriverId: model.DriverID, RideId: model.RideID}); err != nil {
+				log.Printf("❌ Failed to release driver %s: %v", model.DriverID, err)
+			}
+			if err = o.consumer.CommitMessages(ctx, m); err != nil {
+				log.Printf("Failed to commit messages for key=%s: %v", string(m.Key), err)
+			}
+			continue
+		}
+		if err != nil {
 			log.Printf("❌ Failed to update order for RideID=%s: %v", model.RideID, err)
 			continue
 		}
@@ -81,3 +113,35 @@ func (o *OrderWorker) Start(ctx context.Context) error {
 
 	}
 }
+
+// poolSequences turns the stop list of a shared trip into the 1-based pickup
+// and drop-off position of every order on it. Riders already on board have no
+// pickup left and get 0.
+func poolSequences(tripID string, stops []dispatchModel.PoolStop) db.UpdatePoolSequencesParams {
+	arg := db.UpdatePoolSequencesParams{PoolTripID: tripID}
+	index := make(map[string]int)
+
+	for i, stop := range stops {
+		pos, ok := index[stop.RideID]
+		if !ok {
+			var orderID pgtype.UUID
+			if err := orderID.Scan(stop.RideID); err != nil {
+				log.Printf("❌ Skipping stop with invalid RideID %s on pool trip %s", stop.RideID, tripID)
+				continue
+			}
+			pos = len(arg.OrderIds)
+			index[stop.RideID] = pos
+			arg.OrderIds = append(arg.OrderIds, orderID)
+			arg.PickupSequences = append(arg.PickupSequences, 0)
+			arg.DropoffSequences = append(arg.DropoffSequences, 0)
+		}
+
+		if stop.Type == dispatchModel.StopPickup {
+			arg.PickupSequences[pos] = int32(i + 1)
+		} else {
+			arg.DropoffSequences[pos] = int32(i + 1)
+		}
+	}
+
+	return arg
+}
@@ -8,7 +8,10 @@ import (
 
 	dispatchModel "github.com/dwikikusuma/atlas/internal/dispatch/model"
 	"github.com/dwikikusuma/atlas/internal/order/db"
+	orderModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -35,17 +38,27 @@ type MockStore struct {
 	mock.Mock
 }
 
-func (m *MockStore) UpdateOrderDriver(ctx context.Context, arg db.UpdateOrderDriverParams) error {
+// ExecTx runs fn against the mock itself; the tests don't model rollbacks.
+func (m *MockStore) ExecTx(ctx context.Context, fn func(db.Querier) error) error {
+	return fn(m)
+}
+
+func (m *MockStore) CreateOutboxEvent(ctx context.Context, arg db.CreateOutboxEventParams) error {
 	args := m.Called(ctx, arg)
 	return args.Error(0)
 }
 
+func (m *MockStore) UpdateOrderDriver(ctx context.Context, arg db.UpdateOrderDriverParams) (string, error) {
+	args := m.Called(ctx, arg)
+	return args.String(0), args.Error(1)
+}
+
 // --- Test ---
 
 func TestOrderWorker_ProcessMatch(t *testing.T) {
 	mockConsumer := new(MockConsumer)
 	mockStore := new(MockStore)
-	worker := NewOrderWorker(mockConsumer, mockStore)
+	worker := NewOrderWorker(mockConsumer, mockStore, nil)
 
 	// 1. Setup Data
 	orderID := "550e8400-e29b-41d4-a716-446655440000" // Valid UUID
@@ -71,8 +84,23 @@ func TestOrderWorker_ProcessMatch(t *testing.T) {
 		// Verify UUID conversion worked
 		validID := arg.ID.Bytes != [16]byte{}
 		validDriver := arg.DriverID.String == driverID
-		return validID && validDriver
-	})).Return(nil)
+		return validID && validDriver && arg.Actor == ActorDispatch
+	})).Return("SEARCHING", nil)
+
+	// Expect the match to be queued in the outbox with the updated order
+	var uuidOrder pgtype.UUID
+	_ = uuidOrder.Scan(orderID)
+	mockStore.On("GetOrder", mock.Anything, uuidOrder).Return(db.Order{
+		ID:       uuidOrder,
+		Status:   "MATCHED",
+		DriverID: pgtype.Text{String: driverID, Valid: true},
+	}, nil)
+	mockStore.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var e orderModel.OrderEvent
+		return arg.Topic == orderEventsTopic && arg.MessageKey == orderID &&
+			json.Unmarshal(arg.Payload, &e) == nil &&
+			e.Type == orderModel.OrderEventMatched && e.FromStatus == "SEARCHING" && e.Order.DriverID == driverID
+	})).Return(nil).Once()
 
 	// Expect Commit
 	mockConsumer.On("CommitMessages", mock.Anything, mock.Anything).Return(nil)
@@ -90,3 +118,24 @@ func TestOrderWorker_ProcessMatch(t *testing.T) {
 	mockStore.AssertExpectations(t)
 	mockConsumer.AssertExpectations(t)
 }
+
+func TestPoolSequences(t *testing.T) {
+	first := "550e8400-e29b-41d4-a716-446655440000"
+	second := "550e8400-e29b-41d4-a716-446655440001"
+
+	// first is already on board; second is picked up and dropped on the way.
+	stops := []dispatchModel.PoolStop{
+		{RideID: second, Type: dispatchModel.StopPickup},
+		{RideID: second, Type: dispatchModel.StopDropoff},
+		{RideID: first, Type: dispatchModel.StopDropoff},
+	}
+
+	arg := poolSequences("pool-1", stops)
+
+	assert.Equal(t, "pool-1", arg.PoolTripID)
+	assert.Len(t, arg.OrderIds, 2)
+	assert.Equal(t, second, arg.OrderIds[0].String())
+	assert.Equal(t, first, arg.OrderIds[1].String())
+	assert.Equal(t, []int32{1, 0}, arg.PickupSequences)
+	assert.Equal(t, []int32{2, 3}, arg.DropoffSequences)
+}
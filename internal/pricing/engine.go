@@ -0,0 +1,184 @@
+package pricing
+
+import (
+	"context"
+	"log"
+	"math"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Quote is the fare of a trip and how it was computed.
+type Quote struct {
+	TariffVersion string
+	City          string
+	VehicleType   string
+	DistanceKm    float64
+	DurationMin   float64
+	// The parts of the fare before any multiplier, in IDR.
+	BaseFare     float64
+	DistanceFare float64
+	TimeFare     float64
+	BookingFee   float64
+	// MinimumFare is set when the trip priced below the tariff's minimum
+	// fare and was charged the minimum instead.
+	MinimumFare float64
+	// Rule is the time-of-day rule that applied, empty when none did.
+	Rule       string
+	Multiplier float64
+	// Surge is the demand multiplier of the pickup zone, 1 when not surging.
+	Surge float64
+	Fare  float64
+}
+
+// Trip is what a quote is asked for.
+type Trip struct {
+	VehicleType string
+	PickupLat   float64
+	PickupLong  float64
+	DropoffLat  float64
+	DropoffLong float64
+	// Stops are visited in order between pickup and dropoff.
+	Stops []Point
+	// At is when the trip starts; it selects the time-of-day rule.
+	At time.Time
+	// Surge multiplies the fare on top of the time-of-day rule. Zero means none.
+	Surge float64
+}
+
+// Engine prices trips with the current tariff config. The config can be
+// swapped while the engine is in use.
+type Engine struct {
+	cfg atomic.Pointer[Config]
+}
+
+func NewEngine(cfg *Config) *Engine {
+	e := &Engine{}
+	e.cfg.Store(cfg)
+	return e
+}
+
+// Version returns the version of the tariffs currently in use.
+func (e *Engine) Version() string {
+	return e.cfg.Load().Version
+}
+
+// Quote prices a trip, estimating its distance and duration from the straight
+// lines between pickup, each stop and dropoff.
+func (e *Engine) Quote(trip Trip) (Quote, error) {
+	return e.price(trip, func(city *City) (float64, float64) {
+		km := RouteKm(trip.route()...)
+		return km, km / city.AvgSpeedKmh * 60
+	})
+}
+
+// route is every point of the trip in the order it is driven.
+func (t Trip) route() []Point {
+	route := make([]Point, 0, len(t.Stops)+2)
+	route = append(route, Point{Lat: t.PickupLat, Long: t.PickupLong})
+	route = append(route, t.Stops...)
+	return append(route, Point{Lat: t.DropoffLat, Long: t.DropoffLong})
+}
+
+// Commission returns the share of the fare the platform keeps on a trip of
+// vehicleType starting at the pickup.
+func (e *Engine) Commission(vehicleType string, pickupLat, pickupLong float64) (float64, error) {
+	city, ok := e.cfg.Load().cityFor(pickupLat, pickupLong)
+	if !ok {
+		return 0, ErrNoTariff
+	}
+	tariff, ok := city.Tariffs[vehicleType]
+	if !ok {
+		return 0, ErrNoTariff
+	}
+	return tariff.Commission, nil
+}
+
+// Meter prices a finished trip by the distance and time it actually took.
+func (e *Engine) Meter(trip Trip, distanceKm, durationMin float64) (Quote, error) {
+	return e.price(trip, func(*City) (float64, float64) {
+		return distanceKm, durationMin
+	})
+}
+
+// price applies the tariff to a trip whose distance and duration measure returns.
+func (e *Engine) price(trip Trip, measure func(city *City) (distanceKm, durationMin float64)) (Quote, error) {
+	cfg := e.cfg.Load()
+
+	city, ok := cfg.cityFor(trip.PickupLat, trip.PickupLong)
+	if !ok {
+		return Quote{}, ErrNoTariff
+	}
+	tariff, ok := city.Tariffs[trip.VehicleType]
+	if !ok {
+		return Quote{}, ErrNoTariff
+	}
+
+	q := Quote{
+		TariffVersion: cfg.Version,
+		City:          city.Name,
+		VehicleType:   trip.VehicleType,
+		Multiplier:    1,
+		Surge:         math.Max(trip.Surge, 1),
+	}
+	q.DistanceKm, q.DurationMin = measure(city)
+	q.BaseFare = tariff.BaseFare
+	q.DistanceFare = tariff.PerKm * q.DistanceKm
+	q.TimeFare = tariff.PerMinute * q.DurationMin
+	q.BookingFee = tariff.BookingFee
+
+	if rule, ok := city.ruleAt(trip.VehicleType, trip.At); ok {
+		q.Rule = rule.Name
+		q.Multiplier = rule.Multiplier
+	}
+
+	// Surge scales the trip itself; the minimum fare and booking fee stay put.
+	fare := (q.BaseFare + q.DistanceFare + q.TimeFare) * q.Multiplier * q.Surge
+	if fare < tariff.MinimumFare {
+		fare = tariff.MinimumFare
+		q.MinimumFare = tariff.MinimumFare
+	}
+	fare += q.BookingFee
+
+	// Round to nearest whole number for clean display
+	q.Fare = math.Round(fare)
+	return q, nil
+}
+
+// Watch reloads the tariffs from path whenever the file changes, so prices can
+// be updated without a deploy. An invalid file is logged and ignored.
+func (e *Engine) Watch(ctx context.Context, path string, interval time.Duration) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				log.Printf("❌ Cannot stat tariffs %s: %v", path, err)
+				continue
+			}
+			if !info.ModTime().After(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+
+			cfg, err := LoadFile(path)
+			if err != nil {
+				log.Printf("❌ Keeping tariffs %s: %v", e.Version(), err)
+				continue
+			}
+			e.cfg.Store(cfg)
+			log.Printf("💲 Loaded tariffs version %s", cfg.Version)
+		}
+	}
+}
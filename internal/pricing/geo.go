@@ -0,0 +1,32 @@
+package pricing
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle (haversine) distance between two coordinates.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// Point is a coordinate on a route.
+type Point struct {
+	Lat  float64
+	Long float64
+}
+
+// RouteKm returns the length of the route visiting points in order, each leg
+// measured as a straight line.
+func RouteKm(points ...Point) float64 {
+	var km float64
+	for i := 1; i < len(points); i++ {
+		km += DistanceKm(points[i-1].Lat, points[i-1].Long, points[i].Lat, points[i].Long)
+	}
+	return km
+}
@@ -0,0 +1,198 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testConfig drives at 60 km/h, so a trip takes as many minutes as it has km.
+func testConfig() *Config {
+	return &Config{
+		Version: "test-1",
+		Cities: []City{{
+			Name:        "test",
+			RadiusKm:    50,
+			Timezone:    "UTC",
+			AvgSpeedKmh: 60,
+			Tariffs: map[string]Tariff{
+				"go-ride": {BaseFare: 1000, PerKm: 100, PerMinute: 10, MinimumFare: 2000, BookingFee: 500, Commission: 0.2},
+			},
+			Rules: []TimeRule{
+				{Name: "night", Days: []string{"wed"}, From: "22:00", To: "06:00", Multiplier: 2},
+			},
+		}},
+	}
+}
+
+func newTestEngine(t *testing.T) *Engine {
+	cfg := testConfig()
+	assert.NoError(t, cfg.Validate())
+	return NewEngine(cfg)
+}
+
+func TestDistanceKm(t *testing.T) {
+	// Monas to Bundaran HI
+	assert.InDelta(t, 2.228, DistanceKm(-6.1754, 106.8272, -6.1950, 106.8230), 0.001)
+	assert.InDelta(t, 111.195, DistanceKm(0, 0, 0, 1), 0.001)
+	assert.Zero(t, DistanceKm(1, 1, 1, 1))
+}
+
+func TestRouteKm(t *testing.T) {
+	there := Point{Long: 1}
+	assert.InDelta(t, 222.390, RouteKm(Point{}, there, Point{}), 0.001)
+	assert.InDelta(t, 111.195, RouteKm(Point{}, there), 0.001)
+	assert.Zero(t, RouteKm(there))
+	assert.Zero(t, RouteKm())
+}
+
+func TestEngine_Quote(t *testing.T) {
+	engine := newTestEngine(t)
+	// A Wednesday.
+	noon := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		dropLong float64
+		at       time.Time
+		wantFare float64
+		surge    float64
+		wantRule string
+	}{
+		// 1000 + 11.12 km * 100 + 11.12 min * 10 + 500
+		{"Distance And Time", 0.1, noon, 2723, 0, ""},
+		{"Minimum Fare", 0.001, noon, 2500, 0, ""},
+		{"Night Rule", 0.1, noon.Add(11 * time.Hour), 4946, 0, "night"},
+		// 02:00 Thursday is still Wednesday night.
+		{"Night Rule After Midnight", 0.1, noon.Add(14 * time.Hour), 4946, 0, "night"},
+		// 02:00 Wednesday belongs to Tuesday night.
+		{"Night Rule Other Day", 0.1, noon.Add(-10 * time.Hour), 2723, 0, ""},
+		// (1000 + 1112 + 111.2) * 1.5 + 500
+		{"Surge", 0.1, noon, 3835, 1.5, ""},
+		{"Surge On Night Rule", 0.1, noon.Add(11 * time.Hour), 7169, 1.5, "night"},
+		{"Surge Below Minimum Fare", 0.001, noon, 2500, 1.5, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := engine.Quote(Trip{VehicleType: "go-ride", DropoffLong: tt.dropLong, At: tt.at, Surge: tt.surge})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFare, q.Fare)
+			assert.Equal(t, tt.wantRule, q.Rule)
+			assert.Equal(t, "test-1", q.TariffVersion)
+			assert.Equal(t, "test", q.City)
+		})
+	}
+}
+
+func TestEngine_QuoteBreakdown(t *testing.T) {
+	engine := newTestEngine(t)
+	noon := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	q, err := engine.Quote(Trip{VehicleType: "go-ride", DropoffLong: 0.1, At: noon})
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.0, q.BaseFare)
+	assert.InDelta(t, 1111.9, q.DistanceFare, 0.1)
+	assert.InDelta(t, 111.2, q.TimeFare, 0.1)
+	assert.Equal(t, 500.0, q.BookingFee)
+	assert.Zero(t, q.MinimumFare)
+
+	q, err = engine.Quote(Trip{VehicleType: "go-ride", DropoffLong: 0.001, At: noon})
+	assert.NoError(t, err)
+	assert.Equal(t, 2000.0, q.MinimumFare)
+}
+
+func TestEngine_QuoteWithStops(t *testing.T) {
+	engine := newTestEngine(t)
+	noon := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	// Out to a stop and back past the pickup: 0.1 + 0.2 degrees, 33.36 km.
+	q, err := engine.Quote(Trip{VehicleType: "go-ride", DropoffLong: -0.1, Stops: []Point{{Long: 0.1}}, At: noon})
+	assert.NoError(t, err)
+	assert.InDelta(t, 33.36, q.DistanceKm, 0.01)
+	// 1000 + 33.36 km * 100 + 33.36 min * 10 + 500
+	assert.Equal(t, 5169.0, q.Fare)
+}
+
+func TestEngine_Meter(t *testing.T) {
+	engine := newTestEngine(t)
+	noon := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	trip := Trip{VehicleType: "go-ride", DropoffLong: 0.1, At: noon}
+
+	// The measured distance and time replace the straight-line estimate:
+	// 1000 + 15 km * 100 + 30 min * 10 + 500
+	q, err := engine.Meter(trip, 15, 30)
+	assert.NoError(t, err)
+	assert.Equal(t, 3300.0, q.Fare)
+	assert.Equal(t, 15.0, q.DistanceKm)
+	assert.Equal(t, 30.0, q.DurationMin)
+
+	trip.Surge = 1.5
+	q, err = engine.Meter(trip, 15, 30)
+	assert.NoError(t, err)
+	assert.Equal(t, 4700.0, q.Fare)
+
+	q, err = engine.Meter(trip, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2500.0, q.Fare)
+}
+
+func TestEngine_QuoteWithoutTariff(t *testing.T) {
+	engine := newTestEngine(t)
+	now := time.Now()
+
+	_, err := engine.Quote(Trip{VehicleType: "go-ride", PickupLat: 1, PickupLong: 1, DropoffLat: 1, DropoffLong: 1.1, At: now})
+	assert.ErrorIs(t, err, ErrNoTariff)
+
+	_, err = engine.Quote(Trip{VehicleType: "go-car", DropoffLong: 0.1, At: now})
+	assert.ErrorIs(t, err, ErrNoTariff)
+}
+
+func TestEngine_Commission(t *testing.T) {
+	engine := newTestEngine(t)
+
+	rate, err := engine.Commission("go-ride", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.2, rate)
+
+	_, err = engine.Commission("go-car", 0, 0)
+	assert.ErrorIs(t, err, ErrNoTariff)
+	_, err = engine.Commission("go-ride", 1, 1)
+	assert.ErrorIs(t, err, ErrNoTariff)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := testConfig()
+	cfg.Cities[0].Timezone = "Mars/Olympus"
+	assert.Error(t, cfg.Validate())
+
+	cfg = testConfig()
+	cfg.Cities[0].Rules[0].From = "10pm"
+	assert.Error(t, cfg.Validate())
+
+	cfg = testConfig()
+	cfg.Version = ""
+	assert.Error(t, cfg.Validate())
+
+	cfg = testConfig()
+	cfg.Cities[0].Tariffs["go-ride"] = Tariff{Commission: 1.5}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestLoadFile_RepoTariffs(t *testing.T) {
+	cfg, err := LoadFile("../../config/tariffs.json")
+	assert.NoError(t, err)
+
+	engine := NewEngine(cfg)
+	at := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	q, err := engine.Quote(Trip{VehicleType: "go-ride", PickupLat: -6.1754, PickupLong: 106.8272, DropoffLat: -6.1950, DropoffLong: 106.8230, At: at})
+	assert.NoError(t, err)
+	assert.Equal(t, "jakarta", q.City)
+
+	q, err = engine.Quote(Trip{VehicleType: "go-car", PickupLat: 3.5952, PickupLong: 98.6722, DropoffLat: 3.6, DropoffLong: 98.7, At: at})
+	assert.NoError(t, err)
+	assert.Equal(t, "default", q.City)
+}
@@ -0,0 +1,212 @@
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNoTariff is returned when no city covers the pickup or the city has no
+// tariff for the requested vehicle type.
+var ErrNoTariff = errors.New("no tariff for this trip")
+
+// Config is a complete, versioned set of tariffs. Orders record the version
+// that priced them, so a version must never be reused for different prices.
+type Config struct {
+	Version string `json:"version"`
+	// Cities are matched in order against the pickup; a city without a
+	// radius covers everything and belongs last.
+	Cities []City `json:"cities"`
+}
+
+// City prices trips that start within RadiusKm of its center.
+type City struct {
+	Name       string  `json:"name"`
+	CenterLat  float64 `json:"center_lat"`
+	CenterLong float64 `json:"center_long"`
+	RadiusKm   float64 `json:"radius_km"`
+	// Timezone is the IANA zone the time-of-day rules are written in.
+	Timezone string `json:"timezone"`
+	// AvgSpeedKmh turns the trip distance into the minutes charged per minute.
+	AvgSpeedKmh float64 `json:"avg_speed_kmh"`
+	// Tariffs by vehicle type.
+	Tariffs map[string]Tariff `json:"tariffs"`
+	// Rules scale the distance and time fare during parts of the day. The
+	// first matching rule applies.
+	Rules []TimeRule `json:"rules"`
+
+	location *time.Location
+}
+
+// Tariff is the price list of one vehicle type in one city, in IDR.
+type Tariff struct {
+	BaseFare    float64 `json:"base_fare"`
+	PerKm       float64 `json:"per_km"`
+	PerMinute   float64 `json:"per_minute"`
+	MinimumFare float64 `json:"minimum_fare"`
+	BookingFee  float64 `json:"booking_fee"`
+	// Commission is the share of the fare, from 0 to 1, the platform keeps;
+	// the driver earns the rest.
+	Commission float64 `json:"commission"`
+}
+
+// TimeRule multiplies the fare between From and To ("15:04", local time). A
+// rule whose To is before its From runs past midnight.
+type TimeRule struct {
+	Name string `json:"name"`
+	// Days limits the rule to some weekdays ("mon" ... "sun"); empty means every day.
+	Days []string `json:"days,omitempty"`
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	// VehicleTypes limits the rule to some vehicle types; empty means all.
+	VehicleTypes []string `json:"vehicle_types,omitempty"`
+	Multiplier   float64  `json:"multiplier"`
+
+	from, to int // minutes after midnight
+}
+
+// LoadFile reads and validates a tariff config.
+func LoadFile(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err = json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tariffs in %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks the config and prepares it for quoting.
+func (c *Config) Validate() error {
+	if c.Version == "" {
+		return errors.New("version is required")
+	}
+	if len(c.Cities) == 0 {
+		return errors.New("at least one city is required")
+	}
+
+	for i := range c.Cities {
+		city := &c.Cities[i]
+		loc, err := time.LoadLocation(city.Timezone)
+		if err != nil {
+			return fmt.Errorf("city %s: %w", city.Name, err)
+		}
+		city.location = loc
+
+		if city.AvgSpeedKmh <= 0 {
+			return fmt.Errorf("city %s: avg_speed_kmh must be positive", city.Name)
+		}
+		for vehicleType, t := range city.Tariffs {
+			if t.BaseFare < 0 || t.PerKm < 0 || t.PerMinute < 0 || t.MinimumFare < 0 || t.BookingFee < 0 {
+				return fmt.Errorf("city %s: tariff %s has a negative price", city.Name, vehicleType)
+			}
+			if t.Commission < 0 || t.Commission > 1 {
+				return fmt.Errorf("city %s: tariff %s needs a commission between 0 and 1", city.Name, vehicleType)
+			}
+		}
+		for j := range city.Rules {
+			rule := &city.Rules[j]
+			if rule.Multiplier <= 0 {
+				return fmt.Errorf("city %s: rule %s needs a positive multiplier", city.Name, rule.Name)
+			}
+			if rule.from, err = minuteOfDay(rule.From); err != nil {
+				return fmt.Errorf("city %s: rule %s: %w", city.Name, rule.Name, err)
+			}
+			if rule.to, err = minuteOfDay(rule.To); err != nil {
+				return fmt.Errorf("city %s: rule %s: %w", city.Name, rule.Name, err)
+			}
+			for _, day := range rule.Days {
+				if _, ok := weekdays[strings.ToLower(day)]; !ok {
+					return fmt.Errorf("city %s: rule %s: unknown day %q", city.Name, rule.Name, day)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func minuteOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// cityFor returns the first city covering the pickup.
+func (c *Config) cityFor(lat, long float64) (*City, bool) {
+	for i := range c.Cities {
+		city := &c.Cities[i]
+		if city.RadiusKm == 0 || DistanceKm(lat, long, city.CenterLat, city.CenterLong) <= city.RadiusKm {
+			return city, true
+		}
+	}
+	return nil, false
+}
+
+// ruleAt returns the first rule active at the given time for the vehicle type.
+func (c *City) ruleAt(vehicleType string, at time.Time) (TimeRule, bool) {
+	local := at.In(c.location)
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, rule := range c.Rules {
+		if !rule.appliesTo(vehicleType) {
+			continue
+		}
+
+		day := local.Weekday()
+		var active bool
+		if rule.from <= rule.to {
+			active = minute >= rule.from && minute < rule.to
+		} else {
+			// Past midnight: the part after midnight belongs to the day the rule started.
+			active = minute >= rule.from
+			if minute < rule.to {
+				active = true
+				day = (day + 6) % 7
+			}
+		}
+		if active && rule.onDay(day) {
+			return rule, true
+		}
+	}
+	return TimeRule{}, false
+}
+
+func (r TimeRule) appliesTo(vehicleType string) bool {
+	if len(r.VehicleTypes) == 0 {
+		return true
+	}
+	for _, v := range r.VehicleTypes {
+		if v == vehicleType {
+			return true
+		}
+	}
+	return false
+}
+
+func (r TimeRule) onDay(day time.Weekday) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, d := range r.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
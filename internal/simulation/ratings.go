@@ -0,0 +1,18 @@
+package simulation
+
+import (
+	"context"
+
+	"github.com/dwikikusuma/atlas/pkg/pb/order"
+	"google.golang.org/grpc"
+)
+
+// ratingsClient stands in for the Order Service when dispatch scores
+// drivers. Simulated drivers were never rated, so they all score as unrated.
+type ratingsClient struct {
+	order.OrderServiceClient
+}
+
+func (ratingsClient) GetUserRatings(ctx context.Context, in *order.GetUserRatingsRequest, opts ...grpc.CallOption) (*order.GetUserRatingsResponse, error) {
+	return &order.GetUserRatingsResponse{}, nil
+}
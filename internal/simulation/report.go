@@ -0,0 +1,61 @@
+package simulation
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Report summarizes how well dispatch served a scenario.
+type Report struct {
+	Requests  int
+	Matched   int
+	Pooled    int
+	Completed int
+	Cancelled int
+	Expired   int
+	Failed    int
+	// Open counts requests still searching when the simulation ended.
+	Open int
+
+	// MatchRate is the share of requests that got a driver.
+	MatchRate float64
+	// MeanWait is the time from request to match.
+	MeanWait time.Duration
+	// MeanPickupKm is the straight-line distance between driver and pickup at match time.
+	MeanPickupKm float64
+	// MeanPickupETA is the time from match until the passenger was picked up.
+	MeanPickupETA time.Duration
+	// Utilization is the share of driver time spent with at least one ride assigned.
+	Utilization float64
+}
+
+func (r Report) Write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `Requests:         %d
+Matched:          %d (%.1f%%)
+  pooled:         %d
+Completed:        %d
+Cancelled:        %d
+Expired:          %d
+Failed:           %d
+Still searching:  %d
+Mean wait:        %s
+Mean pickup:      %.2f km
+Mean pickup ETA:  %s
+Utilization:      %.1f%%
+`,
+		r.Requests,
+		r.Matched, r.MatchRate*100,
+		r.Pooled,
+		r.Completed,
+		r.Cancelled,
+		r.Expired,
+		r.Failed,
+		r.Open,
+		r.MeanWait.Round(time.Second),
+		r.MeanPickupKm,
+		r.MeanPickupETA.Round(time.Second),
+		r.Utilization*100,
+	)
+	return err
+}
@@ -0,0 +1,293 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+)
+
+// Vehicle types requested by generated passengers.
+const (
+	VehicleTypeRide = "go-ride"
+	VehicleTypeCar  = "go-car"
+)
+
+// Driver is a driver that comes online when the simulation starts.
+type Driver struct {
+	ID    string  `json:"id"`
+	Lat   float64 `json:"lat"`
+	Long  float64 `json:"long"`
+	Seats int     `json:"seats"`
+}
+
+// Request is a ride request issued AtSec seconds into the simulation. The
+// passenger cancels when no driver is found within PatienceSec.
+type Request struct {
+	AtSec       int64   `json:"at_sec"`
+	RideID      string  `json:"ride_id"`
+	PassengerID string  `json:"passenger_id"`
+	PickupLat   float64 `json:"pickup_lat"`
+	PickupLong  float64 `json:"pickup_long"`
+	DropoffLat  float64 `json:"dropoff_lat"`
+	DropoffLong float64 `json:"dropoff_long"`
+	VehicleType string  `json:"vehicle_type"`
+	Seats       int     `json:"seats,omitempty"`
+	PatienceSec int64   `json:"patience_sec"`
+}
+
+// Scenario is the supply and demand replayed by a simulation.
+type Scenario struct {
+	Drivers  []Driver  `json:"drivers"`
+	Requests []Request `json:"requests"`
+}
+
+// GenerateConfig describes a synthetic day of demand around a city center.
+type GenerateConfig struct {
+	Seed       int64
+	Drivers    int
+	Requests   int
+	Duration   time.Duration
+	CenterLat  float64
+	CenterLong float64
+	RadiusKm   float64
+	// PoolShare is the fraction of requests that ask for a shared ride.
+	PoolShare float64
+}
+
+// Generate builds a scenario from a seed; the same config always yields the
+// same scenario. Demand peaks around the 08:00 and 18:00 rush hours.
+func Generate(cfg GenerateConfig) Scenario {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	var scenario Scenario
+
+	for i := 0; i < cfg.Drivers; i++ {
+		lat, long := randomPoint(rng, cfg.CenterLat, cfg.CenterLong, cfg.RadiusKm)
+		scenario.Drivers = append(scenario.Drivers, Driver{
+			ID:    fmt.Sprintf("driver-%04d", i+1),
+			Lat:   lat,
+			Long:  long,
+			Seats: 4,
+		})
+	}
+
+	for i := 0; i < cfg.Requests; i++ {
+		at := demandTime(rng, cfg.Duration)
+		pickupLat, pickupLong := randomPoint(rng, cfg.CenterLat, cfg.CenterLong, cfg.RadiusKm)
+		dropoffLat, dropoffLong := offset(pickupLat, pickupLong, 1+rng.Float64()*11, rng.Float64()*2*math.Pi)
+
+		req := Request{
+			AtSec:       int64(at / time.Second),
+			PassengerID: fmt.Sprintf("passenger-%05d", i+1),
+			PickupLat:   pickupLat,
+			PickupLong:  pickupLong,
+			DropoffLat:  dropoffLat,
+			DropoffLong: dropoffLong,
+			VehicleType: VehicleTypeRide,
+			PatienceSec: 180 + rng.Int63n(540),
+		}
+		switch r := rng.Float64(); {
+		case r < cfg.PoolShare:
+			req.VehicleType = model.VehicleTypePool
+			req.Seats = 1
+			if rng.Float64() < 0.2 {
+				req.Seats = 2
+			}
+		case r < cfg.PoolShare+(1-cfg.PoolShare)/2:
+			req.VehicleType = VehicleTypeCar
+		}
+		scenario.Requests = append(scenario.Requests, req)
+	}
+
+	sort.SliceStable(scenario.Requests, func(i, j int) bool {
+		return scenario.Requests[i].AtSec < scenario.Requests[j].AtSec
+	})
+	for i := range scenario.Requests {
+		scenario.Requests[i].RideID = fmt.Sprintf("ride-%05d", i+1)
+	}
+	return scenario
+}
+
+// demandTime draws a request time from a day-shaped demand curve.
+func demandTime(rng *rand.Rand, duration time.Duration) time.Duration {
+	for {
+		at := time.Duration(rng.Int63n(int64(duration)))
+		hour := math.Mod(at.Hours(), 24)
+		weight := 0.2 + math.Exp(-(hour-8)*(hour-8)/2) + math.Exp(-(hour-18)*(hour-18)/2)
+		if rng.Float64()*1.2 < weight {
+			return at
+		}
+	}
+}
+
+// randomPoint returns a uniformly distributed point within radiusKm of the center.
+func randomPoint(rng *rand.Rand, lat, long, radiusKm float64) (float64, float64) {
+	distance := radiusKm * math.Sqrt(rng.Float64())
+	return offset(lat, long, distance, rng.Float64()*2*math.Pi)
+}
+
+// offset moves a coordinate distanceKm along a bearing (radians, 0 is north).
+func offset(lat, long, distanceKm, bearing float64) (float64, float64) {
+	dLat := distanceKm * math.Cos(bearing) / kmPerDegree
+	dLong := distanceKm * math.Sin(bearing) / (kmPerDegree * math.Cos(lat*math.Pi/180))
+	return lat + dLat, long + dLong
+}
+
+// LoadJSON reads a scenario written by SaveJSON.
+func LoadJSON(path string) (Scenario, error) {
+	var scenario Scenario
+
+	file, err := os.Open(path)
+	if err != nil {
+		return scenario, err
+	}
+	defer file.Close()
+
+	if err = json.NewDecoder(file).Decode(&scenario); err != nil {
+		return scenario, fmt.Errorf("decode scenario %s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// SaveJSON writes a scenario so it can be edited and replayed.
+func SaveJSON(path string, scenario Scenario) error {
+	payload, err := json.MarshalIndent(scenario, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, payload, 0o644)
+}
+
+// LoadDriversCSV reads drivers from a CSV file with the header
+// id,lat,long,seats.
+func LoadDriversCSV(path string) ([]Driver, error) {
+	rows, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+
+	drivers := make([]Driver, 0, len(rows))
+	for _, row := range rows {
+		var d Driver
+		d.ID = row.str("id")
+		d.Lat = row.float("lat")
+		d.Long = row.float("long")
+		d.Seats = int(row.int("seats"))
+		if row.err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, row.line, row.err)
+		}
+		drivers = append(drivers, d)
+	}
+	return drivers, nil
+}
+
+// LoadRequestsCSV reads ride requests from a CSV file with the header
+// at_sec,ride_id,passenger_id,pickup_lat,pickup_long,dropoff_lat,dropoff_long,vehicle_type,seats,patience_sec.
+func LoadRequestsCSV(path string) ([]Request, error) {
+	rows, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]Request, 0, len(rows))
+	for _, row := range rows {
+		var r Request
+		r.AtSec = row.int("at_sec")
+		r.RideID = row.str("ride_id")
+		r.PassengerID = row.str("passenger_id")
+		r.PickupLat = row.float("pickup_lat")
+		r.PickupLong = row.float("pickup_long")
+		r.DropoffLat = row.float("dropoff_lat")
+		r.DropoffLong = row.float("dropoff_long")
+		r.VehicleType = row.str("vehicle_type")
+		r.Seats = int(row.int("seats"))
+		r.PatienceSec = row.int("patience_sec")
+		if row.err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, row.line, row.err)
+		}
+		requests = append(requests, r)
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].AtSec < requests[j].AtSec
+	})
+	return requests, nil
+}
+
+// csvRow looks up columns by header name and keeps the first parse error.
+type csvRow struct {
+	line   int
+	header map[string]int
+	values []string
+	err    error
+}
+
+func readCSV(path string) ([]*csvRow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header of %s: %w", path, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	var rows []*csvRow
+	for line := 2; ; line++ {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		rows = append(rows, &csvRow{line: line, header: columns, values: values})
+	}
+}
+
+func (r *csvRow) str(column string) string {
+	i, ok := r.header[column]
+	if !ok || i >= len(r.values) {
+		return ""
+	}
+	return r.values[i]
+}
+
+func (r *csvRow) float(column string) float64 {
+	value := r.str(column)
+	if value == "" || r.err != nil {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.err = fmt.Errorf("column %s: %w", column, err)
+	}
+	return f
+}
+
+func (r *csvRow) int(column string) int64 {
+	value := r.str(column)
+	if value == "" || r.err != nil {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		r.err = fmt.Errorf("column %s: %w", column, err)
+	}
+	return n
+}
@@ -0,0 +1,412 @@
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/model"
+	dispatchRepo "github.com/dwikikusuma/atlas/internal/dispatch/repository"
+	dispatchService "github.com/dwikikusuma/atlas/internal/dispatch/service"
+	trackerRepo "github.com/dwikikusuma/atlas/internal/tracker/repository"
+	trackerService "github.com/dwikikusuma/atlas/internal/tracker/service"
+	"github.com/dwikikusuma/atlas/pkg/kafka"
+	pkgModel "github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/dispatch"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
+	kafkaGo "github.com/segmentio/kafka-go"
+)
+
+const (
+	gpsTopic        = "driver-gps"
+	dispatchTopic   = "ride-dispatch"
+	rideStatusTopic = "ride-status"
+
+	kmPerDegree   = 111.32
+	earthRadiusKm = 6371.0
+
+	// stopArrivalKm must match the dispatch service, which drops the stops a
+	// driver reports being this close to from its shared trip.
+	stopArrivalKm = 0.15
+
+	// Idle drivers cruise at a fraction of their driving speed.
+	idleSpeedFactor = 0.3
+)
+
+// Config controls the simulated world; the scenario decides who is in it.
+type Config struct {
+	// Seed drives the movement of idle drivers.
+	Seed     int64
+	Start    time.Time
+	Duration time.Duration
+	// Tick is how often drivers move and report their position.
+	Tick     time.Duration
+	SpeedKmh float64
+
+	// Idle drivers wander within RadiusKm of the center.
+	CenterLat  float64
+	CenterLong float64
+	RadiusKm   float64
+
+	PendingTTL time.Duration
+	Pool       dispatchService.PoolConfig
+}
+
+type rideState int
+
+const (
+	rideSearching rideState = iota
+	rideMatched
+	rideOnBoard
+	rideCompleted
+	rideCancelled
+	rideExpired
+	rideFailed
+)
+
+type ride struct {
+	Request
+	state       rideState
+	driverID    string
+	pooled      bool
+	requestedAt time.Time
+	matchedAt   time.Time
+	pickedUpAt  time.Time
+	pickupKm    float64
+}
+
+type driver struct {
+	Driver
+	heading float64
+	stops   []model.PoolStop
+	busy    time.Duration
+}
+
+// Simulator replays a scenario against the real tracker and dispatch services,
+// wired to in-memory repositories and an in-process message bus. Everything
+// runs on one goroutine and a virtual clock, so a run is fully deterministic.
+type Simulator struct {
+	cfg      Config
+	scenario Scenario
+	rng      *rand.Rand
+	now      time.Time
+
+	tracker  *trackerService.Server
+	dispatch *dispatchService.DispatchService
+
+	drivers    []*driver
+	driverByID map[string]*driver
+	rides      []*ride
+	rideByID   map[string]*ride
+	next       int
+}
+
+func New(scenario Scenario, cfg Config) *Simulator {
+	s := &Simulator{
+		cfg:        cfg,
+		scenario:   scenario,
+		rng:        rand.New(rand.NewSource(cfg.Seed)),
+		now:        cfg.Start,
+		driverByID: make(map[string]*driver),
+		rideByID:   make(map[string]*ride),
+	}
+
+	bus := kafka.NewMemoryBus()
+	locations := trackerRepo.NewMemoryLocationRepo()
+	meters := trackerRepo.NewMemoryTripMeterRepo()
+	s.tracker = trackerService.NewServer(bus, locations, meters)
+
+	s.dispatch = dispatchService.NewDispatchService(
+		trackerClient{server: s.tracker},
+		ratingsClient{},
+		bus,
+		dispatchRepo.NewMemoryPendingRepo(),
+		dispatchRepo.NewMemoryPoolTripRepo(),
+		dispatchRepo.NewMemoryAuditRepo(),
+		cfg.PendingTTL,
+		cfg.Pool,
+	)
+	s.dispatch.UseClock(func() time.Time { return s.now })
+
+	// The tracker stores the position before dispatch reacts to it, as the
+	// two consumer groups would in production.
+	ingestion := trackerService.NewIngestionWorker(nil, locations, meters)
+	matcher := dispatchService.NewMatcherWorker(nil, s.dispatch)
+	bus.Subscribe(gpsTopic, func(ctx context.Context, msg kafkaGo.Message) error {
+		var event pkgModel.LocationEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return err
+		}
+		if err := ingestion.Handle(ctx, event); err != nil {
+			return err
+		}
+		return matcher.Handle(ctx, event)
+	})
+	bus.Subscribe(dispatchTopic, s.onDispatched)
+	bus.Subscribe(rideStatusTopic, s.onStatus)
+
+	for _, d := range scenario.Drivers {
+		sim := &driver{Driver: d, heading: s.rng.Float64() * 2 * math.Pi}
+		s.drivers = append(s.drivers, sim)
+		s.driverByID[d.ID] = sim
+	}
+	return s
+}
+
+// Run plays the scenario until the configured duration has passed.
+func (s *Simulator) Run(ctx context.Context) (Report, error) {
+	for _, d := range s.drivers {
+		if err := s.reportPosition(ctx, d); err != nil {
+			return Report{}, err
+		}
+	}
+
+	end := s.cfg.Start.Add(s.cfg.Duration)
+	for s.now.Before(end) {
+		if err := ctx.Err(); err != nil {
+			return Report{}, err
+		}
+		if err := s.step(ctx); err != nil {
+			return Report{}, err
+		}
+		s.now = s.now.Add(s.cfg.Tick)
+	}
+
+	return s.report(), nil
+}
+
+func (s *Simulator) step(ctx context.Context) error {
+	elapsed := int64(s.now.Sub(s.cfg.Start) / time.Second)
+
+	for s.next < len(s.scenario.Requests) && s.scenario.Requests[s.next].AtSec <= elapsed {
+		s.request(ctx, s.scenario.Requests[s.next])
+		s.next++
+	}
+
+	for _, d := range s.drivers {
+		s.move(ctx, d)
+		if err := s.reportPosition(ctx, d); err != nil {
+			return err
+		}
+		if len(d.stops) > 0 {
+			d.busy += s.cfg.Tick
+		}
+	}
+
+	for _, r := range s.rides {
+		if r.state == rideSearching && elapsed >= r.AtSec+r.PatienceSec {
+			if err := s.dispatch.CancelPending(ctx, r.RideID); err != nil {
+				return err
+			}
+			r.state = rideCancelled
+		}
+	}
+
+	return s.dispatch.ExpirePending(ctx)
+}
+
+func (s *Simulator) request(ctx context.Context, req Request) {
+	r := &ride{Request: req, requestedAt: s.now}
+	s.rides = append(s.rides, r)
+	s.rideByID[req.RideID] = r
+
+	_, err := s.dispatch.RequestRide(ctx, &dispatch.RequestRideRequest{
+		OrderId:     req.RideID,
+		PassengerId: req.PassengerID,
+		PickupLat:   req.PickupLat,
+		PickupLong:  req.PickupLong,
+		DropoffLat:  req.DropoffLat,
+		DropoffLong: req.DropoffLong,
+		VehicleType: req.VehicleType,
+		Seats:       int32(req.Seats),
+	})
+	if err != nil && r.state == rideSearching {
+		r.state = rideFailed
+	}
+}
+
+// move drives a busy driver towards its next stop, or lets an idle one wander.
+func (s *Simulator) move(ctx context.Context, d *driver) {
+	step := s.cfg.SpeedKmh * s.cfg.Tick.Hours()
+
+	if len(d.stops) == 0 {
+		if distanceKm(d.Lat, d.Long, s.cfg.CenterLat, s.cfg.CenterLong) > s.cfg.RadiusKm {
+			d.heading = math.Atan2(
+				(s.cfg.CenterLong-d.Long)*math.Cos(d.Lat*math.Pi/180),
+				s.cfg.CenterLat-d.Lat,
+			)
+		} else {
+			d.heading += (s.rng.Float64() - 0.5) * 0.5
+		}
+		d.Lat, d.Long = offset(d.Lat, d.Long, step*idleSpeedFactor, d.heading)
+		return
+	}
+
+	target := d.stops[0]
+	remaining := distanceKm(d.Lat, d.Long, target.Lat, target.Long)
+	if remaining > step {
+		f := step / remaining
+		d.Lat += (target.Lat - d.Lat) * f
+		d.Long += (target.Long - d.Long) * f
+		return
+	}
+
+	// Arrived: handle this stop and any other stop right next to it, the
+	// same way the dispatch service advances the shared trip.
+	d.Lat, d.Long = target.Lat, target.Long
+	for len(d.stops) > 0 && distanceKm(d.Lat, d.Long, d.stops[0].Lat, d.stops[0].Long) <= stopArrivalKm {
+		s.visit(ctx, d, d.stops[0])
+		d.stops = d.stops[1:]
+	}
+}
+
+func (s *Simulator) visit(ctx context.Context, d *driver, stop model.PoolStop) {
+	r, ok := s.rideByID[stop.RideID]
+	if !ok {
+		return
+	}
+
+	if stop.Type == model.StopPickup {
+		r.state = rideOnBoard
+		r.pickedUpAt = s.now
+		return
+	}
+
+	r.state = rideCompleted
+	// The Order Service frees the seat when a ride completes.
+	_, _ = s.tracker.ReleaseDriver(ctx, &tracker.ReleaseDriverRequest{DriverId: d.ID, RideId: r.RideID})
+}
+
+func (s *Simulator) reportPosition(ctx context.Context, d *driver) error {
+	_, err := s.tracker.UpdateLocation(ctx, &tracker.UpdateLocationRequest{
+		UserId:       d.ID,
+		Latitude:     d.Lat,
+		Longitude:    d.Long,
+		Timestamp:    s.now.Format(time.RFC3339),
+		SeatCapacity: int32(d.Seats),
+	})
+	if err != nil {
+		return fmt.Errorf("update location of %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (s *Simulator) onDispatched(ctx context.Context, msg kafkaGo.Message) error {
+	var event model.RideDispatchedEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return err
+	}
+
+	r, ok := s.rideByID[event.RideID]
+	d, found := s.driverByID[event.DriverID]
+	if !ok || !found {
+		return fmt.Errorf("dispatched unknown ride %s to driver %s", event.RideID, event.DriverID)
+	}
+
+	r.state = rideMatched
+	r.driverID = d.ID
+	r.matchedAt = s.now
+	r.pickupKm = distanceKm(d.Lat, d.Long, r.PickupLat, r.PickupLong)
+
+	if event.PoolTripID != "" {
+		// The event carries the whole re-planned trip.
+		r.pooled = true
+		d.stops = append([]model.PoolStop(nil), event.Stops...)
+		return nil
+	}
+
+	d.stops = append(d.stops,
+		model.PoolStop{RideID: r.RideID, PassengerID: r.PassengerID, Type: model.StopPickup, Lat: r.PickupLat, Long: r.PickupLong},
+		model.PoolStop{RideID: r.RideID, PassengerID: r.PassengerID, Type: model.StopDropoff, Lat: r.DropoffLat, Long: r.DropoffLong},
+	)
+	return nil
+}
+
+func (s *Simulator) onStatus(ctx context.Context, msg kafkaGo.Message) error {
+	var event model.RideStatusEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return err
+	}
+
+	if event.Status == model.RideStatusExpired {
+		if r, ok := s.rideByID[event.RideID]; ok {
+			r.state = rideExpired
+		}
+	}
+	return nil
+}
+
+func (s *Simulator) report() Report {
+	var (
+		rep             Report
+		wait, pickupETA time.Duration
+		pickupKm        float64
+		pickedUp        int
+		busy, online    time.Duration
+	)
+
+	rep.Requests = len(s.rides)
+	for _, r := range s.rides {
+		switch r.state {
+		case rideSearching:
+			rep.Open++
+		case rideCancelled:
+			rep.Cancelled++
+		case rideExpired:
+			rep.Expired++
+		case rideFailed:
+			rep.Failed++
+		case rideCompleted:
+			rep.Completed++
+		}
+
+		if r.matchedAt.IsZero() {
+			continue
+		}
+		rep.Matched++
+		if r.pooled {
+			rep.Pooled++
+		}
+		wait += r.matchedAt.Sub(r.requestedAt)
+		pickupKm += r.pickupKm
+		if !r.pickedUpAt.IsZero() {
+			pickedUp++
+			pickupETA += r.pickedUpAt.Sub(r.matchedAt)
+		}
+	}
+
+	if rep.Requests > 0 {
+		rep.MatchRate = float64(rep.Matched) / float64(rep.Requests)
+	}
+	if rep.Matched > 0 {
+		rep.MeanWait = wait / time.Duration(rep.Matched)
+		rep.MeanPickupKm = pickupKm / float64(rep.Matched)
+	}
+	if pickedUp > 0 {
+		rep.MeanPickupETA = pickupETA / time.Duration(pickedUp)
+	}
+
+	for _, d := range s.drivers {
+		busy += d.busy
+		online += s.cfg.Duration
+	}
+	if online > 0 {
+		rep.Utilization = float64(busy) / float64(online)
+	}
+	return rep
+}
+
+// distanceKm uses the same great-circle formula as the dispatch service so
+// that arrivals line up with the stops it drops.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
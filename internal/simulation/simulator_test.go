@@ -0,0 +1,85 @@
+package simulation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/dispatch/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig(seed int64) Config {
+	return Config{
+		Seed:       seed,
+		Start:      time.Date(2025, time.January, 6, 0, 0, 0, 0, time.UTC),
+		Duration:   3 * time.Hour,
+		Tick:       10 * time.Second,
+		SpeedKmh:   25,
+		CenterLat:  -6.2088,
+		CenterLong: 106.8456,
+		RadiusKm:   8,
+		PendingTTL: 10 * time.Minute,
+		Pool:       service.PoolConfig{MaxDetourRatio: 0.5, MaxDetourKm: 3, MaxPickupKm: 5},
+	}
+}
+
+func testScenario(seed int64) Scenario {
+	return Generate(GenerateConfig{
+		Seed:       seed,
+		Drivers:    8,
+		Requests:   60,
+		Duration:   3 * time.Hour,
+		CenterLat:  -6.2088,
+		CenterLong: 106.8456,
+		RadiusKm:   8,
+		PoolShare:  0.3,
+	})
+}
+
+func TestSimulator_Deterministic(t *testing.T) {
+	first, err := New(testScenario(7), testConfig(7)).Run(context.Background())
+	assert.NoError(t, err)
+	second, err := New(testScenario(7), testConfig(7)).Run(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second, "the same seed must replay the same day")
+
+	assert.Equal(t, 60, first.Requests)
+	assert.Greater(t, first.Matched, 0)
+	assert.Equal(t, first.Requests, first.Matched+first.Cancelled+first.Expired+first.Failed+first.Open)
+	assert.LessOrEqual(t, first.Completed, first.Matched)
+	assert.InDelta(t, float64(first.Matched)/float64(first.Requests), first.MatchRate, 1e-9)
+	assert.Greater(t, first.Utilization, 0.0)
+}
+
+func TestLoadCSV(t *testing.T) {
+	dir := t.TempDir()
+	driversPath := filepath.Join(dir, "drivers.csv")
+	requestsPath := filepath.Join(dir, "requests.csv")
+
+	assert.NoError(t, os.WriteFile(driversPath, []byte("id,lat,long,seats\ndriver-1,-6.2,106.8,4\n"), 0o644))
+	assert.NoError(t, os.WriteFile(requestsPath, []byte(
+		"at_sec,ride_id,passenger_id,pickup_lat,pickup_long,dropoff_lat,dropoff_long,vehicle_type,seats,patience_sec\n"+
+			"120,ride-2,p-2,-6.21,106.84,-6.25,106.80,go-pool,2,300\n"+
+			"60,ride-1,p-1,-6.20,106.85,-6.22,106.82,go-ride,,300\n"), 0o644))
+
+	drivers, err := LoadDriversCSV(driversPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []Driver{{ID: "driver-1", Lat: -6.2, Long: 106.8, Seats: 4}}, drivers)
+
+	requests, err := LoadRequestsCSV(requestsPath)
+	assert.NoError(t, err)
+	if !assert.Len(t, requests, 2) {
+		return
+	}
+	assert.Equal(t, "ride-1", requests[0].RideID, "requests are replayed in time order")
+	assert.Equal(t, 0, requests[0].Seats)
+	assert.Equal(t, 2, requests[1].Seats)
+
+	assert.NoError(t, os.WriteFile(driversPath, []byte("id,lat,long,seats\ndriver-1,north,106.8,4\n"), 0o644))
+	_, err = LoadDriversCSV(driversPath)
+	assert.ErrorContains(t, err, "line 2")
+}
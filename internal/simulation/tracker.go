@@ -0,0 +1,42 @@
+package simulation
+
+import (
+	"context"
+
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
+	"google.golang.org/grpc"
+)
+
+// trackerClient lets the dispatch service call an in-process tracker server
+// as if it were the remote gRPC service.
+type trackerClient struct {
+	server tracker.TrackerServiceServer
+}
+
+func (c trackerClient) UpdateLocation(ctx context.Context, in *tracker.UpdateLocationRequest, opts ...grpc.CallOption) (*tracker.UpdateLocationResponse, error) {
+	return c.server.UpdateLocation(ctx, in)
+}
+
+func (c trackerClient) GetNearbyDrivers(ctx context.Context, in *tracker.GetNearbyDriverRequest, opts ...grpc.CallOption) (*tracker.GetNearbyDriverResponse, error) {
+	return c.server.GetNearbyDrivers(ctx, in)
+}
+
+func (c trackerClient) GetDriverLocation(ctx context.Context, in *tracker.GetDriverLocationRequest, opts ...grpc.CallOption) (*tracker.GetDriverLocationResponse, error) {
+	return c.server.GetDriverLocation(ctx, in)
+}
+
+func (c trackerClient) ReserveDriver(ctx context.Context, in *tracker.ReserveDriverRequest, opts ...grpc.CallOption) (*tracker.ReserveDriverResponse, error) {
+	return c.server.ReserveDriver(ctx, in)
+}
+
+func (c trackerClient) ReleaseDriver(ctx context.Context, in *tracker.ReleaseDriverRequest, opts ...grpc.CallOption) (*tracker.ReleaseDriverResponse, error) {
+	return c.server.ReleaseDriver(ctx, in)
+}
+
+func (c trackerClient) StartTrip(ctx context.Context, in *tracker.StartTripRequest, opts ...grpc.CallOption) (*tracker.StartTripResponse, error) {
+	return c.server.StartTrip(ctx, in)
+}
+
+func (c trackerClient) EndTrip(ctx context.Context, in *tracker.EndTripRequest, opts ...grpc.CallOption) (*tracker.EndTripResponse, error) {
+	return c.server.EndTrip(ctx, in)
+}
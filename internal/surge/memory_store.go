@@ -0,0 +1,95 @@
+package surge
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore mirrors RedisStore for tests and simulations.
+type MemoryStore struct {
+	mu          sync.Mutex
+	requests    map[string]map[string]time.Time // zone -> requestID -> requested_at
+	multipliers map[string]float64
+	lockOwner   string
+	lockUntil   time.Time
+}
+
+func NewMemoryStore() Store {
+	return &MemoryStore{
+		requests:    make(map[string]map[string]time.Time),
+		multipliers: make(map[string]float64),
+	}
+}
+
+func (s *MemoryStore) RecordRequest(ctx context.Context, zone, requestID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.requests[zone] == nil {
+		s.requests[zone] = make(map[string]time.Time)
+	}
+	s.requests[zone][requestID] = at
+	return nil
+}
+
+func (s *MemoryStore) Demand(ctx context.Context, since time.Time) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	demand := make(map[string]int)
+	for zone, requests := range s.requests {
+		for id, at := range requests {
+			if at.Before(since) {
+				delete(requests, id)
+				continue
+			}
+			demand[zone]++
+		}
+		if len(requests) == 0 {
+			delete(s.requests, zone)
+		}
+	}
+	return demand, nil
+}
+
+func (s *MemoryStore) Multipliers(ctx context.Context) (map[string]float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	multipliers := make(map[string]float64, len(s.multipliers))
+	for zone, m := range s.multipliers {
+		multipliers[zone] = m
+	}
+	return multipliers, nil
+}
+
+func (s *MemoryStore) Multiplier(ctx context.Context, zone string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.multipliers[zone]; ok {
+		return m, nil
+	}
+	return 1, nil
+}
+
+func (s *MemoryStore) SetMultiplier(ctx context.Context, zone string, multiplier float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if multiplier <= 1 {
+		delete(s.multipliers, zone)
+	} else {
+		s.multipliers[zone] = multiplier
+	}
+	return nil
+}
+
+func (s *MemoryStore) TryLock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.lockOwner != owner && now.Before(s.lockUntil) {
+		return false, nil
+	}
+	s.lockOwner, s.lockUntil = owner, now.Add(ttl)
+	return true, nil
+}
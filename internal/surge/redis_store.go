@@ -0,0 +1,119 @@
+package surge
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyRequests    = "atlas:surge:requests"    // ZSET zone|requestID -> requested_at (ms)
+	keyMultipliers = "atlas:surge:multipliers" // HASH zone -> multiplier
+	keyLock        = "atlas:surge:lock"        // STRING owner, expires
+)
+
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &RedisStore{
+		client: client,
+	}
+}
+
+func (r *RedisStore) RecordRequest(ctx context.Context, zone, requestID string, at time.Time) error {
+	err := r.client.ZAdd(ctx, keyRequests, redis.Z{
+		Score:  float64(at.UnixMilli()),
+		Member: zone + "|" + requestID,
+	}).Err()
+	if err != nil {
+		log.Printf("redis surge record failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisStore) Demand(ctx context.Context, since time.Time) (map[string]int, error) {
+	min := strconv.FormatInt(since.UnixMilli(), 10)
+
+	var members *redis.StringSliceCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, keyRequests, "-inf", "("+min)
+		members = pipe.ZRangeByScore(ctx, keyRequests, &redis.ZRangeBy{Min: min, Max: "+inf"})
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis surge demand failed: %v", err)
+		return nil, err
+	}
+
+	demand := make(map[string]int)
+	for _, member := range members.Val() {
+		zone, _, _ := strings.Cut(member, "|")
+		demand[zone]++
+	}
+	return demand, nil
+}
+
+func (r *RedisStore) Multipliers(ctx context.Context) (map[string]float64, error) {
+	res, err := r.client.HGetAll(ctx, keyMultipliers).Result()
+	if err != nil {
+		log.Printf("redis HGetAll failed: %v", err)
+		return nil, err
+	}
+
+	multipliers := make(map[string]float64, len(res))
+	for zone, value := range res {
+		m, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Printf("skipping malformed surge multiplier %s: %v", zone, err)
+			continue
+		}
+		multipliers[zone] = m
+	}
+	return multipliers, nil
+}
+
+func (r *RedisStore) Multiplier(ctx context.Context, zone string) (float64, error) {
+	m, err := r.client.HGet(ctx, keyMultipliers, zone).Float64()
+	if errors.Is(err, redis.Nil) {
+		return 1, nil
+	}
+	if err != nil {
+		log.Printf("redis HGet failed: %v", err)
+		return 0, err
+	}
+	return m, nil
+}
+
+func (r *RedisStore) SetMultiplier(ctx context.Context, zone string, multiplier float64) error {
+	var err error
+	if multiplier <= 1 {
+		err = r.client.HDel(ctx, keyMultipliers, zone).Err()
+	} else {
+		err = r.client.HSet(ctx, keyMultipliers, zone, multiplier).Err()
+	}
+	if err != nil {
+		log.Printf("redis surge set failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisStore) TryLock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	// The holder renews its own lock; anyone else waits for it to expire.
+	held, err := r.client.Get(ctx, keyLock).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+	if held == owner {
+		return true, r.client.Expire(ctx, keyLock, ttl).Err()
+	}
+	return r.client.SetNX(ctx, keyLock, owner, ttl).Result()
+}
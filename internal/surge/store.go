@@ -0,0 +1,31 @@
+package surge
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps the demand and the multipliers shared by every order service
+// replica.
+type Store interface {
+	// RecordRequest counts a ride request in a zone. requestID makes
+	// recording the same request twice count once.
+	RecordRequest(ctx context.Context, zone, requestID string, at time.Time) error
+
+	// Demand returns the number of requests per zone since the given time.
+	// Older requests are forgotten.
+	Demand(ctx context.Context, since time.Time) (map[string]int, error)
+
+	// Multipliers returns the multiplier of every surging zone.
+	Multipliers(ctx context.Context) (map[string]float64, error)
+
+	// Multiplier returns the multiplier of a zone, 1 when it is not surging.
+	Multiplier(ctx context.Context, zone string) (float64, error)
+
+	// SetMultiplier stores the multiplier of a zone; 1 ends its surge.
+	SetMultiplier(ctx context.Context, zone string, multiplier float64) error
+
+	// TryLock takes the calculator lock for ttl. It returns false while
+	// another replica holds it.
+	TryLock(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+}
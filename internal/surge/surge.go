@@ -0,0 +1,215 @@
+package surge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/dwikikusuma/atlas/pkg/kafka"
+	"github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
+)
+
+// Topic carries a SurgeChangedEvent, keyed by zone, whenever a zone's
+// multiplier changes.
+const Topic = "surge-updates"
+
+type Config struct {
+	Interval time.Duration
+	// Window is how far back ride requests count as demand.
+	Window time.Duration
+	CellKm float64
+	// MinRequests is the demand a zone needs before it can surge, so a
+	// single request in an empty area does not double its price.
+	MinRequests int
+	// Threshold is the requests per free driver above which prices surge.
+	Threshold float64
+	// Sensitivity is how much the multiplier rises per request per driver
+	// above the threshold.
+	Sensitivity   float64
+	MaxMultiplier float64
+	// Smoothing (0, 1] is the weight of the latest reading; lower values
+	// make the multiplier move more slowly.
+	Smoothing float64
+	// Step rounds the multiplier, e.g. to 0.1, so riders see stable prices.
+	Step float64
+}
+
+// Surge computes a price multiplier per zone from recent ride requests and
+// the drivers the tracker reports free around the zone.
+type Surge struct {
+	store     Store
+	tracker   tracker.TrackerServiceClient
+	producer  kafka.EventProducer
+	grid      Grid
+	cfg       Config
+	lockOwner string
+}
+
+func New(store Store, trackerClient tracker.TrackerServiceClient, producer kafka.EventProducer, cfg Config) *Surge {
+	host, _ := os.Hostname()
+	return &Surge{
+		store:     store,
+		tracker:   trackerClient,
+		producer:  producer,
+		grid:      Grid{CellKm: cfg.CellKm},
+		cfg:       cfg,
+		lockOwner: fmt.Sprintf("%s-%d", host, os.Getpid()),
+	}
+}
+
+// RecordRequest counts a ride request as demand in its pickup zone.
+func (s *Surge) RecordRequest(ctx context.Context, requestID string, lat, long float64, at time.Time) error {
+	return s.store.RecordRequest(ctx, s.grid.ZoneOf(lat, long), requestID, at)
+}
+
+// MultiplierAt returns the current multiplier of the zone containing a pickup.
+func (s *Surge) MultiplierAt(ctx context.Context, lat, long float64) (float64, error) {
+	return s.store.Multiplier(ctx, s.grid.ZoneOf(lat, long))
+}
+
+// Run recomputes the multipliers every interval. Only the replica holding the
+// lock computes, so each change is published once.
+func (s *Surge) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := s.store.TryLock(ctx, s.lockOwner, 2*s.cfg.Interval)
+			if err != nil {
+				log.Printf("❌ Failed to take surge lock: %v", err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if err = s.Recompute(ctx, time.Now()); err != nil {
+				log.Printf("❌ Failed to recompute surge: %v", err)
+			}
+		}
+	}
+}
+
+// Recompute updates the multiplier of every zone with recent demand or an
+// ongoing surge.
+func (s *Surge) Recompute(ctx context.Context, now time.Time) error {
+	demand, err := s.store.Demand(ctx, now.Add(-s.cfg.Window))
+	if err != nil {
+		return err
+	}
+	current, err := s.store.Multipliers(ctx)
+	if err != nil {
+		return err
+	}
+
+	zones := make([]string, 0, len(demand)+len(current))
+	for zone := range demand {
+		zones = append(zones, zone)
+	}
+	for zone := range current {
+		if _, ok := demand[zone]; !ok {
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+
+	for _, zone := range zones {
+		if err = s.recomputeZone(ctx, zone, demand[zone], current[zone], now); err != nil {
+			log.Printf("❌ Failed to recompute surge in zone %s: %v", zone, err)
+		}
+	}
+	return nil
+}
+
+func (s *Surge) recomputeZone(ctx context.Context, zone string, requests int, previous float64, now time.Time) error {
+	if previous == 0 {
+		previous = 1
+	}
+	lat, long, err := s.grid.Center(zone)
+	if err != nil {
+		return err
+	}
+
+	supply := 0
+	if requests > 0 {
+		res, err := s.tracker.GetNearbyDrivers(ctx, &tracker.GetNearbyDriverRequest{
+			Latitude:  lat,
+			Longitude: long,
+			Radius:    s.grid.SupplyRadiusKm(),
+		})
+		if err != nil {
+			return err
+		}
+		supply = len(res.Drivers)
+	}
+
+	next := s.smooth(previous, s.target(requests, supply))
+	if next == previous {
+		return nil
+	}
+	if err = s.store.SetMultiplier(ctx, zone, next); err != nil {
+		return err
+	}
+	log.Printf("📈 Surge in zone %s: %.1fx -> %.1fx (%d requests, %d drivers)", zone, previous, next, requests, supply)
+
+	s.publish(ctx, model.SurgeChangedEvent{
+		Zone:       zone,
+		CenterLat:  lat,
+		CenterLong: long,
+		Multiplier: next,
+		Previous:   previous,
+		Requests:   requests,
+		Drivers:    supply,
+		UpdatedAt:  now.Unix(),
+	})
+	return nil
+}
+
+// target is the multiplier the zone's current supply and demand call for.
+func (s *Surge) target(requests, drivers int) float64 {
+	if requests < s.cfg.MinRequests {
+		return 1
+	}
+	// With no free driver around, every request is unserved demand.
+	ratio := float64(requests) / math.Max(float64(drivers), 1)
+	if ratio <= s.cfg.Threshold {
+		return 1
+	}
+	return math.Min(1+s.cfg.Sensitivity*(ratio-s.cfg.Threshold), s.cfg.MaxMultiplier)
+}
+
+// smooth moves the multiplier part of the way to the target and rounds it to
+// the step. Once within a step of the target it lands on it, so a surge ends
+// instead of lingering just above 1.
+func (s *Surge) smooth(previous, target float64) float64 {
+	next := previous + s.cfg.Smoothing*(target-previous)
+	if math.Abs(next-target) < s.cfg.Step {
+		next = target
+	}
+	next = math.Round(next/s.cfg.Step) * s.cfg.Step
+	// Round away the float error of the division.
+	next = math.Round(next*100) / 100
+	return math.Min(math.Max(next, 1), s.cfg.MaxMultiplier)
+}
+
+// publish is best-effort: the multiplier is already stored and priced in, the
+// event only tells the apps to refresh.
+func (s *Surge) publish(ctx context.Context, event model.SurgeChangedEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("❌ Failed to marshal surge event: %v", err)
+		return
+	}
+	if err = s.producer.Publish(ctx, Topic, event.Zone, payload); err != nil {
+		log.Printf("❌ Failed to publish surge event for zone %s: %v", event.Zone, err)
+	}
+}
@@ -0,0 +1,180 @@
+package surge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/pkg/kafka"
+	"github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+)
+
+type MockTrackerClient struct {
+	tracker.TrackerServiceClient
+	mock.Mock
+}
+
+func (m *MockTrackerClient) GetNearbyDrivers(ctx context.Context, in *tracker.GetNearbyDriverRequest, opts ...grpc.CallOption) (*tracker.GetNearbyDriverResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*tracker.GetNearbyDriverResponse), args.Error(1)
+}
+
+func drivers(n int) *tracker.GetNearbyDriverResponse {
+	res := &tracker.GetNearbyDriverResponse{}
+	for i := 0; i < n; i++ {
+		res.Drivers = append(res.Drivers, &tracker.Driver{DriverId: fmt.Sprintf("driver-%d", i)})
+	}
+	return res
+}
+
+var testConfig = Config{
+	Interval:      time.Second,
+	Window:        10 * time.Minute,
+	CellKm:        2,
+	MinRequests:   3,
+	Threshold:     1,
+	Sensitivity:   0.5,
+	MaxMultiplier: 2.5,
+	Smoothing:     0.5,
+	Step:          0.1,
+}
+
+func TestGrid(t *testing.T) {
+	grid := Grid{CellKm: 2}
+
+	zone := grid.ZoneOf(-6.1754, 106.8272)
+	assert.Equal(t, zone, grid.ZoneOf(-6.1760, 106.8280))
+	assert.NotEqual(t, zone, grid.ZoneOf(-6.2100, 106.8272))
+
+	lat, long, err := grid.Center(zone)
+	assert.NoError(t, err)
+	assert.Equal(t, zone, grid.ZoneOf(lat, long))
+
+	_, _, err = grid.Center("nowhere")
+	assert.Error(t, err)
+}
+
+func TestSurge_Target(t *testing.T) {
+	s := New(NewMemoryStore(), nil, nil, testConfig)
+
+	tests := []struct {
+		name     string
+		requests int
+		drivers  int
+		want     float64
+	}{
+		{"Too Few Requests", 2, 0, 1},
+		{"Enough Drivers", 10, 10, 1},
+		// 1 + 0.5 * (10/4 - 1)
+		{"Busy", 10, 4, 1.75},
+		{"No Drivers", 4, 0, 2.5},
+		{"Capped", 40, 2, 2.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.target(tt.requests, tt.drivers))
+		})
+	}
+}
+
+func TestSurge_Smooth(t *testing.T) {
+	s := New(NewMemoryStore(), nil, nil, testConfig)
+
+	assert.Equal(t, 1.4, s.smooth(1, 1.75))
+	assert.Equal(t, 1.6, s.smooth(1.4, 1.75))
+	assert.Equal(t, 1.8, s.smooth(1.7, 1.75))
+	// Within a step of 1 the surge ends rather than lingering.
+	assert.Equal(t, 1.0, s.smooth(1.1, 1))
+	assert.Equal(t, 2.5, s.smooth(2.5, 4))
+}
+
+func TestSurge_Recompute(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore()
+	trackerClient := new(MockTrackerClient)
+	bus := kafka.NewMemoryBus()
+	var events []model.SurgeChangedEvent
+	bus.Subscribe(Topic, func(ctx context.Context, msg kafkago.Message) error {
+		var event model.SurgeChangedEvent
+		assert.NoError(t, json.Unmarshal(msg.Value, &event))
+		assert.Equal(t, event.Zone, string(msg.Key))
+		events = append(events, event)
+		return nil
+	})
+	s := New(store, trackerClient, bus, testConfig)
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, s.RecordRequest(ctx, fmt.Sprintf("order-%d", i), -6.1754, 106.8272, now.Add(-time.Minute)))
+	}
+	// Recorded twice, counted once.
+	assert.NoError(t, s.RecordRequest(ctx, "order-0", -6.1754, 106.8272, now.Add(-time.Minute)))
+	// Too old to count.
+	assert.NoError(t, s.RecordRequest(ctx, "order-old", -6.1754, 106.8272, now.Add(-time.Hour)))
+
+	zone := s.grid.ZoneOf(-6.1754, 106.8272)
+	trackerClient.On("GetNearbyDrivers", ctx, mock.Anything).Return(drivers(4), nil).Twice()
+
+	t.Run("Surge Builds Up", func(t *testing.T) {
+		assert.NoError(t, s.Recompute(ctx, now))
+		m, err := s.MultiplierAt(ctx, -6.1754, 106.8272)
+		assert.NoError(t, err)
+		assert.Equal(t, 1.4, m)
+
+		assert.NoError(t, s.Recompute(ctx, now))
+		m, _ = s.MultiplierAt(ctx, -6.1754, 106.8272)
+		assert.Equal(t, 1.6, m)
+
+		assert.Len(t, events, 2)
+		assert.Equal(t, model.SurgeChangedEvent{
+			Zone:       zone,
+			CenterLat:  events[1].CenterLat,
+			CenterLong: events[1].CenterLong,
+			Multiplier: 1.6,
+			Previous:   1.4,
+			Requests:   10,
+			Drivers:    4,
+			UpdatedAt:  now.Unix(),
+		}, events[1])
+	})
+
+	t.Run("Surge Fades Once Demand Is Gone", func(t *testing.T) {
+		later := now.Add(time.Hour)
+		assert.NoError(t, s.Recompute(ctx, later))
+		assert.NoError(t, s.Recompute(ctx, later))
+		assert.NoError(t, s.Recompute(ctx, later))
+
+		m, _ := s.MultiplierAt(ctx, -6.1754, 106.8272)
+		assert.Equal(t, 1.0, m)
+		assert.Equal(t, 1.0, events[len(events)-1].Multiplier)
+
+		multipliers, _ := store.Multipliers(ctx)
+		assert.Empty(t, multipliers)
+	})
+
+	trackerClient.AssertExpectations(t)
+}
+
+func TestMemoryStore_TryLock(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	ok, _ := store.TryLock(ctx, "a", time.Minute)
+	assert.True(t, ok)
+	ok, _ = store.TryLock(ctx, "b", time.Minute)
+	assert.False(t, ok)
+	ok, _ = store.TryLock(ctx, "a", time.Minute)
+	assert.True(t, ok)
+}
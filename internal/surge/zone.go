@@ -0,0 +1,38 @@
+package surge
+
+import (
+	"fmt"
+	"math"
+)
+
+// kmPerDegree is the length of one degree of latitude.
+const kmPerDegree = 111.32
+
+// Grid splits the map into square zones of CellKm a side. Cells are laid out
+// in degrees, so they narrow slightly away from the equator; that is fine at
+// the latitudes we operate in.
+type Grid struct {
+	CellKm float64
+}
+
+// ZoneOf returns the ID of the zone containing a coordinate, e.g. "-56:959".
+func (g Grid) ZoneOf(lat, long float64) string {
+	size := g.CellKm / kmPerDegree
+	return fmt.Sprintf("%d:%d", int(math.Floor(lat/size)), int(math.Floor(long/size)))
+}
+
+// Center returns the coordinate at the middle of a zone.
+func (g Grid) Center(zone string) (float64, float64, error) {
+	var row, col int
+	if _, err := fmt.Sscanf(zone, "%d:%d", &row, &col); err != nil {
+		return 0, 0, fmt.Errorf("invalid zone %q: %w", zone, err)
+	}
+	size := g.CellKm / kmPerDegree
+	return (float64(row) + 0.5) * size, (float64(col) + 0.5) * size, nil
+}
+
+// SupplyRadiusKm is the radius around a zone's center that covers the whole
+// cell, used to count the drivers available to it.
+func (g Grid) SupplyRadiusKm() float64 {
+	return g.CellKm * math.Sqrt2 / 2
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/dwikikusuma/atlas/pkg/model"
 )
@@ -13,4 +14,37 @@ type LocationRepository interface {
 	GetNearbyDrivers(ctx context.Context, lat float64, lon float64, radius float64) ([]model.LocationEvent, error)
 
 	GetDriverLocation(ctx context.Context, driverID string) (*model.LocationEvent, error)
+
+	// SetSeatCapacity records how many passengers the driver's vehicle can carry.
+	SetSeatCapacity(ctx context.Context, driverID string, seats int) error
+
+	// GetDriverLoads returns the current assignments of the given drivers,
+	// keyed by driver ID. Drivers without any ride get an empty load.
+	GetDriverLoads(ctx context.Context, driverIDs []string) (map[string]model.DriverLoad, error)
+
+	// ReserveDriver assigns a driver to a ride. seats == 0 reserves the whole
+	// vehicle for an exclusive ride; seats > 0 books seats on a pooled trip.
+	// It returns false when the driver cannot take the ride, so two rides can
+	// never claim the same seat.
+	ReserveDriver(ctx context.Context, driverID string, rideID string, seats int) (bool, error)
+
+	// ReleaseDriver frees the seats held by a ride. An empty rideID releases
+	// every ride of the driver.
+	ReleaseDriver(ctx context.Context, driverID string, rideID string) error
+}
+
+// TripMeterRepository measures the distance of rides in progress.
+type TripMeterRepository interface {
+	// StartTrip starts measuring a ride from the given position; from is nil
+	// when the driver's position is not known yet. Starting a ride that is
+	// already metered is a no-op.
+	StartTrip(ctx context.Context, driverID string, rideID string, from *model.LocationEvent, at time.Time) error
+
+	// RecordPosition adds the distance since the last position to every ride
+	// the driver is metering. Moves shorter than GPS jitter are ignored.
+	RecordPosition(ctx context.Context, driverID string, lat float64, lon float64) error
+
+	// EndTrip stops the meter of a ride and returns what it measured. Ending
+	// it again returns the same result; it returns nil for an unknown ride.
+	EndTrip(ctx context.Context, rideID string, at time.Time) (*model.TripMeter, error)
 }
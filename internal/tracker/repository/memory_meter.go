@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/tracker/domain"
+	"github.com/dwikikusuma/atlas/pkg/model"
+)
+
+// MemoryTripMeterRepo is an in-process TripMeterRepository with the same
+// semantics as the Redis repository, used by tests and the simulator.
+type MemoryTripMeterRepo struct {
+	mu     sync.Mutex
+	trips  map[string]*model.TripMeter
+	active map[string]map[string]bool // driverID -> rides being metered
+}
+
+func NewMemoryTripMeterRepo() domain.TripMeterRepository {
+	return &MemoryTripMeterRepo{
+		trips:  make(map[string]*model.TripMeter),
+		active: make(map[string]map[string]bool),
+	}
+}
+
+func (r *MemoryTripMeterRepo) StartTrip(ctx context.Context, driverID string, rideID string, from *model.LocationEvent, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.trips[rideID]; ok {
+		return nil
+	}
+
+	trip := &model.TripMeter{RideID: rideID, DriverID: driverID, StartedAt: at}
+	if from != nil {
+		trip.LastLat, trip.LastLong, trip.HasLast = from.Latitude, from.Longitude, true
+	}
+	r.trips[rideID] = trip
+
+	if r.active[driverID] == nil {
+		r.active[driverID] = make(map[string]bool)
+	}
+	r.active[driverID][rideID] = true
+	return nil
+}
+
+func (r *MemoryTripMeterRepo) RecordPosition(ctx context.Context, driverID string, lat float64, lon float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for rideID := range r.active[driverID] {
+		advance(r.trips[rideID], lat, lon)
+	}
+	return nil
+}
+
+func (r *MemoryTripMeterRepo) EndTrip(ctx context.Context, rideID string, at time.Time) (*model.TripMeter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[rideID]
+	if !ok {
+		return nil, nil
+	}
+	if trip.EndedAt.IsZero() {
+		trip.EndedAt = at
+		delete(r.active[trip.DriverID], rideID)
+	}
+
+	ended := *trip
+	return &ended, nil
+}
+
+// advance moves the meter to a new position, ignoring GPS jitter.
+func advance(trip *model.TripMeter, lat, lon float64) {
+	if !trip.HasLast {
+		trip.LastLat, trip.LastLong, trip.HasLast = lat, lon, true
+		return
+	}
+
+	d := haversineKm(trip.LastLat, trip.LastLong, lat, lon)
+	if d < minMeterMoveKm {
+		return
+	}
+	trip.DistanceKm += d
+	trip.Points++
+	trip.LastLat, trip.LastLong = lat, lon
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/dwikikusuma/atlas/internal/tracker/domain"
+	"github.com/dwikikusuma/atlas/pkg/model"
+)
+
+// nearbyLimit mirrors the COUNT of the Redis GEOSEARCH.
+const nearbyLimit = 10
+
+// MemoryLocationRepo is an in-process LocationRepository with the same
+// semantics as the Redis repository, used by the dispatch simulator.
+type MemoryLocationRepo struct {
+	mu        sync.Mutex
+	positions map[string]model.LocationEvent
+	capacity  map[string]int
+	rides     map[string]map[string]int
+}
+
+func NewMemoryLocationRepo() domain.LocationRepository {
+	return &MemoryLocationRepo{
+		positions: make(map[string]model.LocationEvent),
+		capacity:  make(map[string]int),
+		rides:     make(map[string]map[string]int),
+	}
+}
+
+func (r *MemoryLocationRepo) UpdatePosition(ctx context.Context, userID string, lat float64, lon float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.positions[userID] = model.LocationEvent{UserID: userID, Latitude: lat, Longitude: lon}
+	return nil
+}
+
+func (r *MemoryLocationRepo) GetNearbyDrivers(ctx context.Context, lat float64, lon float64, radius float64) ([]model.LocationEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type hit struct {
+		loc      model.LocationEvent
+		distance float64
+	}
+	var hits []hit
+	for _, loc := range r.positions {
+		if d := haversineKm(lat, lon, loc.Latitude, loc.Longitude); d <= radius {
+			hits = append(hits, hit{loc: loc, distance: d})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].distance != hits[j].distance {
+			return hits[i].distance < hits[j].distance
+		}
+		return hits[i].loc.UserID < hits[j].loc.UserID
+	})
+	if len(hits) > nearbyLimit {
+		hits = hits[:nearbyLimit]
+	}
+
+	var drivers []model.LocationEvent
+	for _, h := range hits {
+		drivers = append(drivers, h.loc)
+	}
+	return drivers, nil
+}
+
+func (r *MemoryLocationRepo) GetDriverLocation(ctx context.Context, driverID string) (*model.LocationEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loc, ok := r.positions[driverID]
+	if !ok {
+		return nil, errors.New("no driver found")
+	}
+	return &loc, nil
+}
+
+func (r *MemoryLocationRepo) SetSeatCapacity(ctx context.Context, driverID string, seats int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.capacity[driverID] = seats
+	return nil
+}
+
+func (r *MemoryLocationRepo) GetDriverLoads(ctx context.Context, driverIDs []string) (map[string]model.DriverLoad, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loads := make(map[string]model.DriverLoad, len(driverIDs))
+	for _, id := range driverIDs {
+		load := model.DriverLoad{Capacity: r.capacityOf(id), Rides: make(map[string]int)}
+		for rideID, seats := range r.rides[id] {
+			load.Rides[rideID] = seats
+		}
+		loads[id] = load
+	}
+	return loads, nil
+}
+
+func (r *MemoryLocationRepo) ReserveDriver(ctx context.Context, driverID string, rideID string, seats int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rides := r.rides[driverID]
+	if _, ok := rides[rideID]; ok {
+		return true, nil
+	}
+
+	used := 0
+	for _, s := range rides {
+		if s == 0 || seats == 0 {
+			return false, nil
+		}
+		used += s
+	}
+	if seats > 0 && used+seats > r.capacityOf(driverID) {
+		return false, nil
+	}
+
+	if rides == nil {
+		rides = make(map[string]int)
+		r.rides[driverID] = rides
+	}
+	rides[rideID] = seats
+	return true, nil
+}
+
+func (r *MemoryLocationRepo) ReleaseDriver(ctx context.Context, driverID string, rideID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rideID == "" {
+		delete(r.rides, driverID)
+		return nil
+	}
+	delete(r.rides[driverID], rideID)
+	return nil
+}
+
+func (r *MemoryLocationRepo) capacityOf(driverID string) int {
+	if c, ok := r.capacity[driverID]; ok {
+		return c
+	}
+	return model.DefaultSeatCapacity
+}
+
+// haversineKm returns the great-circle distance between two coordinates,
+// matching what Redis GEOSEARCH uses.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6372.797560856 // the radius Redis uses for GEO commands
+
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadiusKm * 2 * math.Asin(math.Sqrt(a))
+}
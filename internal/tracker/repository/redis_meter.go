@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/tracker/domain"
+	"github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyTripMeter    = "atlas:tracker:trip:"   // + rideID, HASH of the meter
+	keyDriverMeters = "atlas:tracker:meters:" // + driverID, SET of rides being metered
+
+	// minMeterMoveKm is the smallest move counted towards a trip, so a parked
+	// car does not drift the meter with GPS noise.
+	minMeterMoveKm = 0.01
+
+	// A running meter is dropped if the ride never ends; an ended one is kept
+	// long enough for the order service to retry reading it.
+	activeMeterTTL = 12 * time.Hour
+	endedMeterTTL  = 24 * time.Hour
+)
+
+// startMeterScript creates the meter of a ride unless it already exists.
+//
+// KEYS[1] = trip hash, KEYS[2] = meters set of the driver
+// ARGV[1] = rideID, ARGV[2] = driverID, ARGV[3] = started at (unix ms),
+// ARGV[4] = 1 when the start position is known, ARGV[5] = lat, ARGV[6] = long,
+// ARGV[7] = TTL in seconds
+var startMeterScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+  return 0
+end
+redis.call('HSET', KEYS[1], 'driver_id', ARGV[2], 'started_at', ARGV[3], 'distance_km', 0, 'points', 0)
+if ARGV[4] == '1' then
+  redis.call('HSET', KEYS[1], 'last_lat', ARGV[5], 'last_long', ARGV[6])
+end
+redis.call('EXPIRE', KEYS[1], ARGV[7])
+redis.call('SADD', KEYS[2], ARGV[1])
+redis.call('EXPIRE', KEYS[2], ARGV[7])
+return 1
+`)
+
+// advanceMeterScript moves a running meter to a new position. It returns -1
+// when the meter is gone or ended, so the ride can be dropped from the set.
+//
+// KEYS[1] = trip hash
+// ARGV[1] = lat, ARGV[2] = long, ARGV[3] = minimum move in km, ARGV[4] = earth radius in km
+var advanceMeterScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 or redis.call('HEXISTS', KEYS[1], 'ended_at') == 1 then
+  return -1
+end
+local last = redis.call('HMGET', KEYS[1], 'last_lat', 'last_long')
+if not last[1] then
+  redis.call('HSET', KEYS[1], 'last_lat', ARGV[1], 'last_long', ARGV[2])
+  return 0
+end
+local rad = math.pi / 180
+local lat1, lon1 = tonumber(last[1]), tonumber(last[2])
+local lat2, lon2 = tonumber(ARGV[1]), tonumber(ARGV[2])
+local dLat, dLon = (lat2 - lat1) * rad, (lon2 - lon1) * rad
+local a = math.sin(dLat / 2) ^ 2 + math.cos(lat1 * rad) * math.cos(lat2 * rad) * math.sin(dLon / 2) ^ 2
+local d = 2 * tonumber(ARGV[4]) * math.asin(math.sqrt(a))
+if d < tonumber(ARGV[3]) then
+  return 0
+end
+redis.call('HINCRBYFLOAT', KEYS[1], 'distance_km', d)
+redis.call('HINCRBY', KEYS[1], 'points', 1)
+redis.call('HSET', KEYS[1], 'last_lat', ARGV[1], 'last_long', ARGV[2])
+return 1
+`)
+
+// endMeterScript stops a meter the first time it is called and returns it.
+//
+// KEYS[1] = trip hash, KEYS[2] = meters set of the driver
+// ARGV[1] = rideID, ARGV[2] = ended at (unix ms), ARGV[3] = TTL in seconds
+var endMeterScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return {}
+end
+if redis.call('HEXISTS', KEYS[1], 'ended_at') == 0 then
+  redis.call('HSET', KEYS[1], 'ended_at', ARGV[2])
+  redis.call('SREM', KEYS[2], ARGV[1])
+  redis.call('EXPIRE', KEYS[1], ARGV[3])
+end
+return redis.call('HGETALL', KEYS[1])
+`)
+
+type RedisTripMeterRepo struct {
+	client *redis.Client
+}
+
+func NewRedisTripMeterRepo(client *redis.Client) domain.TripMeterRepository {
+	return &RedisTripMeterRepo{
+		client: client,
+	}
+}
+
+func (r *RedisTripMeterRepo) StartTrip(ctx context.Context, driverID string, rideID string, from *model.LocationEvent, at time.Time) error {
+	known, lat, lon := 0, 0.0, 0.0
+	if from != nil {
+		known, lat, lon = 1, from.Latitude, from.Longitude
+	}
+
+	keys := []string{keyTripMeter + rideID, keyDriverMeters + driverID}
+	err := startMeterScript.Run(ctx, r.client, keys,
+		rideID, driverID, at.UnixMilli(), known, lat, lon, int(activeMeterTTL.Seconds())).Err()
+	if err != nil {
+		log.Printf("redis start meter script failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisTripMeterRepo) RecordPosition(ctx context.Context, driverID string, lat float64, lon float64) error {
+	rides, err := r.client.SMembers(ctx, keyDriverMeters+driverID).Result()
+	if err != nil {
+		log.Printf("redis SMembers failed: %v", err)
+		return err
+	}
+
+	const earthRadiusKm = 6372.797560856 // keep in step with haversineKm
+	for _, rideID := range rides {
+		res, err := advanceMeterScript.Run(ctx, r.client, []string{keyTripMeter + rideID},
+			lat, lon, minMeterMoveKm, earthRadiusKm).Int()
+		if err != nil {
+			log.Printf("redis advance meter script failed: %v", err)
+			return err
+		}
+		if res == -1 {
+			if err := r.client.SRem(ctx, keyDriverMeters+driverID, rideID).Err(); err != nil {
+				log.Printf("redis SRem failed: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RedisTripMeterRepo) EndTrip(ctx context.Context, rideID string, at time.Time) (*model.TripMeter, error) {
+	driverID, err := r.client.HGet(ctx, keyTripMeter+rideID, "driver_id").Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("redis HGet failed: %v", err)
+		return nil, err
+	}
+
+	keys := []string{keyTripMeter + rideID, keyDriverMeters + driverID}
+	fields, err := endMeterScript.Run(ctx, r.client, keys, rideID, at.UnixMilli(), int(endedMeterTTL.Seconds())).StringSlice()
+	if err != nil {
+		log.Printf("redis end meter script failed: %v", err)
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]string, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		values[fields[i]] = fields[i+1]
+	}
+
+	trip := &model.TripMeter{RideID: rideID, DriverID: values["driver_id"]}
+	trip.DistanceKm, _ = strconv.ParseFloat(values["distance_km"], 64)
+	trip.Points, _ = strconv.Atoi(values["points"])
+	trip.StartedAt = unixMilli(values["started_at"])
+	trip.EndedAt = unixMilli(values["ended_at"])
+	if raw, ok := values["last_lat"]; ok {
+		trip.LastLat, _ = strconv.ParseFloat(raw, 64)
+		trip.LastLong, _ = strconv.ParseFloat(values["last_long"], 64)
+		trip.HasLast = true
+	}
+	return trip, nil
+}
+
+func unixMilli(raw string) time.Time {
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
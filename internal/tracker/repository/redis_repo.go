@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/dwikikusuma/atlas/internal/tracker/domain"
@@ -15,8 +16,40 @@ import (
 const (
 	keyDriverPositions = "atlas:tracker:positions"
 	keyDriverLastSeen  = "atlas:tracker:last_seen"
+	keyDriverCapacity  = "atlas:tracker:capacity"
+	keyDriverRides     = "atlas:tracker:rides:" // + driverID, HASH rideID -> seats
 )
 
+// reserveScript books a ride on a driver atomically. An exclusive ride
+// (seats == 0) needs a free driver; a pooled ride needs enough free seats and
+// no exclusive ride in progress. Booking the same ride twice is a no-op.
+//
+// KEYS[1] = rides hash of the driver, KEYS[2] = capacity hash
+// ARGV[1] = driverID, ARGV[2] = rideID, ARGV[3] = seats, ARGV[4] = default capacity
+var reserveScript = redis.NewScript(`
+local rides = redis.call('HGETALL', KEYS[1])
+local seats = tonumber(ARGV[3])
+local used = 0
+for i = 1, #rides, 2 do
+  if rides[i] == ARGV[2] then
+    return 1
+  end
+  local s = tonumber(rides[i + 1])
+  if s == 0 or seats == 0 then
+    return 0
+  end
+  used = used + s
+end
+if seats > 0 then
+  local capacity = tonumber(redis.call('HGET', KEYS[2], ARGV[1]) or ARGV[4])
+  if used + seats > capacity then
+    return 0
+  end
+end
+redis.call('HSET', KEYS[1], ARGV[2], seats)
+return 1
+`)
+
 type RedisClientRepo struct {
 	client *redis.Client
 }
@@ -99,6 +132,76 @@ func (r *RedisClientRepo) GetDriverLocation(ctx context.Context, driverID string
 	}, nil
 }
 
+func (r *RedisClientRepo) SetSeatCapacity(ctx context.Context, driverID string, seats int) error {
+	if err := r.client.HSet(ctx, keyDriverCapacity, driverID, seats).Err(); err != nil {
+		log.Printf("redis HSet failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisClientRepo) GetDriverLoads(ctx context.Context, driverIDs []string) (map[string]model.DriverLoad, error) {
+	loads := make(map[string]model.DriverLoad, len(driverIDs))
+	if len(driverIDs) == 0 {
+		return loads, nil
+	}
+
+	pipe := r.client.Pipeline()
+	capacities := pipe.HMGet(ctx, keyDriverCapacity, driverIDs...)
+	rides := make([]*redis.MapStringStringCmd, len(driverIDs))
+	for i, id := range driverIDs {
+		rides[i] = pipe.HGetAll(ctx, keyDriverRides+id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("redis pipeline exec failed: %v", err)
+		return nil, err
+	}
+
+	for i, id := range driverIDs {
+		load := model.DriverLoad{
+			Capacity: model.DefaultSeatCapacity,
+			Rides:    make(map[string]int),
+		}
+		if raw, ok := capacities.Val()[i].(string); ok {
+			if capacity, err := strconv.Atoi(raw); err == nil {
+				load.Capacity = capacity
+			}
+		}
+		for rideID, raw := range rides[i].Val() {
+			seats, _ := strconv.Atoi(raw)
+			load.Rides[rideID] = seats
+		}
+		loads[id] = load
+	}
+
+	return loads, nil
+}
+
+func (r *RedisClientRepo) ReserveDriver(ctx context.Context, driverID string, rideID string, seats int) (bool, error) {
+	keys := []string{keyDriverRides + driverID, keyDriverCapacity}
+	ok, err := reserveScript.Run(ctx, r.client, keys, driverID, rideID, seats, model.DefaultSeatCapacity).Int()
+	if err != nil {
+		log.Printf("redis reserve script failed: %v", err)
+		return false, err
+	}
+
+	return ok == 1, nil
+}
+
+func (r *RedisClientRepo) ReleaseDriver(ctx context.Context, driverID string, rideID string) error {
+	var err error
+	if rideID == "" {
+		err = r.client.Del(ctx, keyDriverRides+driverID).Err()
+	} else {
+		err = r.client.HDel(ctx, keyDriverRides+driverID, rideID).Err()
+	}
+	if err != nil {
+		log.Printf("redis release driver failed: %v", err)
+		return err
+	}
+	return nil
+}
+
 func (r *RedisClientRepo) RemoveStaleDrivers(ctx context.Context, ttl time.Duration) error {
 	limit := time.Now().Add(-ttl).Unix()
 	staleDrivers, err := r.client.ZRangeByScore(ctx, keyDriverLastSeen, &redis.ZRangeBy{
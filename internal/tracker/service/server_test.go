@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/dwikikusuma/atlas/internal/tracker/repository"
 	"github.com/dwikikusuma/atlas/pkg/model"
 	"github.com/dwikikusuma/atlas/pkg/pb/tracker"
 	"github.com/stretchr/testify/assert"
@@ -56,6 +57,29 @@ func (m *MockLocationRepository) GetDriverLocation(ctx context.Context, driverID
 	return args.Get(0).(*model.LocationEvent), args.Error(1)
 }
 
+func (m *MockLocationRepository) SetSeatCapacity(ctx context.Context, driverID string, seats int) error {
+	args := m.Called(ctx, driverID, seats)
+	return args.Error(0)
+}
+
+func (m *MockLocationRepository) GetDriverLoads(ctx context.Context, driverIDs []string) (map[string]model.DriverLoad, error) {
+	args := m.Called(ctx, driverIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]model.DriverLoad), args.Error(1)
+}
+
+func (m *MockLocationRepository) ReserveDriver(ctx context.Context, driverID string, rideID string, seats int) (bool, error) {
+	args := m.Called(ctx, driverID, rideID, seats)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLocationRepository) ReleaseDriver(ctx context.Context, driverID string, rideID string) error {
+	args := m.Called(ctx, driverID, rideID)
+	return args.Error(0)
+}
+
 // =============================================================================
 // TESTS WITH LOGGING
 // =============================================================================
@@ -63,7 +87,7 @@ func (m *MockLocationRepository) GetDriverLocation(ctx context.Context, driverID
 func TestUpdateLocation(t *testing.T) {
 	mockProducer := new(MockEventProducer)
 	mockRepo := new(MockLocationRepository)
-	server := NewServer(mockProducer, mockRepo)
+	server := NewServer(mockProducer, mockRepo, repository.NewMemoryTripMeterRepo())
 	ctx := context.Background()
 
 	req := &tracker.UpdateLocationRequest{
@@ -117,7 +141,7 @@ func TestUpdateLocation(t *testing.T) {
 func TestGetNearbyDrivers(t *testing.T) {
 	mockProducer := new(MockEventProducer)
 	mockRepo := new(MockLocationRepository)
-	server := NewServer(mockProducer, mockRepo)
+	server := NewServer(mockProducer, mockRepo, repository.NewMemoryTripMeterRepo())
 	ctx := context.Background()
 
 	req := &tracker.GetNearbyDriverRequest{
@@ -136,6 +160,7 @@ func TestGetNearbyDrivers(t *testing.T) {
 		}
 
 		mockRepo.On("GetNearbyDrivers", ctx, req.Latitude, req.Longitude, req.Radius).Return(mockData, nil).Once()
+		mockRepo.On("GetDriverLoads", ctx, []string{"driver-1", "driver-2"}).Return(map[string]model.DriverLoad{}, nil).Once()
 
 		resp, err := server.GetNearbyDrivers(ctx, req)
 
@@ -149,6 +174,44 @@ func TestGetNearbyDrivers(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Filters By Seats", func(t *testing.T) {
+		t.Logf("🧪 [SCENARIO]: Busy Drivers Are Hidden, Pooled Trips Offer Free Seats")
+
+		mockData := []model.LocationEvent{
+			{UserID: "driver-free", Latitude: -6.21, Longitude: 106.81},
+			{UserID: "driver-pool", Latitude: -6.22, Longitude: 106.82},
+			{UserID: "driver-exclusive", Latitude: -6.23, Longitude: 106.83},
+		}
+		loads := map[string]model.DriverLoad{
+			"driver-free":      {Capacity: 4, Rides: map[string]int{}},
+			"driver-pool":      {Capacity: 4, Rides: map[string]int{"ride-1": 2}},
+			"driver-exclusive": {Capacity: 4, Rides: map[string]int{"ride-2": 0}},
+		}
+
+		mockRepo.On("GetNearbyDrivers", ctx, req.Latitude, req.Longitude, req.Radius).Return(mockData, nil).Twice()
+		mockRepo.On("GetDriverLoads", ctx, mock.Anything).Return(loads, nil).Twice()
+
+		exclusive, err := server.GetNearbyDrivers(ctx, req)
+		assert.NoError(t, err)
+
+		pooled, err := server.GetNearbyDrivers(ctx, &tracker.GetNearbyDriverRequest{
+			Latitude:  req.Latitude,
+			Longitude: req.Longitude,
+			Radius:    req.Radius,
+			MinSeats:  2,
+		})
+		assert.NoError(t, err)
+
+		t.Logf("✅ RESULT: %d driver(s) for an exclusive ride, %d for a 2-seat pooled ride", len(exclusive.Drivers), len(pooled.Drivers))
+
+		assert.Len(t, exclusive.Drivers, 1)
+		assert.Equal(t, "driver-free", exclusive.Drivers[0].DriverId)
+		assert.Len(t, pooled.Drivers, 2)
+		assert.Equal(t, int32(2), pooled.Drivers[1].RemainingSeats)
+		assert.Equal(t, []string{"ride-1"}, pooled.Drivers[1].ActiveRideIds)
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("Repo Failure", func(t *testing.T) {
 		t.Logf("🧪 [SCENARIO]: Redis GeoSearch Fails")
 
@@ -167,7 +230,7 @@ func TestGetNearbyDrivers(t *testing.T) {
 func TestGetDriverLocation(t *testing.T) {
 	mockProducer := new(MockEventProducer)
 	mockRepo := new(MockLocationRepository)
-	server := NewServer(mockProducer, mockRepo)
+	server := NewServer(mockProducer, mockRepo, repository.NewMemoryTripMeterRepo())
 	ctx := context.Background()
 
 	req := &tracker.GetDriverLocationRequest{DriverId: "driver-99"}
@@ -204,3 +267,100 @@ func TestGetDriverLocation(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestReserveDriver(t *testing.T) {
+	mockProducer := new(MockEventProducer)
+	mockRepo := new(MockLocationRepository)
+	server := NewServer(mockProducer, mockRepo, repository.NewMemoryTripMeterRepo())
+	ctx := context.Background()
+
+	req := &tracker.ReserveDriverRequest{DriverId: "driver-99", RideId: "ride-1"}
+
+	t.Run("Driver Available", func(t *testing.T) {
+		t.Logf("🧪 [SCENARIO]: Reserve A Free Driver")
+		t.Logf("📝 INPUT: DriverID=%s RideID=%s", req.DriverId, req.RideId)
+
+		mockRepo.On("ReserveDriver", ctx, "driver-99", "ride-1", 0).Return(true, nil).Once()
+
+		resp, err := server.ReserveDriver(ctx, req)
+
+		t.Logf("✅ RESULT: Reserved=%v", resp.Reserved)
+
+		assert.NoError(t, err)
+		assert.True(t, resp.Reserved)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Driver Already Reserved", func(t *testing.T) {
+		t.Logf("🧪 [SCENARIO]: Driver Is Already On Another Ride")
+
+		mockRepo.On("ReserveDriver", ctx, "driver-99", "ride-1", 0).Return(false, nil).Once()
+
+		resp, err := server.ReserveDriver(ctx, req)
+
+		t.Logf("⚠️ RESULT: Reserved=%v", resp.Reserved)
+
+		assert.NoError(t, err)
+		assert.False(t, resp.Reserved)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Missing Ride ID", func(t *testing.T) {
+		t.Logf("🧪 [SCENARIO]: Reserve Without Ride ID")
+
+		_, err := server.ReserveDriver(ctx, &tracker.ReserveDriverRequest{DriverId: "driver-99"})
+
+		t.Logf("⚠️ EXPECTED ERROR (400 Invalid Argument): %v", err)
+
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+	})
+}
+
+func TestTripMeter(t *testing.T) {
+	mockProducer := new(MockEventProducer)
+	mockRepo := new(MockLocationRepository)
+	meters := repository.NewMemoryTripMeterRepo()
+	server := NewServer(mockProducer, mockRepo, meters)
+	worker := NewIngestionWorker(nil, mockRepo, meters)
+	ctx := context.Background()
+
+	mockRepo.On("GetDriverLocation", ctx, "driver-1").Return(&model.LocationEvent{UserID: "driver-1"}, nil).Once()
+	mockRepo.On("UpdatePosition", ctx, "driver-1", mock.Anything, mock.Anything).Return(nil)
+
+	t.Logf("🧪 [SCENARIO]: Driver Drives Two Hundredths Of A Degree North")
+
+	_, err := server.StartTrip(ctx, &tracker.StartTripRequest{DriverId: "driver-1", RideId: "ride-1"})
+	assert.NoError(t, err)
+
+	for _, lat := range []float64{0.01, 0.01, 0.02, 0.02001} {
+		assert.NoError(t, worker.Handle(ctx, model.LocationEvent{UserID: "driver-1", Latitude: lat}))
+	}
+
+	resp, err := server.EndTrip(ctx, &tracker.EndTripRequest{RideId: "ride-1"})
+
+	t.Logf("✅ RESULT: Distance=%.3f km Points=%d", resp.DistanceKm, resp.Points)
+
+	assert.NoError(t, err)
+	// The parked update and the 1 m drift are not counted.
+	assert.InDelta(t, 2.224, resp.DistanceKm, 0.001)
+	assert.Equal(t, int32(2), resp.Points)
+
+	t.Run("Ended Meter Stops", func(t *testing.T) {
+		assert.NoError(t, worker.Handle(ctx, model.LocationEvent{UserID: "driver-1", Latitude: 0.05}))
+
+		again, err := server.EndTrip(ctx, &tracker.EndTripRequest{RideId: "ride-1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, resp.DistanceKm, again.DistanceKm)
+	})
+
+	t.Run("Unknown Ride", func(t *testing.T) {
+		_, err := server.EndTrip(ctx, &tracker.EndTripRequest{RideId: "ride-2"})
+
+		t.Logf("⚠️ EXPECTED ERROR (404 Not Found): %v", err)
+
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.NotFound, st.Code())
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/dwikikusuma/atlas/internal/tracker/domain"
 	"github.com/dwikikusuma/atlas/pkg/kafka"
@@ -17,21 +18,24 @@ type Server struct {
 	tracker.UnimplementedTrackerServiceServer
 	producer kafka.EventProducer
 	repo     domain.LocationRepository
+	meters   domain.TripMeterRepository
 }
 
-func NewServer(producer kafka.EventProducer, repo domain.LocationRepository) *Server {
+func NewServer(producer kafka.EventProducer, repo domain.LocationRepository, meters domain.TripMeterRepository) *Server {
 	return &Server{
 		producer: producer,
 		repo:     repo,
+		meters:   meters,
 	}
 }
 
 func (s *Server) UpdateLocation(ctx context.Context, req *tracker.UpdateLocationRequest) (*tracker.UpdateLocationResponse, error) {
 	event := model.LocationEvent{
-		UserID:    req.UserId,
-		Latitude:  req.Latitude,
-		Longitude: req.Longitude,
-		Timestamp: req.Timestamp,
+		UserID:       req.UserId,
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		Timestamp:    req.Timestamp,
+		SeatCapacity: req.SeatCapacity,
 	}
 
 	eventByte, err := json.Marshal(event)
@@ -57,12 +61,32 @@ func (s *Server) GetNearbyDrivers(ctx context.Context, req *tracker.GetNearbyDri
 		return nil, status.Errorf(codes.Internal, "failed to get nearby drivers: %v", err)
 	}
 
+	if len(location) == 0 {
+		return &tracker.GetNearbyDriverResponse{}, nil
+	}
+
+	ids := make([]string, len(location))
+	for i, loc := range location {
+		ids[i] = loc.UserID
+	}
+	loads, err := s.repo.GetDriverLoads(ctx, ids)
+	if err != nil {
+		log.Printf("failed to get driver loads: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to get driver loads: %v", err)
+	}
+
 	var res []*tracker.Driver
 	for _, loc := range location {
+		load := loads[loc.UserID]
+		if !available(load, int(req.MinSeats)) {
+			continue
+		}
 		res = append(res, &tracker.Driver{
-			DriverId:  loc.UserID,
-			Longitude: loc.Longitude,
-			Latitude:  loc.Latitude,
+			DriverId:       loc.UserID,
+			Longitude:      loc.Longitude,
+			Latitude:       loc.Latitude,
+			RemainingSeats: int32(load.RemainingSeats()),
+			ActiveRideIds:  load.RideIDs(),
 		})
 	}
 
@@ -86,3 +110,87 @@ func (s *Server) GetDriverLocation(ctx context.Context, req *tracker.GetDriverLo
 		Latitude:  location.Latitude,
 	}, nil
 }
+
+func (s *Server) ReserveDriver(ctx context.Context, req *tracker.ReserveDriverRequest) (*tracker.ReserveDriverResponse, error) {
+	if req.DriverId == "" || req.RideId == "" {
+		return nil, status.Error(codes.InvalidArgument, "driver ID and ride ID are required")
+	}
+	if req.Seats < 0 {
+		return nil, status.Error(codes.InvalidArgument, "seats must not be negative")
+	}
+
+	reserved, err := s.repo.ReserveDriver(ctx, req.DriverId, req.RideId, int(req.Seats))
+	if err != nil {
+		log.Printf("failed to reserve driver: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to reserve driver: %v", err)
+	}
+
+	return &tracker.ReserveDriverResponse{Reserved: reserved}, nil
+}
+
+func (s *Server) ReleaseDriver(ctx context.Context, req *tracker.ReleaseDriverRequest) (*tracker.ReleaseDriverResponse, error) {
+	if req.DriverId == "" {
+		return nil, status.Error(codes.InvalidArgument, "driver ID is required")
+	}
+
+	if err := s.repo.ReleaseDriver(ctx, req.DriverId, req.RideId); err != nil {
+		log.Printf("failed to release driver: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to release driver: %v", err)
+	}
+
+	return &tracker.ReleaseDriverResponse{Success: true}, nil
+}
+
+// StartTrip starts metering a ride from the driver's current position.
+func (s *Server) StartTrip(ctx context.Context, req *tracker.StartTripRequest) (*tracker.StartTripResponse, error) {
+	if req.DriverId == "" || req.RideId == "" {
+		return nil, status.Error(codes.InvalidArgument, "driver ID and ride ID are required")
+	}
+
+	// A driver that has not reported a position yet is metered from the
+	// first update that arrives.
+	from, err := s.repo.GetDriverLocation(ctx, req.DriverId)
+	if err != nil {
+		from = nil
+	}
+
+	if err := s.meters.StartTrip(ctx, req.DriverId, req.RideId, from, time.Now()); err != nil {
+		log.Printf("failed to start trip meter: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to start trip meter: %v", err)
+	}
+
+	return &tracker.StartTripResponse{Success: true}, nil
+}
+
+// EndTrip stops the meter of a ride and returns the distance and time it took.
+func (s *Server) EndTrip(ctx context.Context, req *tracker.EndTripRequest) (*tracker.EndTripResponse, error) {
+	if req.RideId == "" {
+		return nil, status.Error(codes.InvalidArgument, "ride ID is required")
+	}
+
+	now := time.Now()
+	trip, err := s.meters.EndTrip(ctx, req.RideId, now)
+	if err != nil {
+		log.Printf("failed to end trip meter: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to end trip meter: %v", err)
+	}
+	if trip == nil {
+		return nil, status.Error(codes.NotFound, "trip was not metered")
+	}
+
+	return &tracker.EndTripResponse{
+		DistanceKm:      trip.DistanceKm,
+		DurationSeconds: int64(trip.Duration(now).Seconds()),
+		Points:          int32(trip.Points),
+	}, nil
+}
+
+// available decides whether a driver can be offered for a ride. Exclusive
+// rides (minSeats == 0) need a driver without any ride; pooled rides need
+// enough free seats on a vehicle that is not on an exclusive ride.
+func available(load model.DriverLoad, minSeats int) bool {
+	if minSeats == 0 {
+		return load.Free()
+	}
+	return load.RemainingSeats() >= minSeats
+}
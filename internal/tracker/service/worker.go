@@ -13,12 +13,14 @@ import (
 type IngestionWorker struct {
 	consumer kafka.EventConsumer
 	repo     domain.LocationRepository
+	meters   domain.TripMeterRepository
 }
 
-func NewIngestionWorker(consumer kafka.EventConsumer, repo domain.LocationRepository) *IngestionWorker {
+func NewIngestionWorker(consumer kafka.EventConsumer, repo domain.LocationRepository, meters domain.TripMeterRepository) *IngestionWorker {
 	return &IngestionWorker{
 		consumer: consumer,
 		repo:     repo,
+		meters:   meters,
 	}
 }
 
@@ -45,9 +47,7 @@ func (w *IngestionWorker) Run(ctx context.Context) {
 			continue
 		}
 
-		err = w.repo.UpdatePosition(ctx, event.UserID, event.Latitude, event.Longitude)
-		if err != nil {
-			log.Printf("Error updating position: %v", err)
+		if err = w.Handle(ctx, event); err != nil {
 			continue
 		}
 
@@ -57,3 +57,28 @@ func (w *IngestionWorker) Run(ctx context.Context) {
 		}
 	}
 }
+
+// Handle stores a single driver location update.
+func (w *IngestionWorker) Handle(ctx context.Context, event model.LocationEvent) error {
+	err := w.repo.UpdatePosition(ctx, event.UserID, event.Latitude, event.Longitude)
+	if err != nil {
+		log.Printf("Error updating position: %v", err)
+		return err
+	}
+
+	err = w.meters.RecordPosition(ctx, event.UserID, event.Latitude, event.Longitude)
+	if err != nil {
+		log.Printf("Error recording trip position: %v", err)
+		return err
+	}
+
+	if event.SeatCapacity > 0 {
+		err = w.repo.SetSeatCapacity(ctx, event.UserID, int(event.SeatCapacity))
+		if err != nil {
+			log.Printf("Error updating seat capacity: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
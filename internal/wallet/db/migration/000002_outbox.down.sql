@@ -0,0 +1,4 @@
+-- internal/wallet/db/migration/000002_outbox.down.sql
+-- Rollback for 000002_outbox.up.sql
+
+DROP TABLE IF EXISTS outbox;
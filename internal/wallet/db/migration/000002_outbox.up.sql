@@ -0,0 +1,19 @@
+-- internal/wallet/db/migration/000002_outbox.up.sql
+
+-- Events waiting to be published to Kafka. Rows are written in the same
+-- transaction as the state change they describe and published by the relay.
+CREATE TABLE outbox
+(
+    id              BIGSERIAL PRIMARY KEY,
+    topic           VARCHAR(100) NOT NULL,
+    message_key     VARCHAR(100) NOT NULL,
+    payload         JSONB        NOT NULL,
+    attempts        INT          NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    sent_at         TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_pending ON outbox (topic, message_key, id) WHERE sent_at IS NULL;
+CREATE INDEX idx_outbox_sent ON outbox (sent_at) WHERE sent_at IS NOT NULL;
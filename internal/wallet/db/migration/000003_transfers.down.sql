@@ -0,0 +1,4 @@
+-- internal/wallet/db/migration/000003_transfers.down.sql
+-- Rollback for 000003_transfers.up.sql
+
+DROP INDEX IF EXISTS idx_transactions_transfer;
@@ -0,0 +1,7 @@
+-- internal/wallet/db/migration/000003_transfers.up.sql
+
+-- A transfer posts one entry on each wallet under the same reference. Only one
+-- transfer of a kind is allowed per reference, e.g. one tip per order.
+CREATE UNIQUE INDEX idx_transactions_transfer
+    ON transactions (wallet_id, description, reference_id)
+    WHERE description IN ('TIP');
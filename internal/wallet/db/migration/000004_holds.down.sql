@@ -0,0 +1,4 @@
+-- internal/wallet/db/migration/000004_holds.down.sql
+-- Rollback for 000004_holds.up.sql
+
+DROP TABLE IF EXISTS holds;
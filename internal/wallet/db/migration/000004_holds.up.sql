@@ -0,0 +1,17 @@
+-- internal/wallet/db/migration/000004_holds.up.sql
+
+-- Money set aside on a wallet for an order until the ride is paid or called
+-- off. Active holds are subtracted from the balance a user can spend.
+CREATE TABLE holds (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    wallet_id       VARCHAR(50)      NOT NULL REFERENCES wallets (user_id),
+    reference_id    VARCHAR(50)      NOT NULL UNIQUE, -- The order the fare is held for
+    amount          DOUBLE PRECISION NOT NULL CHECK (amount > 0),
+    status          VARCHAR(20)      NOT NULL DEFAULT 'HELD', -- HELD, CAPTURED or RELEASED
+    captured_amount DOUBLE PRECISION, -- What was finally debited, may differ from amount
+    expires_at      TIMESTAMPTZ      NOT NULL, -- Stops counting against the balance after this
+    created_at      TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_holds_wallet_active ON holds (wallet_id) WHERE status = 'HELD';
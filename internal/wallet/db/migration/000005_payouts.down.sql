@@ -0,0 +1,9 @@
+-- internal/wallet/db/migration/000005_payouts.down.sql
+-- Rollback for 000005_payouts.up.sql
+
+DROP INDEX IF EXISTS idx_transactions_transfer;
+CREATE UNIQUE INDEX idx_transactions_transfer
+    ON transactions (wallet_id, description, reference_id)
+    WHERE description IN ('TIP');
+
+-- The platform wallet keeps its ledger; it is only no longer paid into.
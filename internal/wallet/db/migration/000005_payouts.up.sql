@@ -0,0 +1,13 @@
+-- internal/wallet/db/migration/000005_payouts.up.sql
+
+-- The platform's own wallet, which keeps the commission on every fare and pays
+-- for promo discounts. It may go negative.
+INSERT INTO wallets (user_id, balance)
+VALUES ('platform', 0.0)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- Paying out a fare posts each of its entries once per order, like transfers.
+DROP INDEX IF EXISTS idx_transactions_transfer;
+CREATE UNIQUE INDEX idx_transactions_transfer
+    ON transactions (wallet_id, description, reference_id)
+    WHERE description IN ('TIP', 'EARNINGS', 'COMMISSION', 'PROMO_SUBSIDY');
@@ -0,0 +1,7 @@
+-- internal/wallet/db/migration/000006_payment_methods.up.sql
+
+-- Card payments and cash commissions are posted once per order too.
+DROP INDEX IF EXISTS idx_transactions_transfer;
+CREATE UNIQUE INDEX idx_transactions_transfer
+    ON transactions (wallet_id, description, reference_id)
+    WHERE description IN ('TIP', 'EARNINGS', 'COMMISSION', 'PROMO_SUBSIDY', 'CARD', 'CASH_COMMISSION');
@@ -8,6 +8,30 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Hold struct {
+	ID             pgtype.UUID        `json:"id"`
+	WalletID       string             `json:"wallet_id"`
+	ReferenceID    string             `json:"reference_id"`
+	Amount         float64            `json:"amount"`
+	Status         string             `json:"status"`
+	CapturedAmount pgtype.Float8      `json:"captured_amount"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Outbox struct {
+	ID            int64              `json:"id"`
+	Topic         string             `json:"topic"`
+	MessageKey    string             `json:"message_key"`
+	Payload       []byte             `json:"payload"`
+	Attempts      int32              `json:"attempts"`
+	LastError     pgtype.Text        `json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	SentAt        pgtype.Timestamptz `json:"sent_at"`
+}
+
 type Transaction struct {
 	ID          pgtype.UUID        `json:"id"`
 	WalletID    string             `json:"wallet_id"`
@@ -6,14 +6,37 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
 	AddWalletBalance(ctx context.Context, arg AddWalletBalanceParams) (Wallet, error)
+	// Leases the oldest unsent event of every topic and key that is due. Later
+	// events of the same key wait until it is sent, so consumers see them in order.
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error)
+	// Captures or releases an active hold. Returns no rows when the hold is
+	// unknown or already closed.
+	CloseHold(ctx context.Context, arg CloseHoldParams) (Hold, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error
 	CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error)
 	// internal/wallet/db/query/wallet.sql
 	CreateWallet(ctx context.Context, userID string) (Wallet, error)
+	DeleteSentOutboxEvents(ctx context.Context, sentBefore pgtype.Timestamptz) (int64, error)
+	// Opens a wallet for a user unless they already have one, e.g. a driver paid
+	// for their first ride.
+	EnsureWallet(ctx context.Context, userID string) error
+	// Sums the active holds of a wallet. Expired holds no longer count.
+	GetHeldAmount(ctx context.Context, walletID string) (float64, error)
+	GetHoldByReference(ctx context.Context, referenceID string) (Hold, error)
+	GetTransactionByReference(ctx context.Context, arg GetTransactionByReferenceParams) (Transaction, error)
 	GetWallet(ctx context.Context, userID string) (Wallet, error)
+	// Reads a wallet and keeps other transactions from changing it until commit.
+	LockWallet(ctx context.Context, userID string) (Wallet, error)
+	// Records a failed publish and postpones the next attempt.
+	MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error
+	MarkOutboxEventSent(ctx context.Context, id int64) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -7,10 +7,23 @@ INSERT INTO wallets (
              $1, 0.0
          ) RETURNING *;
 
+-- name: EnsureWallet :exec
+-- Opens a wallet for a user unless they already have one, e.g. a driver paid
+-- for their first ride.
+INSERT INTO wallets (user_id, balance)
+VALUES ($1, 0.0)
+ON CONFLICT (user_id) DO NOTHING;
+
 -- name: GetWallet :one
 SELECT * FROM wallets
 WHERE user_id = $1 LIMIT 1;
 
+-- name: LockWallet :one
+-- Reads a wallet and keeps other transactions from changing it until commit.
+SELECT * FROM wallets
+WHERE user_id = $1
+FOR UPDATE;
+
 -- name: CreateTransaction :one
 INSERT INTO transactions (
     wallet_id, amount, description, reference_id
@@ -18,9 +31,84 @@ INSERT INTO transactions (
              $1, $2, $3, $4
          ) RETURNING *;
 
+-- name: GetTransactionByReference :one
+SELECT * FROM transactions
+WHERE wallet_id = $1 AND description = $2 AND reference_id = $3
+LIMIT 1;
+
 -- name: AddWalletBalance :one
 UPDATE wallets
 SET balance = balance + sqlc.arg(amount), -- SQLC will generate 'Amount' param
     updated_at = NOW()
 WHERE user_id = sqlc.arg(user_id)
-    RETURNING *;
\ No newline at end of file
+    RETURNING *;
+
+-- name: GetHeldAmount :one
+-- Sums the active holds of a wallet. Expired holds no longer count.
+SELECT COALESCE(SUM(amount), 0)::float8 AS held
+FROM holds
+WHERE wallet_id = $1 AND status = 'HELD' AND expires_at > NOW();
+
+-- name: CreateHold :one
+INSERT INTO holds (
+    wallet_id, reference_id, amount, expires_at
+) VALUES (
+             $1, $2, $3, $4
+         ) RETURNING *;
+
+-- name: GetHoldByReference :one
+SELECT * FROM holds
+WHERE reference_id = $1 LIMIT 1;
+
+-- name: CloseHold :one
+-- Captures or releases an active hold. Returns no rows when the hold is
+-- unknown or already closed.
+UPDATE holds
+SET status          = sqlc.arg(status),
+    captured_amount = sqlc.narg(captured_amount),
+    updated_at      = NOW()
+WHERE reference_id = sqlc.arg(reference_id) AND status = 'HELD'
+RETURNING *;
+
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox (topic, message_key, payload)
+VALUES ($1, $2, $3);
+
+-- name: ClaimOutboxEvents :many
+-- Leases the oldest unsent event of every topic and key that is due. Later
+-- events of the same key wait until it is sent, so consumers see them in order.
+UPDATE outbox
+SET next_attempt_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id IN (
+    SELECT o.id FROM outbox o
+    WHERE o.sent_at IS NULL
+      AND o.next_attempt_at <= NOW()
+      AND NOT EXISTS (
+          SELECT 1 FROM outbox e
+          WHERE e.topic = o.topic
+            AND e.message_key = o.message_key
+            AND e.sent_at IS NULL
+            AND e.id < o.id
+      )
+    ORDER BY o.id
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventSent :exec
+UPDATE outbox
+SET sent_at = NOW()
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+-- Records a failed publish and postpones the next attempt.
+UPDATE outbox
+SET attempts        = attempts + 1,
+    last_error      = sqlc.arg(last_error)::text,
+    next_attempt_at = NOW() + make_interval(secs => sqlc.arg(retry_in_seconds)::int)
+WHERE id = sqlc.arg(id);
+
+-- name: DeleteSentOutboxEvents :execrows
+DELETE FROM outbox
+WHERE sent_at < sqlc.arg(sent_before)::timestamptz;
@@ -31,6 +31,151 @@ func (q *Queries) AddWalletBalance(ctx context.Context, arg AddWalletBalancePara
 	return i, err
 }
 
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+UPDATE outbox
+SET next_attempt_at = NOW() + make_interval(secs => $1::int)
+WHERE id IN (
+    SELECT o.id FROM outbox o
+    WHERE o.sent_at IS NULL
+      AND o.next_attempt_at <= NOW()
+      AND NOT EXISTS (
+          SELECT 1 FROM outbox e
+          WHERE e.topic = o.topic
+            AND e.message_key = o.message_key
+            AND e.sent_at IS NULL
+            AND e.id < o.id
+      )
+    ORDER BY o.id
+    LIMIT $2::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, topic, message_key, payload, attempts, last_error, next_attempt_at, created_at, sent_at
+`
+
+type ClaimOutboxEventsParams struct {
+	LeaseSeconds int32 `json:"lease_seconds"`
+	BatchSize    int32 `json:"batch_size"`
+}
+
+// Leases the oldest unsent event of every topic and key that is due. Later
+// events of the same key wait until it is sent, so consumers see them in order.
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEvents, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.MessageKey,
+			&i.Payload,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.SentAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const closeHold = `-- name: CloseHold :one
+UPDATE holds
+SET status          = $1,
+    captured_amount = $2,
+    updated_at      = NOW()
+WHERE reference_id = $3 AND status = 'HELD'
+RETURNING id, wallet_id, reference_id, amount, status, captured_amount, expires_at, created_at, updated_at
+`
+
+type CloseHoldParams struct {
+	Status         string        `json:"status"`
+	CapturedAmount pgtype.Float8 `json:"captured_amount"`
+	ReferenceID    string        `json:"reference_id"`
+}
+
+// Captures or releases an active hold. Returns no rows when the hold is
+// unknown or already closed.
+func (q *Queries) CloseHold(ctx context.Context, arg CloseHoldParams) (Hold, error) {
+	row := q.db.QueryRow(ctx, closeHold, arg.Status, arg.CapturedAmount, arg.ReferenceID)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.WalletID,
+		&i.ReferenceID,
+		&i.Amount,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createHold = `-- name: CreateHold :one
+INSERT INTO holds (
+    wallet_id, reference_id, amount, expires_at
+) VALUES (
+             $1, $2, $3, $4
+         ) RETURNING id, wallet_id, reference_id, amount, status, captured_amount, expires_at, created_at, updated_at
+`
+
+type CreateHoldParams struct {
+	WalletID    string             `json:"wallet_id"`
+	ReferenceID string             `json:"reference_id"`
+	Amount      float64            `json:"amount"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	row := q.db.QueryRow(ctx, createHold,
+		arg.WalletID,
+		arg.ReferenceID,
+		arg.Amount,
+		arg.ExpiresAt,
+	)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.WalletID,
+		&i.ReferenceID,
+		&i.Amount,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :exec
+INSERT INTO outbox (topic, message_key, payload)
+VALUES ($1, $2, $3)
+`
+
+type CreateOutboxEventParams struct {
+	Topic      string `json:"topic"`
+	MessageKey string `json:"message_key"`
+	Payload    []byte `json:"payload"`
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error {
+	_, err := q.db.Exec(ctx, createOutboxEvent, arg.Topic, arg.MessageKey, arg.Payload)
+	return err
+}
+
 const createTransaction = `-- name: CreateTransaction :one
 INSERT INTO transactions (
     wallet_id, amount, description, reference_id
@@ -82,6 +227,94 @@ func (q *Queries) CreateWallet(ctx context.Context, userID string) (Wallet, erro
 	return i, err
 }
 
+const deleteSentOutboxEvents = `-- name: DeleteSentOutboxEvents :execrows
+DELETE FROM outbox
+WHERE sent_at < $1::timestamptz
+`
+
+func (q *Queries) DeleteSentOutboxEvents(ctx context.Context, sentBefore pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSentOutboxEvents, sentBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const ensureWallet = `-- name: EnsureWallet :exec
+INSERT INTO wallets (user_id, balance)
+VALUES ($1, 0.0)
+ON CONFLICT (user_id) DO NOTHING
+`
+
+// Opens a wallet for a user unless they already have one, e.g. a driver paid
+// for their first ride.
+func (q *Queries) EnsureWallet(ctx context.Context, userID string) error {
+	_, err := q.db.Exec(ctx, ensureWallet, userID)
+	return err
+}
+
+const getHeldAmount = `-- name: GetHeldAmount :one
+SELECT COALESCE(SUM(amount), 0)::float8 AS held
+FROM holds
+WHERE wallet_id = $1 AND status = 'HELD' AND expires_at > NOW()
+`
+
+// Sums the active holds of a wallet. Expired holds no longer count.
+func (q *Queries) GetHeldAmount(ctx context.Context, walletID string) (float64, error) {
+	row := q.db.QueryRow(ctx, getHeldAmount, walletID)
+	var held float64
+	err := row.Scan(&held)
+	return held, err
+}
+
+const getHoldByReference = `-- name: GetHoldByReference :one
+SELECT id, wallet_id, reference_id, amount, status, captured_amount, expires_at, created_at, updated_at FROM holds
+WHERE reference_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetHoldByReference(ctx context.Context, referenceID string) (Hold, error) {
+	row := q.db.QueryRow(ctx, getHoldByReference, referenceID)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.WalletID,
+		&i.ReferenceID,
+		&i.Amount,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTransactionByReference = `-- name: GetTransactionByReference :one
+SELECT id, wallet_id, amount, description, reference_id, created_at FROM transactions
+WHERE wallet_id = $1 AND description = $2 AND reference_id = $3
+LIMIT 1
+`
+
+type GetTransactionByReferenceParams struct {
+	WalletID    string      `json:"wallet_id"`
+	Description string      `json:"description"`
+	ReferenceID pgtype.Text `json:"reference_id"`
+}
+
+func (q *Queries) GetTransactionByReference(ctx context.Context, arg GetTransactionByReferenceParams) (Transaction, error) {
+	row := q.db.QueryRow(ctx, getTransactionByReference, arg.WalletID, arg.Description, arg.ReferenceID)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.WalletID,
+		&i.Amount,
+		&i.Description,
+		&i.ReferenceID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getWallet = `-- name: GetWallet :one
 SELECT user_id, balance, updated_at FROM wallets
 WHERE user_id = $1 LIMIT 1
@@ -93,3 +326,48 @@ func (q *Queries) GetWallet(ctx context.Context, userID string) (Wallet, error)
 	err := row.Scan(&i.UserID, &i.Balance, &i.UpdatedAt)
 	return i, err
 }
+
+const lockWallet = `-- name: LockWallet :one
+SELECT user_id, balance, updated_at FROM wallets
+WHERE user_id = $1
+FOR UPDATE
+`
+
+// Reads a wallet and keeps other transactions from changing it until commit.
+func (q *Queries) LockWallet(ctx context.Context, userID string) (Wallet, error) {
+	row := q.db.QueryRow(ctx, lockWallet, userID)
+	var i Wallet
+	err := row.Scan(&i.UserID, &i.Balance, &i.UpdatedAt)
+	return i, err
+}
+
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET attempts        = attempts + 1,
+    last_error      = $1::text,
+    next_attempt_at = NOW() + make_interval(secs => $2::int)
+WHERE id = $3
+`
+
+type MarkOutboxEventFailedParams struct {
+	LastError      string `json:"last_error"`
+	RetryInSeconds int32  `json:"retry_in_seconds"`
+	ID             int64  `json:"id"`
+}
+
+// Records a failed publish and postpones the next attempt.
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEventFailed, arg.LastError, arg.RetryInSeconds, arg.ID)
+	return err
+}
+
+const markOutboxEventSent = `-- name: MarkOutboxEventSent :exec
+UPDATE outbox
+SET sent_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxEventSent(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markOutboxEventSent, id)
+	return err
+}
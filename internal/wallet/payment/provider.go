@@ -0,0 +1,104 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDeclined is returned when the payment method refuses the amount,
+	// e.g. a card over its limit. Trying again later may succeed.
+	ErrDeclined = errors.New("payment declined")
+	// ErrAuthorized is returned when a reference was already authorized for
+	// another amount.
+	ErrAuthorized = errors.New("reference already authorized")
+)
+
+// Provider charges a payment method the wallet doesn't hold the money of, such
+// as a card. Calls are keyed by a reference, the order ID, so a repeated call
+// doesn't charge twice.
+type Provider interface {
+	// Authorize reserves amount on the passenger's method until expiresAt.
+	// Authorizing a reference again for the same amount does nothing.
+	Authorize(ctx context.Context, passengerID, reference string, amount float64, expiresAt time.Time) error
+	// Capture charges amount for a reference, using up its authorization if
+	// there is one. Capturing a reference again does nothing.
+	Capture(ctx context.Context, passengerID, reference string, amount float64) error
+	// Void gives back the authorization of a reference. Voiding a reference
+	// that has none does nothing.
+	Void(ctx context.Context, reference string) error
+}
+
+// Fake is an in-memory Provider for local runs and tests. It declines any
+// amount over its limit and forgets everything on restart.
+type Fake struct {
+	limit float64
+
+	mu       sync.Mutex
+	auths    map[string]float64
+	captures map[string]float64
+}
+
+func NewFake(limit float64) *Fake {
+	return &Fake{
+		limit:    limit,
+		auths:    make(map[string]float64),
+		captures: make(map[string]float64),
+	}
+}
+
+func (f *Fake) Authorize(ctx context.Context, passengerID, reference string, amount float64, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if prev, ok := f.auths[reference]; ok {
+		if prev != amount {
+			return ErrAuthorized
+		}
+		return nil
+	}
+	if amount > f.limit {
+		return ErrDeclined
+	}
+	f.auths[reference] = amount
+	return nil
+}
+
+func (f *Fake) Capture(ctx context.Context, passengerID, reference string, amount float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.captures[reference]; ok {
+		return nil
+	}
+	if amount > f.limit {
+		return ErrDeclined
+	}
+	delete(f.auths, reference)
+	f.captures[reference] = amount
+	return nil
+}
+
+func (f *Fake) Void(ctx context.Context, reference string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.auths, reference)
+	return nil
+}
+
+// Authorized returns the amount authorized for a reference, 0 when none is.
+func (f *Fake) Authorized(reference string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.auths[reference]
+}
+
+// Captured returns the amount captured for a reference, 0 when none was.
+func (f *Fake) Captured(reference string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.captures[reference]
+}
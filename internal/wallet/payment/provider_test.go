@@ -0,0 +1,46 @@
+package payment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("Authorize Then Capture", func(t *testing.T) {
+		f := NewFake(100000)
+
+		assert.NoError(t, f.Authorize(ctx, "passenger-1", "order-1", 25000, expiresAt))
+		assert.NoError(t, f.Authorize(ctx, "passenger-1", "order-1", 25000, expiresAt))
+		assert.ErrorIs(t, f.Authorize(ctx, "passenger-1", "order-1", 30000, expiresAt), ErrAuthorized)
+		assert.Equal(t, 25000.0, f.Authorized("order-1"))
+
+		// The final fare may differ from what was authorized.
+		assert.NoError(t, f.Capture(ctx, "passenger-1", "order-1", 27000))
+		assert.NoError(t, f.Capture(ctx, "passenger-1", "order-1", 27000))
+		assert.Zero(t, f.Authorized("order-1"))
+		assert.Equal(t, 27000.0, f.Captured("order-1"))
+	})
+
+	t.Run("Declines Over Limit", func(t *testing.T) {
+		f := NewFake(20000)
+
+		assert.ErrorIs(t, f.Authorize(ctx, "passenger-1", "order-1", 25000, expiresAt), ErrDeclined)
+		assert.ErrorIs(t, f.Capture(ctx, "passenger-1", "order-1", 25000), ErrDeclined)
+		assert.Zero(t, f.Captured("order-1"))
+	})
+
+	t.Run("Void", func(t *testing.T) {
+		f := NewFake(100000)
+
+		assert.NoError(t, f.Authorize(ctx, "passenger-1", "order-1", 25000, expiresAt))
+		assert.NoError(t, f.Void(ctx, "order-1"))
+		assert.NoError(t, f.Void(ctx, "order-1"))
+		assert.Zero(t, f.Authorized("order-1"))
+	})
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/wallet/db"
+	"github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Hold statuses. Only HELD holds count against a wallet's balance.
+const (
+	HoldStatusHeld     = "HELD"
+	HoldStatusCaptured = "CAPTURED"
+	HoldStatusReleased = "RELEASED"
+)
+
+var errHoldClosed = errors.New("hold was already closed")
+
+// PlaceHold sets money aside on a wallet so it can't be spent elsewhere, e.g.
+// the fare of an order until the ride is paid, or authorizes it on a card.
+// Placing the same hold again returns it unchanged.
+func (s *PostgresWalletService) PlaceHold(ctx context.Context, req *wallet.PlaceHoldRequest) (*wallet.HoldResponse, error) {
+	if req.UserId == "" || req.ReferenceId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id and reference_id are required")
+	}
+	if req.Amount <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+	expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid expires_at: %v", err)
+	}
+	switch req.PaymentMethod {
+	case "", model.PaymentMethodWallet:
+	case model.PaymentMethodCard:
+		return s.authorizeCard(ctx, req, expiresAt)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "nothing to hold for payment method %s", req.PaymentMethod)
+	}
+
+	var res *wallet.HoldResponse
+	err = s.store.ExecTx(ctx, func(q db.Querier) error {
+		// Locking the wallet serializes holds and debits on it, so two orders
+		// can't both be placed against the same money.
+		w, err := q.LockWallet(ctx, req.UserId)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return status.Errorf(codes.NotFound, "wallet not found: %s", req.UserId)
+			}
+			return status.Errorf(codes.Internal, "failed to get wallet: %v", err)
+		}
+
+		prev, err := q.GetHoldByReference(ctx, req.ReferenceId)
+		switch {
+		case err == nil:
+			if prev.WalletID != req.UserId || prev.Amount != req.Amount {
+				return status.Errorf(codes.AlreadyExists, "another hold was already placed for %s", req.ReferenceId)
+			}
+			res, err = holdResponse(ctx, q, prev, w.Balance)
+			return err
+		case !errors.Is(err, pgx.ErrNoRows):
+			return status.Errorf(codes.Internal, "failed to get hold: %v", err)
+		}
+
+		held, err := q.GetHeldAmount(ctx, req.UserId)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to get held amount: %v", err)
+		}
+		if available := w.Balance - held; available < req.Amount {
+			return status.Errorf(codes.FailedPrecondition, "insufficient balance: %v", available)
+		}
+
+		hold, err := q.CreateHold(ctx, db.CreateHoldParams{
+			WalletID:    req.UserId,
+			ReferenceID: req.ReferenceId,
+			Amount:      req.Amount,
+			ExpiresAt:   pgtype.Timestamptz{Time: expiresAt, Valid: true},
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to create hold: %v", err)
+		}
+		res, err = holdResponse(ctx, q, hold, w.Balance)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ReleaseHold gives held money back without debiting anything, e.g. when the
+// order it was held for could not be created.
+func (s *PostgresWalletService) ReleaseHold(ctx context.Context, req *wallet.ReleaseHoldRequest) (*wallet.HoldResponse, error) {
+	if req.PaymentMethod == model.PaymentMethodCard {
+		return s.voidCard(ctx, req.ReferenceId)
+	}
+
+	var res *wallet.HoldResponse
+	err := s.store.ExecTx(ctx, func(q db.Querier) error {
+		hold, err := closeHold(ctx, q, req.ReferenceId, 0)
+		if err != nil && !errors.Is(err, errHoldClosed) {
+			return err
+		}
+		w, err := q.GetWallet(ctx, hold.WalletID)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to get wallet: %v", err)
+		}
+		res, err = holdResponse(ctx, q, hold, w.Balance)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// closeHold captures a hold for amount, or releases it when amount is 0. A
+// hold that was closed before is returned as it is, with errHoldClosed.
+func closeHold(ctx context.Context, q db.Querier, referenceID string, amount float64) (db.Hold, error) {
+	params := db.CloseHoldParams{ReferenceID: referenceID, Status: HoldStatusReleased}
+	if amount > 0 {
+		params.Status = HoldStatusCaptured
+		params.CapturedAmount = pgtype.Float8{Float64: amount, Valid: true}
+	}
+
+	hold, err := q.CloseHold(ctx, params)
+	if err == nil {
+		return hold, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return db.Hold{}, status.Errorf(codes.Internal, "failed to close hold: %v", err)
+	}
+
+	hold, err = q.GetHoldByReference(ctx, referenceID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Hold{}, status.Errorf(codes.NotFound, "hold not found: %s", referenceID)
+	}
+	if err != nil {
+		return db.Hold{}, status.Errorf(codes.Internal, "failed to get hold: %v", err)
+	}
+	return hold, errHoldClosed
+}
+
+func holdResponse(ctx context.Context, q db.Querier, hold db.Hold, balance float64) (*wallet.HoldResponse, error) {
+	held, err := q.GetHeldAmount(ctx, hold.WalletID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get held amount: %v", err)
+	}
+	return &wallet.HoldResponse{
+		ReferenceId:      hold.ReferenceID,
+		Amount:           hold.Amount,
+		Status:           hold.Status,
+		AvailableBalance: balance - held,
+	}, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dwikikusuma/atlas/internal/wallet/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// --- Mocks ---
+
+type MockStore struct {
+	db.Querier // Embed the interface to skip implementing all methods
+	mock.Mock
+}
+
+// ExecTx runs fn against the mock itself; the tests don't model rollbacks.
+func (m *MockStore) ExecTx(ctx context.Context, fn func(db.Querier) error) error {
+	return fn(m)
+}
+
+func (m *MockStore) CloseHold(ctx context.Context, arg db.CloseHoldParams) (db.Hold, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Hold), args.Error(1)
+}
+
+func (m *MockStore) GetHoldByReference(ctx context.Context, referenceID string) (db.Hold, error) {
+	args := m.Called(ctx, referenceID)
+	return args.Get(0).(db.Hold), args.Error(1)
+}
+
+// --- Test ---
+
+func TestCloseHold(t *testing.T) {
+	ctx := context.Background()
+	held := db.Hold{WalletID: "passenger-1", ReferenceID: "order-1", Amount: 26000, Status: HoldStatusHeld}
+	captured := held
+	captured.Status = HoldStatusCaptured
+	captured.CapturedAmount = pgtype.Float8{Float64: 21000, Valid: true}
+	released := held
+	released.Status = HoldStatusReleased
+
+	tests := []struct {
+		name       string
+		amount     float64
+		params     db.CloseHoldParams
+		closed     db.Hold
+		closeErr   error
+		stored     *db.Hold
+		getErr     error
+		wantHold   db.Hold
+		wantErr    error
+		wantStatus codes.Code
+	}{
+		{
+			name:     "Capture",
+			amount:   21000,
+			params:   db.CloseHoldParams{ReferenceID: "order-1", Status: HoldStatusCaptured, CapturedAmount: pgtype.Float8{Float64: 21000, Valid: true}},
+			closed:   captured,
+			wantHold: captured,
+		},
+		{
+			name:     "Release",
+			params:   db.CloseHoldParams{ReferenceID: "order-1", Status: HoldStatusReleased},
+			closed:   released,
+			wantHold: released,
+		},
+		{
+			name:     "Captured Before",
+			amount:   21000,
+			params:   db.CloseHoldParams{ReferenceID: "order-1", Status: HoldStatusCaptured, CapturedAmount: pgtype.Float8{Float64: 21000, Valid: true}},
+			closeErr: pgx.ErrNoRows,
+			stored:   &captured,
+			wantHold: captured,
+			wantErr:  errHoldClosed,
+		},
+		{
+			name:     "Released Before",
+			amount:   21000,
+			params:   db.CloseHoldParams{ReferenceID: "order-1", Status: HoldStatusCaptured, CapturedAmount: pgtype.Float8{Float64: 21000, Valid: true}},
+			closeErr: pgx.ErrNoRows,
+			stored:   &released,
+			wantHold: released,
+			wantErr:  errHoldClosed,
+		},
+		{
+			name:       "Unknown Hold",
+			params:     db.CloseHoldParams{ReferenceID: "order-1", Status: HoldStatusReleased},
+			closeErr:   pgx.ErrNoRows,
+			stored:     &db.Hold{},
+			getErr:     pgx.ErrNoRows,
+			wantStatus: codes.NotFound,
+		},
+		{
+			name:       "Database Down",
+			params:     db.CloseHoldParams{ReferenceID: "order-1", Status: HoldStatusReleased},
+			closeErr:   errors.New("connection refused"),
+			wantStatus: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockStore)
+			store.On("CloseHold", mock.Anything, tt.params).Return(tt.closed, tt.closeErr).Once()
+			if tt.stored != nil {
+				store.On("GetHoldByReference", mock.Anything, "order-1").Return(*tt.stored, tt.getErr).Once()
+			}
+
+			hold, err := closeHold(ctx, store, "order-1", tt.amount)
+
+			switch {
+			case tt.wantStatus != codes.OK:
+				assert.Equal(t, tt.wantStatus, status.Code(err))
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, tt.wantHold, hold)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantHold, hold)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
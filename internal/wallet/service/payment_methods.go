@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/wallet/db"
+	"github.com/dwikikusuma/atlas/internal/wallet/payment"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Entries of the fares the wallet doesn't hold the money of, all under the
+// reference of the order.
+const (
+	// TransactionTypeCard credits a passenger with the fare charged to their
+	// card, which the DEBIT of the fare then takes.
+	TransactionTypeCard = "CARD"
+	// TransactionTypeCashCommission debits a driver with the commission on a
+	// fare they collected in cash, less the promo the platform paid for.
+	TransactionTypeCashCommission = "CASH_COMMISSION"
+)
+
+// authorizeCard reserves the fare of an order on the passenger's card in
+// place of a wallet hold.
+func (s *PostgresWalletService) authorizeCard(ctx context.Context, req *wallet.PlaceHoldRequest, expiresAt time.Time) (*wallet.HoldResponse, error) {
+	if err := s.cards.Authorize(ctx, req.UserId, req.ReferenceId, req.Amount, expiresAt); err != nil {
+		return nil, cardError(err)
+	}
+	return &wallet.HoldResponse{
+		ReferenceId: req.ReferenceId,
+		Amount:      req.Amount,
+		Status:      HoldStatusHeld,
+	}, nil
+}
+
+// voidCard gives back the fare reserved on a card.
+func (s *PostgresWalletService) voidCard(ctx context.Context, referenceID string) (*wallet.HoldResponse, error) {
+	if err := s.cards.Void(ctx, referenceID); err != nil {
+		return nil, cardError(err)
+	}
+	return &wallet.HoldResponse{ReferenceId: referenceID, Status: HoldStatusReleased}, nil
+}
+
+// chargeCard captures a fare from the passenger's card and books it through
+// their wallet: the card payment is credited and the fare debited in one
+// transaction, so the ledger and the payout work as for wallet payments and
+// the balance is unchanged. A reference is charged once, the provider and the
+// DEBIT entry both being keyed by it.
+func (s *PostgresWalletService) chargeCard(ctx context.Context, req *wallet.DebitBalanceRequest, report bool) (*wallet.BalanceResponse, error) {
+	if req.Amount == 0 {
+		// Nothing is charged: only the reserved fare is given back.
+		if _, err := s.voidCard(ctx, req.HoldReferenceId); err != nil {
+			return nil, err
+		}
+		return &wallet.BalanceResponse{}, nil
+	}
+
+	var balance float64
+	err := s.store.ExecTx(ctx, func(q db.Querier) error {
+		// Card payers may never have topped up a wallet.
+		if err := q.EnsureWallet(ctx, req.UserId); err != nil {
+			return status.Errorf(codes.Internal, "failed to open wallet: %v", err)
+		}
+		w, err := q.LockWallet(ctx, req.UserId)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to get wallet: %v", err)
+		}
+		balance = w.Balance
+
+		_, err = q.GetTransactionByReference(ctx, db.GetTransactionByReferenceParams{
+			WalletID:    req.UserId,
+			Description: "DEBIT",
+			ReferenceID: pgtype.Text{String: req.ReferenceId, Valid: true},
+		})
+		if err == nil {
+			// A repeated request: the reference was charged before.
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return status.Errorf(codes.Internal, "failed to get transaction: %v", err)
+		}
+
+		if err = s.cards.Capture(ctx, req.UserId, req.ReferenceId, req.Amount); err != nil {
+			return cardError(err)
+		}
+		// A fee is captured under its own reference; the fare reserved for
+		// the ride is no longer needed.
+		if req.HoldReferenceId != "" && req.HoldReferenceId != req.ReferenceId {
+			if err = s.cards.Void(ctx, req.HoldReferenceId); err != nil {
+				return cardError(err)
+			}
+		}
+		err = postEntries(ctx, q, req.ReferenceId, []entry{
+			{req.UserId, req.Amount, TransactionTypeCard},
+			{req.UserId, -req.Amount, "DEBIT"},
+		})
+		if err != nil {
+			return err
+		}
+
+		if req.Payout != nil {
+			if err = payOut(ctx, q, req.ReferenceId, req.Payout); err != nil {
+				return err
+			}
+		}
+		if report {
+			return enqueuePaymentResult(ctx, q, req, balance, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &wallet.BalanceResponse{NewBalance: balance}, nil
+}
+
+// settleCash records a fare the driver collected in cash. The driver keeps
+// the cash and owes the platform its commission, less the promo it paid for,
+// so that is debited from their wallet. The wallet may go negative: the debt
+// is paid off by the earnings of their next wallet and card rides.
+func (s *PostgresWalletService) settleCash(ctx context.Context, req *wallet.DebitBalanceRequest, report bool) (*wallet.BalanceResponse, error) {
+	if req.Payout == nil {
+		return nil, status.Error(codes.InvalidArgument, "a cash payment needs a payout")
+	}
+	p := req.Payout
+
+	err := s.store.ExecTx(ctx, func(q db.Querier) error {
+		err := postEntries(ctx, q, req.ReferenceId, []entry{
+			{p.DriverId, p.Subsidy - p.Commission, TransactionTypeCashCommission},
+			{PlatformWalletID, p.Commission, TransactionTypeCommission},
+			{PlatformWalletID, -p.Subsidy, TransactionTypePromoSubsidy},
+		})
+		if err != nil {
+			return err
+		}
+		if report {
+			return enqueuePaymentResult(ctx, q, req, 0, nil)
+		}
+		return nil
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		// A repeated request: the entries of the ride were posted before.
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wallet.BalanceResponse{Message: "collected in cash by the driver"}, nil
+}
+
+// cardError maps a payment provider failure to a gRPC status. A declined
+// card is like a short balance: it may go through later.
+func cardError(err error) error {
+	switch {
+	case errors.Is(err, payment.ErrDeclined):
+		return status.Errorf(codes.FailedPrecondition, "card declined: %v", err)
+	case errors.Is(err, payment.ErrAuthorized):
+		return status.Errorf(codes.AlreadyExists, "another hold was already placed: %v", err)
+	default:
+		return status.Errorf(codes.Internal, "failed to charge card: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/wallet/db"
+	"github.com/dwikikusuma/atlas/internal/wallet/payment"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) EnsureWallet(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockStore) LockWallet(ctx context.Context, userID string) (db.Wallet, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(db.Wallet), args.Error(1)
+}
+
+func (m *MockStore) GetTransactionByReference(ctx context.Context, arg db.GetTransactionByReferenceParams) (db.Transaction, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Transaction), args.Error(1)
+}
+
+func (m *MockStore) CreateTransaction(ctx context.Context, arg db.CreateTransactionParams) (db.Transaction, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Transaction), args.Error(1)
+}
+
+func (m *MockStore) AddWalletBalance(ctx context.Context, arg db.AddWalletBalanceParams) (db.Wallet, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(db.Wallet), args.Error(1)
+}
+
+func (m *MockStore) CreateOutboxEvent(ctx context.Context, arg db.CreateOutboxEventParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+// entryOf matches the ledger entry posted on a wallet under a reference.
+func entryOf(userID, description string, amount float64, reference string) any {
+	return db.CreateTransactionParams{
+		WalletID:    userID,
+		Amount:      amount,
+		Description: description,
+		ReferenceID: pgtype.Text{String: reference, Valid: true},
+	}
+}
+
+// expectEntry expects an entry to be posted with its balance update and
+// wallet-events announcement.
+func expectEntry(store *MockStore, userID, description string, amount float64, reference string) {
+	store.On("CreateTransaction", mock.Anything, entryOf(userID, description, amount, reference)).
+		Return(db.Transaction{WalletID: userID, Amount: amount, Description: description}, nil).Once()
+	store.On("AddWalletBalance", mock.Anything, db.AddWalletBalanceParams{UserID: userID, Amount: amount}).
+		Return(db.Wallet{UserID: userID}, nil).Once()
+	store.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		return arg.Topic == walletEventsTopic && arg.MessageKey == userID
+	})).Return(nil).Once()
+}
+
+func TestChargeCard(t *testing.T) {
+	ctx := context.Background()
+	debitOf := func(reference string) db.GetTransactionByReferenceParams {
+		return db.GetTransactionByReferenceParams{
+			WalletID:    "passenger-1",
+			Description: "DEBIT",
+			ReferenceID: pgtype.Text{String: reference, Valid: true},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		req          *wallet.DebitBalanceRequest
+		chargedDebit bool // the DEBIT of the reference was posted before
+		wantCode     codes.Code
+		wantCaptured float64
+		wantVoided   bool // the fare reserved under the hold is given back
+	}{
+		{
+			name:         "Fare",
+			req:          &wallet.DebitBalanceRequest{UserId: "passenger-1", ReferenceId: "order-1", HoldReferenceId: "order-1", Amount: 21000},
+			wantCaptured: 21000,
+		},
+		{
+			name:         "Fee Voids The Fare",
+			req:          &wallet.DebitBalanceRequest{UserId: "passenger-1", ReferenceId: "cancel-order-1", HoldReferenceId: "order-1", Amount: 5000},
+			wantCaptured: 5000,
+			wantVoided:   true,
+		},
+		{
+			name:         "Repeated Reference",
+			req:          &wallet.DebitBalanceRequest{UserId: "passenger-1", ReferenceId: "order-1", HoldReferenceId: "order-1", Amount: 21000},
+			chargedDebit: true,
+		},
+		{
+			name:     "Declined",
+			req:      &wallet.DebitBalanceRequest{UserId: "passenger-1", ReferenceId: "order-1", HoldReferenceId: "order-1", Amount: 2_000_000},
+			wantCode: codes.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockStore)
+			cards := payment.NewFake(1_000_000)
+			svc := NewPostgresWalletService(store, cards)
+			assert.NoError(t, cards.Authorize(ctx, "passenger-1", "order-1", 21000, time.Now().Add(time.Hour)))
+
+			store.On("EnsureWallet", mock.Anything, "passenger-1").Return(nil)
+			store.On("LockWallet", mock.Anything, "passenger-1").Return(db.Wallet{UserID: "passenger-1", Balance: 3000}, nil).Once()
+			if tt.chargedDebit {
+				store.On("GetTransactionByReference", mock.Anything, debitOf(tt.req.ReferenceId)).Return(db.Transaction{}, nil).Once()
+			} else {
+				store.On("GetTransactionByReference", mock.Anything, debitOf(tt.req.ReferenceId)).Return(db.Transaction{}, pgx.ErrNoRows).Once()
+			}
+			if tt.wantCaptured > 0 {
+				expectEntry(store, "passenger-1", TransactionTypeCard, tt.req.Amount, tt.req.ReferenceId)
+				expectEntry(store, "passenger-1", "DEBIT", -tt.req.Amount, tt.req.ReferenceId)
+			}
+
+			res, err := svc.chargeCard(ctx, tt.req, false)
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if err == nil {
+				assert.Equal(t, 3000.0, res.NewBalance, "the card pays, the balance is unchanged")
+			}
+			assert.Equal(t, tt.wantCaptured, cards.Captured(tt.req.ReferenceId))
+			if tt.wantVoided {
+				assert.Zero(t, cards.Authorized("order-1"))
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSettleCash(t *testing.T) {
+	ctx := context.Background()
+	payout := &wallet.Payout{DriverId: "driver-1", Earnings: 20800, Commission: 5200, Subsidy: 2000}
+
+	tests := []struct {
+		name     string
+		req      *wallet.DebitBalanceRequest
+		posted   bool // the entries of the reference were posted before
+		wantCode codes.Code
+	}{
+		{
+			name: "Settled",
+			req:  &wallet.DebitBalanceRequest{UserId: "passenger-1", ReferenceId: "order-1", Amount: 24000, Payout: payout},
+		},
+		{
+			name:   "Repeated Reference",
+			req:    &wallet.DebitBalanceRequest{UserId: "passenger-1", ReferenceId: "order-1", Amount: 24000, Payout: payout},
+			posted: true,
+		},
+		{
+			name:     "Missing Payout",
+			req:      &wallet.DebitBalanceRequest{UserId: "passenger-1", ReferenceId: "order-1", Amount: 24000},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockStore)
+			svc := NewPostgresWalletService(store, payment.NewFake(1_000_000))
+
+			if tt.req.Payout != nil {
+				store.On("EnsureWallet", mock.Anything, "driver-1").Return(nil).Once()
+			}
+			switch {
+			case tt.posted:
+				// The driver's entry sorts first and hits the unique index.
+				store.On("CreateTransaction", mock.Anything, entryOf("driver-1", TransactionTypeCashCommission, -3200, "order-1")).
+					Return(db.Transaction{}, &pgconn.PgError{Code: "23505"}).Once()
+			case tt.req.Payout != nil:
+				expectEntry(store, "driver-1", TransactionTypeCashCommission, -3200, "order-1")
+				expectEntry(store, PlatformWalletID, TransactionTypeCommission, 5200, "order-1")
+				expectEntry(store, PlatformWalletID, TransactionTypePromoSubsidy, -2000, "order-1")
+			}
+
+			res, err := svc.settleCash(ctx, tt.req, false)
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if err == nil {
+				assert.Equal(t, "collected in cash by the driver", res.Message)
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
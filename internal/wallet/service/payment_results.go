@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dwikikusuma/atlas/internal/wallet/db"
+	"github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"google.golang.org/grpc/status"
+)
+
+// Topics the outcome of every charge is published to, for the Order Service
+// to mark the order paid or retry the payment.
+const (
+	paymentSucceededTopic = "payment-succeeded"
+	paymentFailedTopic    = "payment-failed"
+)
+
+// errResultNotRecorded is returned by ChargeDebit when the failure of a charge
+// couldn't be stored in the outbox, so nothing will report it.
+var errResultNotRecorded = errors.New("payment result not recorded")
+
+// ChargeDebit debits like DebitBalance and reports the outcome of the charge
+// through the outbox: a charge that goes through in the transaction posting
+// it, a failed one on its own. Releasing a hold charges nothing, so there is
+// nothing to report. The error of a failed charge is returned once its
+// failure is stored; otherwise it wraps errResultNotRecorded.
+func (s *PostgresWalletService) ChargeDebit(ctx context.Context, req *wallet.DebitBalanceRequest) (*wallet.BalanceResponse, error) {
+	report := req.Amount > 0
+	res, err := s.debit(ctx, req, report)
+	if err == nil || !report {
+		return res, err
+	}
+
+	if recordErr := enqueuePaymentResult(ctx, s.store, req, 0, err); recordErr != nil {
+		return nil, fmt.Errorf("%w: %v (charge failed: %v)", errResultNotRecorded, recordErr, err)
+	}
+	return nil, err
+}
+
+// enqueuePaymentResult stores the outcome of a charge in the outbox, keyed by
+// its reference: payment-succeeded with the balance after it, or
+// payment-failed with the code and reason of chargeErr.
+func enqueuePaymentResult(ctx context.Context, q db.Querier, req *wallet.DebitBalanceRequest, balance float64, chargeErr error) error {
+	result := model.PaymentResultEvent{
+		UserID:    req.UserId,
+		Reference: req.ReferenceId,
+		Amount:    req.Amount,
+		Timestamp: time.Now().Unix(),
+	}
+	topic := paymentSucceededTopic
+	if chargeErr != nil {
+		topic = paymentFailedTopic
+		st := status.Convert(chargeErr)
+		result.Code = st.Code().String()
+		result.Reason = st.Message()
+	} else {
+		result.Balance = balance
+	}
+	return enqueueJSON(ctx, q, topic, req.ReferenceId, &result)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dwikikusuma/atlas/internal/wallet/db"
+	"github.com/dwikikusuma/atlas/internal/wallet/payment"
+	"github.com/dwikikusuma/atlas/pkg/model"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (m *MockStore) GetHeldAmount(ctx context.Context, walletID string) (float64, error) {
+	args := m.Called(ctx, walletID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+// resultOf matches the payment result of order-1 stored in the outbox.
+func resultOf(topic string, code string) any {
+	return mock.MatchedBy(func(arg db.CreateOutboxEventParams) bool {
+		var result model.PaymentResultEvent
+		if arg.Topic != topic || arg.MessageKey != "order-1" || json.Unmarshal(arg.Payload, &result) != nil {
+			return false
+		}
+		return result.Reference == "order-1" && result.Code == code
+	})
+}
+
+func TestChargeDebit(t *testing.T) {
+	ctx := context.Background()
+	fare := &wallet.DebitBalanceRequest{UserId: "passenger-1", ReferenceId: "order-1", Amount: 21000}
+
+	tests := []struct {
+		name      string
+		req       *wallet.DebitBalanceRequest
+		balance   float64
+		recordErr error // storing the result in the outbox fails
+		wantCode  codes.Code
+		// wantTopic is where the outcome is reported, none for a release.
+		wantTopic      string
+		wantUnrecorded bool // the failure wasn't stored, so the message is retried
+	}{
+		{
+			name:      "Charged",
+			req:       fare,
+			balance:   30000,
+			wantTopic: paymentSucceededTopic,
+		},
+		{
+			name:      "Insufficient Balance",
+			req:       fare,
+			balance:   3000,
+			wantCode:  codes.FailedPrecondition,
+			wantTopic: paymentFailedTopic,
+		},
+		{
+			name:           "Failure Not Recorded",
+			req:            fare,
+			balance:        3000,
+			recordErr:      errors.New("connection refused"),
+			wantTopic:      paymentFailedTopic,
+			wantUnrecorded: true,
+		},
+		{
+			name:    "Release",
+			req:     &wallet.DebitBalanceRequest{UserId: "passenger-1", ReferenceId: "order-1", HoldReferenceId: "order-1"},
+			balance: 30000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(MockStore)
+			svc := NewPostgresWalletService(store, payment.NewFake(1_000_000))
+
+			store.On("LockWallet", mock.Anything, "passenger-1").Return(db.Wallet{UserID: "passenger-1", Balance: tt.balance}, nil).Once()
+			if tt.req.HoldReferenceId != "" {
+				store.On("CloseHold", mock.Anything, db.CloseHoldParams{ReferenceID: "order-1", Status: HoldStatusReleased}).
+					Return(db.Hold{ReferenceID: "order-1", Status: HoldStatusReleased}, nil).Once()
+			} else {
+				store.On("GetTransactionByReference", mock.Anything, db.GetTransactionByReferenceParams{
+					WalletID:    "passenger-1",
+					Description: "DEBIT",
+					ReferenceID: pgtype.Text{String: "order-1", Valid: true},
+				}).Return(db.Transaction{}, pgx.ErrNoRows).Once()
+				store.On("GetHeldAmount", mock.Anything, "passenger-1").Return(0.0, nil).Once()
+			}
+			switch tt.wantTopic {
+			case paymentSucceededTopic:
+				expectEntry(store, "passenger-1", "DEBIT", -tt.req.Amount, "order-1")
+				store.On("CreateOutboxEvent", mock.Anything, resultOf(paymentSucceededTopic, "")).Return(tt.recordErr).Once()
+			case paymentFailedTopic:
+				store.On("CreateOutboxEvent", mock.Anything, resultOf(paymentFailedTopic, "FailedPrecondition")).Return(tt.recordErr).Once()
+			}
+
+			_, err := svc.ChargeDebit(ctx, tt.req)
+
+			if tt.wantUnrecorded {
+				assert.ErrorIs(t, err, errResultNotRecorded)
+			} else {
+				assert.Equal(t, tt.wantCode, status.Code(err))
+			}
+			store.AssertExpectations(t)
+		})
+	}
+}
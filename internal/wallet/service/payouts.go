@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/dwikikusuma/atlas/internal/wallet/db"
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PlatformWalletID is the wallet of the platform itself, created by migration.
+const PlatformWalletID = "platform"
+
+// Entries paying out a fare, all under the reference of the order.
+const (
+	TransactionTypeEarnings     = "EARNINGS"
+	TransactionTypeCommission   = "COMMISSION"
+	TransactionTypePromoSubsidy = "PROMO_SUBSIDY"
+)
+
+// validatePayout checks that a payout splits exactly the amount debited.
+func validatePayout(payout *wallet.Payout, amount float64) error {
+	if payout.DriverId == "" || payout.DriverId == PlatformWalletID {
+		return status.Error(codes.InvalidArgument, "payout driver_id is required")
+	}
+	if payout.Earnings < 0 || payout.Commission < 0 || payout.Subsidy < 0 {
+		return status.Error(codes.InvalidArgument, "payout amounts must not be negative")
+	}
+	if split := payout.Earnings + payout.Commission - payout.Subsidy; math.Abs(split-amount) > 0.01 {
+		return status.Errorf(codes.InvalidArgument, "payout of %.2f does not match the amount of %.2f", split, amount)
+	}
+	return nil
+}
+
+// entry is a ledger entry posted on a wallet, positive when it is credited.
+type entry struct {
+	userID      string
+	amount      float64
+	description string
+}
+
+// payOut credits the driver their earnings and the platform its commission,
+// less the promo it paid for, in the transaction of the debit.
+func payOut(ctx context.Context, q db.Querier, reference string, payout *wallet.Payout) error {
+	return postEntries(ctx, q, reference, []entry{
+		{payout.DriverId, payout.Earnings, TransactionTypeEarnings},
+		{PlatformWalletID, payout.Commission, TransactionTypeCommission},
+		{PlatformWalletID, -payout.Subsidy, TransactionTypePromoSubsidy},
+	})
+}
+
+// postEntries posts entries under a reference and announces them, skipping
+// those of 0. The driver's wallet is opened on their first ride. Wallets are
+// updated in ID order, like transfers.
+func postEntries(ctx context.Context, q db.Querier, reference string, entries []entry) error {
+	for _, e := range entries {
+		if e.userID == PlatformWalletID {
+			continue
+		}
+		if err := q.EnsureWallet(ctx, e.userID); err != nil {
+			return status.Errorf(codes.Internal, "failed to open driver wallet: %v", err)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].userID < entries[j].userID })
+
+	for _, e := range entries {
+		if e.amount == 0 {
+			continue
+		}
+		txn, err := q.CreateTransaction(ctx, db.CreateTransactionParams{
+			WalletID:    e.userID,
+			Amount:      e.amount,
+			Description: e.description,
+			ReferenceID: pgtype.Text{String: reference, Valid: reference != ""},
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return status.Errorf(codes.AlreadyExists, "%s was already paid out for %s", e.description, reference)
+			}
+			return status.Errorf(codes.Internal, "failed to create transaction: %v", err)
+		}
+
+		w, err := q.AddWalletBalance(ctx, db.AddWalletBalanceParams{
+			UserID: e.userID,
+			Amount: e.amount,
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to pay out: %v", err)
+		}
+		if err = enqueueTransaction(ctx, q, txn, w.Balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
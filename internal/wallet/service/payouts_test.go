@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidatePayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		payout *wallet.Payout
+		amount float64
+		want   codes.Code
+	}{
+		{"Exact Split", &wallet.Payout{DriverId: "driver-1", Earnings: 16800, Commission: 4200}, 21000, codes.OK},
+		{"Promo Paid By Platform", &wallet.Payout{DriverId: "driver-1", Earnings: 20800, Commission: 5200, Subsidy: 5000}, 21000, codes.OK},
+		{"Rounding", &wallet.Payout{DriverId: "driver-1", Earnings: 16802.004, Commission: 4201}, 21003, codes.OK},
+		{"Missing Driver", &wallet.Payout{Earnings: 16800, Commission: 4200}, 21000, codes.InvalidArgument},
+		{"Platform As Driver", &wallet.Payout{DriverId: PlatformWalletID, Earnings: 16800, Commission: 4200}, 21000, codes.InvalidArgument},
+		{"Negative Earnings", &wallet.Payout{DriverId: "driver-1", Earnings: -1000, Commission: 22000}, 21000, codes.InvalidArgument},
+		{"Negative Subsidy", &wallet.Payout{DriverId: "driver-1", Earnings: 16800, Commission: 4200, Subsidy: -100}, 21100, codes.InvalidArgument},
+		{"More Than Debited", &wallet.Payout{DriverId: "driver-1", Earnings: 20000, Commission: 4200}, 21000, codes.InvalidArgument},
+		{"Less Than Debited", &wallet.Payout{DriverId: "driver-1", Earnings: 16800, Commission: 4200, Subsidy: 5000}, 21000, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePayout(tt.payout, tt.amount)
+
+			assert.Equal(t, tt.want, status.Code(err))
+		})
+	}
+}
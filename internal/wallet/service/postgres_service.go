@@ -7,46 +7,42 @@ import (
 	"log"
 
 	"github.com/dwikikusuma/atlas/internal/wallet/db"
+	"github.com/dwikikusuma/atlas/internal/wallet/payment"
+	"github.com/dwikikusuma/atlas/pkg/model"
 	"github.com/dwikikusuma/atlas/pkg/pb/wallet"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+const walletEventsTopic = "wallet-events"
+
+// TransactionTypeTip marks both entries of a tip from a passenger to a driver.
+const TransactionTypeTip = "TIP"
+
+// transferTypes are the kinds of wallet to wallet transfers the ledger accepts.
+var transferTypes = map[string]bool{
+	TransactionTypeTip: true,
+}
+
 type PostgresWalletService struct {
 	wallet.UnimplementedWalletServiceServer
-	pool *pgxpool.Pool
+	store db.Store
+	// cards charges fares paid by card.
+	cards payment.Provider
 }
 
-func NewPostgresWalletService(db *pgxpool.Pool) *PostgresWalletService {
+func NewPostgresWalletService(store db.Store, cards payment.Provider) *PostgresWalletService {
 	return &PostgresWalletService{
-		pool: db,
+		store: store,
+		cards: cards,
 	}
 }
 
-func (s *PostgresWalletService) execTx(ctx context.Context, fn func(*db.Queries) error) error {
-	tx, err := s.pool.Begin(ctx)
-	if err != nil {
-		log.Println("failed to begin transaction:", err)
-		return err
-	}
-
-	q := db.New(tx)
-
-	if err = fn(q); err != nil {
-		if rbErr := tx.Rollback(ctx); rbErr != nil {
-			log.Println("failed to rollback transaction:", rbErr)
-			return rbErr
-		}
-	}
-
-	return tx.Commit(ctx)
-}
-
 func (s *PostgresWalletService) GetBalance(ctx context.Context, req *wallet.GetBalanceRequest) (*wallet.GetBalanceResponse, error) {
-	q := db.New(s.pool)
-	walletDetail, err := q.GetWallet(ctx, req.UserId)
+	walletDetail, err := s.store.GetWallet(ctx, req.UserId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return &wallet.GetBalanceResponse{
@@ -58,16 +54,23 @@ func (s *PostgresWalletService) GetBalance(ctx context.Context, req *wallet.GetB
 		return nil, status.Errorf(codes.Internal, "failed to get wallet: %v", err)
 	}
 
+	held, err := s.store.GetHeldAmount(ctx, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get held amount: %v", err)
+	}
+
 	return &wallet.GetBalanceResponse{
-		Balance: walletDetail.Balance,
+		UserId:           req.UserId,
+		Balance:          walletDetail.Balance,
+		Held:             held,
+		AvailableBalance: walletDetail.Balance - held,
 	}, nil
 }
 
 func (s *PostgresWalletService) CreditBalance(ctx context.Context, req *wallet.CreditBalanceRequest) (*wallet.BalanceResponse, error) {
 	var balance float64
 
-	q := db.New(s.pool)
-	err := s.execTx(ctx, func(queries *db.Queries) error {
+	err := s.store.ExecTx(ctx, func(q db.Querier) error {
 		_, err := q.GetWallet(ctx, req.UserId)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
@@ -76,7 +79,7 @@ func (s *PostgresWalletService) CreditBalance(ctx context.Context, req *wallet.C
 			return status.Errorf(codes.Internal, "failed to get wallet: %v", err)
 		}
 
-		_, err = q.CreateTransaction(ctx, db.CreateTransactionParams{
+		txn, err := q.CreateTransaction(ctx, db.CreateTransactionParams{
 			WalletID:    req.UserId,
 			Amount:      req.Amount,
 			Description: "CREDIT",
@@ -96,7 +99,7 @@ func (s *PostgresWalletService) CreditBalance(ctx context.Context, req *wallet.C
 
 		balance = w.Balance
 
-		return nil
+		return enqueueTransaction(ctx, q, txn, w.Balance)
 	})
 
 	if err != nil {
@@ -108,20 +111,96 @@ func (s *PostgresWalletService) CreditBalance(ctx context.Context, req *wallet.C
 	}, nil
 }
 
+// DebitBalance charges a fare, or a fee, the way the passenger pays it: from
+// their wallet, to their card, or in cash to the driver. When a payout is
+// given, the amount is paid out in the same transaction.
 func (s *PostgresWalletService) DebitBalance(ctx context.Context, req *wallet.DebitBalanceRequest) (*wallet.BalanceResponse, error) {
+	return s.debit(ctx, req, false)
+}
+
+// debit routes a charge by its payment method. With report set, the charge
+// is reported on payment-succeeded in the transaction posting it.
+func (s *PostgresWalletService) debit(ctx context.Context, req *wallet.DebitBalanceRequest, report bool) (*wallet.BalanceResponse, error) {
+	if req.Payout != nil {
+		if err := validatePayout(req.Payout, req.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	switch req.PaymentMethod {
+	case "", model.PaymentMethodWallet:
+		return s.debitWallet(ctx, req, report)
+	case model.PaymentMethodCard:
+		return s.chargeCard(ctx, req, report)
+	case model.PaymentMethodCash:
+		return s.settleCash(ctx, req, report)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid payment method: %s", req.PaymentMethod)
+	}
+}
+
+// debitWallet charges a wallet, capturing the hold given with it if any. The
+// wallet must cover the amount without the money held for something else, and
+// a reference is debited once: repeating it returns the balance unchanged.
+func (s *PostgresWalletService) debitWallet(ctx context.Context, req *wallet.DebitBalanceRequest, report bool) (*wallet.BalanceResponse, error) {
 	var balance float64
 
-	q := db.New(s.pool)
-	err := s.execTx(ctx, func(queries *db.Queries) error {
-		_, err := q.GetWallet(ctx, req.UserId)
+	err := s.store.ExecTx(ctx, func(q db.Querier) error {
+		// Locking the wallet serializes debits with holds and other debits,
+		// so the balance checked below is the one debited.
+		w, err := q.LockWallet(ctx, req.UserId)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
+			if errors.Is(err, pgx.ErrNoRows) {
 				return status.Errorf(codes.NotFound, "wallet not found: %v", err)
 			}
 			return status.Errorf(codes.Internal, "failed to get wallet: %v", err)
 		}
 
-		_, err = q.CreateTransaction(ctx, db.CreateTransactionParams{
+		if req.HoldReferenceId != "" {
+			_, err = closeHold(ctx, q, req.HoldReferenceId, req.Amount)
+			switch {
+			case errors.Is(err, errHoldClosed):
+				// A repeated request: the debit was made when the hold closed.
+				balance = w.Balance
+				return nil
+			case status.Code(err) == codes.NotFound:
+				// Orders placed before holds existed are debited as before.
+			case err != nil:
+				return err
+			}
+			if req.Amount == 0 {
+				balance = w.Balance
+				return nil
+			}
+		}
+
+		if req.ReferenceId != "" {
+			_, err = q.GetTransactionByReference(ctx, db.GetTransactionByReferenceParams{
+				WalletID:    req.UserId,
+				Description: "DEBIT",
+				ReferenceID: pgtype.Text{String: req.ReferenceId, Valid: true},
+			})
+			if err == nil {
+				// A repeated request: the reference was debited before.
+				balance = w.Balance
+				return nil
+			}
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return status.Errorf(codes.Internal, "failed to get transaction: %v", err)
+			}
+		}
+
+		// A captured hold no longer counts as held, so its money is available
+		// to the debit closing it.
+		held, err := q.GetHeldAmount(ctx, req.UserId)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to get held amount: %v", err)
+		}
+		if available := w.Balance - held; available < req.Amount {
+			return status.Errorf(codes.FailedPrecondition, "insufficient balance: %v", available)
+		}
+
+		txn, err := q.CreateTransaction(ctx, db.CreateTransactionParams{
 			WalletID:    req.UserId,
 			Amount:      -req.Amount,
 			Description: "DEBIT",
@@ -131,7 +210,7 @@ func (s *PostgresWalletService) DebitBalance(ctx context.Context, req *wallet.De
 			return status.Errorf(codes.Internal, "failed to create transaction: %v", err)
 		}
 
-		w, err := q.AddWalletBalance(ctx, db.AddWalletBalanceParams{
+		w, err = q.AddWalletBalance(ctx, db.AddWalletBalanceParams{
 			UserID: req.UserId,
 			Amount: -req.Amount,
 		})
@@ -139,6 +218,18 @@ func (s *PostgresWalletService) DebitBalance(ctx context.Context, req *wallet.De
 			return status.Errorf(codes.Internal, "failed to debit balance: %v", err)
 		}
 		balance = w.Balance
+		if err = enqueueTransaction(ctx, q, txn, w.Balance); err != nil {
+			return err
+		}
+
+		if req.Payout != nil {
+			if err = payOut(ctx, q, req.ReferenceId, req.Payout); err != nil {
+				return err
+			}
+		}
+		if report {
+			return enqueuePaymentResult(ctx, q, req, balance, nil)
+		}
 		return nil
 	})
 
@@ -150,3 +241,137 @@ func (s *PostgresWalletService) DebitBalance(ctx context.Context, req *wallet.De
 		NewBalance: balance,
 	}, nil
 }
+
+// Transfer moves money between two wallets in one transaction, posting an
+// entry of the given type on each. A transfer is recorded once per reference:
+// repeating it returns the balances instead of paying twice.
+func (s *PostgresWalletService) Transfer(ctx context.Context, req *wallet.TransferRequest) (*wallet.TransferResponse, error) {
+	if !transferTypes[req.Type] {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid transfer type: %s", req.Type)
+	}
+	if req.FromUserId == "" || req.ToUserId == "" || req.FromUserId == req.ToUserId {
+		return nil, status.Error(codes.InvalidArgument, "two different wallets are required")
+	}
+	if req.Amount <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+	if req.ReferenceId == "" {
+		return nil, status.Error(codes.InvalidArgument, "reference_id is required")
+	}
+	reference := pgtype.Text{String: req.ReferenceId, Valid: true}
+
+	res := &wallet.TransferResponse{ReferenceId: req.ReferenceId}
+	err := s.store.ExecTx(ctx, func(q db.Querier) error {
+		prev, err := q.GetTransactionByReference(ctx, db.GetTransactionByReferenceParams{
+			WalletID:    req.FromUserId,
+			Description: req.Type,
+			ReferenceID: reference,
+		})
+		switch {
+		case err == nil:
+			if -prev.Amount != req.Amount {
+				return status.Errorf(codes.AlreadyExists, "a %s of %.2f was already made for %s", req.Type, -prev.Amount, req.ReferenceId)
+			}
+			return transferBalances(ctx, q, req, res)
+		case !errors.Is(err, pgx.ErrNoRows):
+			return status.Errorf(codes.Internal, "failed to get transaction: %v", err)
+		}
+
+		// Wallets are updated in ID order, so opposite transfers between the
+		// same two users cannot deadlock.
+		legs := []struct {
+			userID string
+			amount float64
+		}{{req.FromUserId, -req.Amount}, {req.ToUserId, req.Amount}}
+		if req.ToUserId < req.FromUserId {
+			legs[0], legs[1] = legs[1], legs[0]
+		}
+
+		for _, leg := range legs {
+			if _, err := q.GetWallet(ctx, leg.userID); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return status.Errorf(codes.NotFound, "wallet not found: %s", leg.userID)
+				}
+				return status.Errorf(codes.Internal, "failed to get wallet: %v", err)
+			}
+
+			txn, err := q.CreateTransaction(ctx, db.CreateTransactionParams{
+				WalletID:    leg.userID,
+				Amount:      leg.amount,
+				Description: req.Type,
+				ReferenceID: reference,
+			})
+			if err != nil {
+				if isUniqueViolation(err) {
+					return status.Errorf(codes.AlreadyExists, "a %s was already made for %s", req.Type, req.ReferenceId)
+				}
+				return status.Errorf(codes.Internal, "failed to create transaction: %v", err)
+			}
+
+			w, err := q.AddWalletBalance(ctx, db.AddWalletBalanceParams{
+				UserID: leg.userID,
+				Amount: leg.amount,
+			})
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to update balance: %v", err)
+			}
+			if leg.userID == req.FromUserId {
+				held, err := q.GetHeldAmount(ctx, leg.userID)
+				if err != nil {
+					return status.Errorf(codes.Internal, "failed to get held amount: %v", err)
+				}
+				// Money held for unpaid rides can't be transferred either.
+				if w.Balance-held < 0 {
+					return status.Errorf(codes.FailedPrecondition, "insufficient balance: %v", w.Balance-held+req.Amount)
+				}
+				res.FromBalance = w.Balance
+			} else {
+				res.ToBalance = w.Balance
+			}
+
+			if err = enqueueTransaction(ctx, q, txn, w.Balance); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// transferBalances fills in the current balances of both sides of a transfer.
+func transferBalances(ctx context.Context, q db.Querier, req *wallet.TransferRequest, res *wallet.TransferResponse) error {
+	from, err := q.GetWallet(ctx, req.FromUserId)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get wallet: %v", err)
+	}
+	to, err := q.GetWallet(ctx, req.ToUserId)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get wallet: %v", err)
+	}
+	res.FromBalance, res.ToBalance = from.Balance, to.Balance
+	return nil
+}
+
+// isUniqueViolation reports whether a statement failed on a unique index.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// enqueueTransaction announces a ledger entry on wallet-events. It is written
+// to the outbox in the transaction that posts the entry.
+func enqueueTransaction(ctx context.Context, q db.Querier, txn db.Transaction, balance float64) error {
+	event := model.WalletTransactionEvent{
+		TransactionID: txn.ID.String(),
+		UserID:        txn.WalletID,
+		Type:          txn.Description,
+		Amount:        txn.Amount,
+		Balance:       balance,
+		ReferenceID:   txn.ReferenceID.String,
+		CreatedAt:     txn.CreatedAt.Time.Unix(),
+	}
+	return enqueueJSON(ctx, q, walletEventsTopic, txn.WalletID, &event)
+}
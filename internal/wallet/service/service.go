@@ -10,4 +10,8 @@ type WalletService interface {
 	GetBalance(ctx context.Context, req *wallet.GetBalanceRequest) (*wallet.GetBalanceResponse, error)
 	CreditBalance(ctx context.Context, req *wallet.CreditBalanceRequest) (*wallet.BalanceResponse, error)
 	DebitBalance(ctx context.Context, req *wallet.DebitBalanceRequest) (*wallet.BalanceResponse, error)
+	ChargeDebit(ctx context.Context, req *wallet.DebitBalanceRequest) (*wallet.BalanceResponse, error)
+	Transfer(ctx context.Context, req *wallet.TransferRequest) (*wallet.TransferResponse, error)
+	PlaceHold(ctx context.Context, req *wallet.PlaceHoldRequest) (*wallet.HoldResponse, error)
+	ReleaseHold(ctx context.Context, req *wallet.ReleaseHoldRequest) (*wallet.HoldResponse, error)
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"time"
 
@@ -54,7 +55,8 @@ func (w *WalletWorker) Start(ctx context.Context) error {
 			continue
 		}
 
-		if event.UserID == "" || event.Amount <= 0 || event.Reference == "" {
+		// An amount of 0 only releases a hold.
+		if event.UserID == "" || event.Amount < 0 || (event.Amount == 0 && event.Hold == "") || event.Reference == "" {
 			log.Printf("❌ Invalid event data: userID=%s, amount=%.2f, ref=%s",
 				event.UserID, event.Amount, event.Reference)
 			if err = w.consumer.CommitMessages(ctx, m); err != nil {
@@ -65,28 +67,42 @@ func (w *WalletWorker) Start(ctx context.Context) error {
 
 		debitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 		args := wallet.DebitBalanceRequest{
-			UserId:      event.UserID,
-			Amount:      event.Amount,
-			ReferenceId: event.Reference,
+			UserId:          event.UserID,
+			Amount:          event.Amount,
+			ReferenceId:     event.Reference,
+			HoldReferenceId: event.Hold,
+			PaymentMethod:   event.Method,
+		}
+		if p := event.Payout; p != nil {
+			args.Payout = &wallet.Payout{
+				DriverId:   p.DriverID,
+				Earnings:   p.Earnings,
+				Commission: p.Commission,
+				Subsidy:    p.Subsidy,
+			}
 		}
 
-		_, err = w.service.DebitBalance(debitCtx, &args)
+		// The outcome is reported through the outbox with the debit, so a
+		// charge is never left unreported once the message is committed.
+		_, debitErr := w.service.ChargeDebit(debitCtx, &args)
 		cancel()
 
-		if err != nil {
+		if debitErr != nil {
 			if ctx.Err() != nil {
 				return nil
 			}
-			log.Printf("❌ Failed to debit balance for userID=%s, ref=%s: %v", event.UserID, event.Reference, err)
-			if err = w.consumer.CommitMessages(ctx, m); err != nil {
-				log.Printf("⚠️ Failed to commit message for key=%s: %v", string(m.Key), err)
+			if errors.Is(debitErr, errResultNotRecorded) {
+				// Left uncommitted, the debit is redelivered and reported
+				// on the next try.
+				log.Printf("❌ Failed to report payment result for ref=%s: %v", event.Reference, debitErr)
+				continue
 			}
-			continue
+			log.Printf("❌ Failed to debit balance for userID=%s, ref=%s: %v", event.UserID, event.Reference, debitErr)
+		} else {
+			log.Printf("✅ Processed debit: userID=%s, amount=%.2f, ref=%s",
+				event.UserID, event.Amount, event.Reference)
 		}
 
-		log.Printf("✅ Processed debit: userID=%s, amount=%.2f, ref=%s",
-			event.UserID, event.Amount, event.Reference)
-
 		if err = w.consumer.CommitMessages(ctx, m); err != nil {
 			log.Printf("⚠️ Failed to commit message for key=%s: %v", string(m.Key), err)
 		}
@@ -0,0 +1,55 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// MessageHandler processes a message delivered by a MemoryBus.
+type MessageHandler func(ctx context.Context, msg kafka.Message) error
+
+// MemoryBus is an in-process EventProducer that hands every message to the
+// handlers subscribed to its topic, synchronously and in publish order. It
+// lets simulations run the real services without a Kafka broker.
+type MemoryBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]MessageHandler
+}
+
+// Ensure MemoryBus implements the interface at compile time
+var _ EventProducer = (*MemoryBus)(nil)
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		handlers: make(map[string][]MessageHandler),
+	}
+}
+
+// Subscribe registers a handler for every message published on topic.
+func (b *MemoryBus) Subscribe(topic string, handler MessageHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+// Publish delivers the message to the topic's handlers and returns the first
+// handler error. Messages on topics nobody subscribed to are dropped.
+func (b *MemoryBus) Publish(ctx context.Context, topic string, key string, value []byte) error {
+	b.mu.RLock()
+	handlers := b.handlers[topic]
+	b.mu.RUnlock()
+
+	msg := kafka.Message{Topic: topic, Key: []byte(key), Value: value}
+	for _, handler := range handlers {
+		if err := handler(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBus) Close() error {
+	return nil
+}
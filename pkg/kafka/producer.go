@@ -37,6 +37,21 @@ func NewProducer(brokers []string) *Producer {
 	}
 }
 
+// NewSyncProducer returns a producer whose Publish only returns once every
+// in-sync replica has the message, for callers that must know it was delivered.
+func NewSyncProducer(brokers []string) *Producer {
+	writer := kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}
+
+	return &Producer{
+		writer: &writer,
+	}
+}
+
 func (p *Producer) Publish(ctx context.Context, topic string, key string, value []byte) error {
 	msg := kafka.Message{
 		Topic: topic,
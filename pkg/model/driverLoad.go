@@ -0,0 +1,51 @@
+package model
+
+// DefaultSeatCapacity is used for drivers that never reported their vehicle size.
+const DefaultSeatCapacity = 4
+
+// DriverLoad describes the rides a driver is currently assigned to.
+// Rides maps a ride ID to the seats it occupies; 0 marks an exclusive
+// (non-pooled) ride, which blocks the whole vehicle.
+type DriverLoad struct {
+	Capacity int            `json:"capacity"`
+	Rides    map[string]int `json:"rides"`
+}
+
+// Free reports whether the driver has no ride at all.
+func (l DriverLoad) Free() bool {
+	return len(l.Rides) == 0
+}
+
+// Exclusive reports whether the driver is on a non-pooled ride.
+func (l DriverLoad) Exclusive() bool {
+	for _, seats := range l.Rides {
+		if seats == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RemainingSeats returns the seats still available for pooled rides.
+func (l DriverLoad) RemainingSeats() int {
+	if l.Exclusive() {
+		return 0
+	}
+	remaining := l.Capacity
+	for _, seats := range l.Rides {
+		remaining -= seats
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// RideIDs lists the rides the driver is assigned to.
+func (l DriverLoad) RideIDs() []string {
+	ids := make([]string, 0, len(l.Rides))
+	for id := range l.Rides {
+		ids = append(ids, id)
+	}
+	return ids
+}
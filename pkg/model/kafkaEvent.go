@@ -1,14 +1,107 @@
 package model
 
+// How a passenger pays for a ride.
+const (
+	PaymentMethodWallet = "WALLET"
+	PaymentMethodCash   = "CASH"
+	PaymentMethodCard   = "CARD"
+)
+
 type DebitBalanceEvent struct {
 	Amount    float64
 	UserID    string
 	Reference string
+	// Method is how the amount is charged, one of the PaymentMethod
+	// constants. Empty means WALLET.
+	Method string `json:",omitempty"`
+	// Hold is the reference of the hold the debit closes: captured for Amount,
+	// or released when Amount is 0. Empty for a plain debit.
+	Hold string `json:",omitempty"`
+	// Payout splits the fare between the driver and the platform once it is
+	// debited. Empty for charges that pay nobody, e.g. cancellation fees.
+	Payout *Payout `json:",omitempty"`
 }
 
-type LocationEvent struct {
+// Payout is how a charged fare is paid out: Earnings to the driver and
+// Commission to the platform, which also pays the Subsidy of a promo
+// discount. Earnings + Commission - Subsidy is the amount charged.
+type Payout struct {
+	DriverID   string  `json:"driver_id"`
+	Earnings   float64 `json:"earnings"`
+	Commission float64 `json:"commission"`
+	Subsidy    float64 `json:"subsidy,omitempty"`
+}
+
+// PaymentResultEvent is published by the Wallet Service to payment-succeeded
+// or payment-failed, keyed by reference, once it processed a DebitBalanceEvent
+// with an amount.
+type PaymentResultEvent struct {
 	UserID    string  `json:"user_id"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	Timestamp string  `json:"timestamp"`
+	Reference string  `json:"reference"`
+	Amount    float64 `json:"amount"`
+	Balance   float64 `json:"balance,omitempty"` // after a successful debit
+	Code      string  `json:"code,omitempty"`    // gRPC code of a failure, e.g. FailedPrecondition
+	Reason    string  `json:"reason,omitempty"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// WalletTransactionEvent is published to wallet-events, keyed by user ID, for
+// every entry written to a wallet's ledger.
+type WalletTransactionEvent struct {
+	TransactionID string  `json:"transaction_id"`
+	UserID        string  `json:"user_id"`
+	Type          string  `json:"type"`    // CREDIT or DEBIT
+	Amount        float64 `json:"amount"`  // negative for debits
+	Balance       float64 `json:"balance"` // after the transaction
+	ReferenceID   string  `json:"reference_id,omitempty"`
+	CreatedAt     int64   `json:"created_at"`
+}
+
+type LocationEvent struct {
+	UserID       string  `json:"user_id"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	Timestamp    string  `json:"timestamp"`
+	SeatCapacity int32   `json:"seat_capacity,omitempty"`
+}
+
+type OrderCancelledEvent struct {
+	OrderID         string  `json:"order_id"`
+	PassengerID     string  `json:"passenger_id"`
+	DriverID        string  `json:"driver_id,omitempty"`
+	CancelledBy     string  `json:"cancelled_by"`
+	Reason          string  `json:"reason"`
+	CancellationFee float64 `json:"cancellation_fee"`
+	CancelledAt     int64   `json:"cancelled_at"`
+}
+
+type RideReminderEvent struct {
+	OrderID     string `json:"order_id"`
+	PassengerID string `json:"passenger_id"`
+	ScheduledAt int64  `json:"scheduled_at"`
+	Timestamp   int64  `json:"timestamp"`
+}
+
+type SurgeChangedEvent struct {
+	Zone       string  `json:"zone"`
+	CenterLat  float64 `json:"center_lat"`
+	CenterLong float64 `json:"center_long"`
+	Multiplier float64 `json:"multiplier"` // 1 when the surge ended
+	Previous   float64 `json:"previous"`
+	Requests   int     `json:"requests"`
+	Drivers    int     `json:"drivers"`
+	UpdatedAt  int64   `json:"updated_at"`
+}
+
+// LowRatingEvent flags a trip rated poorly, for the support team to review.
+type LowRatingEvent struct {
+	OrderID      string   `json:"order_id"`
+	RaterID      string   `json:"rater_id"`
+	RateeID      string   `json:"ratee_id"`
+	RateeRole    string   `json:"ratee_role"` // DRIVER or PASSENGER
+	Stars        int32    `json:"stars"`
+	Tags         []string `json:"tags,omitempty"`
+	Comment      string   `json:"comment,omitempty"`
+	RateeAverage float64  `json:"ratee_average"` // Rolling average including this rating
+	RatedAt      int64    `json:"rated_at"`
 }
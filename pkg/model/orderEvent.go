@@ -0,0 +1,65 @@
+package model
+
+// OrderEventVersion is the schema version of OrderEvent. Adding optional
+// fields keeps the version; renaming or removing fields, or changing their
+// meaning, bumps it. The schema is documented in api/events/order-event.v1.schema.json.
+const OrderEventVersion = 1
+
+// Order event types, one per order status an order can reach.
+const (
+	OrderEventCreated       = "order.created"
+	OrderEventScheduled     = "order.scheduled"
+	OrderEventSearching     = "order.searching"
+	OrderEventMatched       = "order.matched"
+	OrderEventDriverArrived = "order.driver_arrived"
+	OrderEventStarted       = "order.started"
+	OrderEventFinished      = "order.finished"
+	OrderEventPaid          = "order.paid"
+	OrderEventPaymentFailed = "order.payment_failed"
+	OrderEventCancelled     = "order.cancelled"
+	OrderEventExpired       = "order.expired"
+)
+
+// OrderEvent is published to the order-events topic, keyed by order ID, every
+// time an order changes status. Order is the state right after the change.
+type OrderEvent struct {
+	Version    int           `json:"version"`
+	Type       string        `json:"type"`
+	OrderID    string        `json:"order_id"`
+	FromStatus string        `json:"from_status,omitempty"` // empty for order.created and order.scheduled
+	Actor      string        `json:"actor"`                 // PASSENGER, DRIVER, DISPATCH, WALLET, SYSTEM
+	OccurredAt int64         `json:"occurred_at"`
+	Order      OrderSnapshot `json:"order"`
+}
+
+// OrderSnapshot is the full order as stored by the Order Service. Timestamps
+// are Unix seconds and omitted while unset.
+type OrderSnapshot struct {
+	ID              string  `json:"id"`
+	PassengerID     string  `json:"passenger_id"`
+	DriverID        string  `json:"driver_id,omitempty"`
+	Status          string  `json:"status"`
+	VehicleType     string  `json:"vehicle_type"`
+	Seats           int32   `json:"seats"`
+	PickupLat       float64 `json:"pickup_lat"`
+	PickupLong      float64 `json:"pickup_long"`
+	DropoffLat      float64 `json:"dropoff_lat"`
+	DropoffLong     float64 `json:"dropoff_long"`
+	Price           float64 `json:"price"`
+	TariffVersion   string  `json:"tariff_version,omitempty"`
+	SurgeMultiplier float64 `json:"surge_multiplier,omitempty"`
+	PromoCode       string  `json:"promo_code,omitempty"`
+	Discount        float64 `json:"discount,omitempty"`
+	FinalPrice      float64 `json:"final_price,omitempty"`
+	FareBasis       string  `json:"fare_basis,omitempty"`
+	PoolTripID      string  `json:"pool_trip_id,omitempty"`
+	CancelledBy     string  `json:"cancelled_by,omitempty"`
+	CancelReason    string  `json:"cancel_reason,omitempty"`
+	CancellationFee float64 `json:"cancellation_fee,omitempty"`
+	CreatedAt       int64   `json:"created_at"`
+	UpdatedAt       int64   `json:"updated_at"`
+	ScheduledAt     int64   `json:"scheduled_at,omitempty"`
+	MatchedAt       int64   `json:"matched_at,omitempty"`
+	CancelledAt     int64   `json:"cancelled_at,omitempty"`
+	PaidAt          int64   `json:"paid_at,omitempty"`
+}
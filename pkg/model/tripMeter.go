@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// TripMeter is the distance a driver actually travelled during a ride,
+// accumulated from the GPS updates the tracker receives while the ride runs.
+type TripMeter struct {
+	RideID     string    `json:"ride_id"`
+	DriverID   string    `json:"driver_id"`
+	DistanceKm float64   `json:"distance_km"`
+	Points     int       `json:"points"` // GPS updates that moved the meter
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"` // zero while the ride runs
+	LastLat    float64   `json:"last_lat"`
+	LastLong   float64   `json:"last_long"`
+	HasLast    bool      `json:"has_last"` // false until the first position is known
+}
+
+// Duration returns how long the ride took, or has taken so far as of now.
+func (m TripMeter) Duration(now time.Time) time.Duration {
+	end := m.EndedAt
+	if end.IsZero() {
+		end = now
+	}
+	if end.Before(m.StartedAt) {
+		return 0
+	}
+	return end.Sub(m.StartedAt)
+}
@@ -0,0 +1,58 @@
+package outbox
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Queries are the outbox queries of one service, as generated by sqlc for its
+// own outbox table. Row is the generated outbox row type and Message turns a
+// claimed row into a Message. Durations are passed in whole seconds, the
+// resolution of the queries.
+type Queries[Row any] struct {
+	Claim      func(ctx context.Context, leaseSeconds, batchSize int32) ([]Row, error)
+	MarkSent   func(ctx context.Context, id int64) error
+	MarkFailed func(ctx context.Context, id int64, retryInSeconds int32, lastError string) error
+	PurgeSent  func(ctx context.Context, before time.Time) (int64, error)
+	Message    func(row Row) Message
+}
+
+type queryStore[Row any] struct {
+	q Queries[Row]
+}
+
+// NewQueryStore exposes a service's outbox table to a Relay through its queries.
+func NewQueryStore[Row any](q Queries[Row]) Store {
+	return &queryStore[Row]{q: q}
+}
+
+func (s *queryStore[Row]) Claim(ctx context.Context, limit int32, lease time.Duration) ([]Message, error) {
+	rows, err := s.q.Claim(ctx, seconds(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, s.q.Message(row))
+	}
+	return messages, nil
+}
+
+func (s *queryStore[Row]) MarkSent(ctx context.Context, id int64) error {
+	return s.q.MarkSent(ctx, id)
+}
+
+func (s *queryStore[Row]) MarkFailed(ctx context.Context, id int64, retryIn time.Duration, cause error) error {
+	return s.q.MarkFailed(ctx, id, seconds(retryIn), cause.Error())
+}
+
+func (s *queryStore[Row]) PurgeSent(ctx context.Context, before time.Time) (int64, error) {
+	return s.q.PurgeSent(ctx, before)
+}
+
+// seconds rounds a duration up to whole seconds.
+func seconds(d time.Duration) int32 {
+	return int32(math.Ceil(d.Seconds()))
+}
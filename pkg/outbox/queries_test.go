@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRow struct {
+	id  int64
+	key string
+}
+
+func TestQueryStore(t *testing.T) {
+	ctx := context.Background()
+
+	var gotLease, gotBatch, gotRetry int32
+	var gotError string
+	store := NewQueryStore(Queries[testRow]{
+		Claim: func(ctx context.Context, leaseSeconds, batchSize int32) ([]testRow, error) {
+			gotLease, gotBatch = leaseSeconds, batchSize
+			return []testRow{{id: 1, key: "order-1"}, {id: 2, key: "order-2"}}, nil
+		},
+		MarkSent: func(ctx context.Context, id int64) error { return nil },
+		MarkFailed: func(ctx context.Context, id int64, retryInSeconds int32, lastError string) error {
+			gotRetry, gotError = retryInSeconds, lastError
+			return nil
+		},
+		PurgeSent: func(ctx context.Context, before time.Time) (int64, error) { return 3, nil },
+		Message: func(row testRow) Message {
+			return Message{ID: row.id, Topic: "ride-status", Key: row.key}
+		},
+	})
+
+	messages, err := store.Claim(ctx, 100, 1500*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, []Message{
+		{ID: 1, Topic: "ride-status", Key: "order-1"},
+		{ID: 2, Topic: "ride-status", Key: "order-2"},
+	}, messages)
+	// Durations are rounded up to whole seconds.
+	assert.Equal(t, int32(2), gotLease)
+	assert.Equal(t, int32(100), gotBatch)
+
+	assert.NoError(t, store.MarkFailed(ctx, 1, 250*time.Millisecond, errors.New("broker down")))
+	assert.Equal(t, int32(1), gotRetry)
+	assert.Equal(t, "broker down", gotError)
+
+	purged, err := store.PurgeSent(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dwikikusuma/atlas/pkg/kafka"
+)
+
+// Message is an event stored in a service's outbox table, waiting to be
+// published to Kafka.
+type Message struct {
+	ID       int64
+	Topic    string
+	Key      string
+	Payload  []byte
+	Attempts int32
+}
+
+// Store is the outbox table of one service.
+type Store interface {
+	// Claim leases up to limit due messages, at most one per topic and key,
+	// hiding them from other relays until the lease runs out.
+	Claim(ctx context.Context, limit int32, lease time.Duration) ([]Message, error)
+	MarkSent(ctx context.Context, id int64) error
+	// MarkFailed records a failed publish and retries the message after retryIn.
+	MarkFailed(ctx context.Context, id int64, retryIn time.Duration, cause error) error
+	// PurgeSent deletes messages sent before the given time.
+	PurgeSent(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Config struct {
+	// Interval is how often the relay polls the outbox once it is drained.
+	Interval time.Duration
+	// BatchSize limits how many messages a single claim takes.
+	BatchSize int32
+	// Lease is how long claimed messages stay hidden from other replicas. A
+	// relay that dies mid-batch has its messages republished after it expires.
+	Lease time.Duration
+	// RetryBase is the wait after the first failed publish; it doubles with
+	// every further failure up to RetryMax.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Retention is how long sent messages are kept. Zero keeps them forever.
+	Retention time.Duration
+}
+
+// Relay publishes the messages of an outbox through an EventProducer. A
+// message is only marked sent after the producer accepted it, so every
+// message is delivered at least once; consumers must tolerate duplicates.
+type Relay struct {
+	store    Store
+	producer kafka.EventProducer
+	cfg      Config
+}
+
+func NewRelay(store Store, producer kafka.EventProducer, cfg Config) *Relay {
+	return &Relay{
+		store:    store,
+		producer: producer,
+		cfg:      cfg,
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	log.Println("Starting outbox relay...")
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopping...")
+			return
+		case <-ticker.C:
+			r.drain(ctx)
+			if r.cfg.Retention > 0 {
+				if _, err := r.store.PurgeSent(ctx, time.Now().Add(-r.cfg.Retention)); err != nil {
+					log.Printf("❌ Failed to purge sent outbox messages: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// drain relays batches until no message is due. Every claim takes only the
+// oldest message of each key, so a busy key needs several rounds.
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := r.RelayOnce(ctx)
+		if err != nil {
+			log.Printf("❌ Failed to claim outbox messages: %v", err)
+			return
+		}
+		if sent == 0 {
+			return
+		}
+	}
+}
+
+// RelayOnce publishes one batch of due messages and returns how many were sent.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	messages, err := r.store.Claim(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, m := range messages {
+		if err = r.producer.Publish(ctx, m.Topic, m.Key, m.Payload); err != nil {
+			retryIn := r.backoff(m.Attempts)
+			log.Printf("⚠️ Publishing outbox message %d to %s failed (attempt %d), retrying in %s: %v", m.ID, m.Topic, m.Attempts+1, retryIn, err)
+			if err = r.store.MarkFailed(ctx, m.ID, retryIn, err); err != nil {
+				log.Printf("❌ Failed to record failed outbox message %d: %v", m.ID, err)
+			}
+			continue
+		}
+
+		// If this fails the message is published again once its lease expires.
+		if err = r.store.MarkSent(ctx, m.ID); err != nil {
+			log.Printf("❌ Failed to mark outbox message %d as sent: %v", m.ID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// backoff is the wait before retrying a message that already failed attempts times.
+func (r *Relay) backoff(attempts int32) time.Duration {
+	wait := r.cfg.RetryBase
+	for i := int32(0); i < attempts && wait < r.cfg.RetryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, r.cfg.RetryMax)
+}
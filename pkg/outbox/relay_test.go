@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mocks ---
+
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) Claim(ctx context.Context, limit int32, lease time.Duration) ([]Message, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]Message), args.Error(1)
+}
+
+func (m *MockStore) MarkSent(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStore) MarkFailed(ctx context.Context, id int64, retryIn time.Duration, cause error) error {
+	args := m.Called(ctx, id, retryIn, cause)
+	return args.Error(0)
+}
+
+func (m *MockStore) PurgeSent(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockEventProducer struct {
+	mock.Mock
+}
+
+func (m *MockEventProducer) Publish(ctx context.Context, topic string, key string, value []byte) error {
+	args := m.Called(ctx, topic, key, value)
+	return args.Error(0)
+}
+
+func (m *MockEventProducer) Close() error { return nil }
+
+// --- Tests ---
+
+var testConfig = Config{
+	Interval:  time.Second,
+	BatchSize: 10,
+	Lease:     30 * time.Second,
+	RetryBase: time.Second,
+	RetryMax:  time.Minute,
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	ctx := context.Background()
+	store := new(MockStore)
+	producer := new(MockEventProducer)
+	relay := NewRelay(store, producer, testConfig)
+
+	messages := []Message{
+		{ID: 1, Topic: "order-events", Key: "order-1", Payload: []byte(`{"a":1}`)},
+		{ID: 2, Topic: "wallet-transactions", Key: "order-2", Payload: []byte(`{"b":2}`), Attempts: 2},
+	}
+	brokerDown := errors.New("broker down")
+
+	store.On("Claim", ctx, int32(10), 30*time.Second).Return(messages, nil).Once()
+	producer.On("Publish", ctx, "order-events", "order-1", []byte(`{"a":1}`)).Return(nil).Once()
+	producer.On("Publish", ctx, "wallet-transactions", "order-2", []byte(`{"b":2}`)).Return(brokerDown).Once()
+	store.On("MarkSent", ctx, int64(1)).Return(nil).Once()
+	// Third attempt: 1s doubled twice.
+	store.On("MarkFailed", ctx, int64(2), 4*time.Second, brokerDown).Return(nil).Once()
+
+	sent, err := relay.RelayOnce(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	store.AssertExpectations(t)
+	producer.AssertExpectations(t)
+	store.AssertNotCalled(t, "MarkSent", ctx, int64(2))
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	relay := NewRelay(nil, nil, testConfig)
+
+	assert.Equal(t, time.Second, relay.backoff(0))
+	assert.Equal(t, 2*time.Second, relay.backoff(1))
+	assert.Equal(t, 32*time.Second, relay.backoff(5))
+	assert.Equal(t, time.Minute, relay.backoff(6))
+	assert.Equal(t, time.Minute, relay.backoff(1000))
+}
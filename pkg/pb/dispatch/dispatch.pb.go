@@ -25,12 +25,15 @@ type RequestRideRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	PassengerId string  `protobuf:"bytes,1,opt,name=passenger_id,json=passengerId,proto3" json:"passenger_id,omitempty"`
-	PickupLat   float64 `protobuf:"fixed64,2,opt,name=pickup_lat,json=pickupLat,proto3" json:"pickup_lat,omitempty"`
-	PickupLong  float64 `protobuf:"fixed64,3,opt,name=pickup_long,json=pickupLong,proto3" json:"pickup_long,omitempty"`
-	DropoffLat  float64 `protobuf:"fixed64,4,opt,name=dropoff_lat,json=dropoffLat,proto3" json:"dropoff_lat,omitempty"`
-	DropoffLong float64 `protobuf:"fixed64,5,opt,name=dropoff_long,json=dropoffLong,proto3" json:"dropoff_long,omitempty"`
-	VehicleType string  `protobuf:"bytes,6,opt,name=vehicle_type,json=vehicleType,proto3" json:"vehicle_type,omitempty"` // e.g., "go-car", "go-ride"
+	PassengerId    string  `protobuf:"bytes,1,opt,name=passenger_id,json=passengerId,proto3" json:"passenger_id,omitempty"`
+	PickupLat      float64 `protobuf:"fixed64,2,opt,name=pickup_lat,json=pickupLat,proto3" json:"pickup_lat,omitempty"`
+	PickupLong     float64 `protobuf:"fixed64,3,opt,name=pickup_long,json=pickupLong,proto3" json:"pickup_long,omitempty"`
+	DropoffLat     float64 `protobuf:"fixed64,4,opt,name=dropoff_lat,json=dropoffLat,proto3" json:"dropoff_lat,omitempty"`
+	DropoffLong    float64 `protobuf:"fixed64,5,opt,name=dropoff_long,json=dropoffLong,proto3" json:"dropoff_long,omitempty"`
+	VehicleType    string  `protobuf:"bytes,6,opt,name=vehicle_type,json=vehicleType,proto3" json:"vehicle_type,omitempty"`              // e.g., "go-car", "go-ride", "go-pool" (shared ride)
+	OrderId        string  `protobuf:"bytes,7,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`                          // Order created by the Order Service, used as the ride ID
+	SearchRadiusKm float64 `protobuf:"fixed64,8,opt,name=search_radius_km,json=searchRadiusKm,proto3" json:"search_radius_km,omitempty"` // Optional, defaults to 5 km. Widened for scheduled rides that stay unmatched
+	Seats          int32   `protobuf:"varint,9,opt,name=seats,proto3" json:"seats,omitempty"`                                            // Passengers travelling together, defaults to 1. Only used for "go-pool"
 }
 
 func (x *RequestRideRequest) Reset() {
@@ -107,14 +110,36 @@ func (x *RequestRideRequest) GetVehicleType() string {
 	return ""
 }
 
+func (x *RequestRideRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *RequestRideRequest) GetSearchRadiusKm() float64 {
+	if x != nil {
+		return x.SearchRadiusKm
+	}
+	return 0
+}
+
+func (x *RequestRideRequest) GetSeats() int32 {
+	if x != nil {
+		return x.Seats
+	}
+	return 0
+}
+
 type RequestRideResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RideId   string `protobuf:"bytes,1,opt,name=ride_id,json=rideId,proto3" json:"ride_id,omitempty"`
-	Status   string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "SEARCHING", "NO_DRIVERS_FOUND"
-	DriverId string `protobuf:"bytes,3,opt,name=driver_id,json=driverId,proto3" json:"driver_id,omitempty"`
+	RideId     string `protobuf:"bytes,1,opt,name=ride_id,json=rideId,proto3" json:"ride_id,omitempty"`
+	Status     string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // "DRIVERS_FOUND", "SEARCHING" (queued until a driver comes online)
+	DriverId   string `protobuf:"bytes,3,opt,name=driver_id,json=driverId,proto3" json:"driver_id,omitempty"`
+	PoolTripId string `protobuf:"bytes,4,opt,name=pool_trip_id,json=poolTripId,proto3" json:"pool_trip_id,omitempty"` // Set when the ride joined a shared trip
 }
 
 func (x *RequestRideResponse) Reset() {
@@ -170,12 +195,398 @@ func (x *RequestRideResponse) GetDriverId() string {
 	return ""
 }
 
+func (x *RequestRideResponse) GetPoolTripId() string {
+	if x != nil {
+		return x.PoolTripId
+	}
+	return ""
+}
+
+type GetDispatchAuditRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OrderId string `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+}
+
+func (x *GetDispatchAuditRequest) Reset() {
+	*x = GetDispatchAuditRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dispatch_dispatch_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDispatchAuditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDispatchAuditRequest) ProtoMessage() {}
+
+func (x *GetDispatchAuditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dispatch_dispatch_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDispatchAuditRequest.ProtoReflect.Descriptor instead.
+func (*GetDispatchAuditRequest) Descriptor() ([]byte, []int) {
+	return file_dispatch_dispatch_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetDispatchAuditRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type GetDispatchAuditResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Audits []*DispatchAudit `protobuf:"bytes,1,rep,name=audits,proto3" json:"audits,omitempty"` // One per dispatch attempt, oldest first
+}
+
+func (x *GetDispatchAuditResponse) Reset() {
+	*x = GetDispatchAuditResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dispatch_dispatch_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDispatchAuditResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDispatchAuditResponse) ProtoMessage() {}
+
+func (x *GetDispatchAuditResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dispatch_dispatch_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDispatchAuditResponse.ProtoReflect.Descriptor instead.
+func (*GetDispatchAuditResponse) Descriptor() ([]byte, []int) {
+	return file_dispatch_dispatch_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetDispatchAuditResponse) GetAudits() []*DispatchAudit {
+	if x != nil {
+		return x.Audits
+	}
+	return nil
+}
+
+type DispatchAudit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RideId           string              `protobuf:"bytes,1,opt,name=ride_id,json=rideId,proto3" json:"ride_id,omitempty"`
+	Source           string              `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`     // "REQUEST" (RequestRide) or "QUEUE" (matched or expired from the pending queue)
+	Strategy         string              `protobuf:"bytes,3,opt,name=strategy,proto3" json:"strategy,omitempty"` // "NEAREST" or "POOL_INSERTION"
+	Request          *RequestRideRequest `protobuf:"bytes,4,opt,name=request,proto3" json:"request,omitempty"`
+	Candidates       []*AuditCandidate   `protobuf:"bytes,5,rep,name=candidates,proto3" json:"candidates,omitempty"`
+	Offers           []*AuditOffer       `protobuf:"bytes,6,rep,name=offers,proto3" json:"offers,omitempty"`
+	Outcome          string              `protobuf:"bytes,7,opt,name=outcome,proto3" json:"outcome,omitempty"` // "DRIVERS_FOUND", "SEARCHING", "EXPIRED", "FAILED"
+	DriverId         string              `protobuf:"bytes,8,opt,name=driver_id,json=driverId,proto3" json:"driver_id,omitempty"`
+	StartedAt        string              `protobuf:"bytes,9,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"` // Send as string ISO8601
+	TrackerLatencyMs int64               `protobuf:"varint,10,opt,name=tracker_latency_ms,json=trackerLatencyMs,proto3" json:"tracker_latency_ms,omitempty"`
+	DurationMs       int64               `protobuf:"varint,11,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+}
+
+func (x *DispatchAudit) Reset() {
+	*x = DispatchAudit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dispatch_dispatch_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DispatchAudit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DispatchAudit) ProtoMessage() {}
+
+func (x *DispatchAudit) ProtoReflect() protoreflect.Message {
+	mi := &file_dispatch_dispatch_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DispatchAudit.ProtoReflect.Descriptor instead.
+func (*DispatchAudit) Descriptor() ([]byte, []int) {
+	return file_dispatch_dispatch_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DispatchAudit) GetRideId() string {
+	if x != nil {
+		return x.RideId
+	}
+	return ""
+}
+
+func (x *DispatchAudit) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *DispatchAudit) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *DispatchAudit) GetRequest() *RequestRideRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *DispatchAudit) GetCandidates() []*AuditCandidate {
+	if x != nil {
+		return x.Candidates
+	}
+	return nil
+}
+
+func (x *DispatchAudit) GetOffers() []*AuditOffer {
+	if x != nil {
+		return x.Offers
+	}
+	return nil
+}
+
+func (x *DispatchAudit) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *DispatchAudit) GetDriverId() string {
+	if x != nil {
+		return x.DriverId
+	}
+	return ""
+}
+
+func (x *DispatchAudit) GetStartedAt() string {
+	if x != nil {
+		return x.StartedAt
+	}
+	return ""
+}
+
+func (x *DispatchAudit) GetTrackerLatencyMs() int64 {
+	if x != nil {
+		return x.TrackerLatencyMs
+	}
+	return 0
+}
+
+func (x *DispatchAudit) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type AuditCandidate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DriverId       string  `protobuf:"bytes,1,opt,name=driver_id,json=driverId,proto3" json:"driver_id,omitempty"`
+	DistanceKm     float64 `protobuf:"fixed64,2,opt,name=distance_km,json=distanceKm,proto3" json:"distance_km,omitempty"` // distance to the pickup
+	RemainingSeats int32   `protobuf:"varint,3,opt,name=remaining_seats,json=remainingSeats,proto3" json:"remaining_seats,omitempty"`
+	Score          float64 `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"` // lower is better: pickup distance (NEAREST) or added route km (POOL_INSERTION), plus the rating penalty
+	Eligible       bool    `protobuf:"varint,5,opt,name=eligible,proto3" json:"eligible,omitempty"`
+	Note           string  `protobuf:"bytes,6,opt,name=note,proto3" json:"note,omitempty"`       // why an ineligible driver was skipped
+	Rating         float64 `protobuf:"fixed64,7,opt,name=rating,proto3" json:"rating,omitempty"` // the driver's average rating the score was computed with
+}
+
+func (x *AuditCandidate) Reset() {
+	*x = AuditCandidate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dispatch_dispatch_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuditCandidate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditCandidate) ProtoMessage() {}
+
+func (x *AuditCandidate) ProtoReflect() protoreflect.Message {
+	mi := &file_dispatch_dispatch_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditCandidate.ProtoReflect.Descriptor instead.
+func (*AuditCandidate) Descriptor() ([]byte, []int) {
+	return file_dispatch_dispatch_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AuditCandidate) GetDriverId() string {
+	if x != nil {
+		return x.DriverId
+	}
+	return ""
+}
+
+func (x *AuditCandidate) GetDistanceKm() float64 {
+	if x != nil {
+		return x.DistanceKm
+	}
+	return 0
+}
+
+func (x *AuditCandidate) GetRemainingSeats() int32 {
+	if x != nil {
+		return x.RemainingSeats
+	}
+	return 0
+}
+
+func (x *AuditCandidate) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *AuditCandidate) GetEligible() bool {
+	if x != nil {
+		return x.Eligible
+	}
+	return false
+}
+
+func (x *AuditCandidate) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *AuditCandidate) GetRating() float64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+type AuditOffer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DriverId  string `protobuf:"bytes,1,opt,name=driver_id,json=driverId,proto3" json:"driver_id,omitempty"`
+	Outcome   string `protobuf:"bytes,2,opt,name=outcome,proto3" json:"outcome,omitempty"`                      // "RESERVED", "BUSY", "CONFLICT", "ERROR"
+	OfferedAt string `protobuf:"bytes,3,opt,name=offered_at,json=offeredAt,proto3" json:"offered_at,omitempty"` // Send as string ISO8601
+}
+
+func (x *AuditOffer) Reset() {
+	*x = AuditOffer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dispatch_dispatch_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuditOffer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditOffer) ProtoMessage() {}
+
+func (x *AuditOffer) ProtoReflect() protoreflect.Message {
+	mi := &file_dispatch_dispatch_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditOffer.ProtoReflect.Descriptor instead.
+func (*AuditOffer) Descriptor() ([]byte, []int) {
+	return file_dispatch_dispatch_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AuditOffer) GetDriverId() string {
+	if x != nil {
+		return x.DriverId
+	}
+	return ""
+}
+
+func (x *AuditOffer) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *AuditOffer) GetOfferedAt() string {
+	if x != nil {
+		return x.OfferedAt
+	}
+	return ""
+}
+
 var File_dispatch_dispatch_proto protoreflect.FileDescriptor
 
 var file_dispatch_dispatch_proto_rawDesc = []byte{
 	0x0a, 0x17, 0x64, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x2f, 0x64, 0x69, 0x73, 0x70, 0x61,
 	0x74, 0x63, 0x68, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x64, 0x69, 0x73, 0x70, 0x61,
-	0x74, 0x63, 0x68, 0x22, 0xde, 0x01, 0x0a, 0x12, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52,
+	0x74, 0x63, 0x68, 0x22, 0xb9, 0x02, 0x0a, 0x12, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52,
 	0x69, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x61,
 	0x73, 0x73, 0x65, 0x6e, 0x67, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
 	0x52, 0x0b, 0x70, 0x61, 0x73, 0x73, 0x65, 0x6e, 0x67, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1d, 0x0a,
@@ -189,22 +600,90 @@ var file_dispatch_dispatch_proto_rawDesc = []byte{
 	0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x64, 0x72, 0x6f, 0x70, 0x6f, 0x66, 0x66, 0x4c, 0x6f, 0x6e,
 	0x67, 0x12, 0x21, 0x0a, 0x0c, 0x76, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x5f, 0x74, 0x79, 0x70,
 	0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x76, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65,
-	0x54, 0x79, 0x70, 0x65, 0x22, 0x63, 0x0a, 0x13, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52,
-	0x69, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x72,
-	0x69, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x69,
-	0x64, 0x65, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1b, 0x0a, 0x09,
-	0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x08, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x49, 0x64, 0x32, 0x5d, 0x0a, 0x0f, 0x44, 0x69, 0x73,
-	0x70, 0x61, 0x74, 0x63, 0x68, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4a, 0x0a, 0x0b,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x69, 0x64, 0x65, 0x12, 0x1c, 0x2e, 0x64, 0x69,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x5f, 0x69, 0x64,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x64, 0x12,
+	0x28, 0x0a, 0x10, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68, 0x5f, 0x72, 0x61, 0x64, 0x69, 0x75, 0x73,
+	0x5f, 0x6b, 0x6d, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0e, 0x73, 0x65, 0x61, 0x72, 0x63,
+	0x68, 0x52, 0x61, 0x64, 0x69, 0x75, 0x73, 0x4b, 0x6d, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x65, 0x61,
+	0x74, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x73, 0x65, 0x61, 0x74, 0x73, 0x22,
+	0x85, 0x01, 0x0a, 0x13, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x69, 0x64, 0x65, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x72, 0x69, 0x64, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x69, 0x64, 0x65, 0x49, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x72, 0x69, 0x76,
+	0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x72, 0x69,
+	0x76, 0x65, 0x72, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x0c, 0x70, 0x6f, 0x6f, 0x6c, 0x5f, 0x74, 0x72,
+	0x69, 0x70, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x6f, 0x6f,
+	0x6c, 0x54, 0x72, 0x69, 0x70, 0x49, 0x64, 0x22, 0x34, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x44, 0x69,
+	0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x41, 0x75, 0x64, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x64, 0x22, 0x4b, 0x0a,
+	0x18, 0x47, 0x65, 0x74, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x41, 0x75, 0x64, 0x69,
+	0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f, 0x0a, 0x06, 0x61, 0x75, 0x64,
+	0x69, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x64, 0x69, 0x73, 0x70,
+	0x61, 0x74, 0x63, 0x68, 0x2e, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x41, 0x75, 0x64,
+	0x69, 0x74, 0x52, 0x06, 0x61, 0x75, 0x64, 0x69, 0x74, 0x73, 0x22, 0xa1, 0x03, 0x0a, 0x0d, 0x44,
+	0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x41, 0x75, 0x64, 0x69, 0x74, 0x12, 0x17, 0x0a, 0x07,
+	0x72, 0x69, 0x64, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72,
+	0x69, 0x64, 0x65, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x36, 0x0a, 0x07, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x64, 0x69, 0x73,
+	0x70, 0x61, 0x74, 0x63, 0x68, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x69, 0x64,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x38, 0x0a, 0x0a, 0x63, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x73, 0x18,
+	0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x64, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68,
+	0x2e, 0x41, 0x75, 0x64, 0x69, 0x74, 0x43, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52,
+	0x0a, 0x63, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74, 0x65, 0x73, 0x12, 0x2c, 0x0a, 0x06, 0x6f,
+	0x66, 0x66, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x64, 0x69,
+	0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x2e, 0x41, 0x75, 0x64, 0x69, 0x74, 0x4f, 0x66, 0x66, 0x65,
+	0x72, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x65, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x75, 0x74,
+	0x63, 0x6f, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x63,
+	0x6f, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x49, 0x64,
+	0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12,
+	0x2c, 0x0a, 0x12, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x5f, 0x6c, 0x61, 0x74, 0x65, 0x6e,
+	0x63, 0x79, 0x5f, 0x6d, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x74, 0x72, 0x61,
+	0x63, 0x6b, 0x65, 0x72, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x73, 0x12, 0x1f, 0x0a,
+	0x0b, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x73, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0a, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x73, 0x22, 0xd5,
+	0x01, 0x0a, 0x0e, 0x41, 0x75, 0x64, 0x69, 0x74, 0x43, 0x61, 0x6e, 0x64, 0x69, 0x64, 0x61, 0x74,
+	0x65, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1f,
+	0x0a, 0x0b, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x6b, 0x6d, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x0a, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x4b, 0x6d, 0x12,
+	0x27, 0x0a, 0x0f, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x65, 0x61,
+	0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e,
+	0x69, 0x6e, 0x67, 0x53, 0x65, 0x61, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x65, 0x6c, 0x69, 0x67, 0x69, 0x62, 0x6c, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x08, 0x65, 0x6c, 0x69, 0x67, 0x69, 0x62, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f,
+	0x74, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f, 0x74, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06,
+	0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x22, 0x62, 0x0a, 0x0a, 0x41, 0x75, 0x64, 0x69, 0x74, 0x4f,
+	0x66, 0x66, 0x65, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x49,
+	0x64, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x6f,
+	0x66, 0x66, 0x65, 0x72, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x6f, 0x66, 0x66, 0x65, 0x72, 0x65, 0x64, 0x41, 0x74, 0x32, 0xb8, 0x01, 0x0a, 0x0f, 0x44,
+	0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4a,
+	0x0a, 0x0b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x69, 0x64, 0x65, 0x12, 0x1c, 0x2e,
+	0x64, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x52, 0x69, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x64, 0x69,
 	0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x69,
-	0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x64, 0x69, 0x73, 0x70,
-	0x61, 0x74, 0x63, 0x68, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x69, 0x64, 0x65,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x77, 0x69, 0x6b, 0x69, 0x6b, 0x75, 0x73, 0x75,
-	0x6d, 0x61, 0x2f, 0x61, 0x74, 0x6c, 0x61, 0x73, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x62, 0x2f,
-	0x64, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x59, 0x0a, 0x10, 0x47, 0x65,
+	0x74, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x41, 0x75, 0x64, 0x69, 0x74, 0x12, 0x21,
+	0x2e, 0x64, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x69, 0x73,
+	0x70, 0x61, 0x74, 0x63, 0x68, 0x41, 0x75, 0x64, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x22, 0x2e, 0x64, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x2e, 0x47, 0x65, 0x74,
+	0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x41, 0x75, 0x64, 0x69, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x77, 0x69, 0x6b, 0x69, 0x6b, 0x75, 0x73, 0x75, 0x6d, 0x61, 0x2f,
+	0x61, 0x74, 0x6c, 0x61, 0x73, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x62, 0x2f, 0x64, 0x69, 0x73,
+	0x70, 0x61, 0x74, 0x63, 0x68, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -219,19 +698,30 @@ func file_dispatch_dispatch_proto_rawDescGZIP() []byte {
 	return file_dispatch_dispatch_proto_rawDescData
 }
 
-var file_dispatch_dispatch_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_dispatch_dispatch_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_dispatch_dispatch_proto_goTypes = []interface{}{
-	(*RequestRideRequest)(nil),  // 0: dispatch.RequestRideRequest
-	(*RequestRideResponse)(nil), // 1: dispatch.RequestRideResponse
+	(*RequestRideRequest)(nil),       // 0: dispatch.RequestRideRequest
+	(*RequestRideResponse)(nil),      // 1: dispatch.RequestRideResponse
+	(*GetDispatchAuditRequest)(nil),  // 2: dispatch.GetDispatchAuditRequest
+	(*GetDispatchAuditResponse)(nil), // 3: dispatch.GetDispatchAuditResponse
+	(*DispatchAudit)(nil),            // 4: dispatch.DispatchAudit
+	(*AuditCandidate)(nil),           // 5: dispatch.AuditCandidate
+	(*AuditOffer)(nil),               // 6: dispatch.AuditOffer
 }
 var file_dispatch_dispatch_proto_depIdxs = []int32{
-	0, // 0: dispatch.DispatchService.RequestRide:input_type -> dispatch.RequestRideRequest
-	1, // 1: dispatch.DispatchService.RequestRide:output_type -> dispatch.RequestRideResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	4, // 0: dispatch.GetDispatchAuditResponse.audits:type_name -> dispatch.DispatchAudit
+	0, // 1: dispatch.DispatchAudit.request:type_name -> dispatch.RequestRideRequest
+	5, // 2: dispatch.DispatchAudit.candidates:type_name -> dispatch.AuditCandidate
+	6, // 3: dispatch.DispatchAudit.offers:type_name -> dispatch.AuditOffer
+	0, // 4: dispatch.DispatchService.RequestRide:input_type -> dispatch.RequestRideRequest
+	2, // 5: dispatch.DispatchService.GetDispatchAudit:input_type -> dispatch.GetDispatchAuditRequest
+	1, // 6: dispatch.DispatchService.RequestRide:output_type -> dispatch.RequestRideResponse
+	3, // 7: dispatch.DispatchService.GetDispatchAudit:output_type -> dispatch.GetDispatchAuditResponse
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_dispatch_dispatch_proto_init() }
@@ -264,6 +754,66 @@ func file_dispatch_dispatch_proto_init() {
 				return nil
 			}
 		}
+		file_dispatch_dispatch_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDispatchAuditRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dispatch_dispatch_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDispatchAuditResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dispatch_dispatch_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DispatchAudit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dispatch_dispatch_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuditCandidate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dispatch_dispatch_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuditOffer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -271,7 +821,7 @@ func file_dispatch_dispatch_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_dispatch_dispatch_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
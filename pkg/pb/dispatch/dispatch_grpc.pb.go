@@ -23,6 +23,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type DispatchServiceClient interface {
 	RequestRide(ctx context.Context, in *RequestRideRequest, opts ...grpc.CallOption) (*RequestRideResponse, error)
+	GetDispatchAudit(ctx context.Context, in *GetDispatchAuditRequest, opts ...grpc.CallOption) (*GetDispatchAuditResponse, error)
 }
 
 type dispatchServiceClient struct {
@@ -42,11 +43,21 @@ func (c *dispatchServiceClient) RequestRide(ctx context.Context, in *RequestRide
 	return out, nil
 }
 
+func (c *dispatchServiceClient) GetDispatchAudit(ctx context.Context, in *GetDispatchAuditRequest, opts ...grpc.CallOption) (*GetDispatchAuditResponse, error) {
+	out := new(GetDispatchAuditResponse)
+	err := c.cc.Invoke(ctx, "/dispatch.DispatchService/GetDispatchAudit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DispatchServiceServer is the server API for DispatchService service.
 // All implementations must embed UnimplementedDispatchServiceServer
 // for forward compatibility
 type DispatchServiceServer interface {
 	RequestRide(context.Context, *RequestRideRequest) (*RequestRideResponse, error)
+	GetDispatchAudit(context.Context, *GetDispatchAuditRequest) (*GetDispatchAuditResponse, error)
 	mustEmbedUnimplementedDispatchServiceServer()
 }
 
@@ -57,6 +68,9 @@ type UnimplementedDispatchServiceServer struct {
 func (UnimplementedDispatchServiceServer) RequestRide(context.Context, *RequestRideRequest) (*RequestRideResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RequestRide not implemented")
 }
+func (UnimplementedDispatchServiceServer) GetDispatchAudit(context.Context, *GetDispatchAuditRequest) (*GetDispatchAuditResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDispatchAudit not implemented")
+}
 func (UnimplementedDispatchServiceServer) mustEmbedUnimplementedDispatchServiceServer() {}
 
 // UnsafeDispatchServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +102,24 @@ func _DispatchService_RequestRide_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DispatchService_GetDispatchAudit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDispatchAuditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DispatchServiceServer).GetDispatchAudit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dispatch.DispatchService/GetDispatchAudit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DispatchServiceServer).GetDispatchAudit(ctx, req.(*GetDispatchAuditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DispatchService_ServiceDesc is the grpc.ServiceDesc for DispatchService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,6 +131,10 @@ var DispatchService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RequestRide",
 			Handler:    _DispatchService_RequestRide_Handler,
 		},
+		{
+			MethodName: "GetDispatchAudit",
+			Handler:    _DispatchService_GetDispatchAudit_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "dispatch/dispatch.proto",
@@ -25,6 +25,10 @@ type TrackerServiceClient interface {
 	UpdateLocation(ctx context.Context, in *UpdateLocationRequest, opts ...grpc.CallOption) (*UpdateLocationResponse, error)
 	GetNearbyDrivers(ctx context.Context, in *GetNearbyDriverRequest, opts ...grpc.CallOption) (*GetNearbyDriverResponse, error)
 	GetDriverLocation(ctx context.Context, in *GetDriverLocationRequest, opts ...grpc.CallOption) (*GetDriverLocationResponse, error)
+	ReserveDriver(ctx context.Context, in *ReserveDriverRequest, opts ...grpc.CallOption) (*ReserveDriverResponse, error)
+	ReleaseDriver(ctx context.Context, in *ReleaseDriverRequest, opts ...grpc.CallOption) (*ReleaseDriverResponse, error)
+	StartTrip(ctx context.Context, in *StartTripRequest, opts ...grpc.CallOption) (*StartTripResponse, error)
+	EndTrip(ctx context.Context, in *EndTripRequest, opts ...grpc.CallOption) (*EndTripResponse, error)
 }
 
 type trackerServiceClient struct {
@@ -62,6 +66,42 @@ func (c *trackerServiceClient) GetDriverLocation(ctx context.Context, in *GetDri
 	return out, nil
 }
 
+func (c *trackerServiceClient) ReserveDriver(ctx context.Context, in *ReserveDriverRequest, opts ...grpc.CallOption) (*ReserveDriverResponse, error) {
+	out := new(ReserveDriverResponse)
+	err := c.cc.Invoke(ctx, "/tracker.TrackerService/ReserveDriver", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) ReleaseDriver(ctx context.Context, in *ReleaseDriverRequest, opts ...grpc.CallOption) (*ReleaseDriverResponse, error) {
+	out := new(ReleaseDriverResponse)
+	err := c.cc.Invoke(ctx, "/tracker.TrackerService/ReleaseDriver", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) StartTrip(ctx context.Context, in *StartTripRequest, opts ...grpc.CallOption) (*StartTripResponse, error) {
+	out := new(StartTripResponse)
+	err := c.cc.Invoke(ctx, "/tracker.TrackerService/StartTrip", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trackerServiceClient) EndTrip(ctx context.Context, in *EndTripRequest, opts ...grpc.CallOption) (*EndTripResponse, error) {
+	out := new(EndTripResponse)
+	err := c.cc.Invoke(ctx, "/tracker.TrackerService/EndTrip", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TrackerServiceServer is the server API for TrackerService service.
 // All implementations must embed UnimplementedTrackerServiceServer
 // for forward compatibility
@@ -69,6 +109,10 @@ type TrackerServiceServer interface {
 	UpdateLocation(context.Context, *UpdateLocationRequest) (*UpdateLocationResponse, error)
 	GetNearbyDrivers(context.Context, *GetNearbyDriverRequest) (*GetNearbyDriverResponse, error)
 	GetDriverLocation(context.Context, *GetDriverLocationRequest) (*GetDriverLocationResponse, error)
+	ReserveDriver(context.Context, *ReserveDriverRequest) (*ReserveDriverResponse, error)
+	ReleaseDriver(context.Context, *ReleaseDriverRequest) (*ReleaseDriverResponse, error)
+	StartTrip(context.Context, *StartTripRequest) (*StartTripResponse, error)
+	EndTrip(context.Context, *EndTripRequest) (*EndTripResponse, error)
 	mustEmbedUnimplementedTrackerServiceServer()
 }
 
@@ -85,6 +129,18 @@ func (UnimplementedTrackerServiceServer) GetNearbyDrivers(context.Context, *GetN
 func (UnimplementedTrackerServiceServer) GetDriverLocation(context.Context, *GetDriverLocationRequest) (*GetDriverLocationResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetDriverLocation not implemented")
 }
+func (UnimplementedTrackerServiceServer) ReserveDriver(context.Context, *ReserveDriverRequest) (*ReserveDriverResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveDriver not implemented")
+}
+func (UnimplementedTrackerServiceServer) ReleaseDriver(context.Context, *ReleaseDriverRequest) (*ReleaseDriverResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseDriver not implemented")
+}
+func (UnimplementedTrackerServiceServer) StartTrip(context.Context, *StartTripRequest) (*StartTripResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartTrip not implemented")
+}
+func (UnimplementedTrackerServiceServer) EndTrip(context.Context, *EndTripRequest) (*EndTripResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EndTrip not implemented")
+}
 func (UnimplementedTrackerServiceServer) mustEmbedUnimplementedTrackerServiceServer() {}
 
 // UnsafeTrackerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -152,6 +208,78 @@ func _TrackerService_GetDriverLocation_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrackerService_ReserveDriver_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveDriverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).ReserveDriver(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tracker.TrackerService/ReserveDriver",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).ReserveDriver(ctx, req.(*ReserveDriverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_ReleaseDriver_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseDriverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).ReleaseDriver(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tracker.TrackerService/ReleaseDriver",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).ReleaseDriver(ctx, req.(*ReleaseDriverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_StartTrip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartTripRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).StartTrip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tracker.TrackerService/StartTrip",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).StartTrip(ctx, req.(*StartTripRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrackerService_EndTrip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EndTripRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrackerServiceServer).EndTrip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tracker.TrackerService/EndTrip",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrackerServiceServer).EndTrip(ctx, req.(*EndTripRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TrackerService_ServiceDesc is the grpc.ServiceDesc for TrackerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -171,6 +299,22 @@ var TrackerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetDriverLocation",
 			Handler:    _TrackerService_GetDriverLocation_Handler,
 		},
+		{
+			MethodName: "ReserveDriver",
+			Handler:    _TrackerService_ReserveDriver_Handler,
+		},
+		{
+			MethodName: "ReleaseDriver",
+			Handler:    _TrackerService_ReleaseDriver_Handler,
+		},
+		{
+			MethodName: "StartTrip",
+			Handler:    _TrackerService_StartTrip_Handler,
+		},
+		{
+			MethodName: "EndTrip",
+			Handler:    _TrackerService_EndTrip_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "tracker/tracker.proto",